@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// TestCountBuffer tests that flags select the counts like the mwc options
+func TestCountBuffer(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		flags    uint32
+		expected wordcount.Counts
+	}{
+		{"Default Counts", "Hello, World!\nBye\n", 0, wordcount.Counts{Lines: 2, Words: 3, Bytes: 18}},
+		{"Selected Counts", "Hello, 世界\n", flagWords | flagChars, wordcount.Counts{Words: 2, Chars: 10}},
+		{"Unique Words", "a b a\n", flagUnique, wordcount.Counts{Unique: 2}},
+		{"Unicode Words", "can't stop, 世界", flagWords | flagUnicodeWords, wordcount.Counts{Words: 4}},
+		{"Empty Buffer", "", flagLines, wordcount.Counts{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counts, status, err := countBuffer([]byte(tt.input), tt.flags)
+			if status != statusOK || err != nil {
+				t.Fatalf("countBuffer() status = %d, error = %v", status, err)
+			}
+			if !counts.Equal(tt.expected) {
+				t.Errorf("countBuffer() = %+v, want %+v", counts, tt.expected)
+			}
+		})
+	}
+}
+
+// TestCountStatus tests the statuses reported for unknown flags and missing files
+func TestCountStatus(t *testing.T) {
+	if _, status, err := countBuffer(nil, flagUnicodeWords<<1); status != statusOption || !errors.Is(err, wordcount.ErrIllegalOption) {
+		t.Errorf("countBuffer() with unknown flags = %d, %v, want %d", status, err, statusOption)
+	}
+	if _, status, err := countFile("testdata/missing.txt", flagWords); status != statusOpen || !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("countFile() of a missing file = %d, %v, want %d", status, err, statusOpen)
+	}
+	counts, status, err := countFile("../mwc/testdata/test1.txt", flagWords)
+	if status != statusOK || err != nil || counts.Words != 2 {
+		t.Errorf("countFile() = %+v, %d, %v, want 2 words", counts, status, err)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+/*
+#include <stdint.h>
+
+// Flags selecting the counts; without any, lines, words and bytes are counted
+#define MWC_LINES         1
+#define MWC_WORDS         2
+#define MWC_CHARS         4
+#define MWC_BYTES         8
+#define MWC_UNIQUE        16
+#define MWC_UNICODE_WORDS 32
+
+// Statuses of an mwc_result
+#define MWC_OK         0
+#define MWC_ERR_OPTION 1
+#define MWC_ERR_OPEN   2
+#define MWC_ERR_READ   3
+
+// mwc_result holds the counts of an input. Counts that weren't requested
+// are zero. Unless status is MWC_OK, error describes the failure.
+typedef struct {
+	int64_t lines;
+	int64_t words;
+	int64_t characters;
+	int64_t bytes;
+	int64_t unique;
+	int     status;
+	char    error[256];
+} mwc_result;
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+//export mwc_api_version
+func mwc_api_version() C.int {
+	return apiVersion
+}
+
+//export mwc_count_file
+func mwc_count_file(path *C.char, flags C.uint) C.mwc_result {
+	return result(countFile(C.GoString(path), uint32(flags)))
+}
+
+//export mwc_count_buffer
+func mwc_count_buffer(data unsafe.Pointer, length C.size_t, flags C.uint) C.mwc_result {
+	// The counter doesn't keep the data, so it can be read in place
+	return result(countBuffer(unsafe.Slice((*byte)(data), int(length)), uint32(flags)))
+}
+
+// result converts counts and a status into an mwc_result
+func result(counts wordcount.Counts, status int, err error) C.mwc_result {
+	r := C.mwc_result{
+		lines: C.int64_t(counts.Lines), words: C.int64_t(counts.Words), characters: C.int64_t(counts.Chars),
+		bytes: C.int64_t(counts.Bytes), unique: C.int64_t(counts.Unique), status: C.int(status),
+	}
+	if err != nil {
+		message := err.Error()
+		for i := 0; i < len(message) && i < len(r.error)-1; i++ {
+			r.error[i] = C.char(message[i])
+		}
+	}
+	return r
+}
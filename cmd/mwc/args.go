@@ -0,0 +1,706 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// parseArgs processes command-line arguments and returns the options and filenames
+func parseArgs(args []string) (cliOptions, []string, error) {
+	options := cliOptions{}
+	var filenames []string
+	hasOptions := false
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		// Everything after "--" is a file name, even if it starts with "-"
+		if arg == "--" {
+			filenames = append(filenames, args[i+1:]...)
+			break
+		}
+		if arg == "-h" || arg == "--help" {
+			options.HelpRequested = true
+			return options, filenames, nil
+		}
+		if strings.HasPrefix(arg, "--") {
+			name, value, hasValue := strings.Cut(arg[2:], "=")
+			switch longOptionValues[name] {
+			case noValue:
+				if hasValue {
+					return cliOptions{}, nil, optionError("--"+name, "option '--%s' doesn't allow an argument", name)
+				}
+			case requiredValue:
+				if !hasValue {
+					if i+1 >= len(args) {
+						return cliOptions{}, nil, optionError("--"+name, "option '--%s' requires an argument", name)
+					}
+					i++
+					value, hasValue = args[i], true
+				}
+			}
+			switch name {
+			case "buffered":
+				options.Buffered = true
+			case "estimate":
+				options.EstimateBlocks = wordcount.DefaultEstimateBlocks
+				if hasValue {
+					blocks, err := strconv.Atoi(value)
+					if err != nil || blocks < 2 {
+						return cliOptions{}, nil, optionError("--estimate", "invalid sample count for --estimate: '%s'", value)
+					}
+					options.EstimateBlocks = blocks
+				}
+			case "cache":
+				options.CacheDir = expandHome(value)
+			case "incremental":
+				options.Incremental = true
+			case "stats":
+				options.Stats = true
+			case "fs-root":
+				info, err := os.Stat(expandHome(value))
+				if err != nil || !info.IsDir() {
+					return cliOptions{}, nil, optionError("--fs-root", "invalid directory for --fs-root: '%s'", value)
+				}
+				options.FSRoot = expandHome(value)
+			case "notify-url":
+				if u, err := url.Parse(value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					return cliOptions{}, nil, optionError("--notify-url", "invalid URL for --notify-url: '%s'", value)
+				}
+				options.NotifyURL = value
+			case "statsd":
+				if _, _, err := net.SplitHostPort(value); err != nil {
+					return cliOptions{}, nil, optionError("--statsd", "invalid address for --statsd: '%s' (expected HOST:PORT)", value)
+				}
+				options.StatsD = value
+			case "otlp":
+				if u, err := url.Parse(value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					return cliOptions{}, nil, optionError("--otlp", "invalid URL for --otlp: '%s'", value)
+				}
+				options.OTLPURL = value
+			case "metrics-tag":
+				key, tagValue, found := strings.Cut(value, "=")
+				if !found || key == "" {
+					return cliOptions{}, nil, optionError("--metrics-tag", "invalid tag for --metrics-tag: '%s' (expected KEY=VALUE)", value)
+				}
+				options.MetricTags = append(options.MetricTags, [2]string{key, tagValue})
+			case "log-format":
+				if value != "text" && value != "json" && value != "plain" {
+					return cliOptions{}, nil, optionError("--log-format", "invalid format for --log-format: '%s' (available: plain, text, json)", value)
+				}
+				options.LogFormat = value
+				if value == "plain" {
+					options.LogFormat = ""
+				}
+			case "log-level":
+				if err := options.LogLevel.UnmarshalText([]byte(value)); err != nil {
+					return cliOptions{}, nil, optionError("--log-level", "invalid level for --log-level: '%s' (available: debug, info, warn, error)", value)
+				}
+			case "remote":
+				if u, err := url.Parse(value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					return cliOptions{}, nil, optionError("--remote", "invalid URL for --remote: '%s'", value)
+				}
+				options.Remote = value
+			case "include", "exclude":
+				pattern := filepath.ToSlash(value)
+				if !validGlob(pattern) {
+					return cliOptions{}, nil, optionError("--"+name, "invalid pattern for --%s: '%s'", name, value)
+				}
+				if name == "include" {
+					options.Include = append(options.Include, pattern)
+				} else {
+					options.Exclude = append(options.Exclude, pattern)
+				}
+			case "gitignore":
+				options.GitIgnore = "on"
+			case "no-gitignore":
+				options.GitIgnore = "off"
+			case "git-tracked":
+				options.GitTracked = true
+			case "hidden":
+				switch value {
+				case "skip":
+					options.Hidden = false
+				case "include", "":
+					options.Hidden = true
+				default:
+					return cliOptions{}, nil, optionError("--hidden", "invalid value for --hidden: '%s' (available: skip, include)", value)
+				}
+			case "follow-symlinks":
+				options.FollowSymlinks = true
+			case "dedup-hardlinks":
+				options.DedupHardlinks = true
+			case "max-depth":
+				depth, err := strconv.Atoi(value)
+				if err != nil || depth < 1 {
+					return cliOptions{}, nil, optionError("--max-depth", "invalid depth for --max-depth: '%s' (must be at least 1)", value)
+				}
+				options.MaxDepth = depth
+			case "group-by":
+				if value != "dir" && value != "tree" {
+					return cliOptions{}, nil, optionError("--group-by", "invalid grouping for --group-by: '%s' (available: dir, tree)", value)
+				}
+				options.GroupBy = value
+			case "jobs":
+				jobs, err := strconv.Atoi(value)
+				if err != nil || jobs < 1 {
+					return cliOptions{}, nil, optionError("--jobs", "invalid number for --jobs: '%s'", value)
+				}
+				options.Jobs = jobs
+			case "max-filesize":
+				size, err := parseSize(value)
+				if err != nil || size < 1 {
+					return cliOptions{}, nil, optionError("--max-filesize", "invalid size for --max-filesize: '%s'", value)
+				}
+				options.MaxFileSize = size
+			case "type":
+				if value != "text" {
+					return cliOptions{}, nil, optionError("--type", "invalid type for --type: '%s' (available: text)", value)
+				}
+				options.Type = value
+			case "follow":
+				options.Follow = time.Second
+				if hasValue {
+					interval, err := time.ParseDuration(value)
+					if err != nil || interval <= 0 {
+						return cliOptions{}, nil, optionError("--follow", "invalid interval for --follow: '%s'", value)
+					}
+					options.Follow = interval
+				}
+			case "interval":
+				interval, err := time.ParseDuration(value)
+				if err != nil || interval <= 0 {
+					return cliOptions{}, nil, optionError("--interval", "invalid duration for --interval: '%s'", value)
+				}
+				options.Interval = interval
+			case "staged":
+				options.Staged = true
+			case "github":
+				options.GitHub = true
+			case "dry-run":
+				options.DryRun = true
+			case "max-words-per-file":
+				limit, err := strconv.ParseInt(value, 10, 64)
+				if err != nil || limit < 1 {
+					return cliOptions{}, nil, optionError("--max-words-per-file", "invalid word count for --max-words-per-file: '%s'", value)
+				}
+				options.MaxWordsPerFile = limit
+			case "goal":
+				goal, err := strconv.ParseInt(value, 10, 64)
+				if err != nil || goal < 1 {
+					return cliOptions{}, nil, optionError("--goal", "invalid word count for --goal: '%s'", value)
+				}
+				options.Goal = goal
+			case "db":
+				options.HistoryDB = value
+			case "by-heading":
+				rule, err := parseHeadingRule(value)
+				if err != nil {
+					return cliOptions{}, nil, optionError("--by-heading", "invalid heading for --by-heading: '%s' (%v)", value, err)
+				}
+				options.ByHeading = rule
+			case "limit":
+				if value != "tweet" && value != "sms" {
+					return cliOptions{}, nil, optionError("--limit", "invalid limit for --limit: '%s' (available: tweet, sms)", value)
+				}
+				options.Limit = value
+			case "normalize":
+				form := strings.ToUpper(value)
+				if _, ok := normalForms[form]; !ok {
+					return cliOptions{}, nil, optionError("--normalize", "invalid form for --normalize: '%s' (available: NFC, NFD, NFKC, NFKD)", value)
+				}
+				options.NormalForm = form
+			case "save-snapshot":
+				options.SaveSnapshot = value
+			case "check-against", "check":
+				options.CheckAgainst = value
+			case "update-baseline":
+				options.UpdateBaseline = true
+			case "tolerance":
+				t, err := parseTolerance(value)
+				if err != nil {
+					return cliOptions{}, nil, optionError("--tolerance", "invalid tolerance for --tolerance: '%s' (expected [COUNT=]AMOUNT, such as 5%%)", value)
+				}
+				options.Tolerances = append(options.Tolerances, t)
+			case "timeout":
+				timeout, err := time.ParseDuration(value)
+				if err != nil || timeout <= 0 {
+					return cliOptions{}, nil, optionError("--timeout", "invalid duration for --timeout: '%s'", value)
+				}
+				options.Timeout = timeout
+			case "tee":
+				options.Tee = true
+			case "no-progress":
+				options.NoProgress = true
+			case "files-from":
+				options.FilesFrom = value
+			case "cpuprofile":
+				options.CPUProfile = value
+			case "memprofile":
+				options.MemProfile = value
+			case "trace":
+				options.Trace = value
+			case "unique":
+				hasOptions = true
+				options.UniqueCount = true
+				options.Order = append(options.Order, "unique")
+			case "plugin":
+				if !slices.Contains(options.Plugins, value) {
+					options.Plugins = append(options.Plugins, value)
+				}
+			case "metric":
+				hasOptions = true
+				if !slices.Contains(options.Metrics, value) {
+					options.Metrics = append(options.Metrics, value)
+					options.Order = append(options.Order, value)
+				}
+			case "exclude-quotes":
+				options.ExcludeQuotes = true
+			case "front-matter":
+				options.FrontMatter = true
+			case "fold-case":
+				options.FoldCase = true
+			case "max-line-length":
+				hasOptions = true
+				options.MaxLineLength = true
+				options.Order = append(options.Order, "max_line_length")
+			case "tabstop":
+				n, err := strconv.Atoi(value)
+				if err != nil || n <= 0 {
+					return cliOptions{}, nil, optionError("--tabstop", "invalid number for --tabstop: '%s'", value)
+				}
+				options.TabStop = n
+			case "unicode-words":
+				options.WordSplitter = wordcount.UnicodeWords
+			case "posix":
+				options.Bytewise = true
+			case "compat":
+				if value != "gnu" && value != "bsd" {
+					return cliOptions{}, nil, optionError("--compat", "invalid mode for --compat: '%s' (available: gnu, bsd)", value)
+				}
+				options.Compat = value
+			case "suspicious-chars":
+				// Hidden characters, for audits
+				hasOptions = true
+				for _, metric := range []string{"zero_width", "bidi_controls", "confusables"} {
+					if !slices.Contains(options.Metrics, metric) {
+						options.Metrics = append(options.Metrics, metric)
+						options.Order = append(options.Order, metric)
+					}
+				}
+			case "encoding-errors":
+				// Ill-formed UTF-8, for data-quality checks
+				hasOptions = true
+				for _, metric := range []string{"invalid_utf8", "overlong", "surrogates"} {
+					if !slices.Contains(options.Metrics, metric) {
+						options.Metrics = append(options.Metrics, metric)
+						options.Order = append(options.Order, metric)
+					}
+				}
+			case "dialogue":
+				// Dialogue and narration words, and the share of dialogue
+				hasOptions = true
+				for _, metric := range []string{"dialogue", "narration"} {
+					if !slices.Contains(options.Metrics, metric) {
+						options.Metrics = append(options.Metrics, metric)
+						options.Order = append(options.Order, metric)
+					}
+				}
+				expr, _ := wordcount.ParseExpr("dialogue_ratio=dialogue/(dialogue+narration)")
+				options.Exprs = append(options.Exprs, expr)
+			case "expr":
+				expr, err := wordcount.ParseExpr(value)
+				if err != nil {
+					return cliOptions{}, nil, optionError("--expr", "%v", err)
+				}
+				options.Exprs = append(options.Exprs, expr)
+			case "max-memory":
+				size, err := parseSize(value)
+				if err != nil {
+					return cliOptions{}, nil, optionError("--max-memory", "invalid size for --max-memory: '%s'", value)
+				}
+				options.MaxMemory = size
+			case "throttle":
+				rate, err := parseRate(value)
+				if err != nil {
+					return cliOptions{}, nil, optionError("--throttle", "invalid rate for --throttle: '%s'", value)
+				}
+				options.Throttle = rate
+			case "buffer-size":
+				size, err := parseSize(value)
+				if err != nil || size < wordcount.MinBufferSize || size > wordcount.MaxBufferSize {
+					return cliOptions{}, nil, optionError("--buffer-size", "invalid size for --buffer-size: '%s' (must be between 512 and 256M)", value)
+				}
+				options.BufferSize = int(size)
+			default:
+				return cliOptions{}, nil, optionError(arg, "unrecognized option '%s'", arg)
+			}
+		} else if strings.HasPrefix(arg, "-") {
+			for _, char := range arg[1:] {
+				switch char {
+				case 'r', 'R':
+					options.Recursive = true
+				case 'l':
+					hasOptions = true
+					options.LineCount = true
+					options.Order = append(options.Order, "lines")
+				case 'w':
+					hasOptions = true
+					options.WordCount = true
+					options.Order = append(options.Order, "words")
+				case 'c':
+					hasOptions = true
+					options.ByteCount = true
+					options.Order = append(options.Order, "bytes")
+				case 'm':
+					hasOptions = true
+					options.CharacterCount = true
+					options.Order = append(options.Order, "characters")
+				case 'L':
+					hasOptions = true
+					options.MaxLineLength = true
+					options.Order = append(options.Order, "max_line_length")
+				default:
+					//_, _ = fmt.Fprintf(os.Stderr, "%s: illegal option -- %c\n", os.Args[0], char)
+					//_, _ = fmt.Fprintf(os.Stderr, "usage: %s [-clmw] [file ...]\n", os.Args[0])
+					//os.Exit(1)
+					return cliOptions{}, nil, optionError("-"+string(char), "illegal option -- %c", char)
+				}
+			}
+		} else {
+			filenames = append(filenames, arg)
+		}
+	}
+
+	if options.FilesFrom != "" {
+		listed, err := readFileList(options.FilesFrom)
+		if err != nil {
+			return cliOptions{}, nil, optionError("--files-from", "can't read the file list '%s': %v", options.FilesFrom, err)
+		}
+		filenames = append(filenames, listed...)
+	}
+
+	// Plugins register their metrics, so load them before checking the
+	// metric names wherever --plugin appeared
+	for _, path := range options.Plugins {
+		if err := loadPlugin(path); err != nil {
+			return cliOptions{}, nil, optionError("--plugin", "can't load plugin '%s': %v", path, err)
+		}
+	}
+	for _, name := range options.Metrics {
+		if !slices.Contains(wordcount.Metrics(), name) {
+			return cliOptions{}, nil, optionError("--metric", "unknown metric '%s' (available: %s)", name, strings.Join(wordcount.Metrics(), ", "))
+		}
+	}
+
+	if err := checkExprs(options); err != nil {
+		return cliOptions{}, nil, err
+	}
+
+	if options.UniqueCount && (options.Incremental || options.EstimateBlocks > 0) {
+		return cliOptions{}, nil, optionError("--unique", "--unique can't be combined with --incremental or --estimate")
+	}
+	// The distinct words of every input go into one set, which can only take
+	// one input at a time
+	if options.UniqueCount && options.Jobs > 1 {
+		return cliOptions{}, nil, optionError("--jobs", "--jobs can't be combined with --unique")
+	}
+	// Distinct words can't be summed into subtotals
+	if options.UniqueCount && options.GroupBy != "" {
+		return cliOptions{}, nil, optionError("--group-by", "--group-by can't be combined with --unique")
+	}
+	if len(options.Metrics) > 0 && (options.Incremental || options.EstimateBlocks > 0) {
+		return cliOptions{}, nil, optionError("--metric", "--metric can't be combined with --incremental or --estimate")
+	}
+	// Following resumes counting where it stopped, which only the built-in
+	// counts other than unique words support
+	if options.Follow > 0 {
+		switch {
+		case options.UniqueCount || len(options.Metrics) > 0:
+			return cliOptions{}, nil, optionError("--follow", "--follow can't be combined with --unique or --metric")
+		case options.Incremental || options.EstimateBlocks > 0 || options.Remote != "" || options.GroupBy != "":
+			return cliOptions{}, nil, optionError("--follow", "--follow can't be combined with --incremental, --estimate, --remote or --group-by")
+		case len(filenames) == 0:
+			return cliOptions{}, nil, optionError("--follow", "--follow needs files to follow")
+		}
+	}
+
+	if options.Interval > 0 && (len(filenames) > 0 || options.FilesFrom != "") {
+		return cliOptions{}, nil, optionError("--interval", "--interval only applies to counting stdin")
+	}
+	if options.HistoryDB != "" && options.Goal == 0 {
+		return cliOptions{}, nil, optionError("--db", "--db needs --goal")
+	}
+	if options.Limit != "" && (options.Follow > 0 || options.Remote != "" || options.EstimateBlocks > 0 || options.Tee || options.Timeout > 0) {
+		return cliOptions{}, nil, optionError("--limit", "--limit can't be combined with --follow, --remote, --estimate, --tee or --timeout")
+	}
+	if options.ByHeading != nil && (options.Follow > 0 || options.Remote != "" || options.EstimateBlocks > 0 || options.Incremental ||
+		options.GroupBy != "" || options.Tee || options.Timeout > 0 || options.Limit != "") {
+		return cliOptions{}, nil, optionError("--by-heading", "--by-heading can't be combined with --follow, --remote, --estimate, --incremental, --group-by, --tee, --timeout or --limit")
+	}
+	if options.ExcludeQuotes && (options.Follow > 0 || options.Remote != "" || options.EstimateBlocks > 0 || options.Incremental || options.Limit != "") {
+		return cliOptions{}, nil, optionError("--exclude-quotes", "--exclude-quotes can't be combined with --follow, --remote, --estimate, --incremental or --limit")
+	}
+	if options.FoldCase && !options.UniqueCount {
+		return cliOptions{}, nil, optionError("--fold-case", "--fold-case needs --unique")
+	}
+	if options.FoldCase && options.Remote != "" {
+		return cliOptions{}, nil, optionError("--fold-case", "--fold-case can't be combined with --remote")
+	}
+	if options.TabStop > 0 && !options.MaxLineLength {
+		return cliOptions{}, nil, optionError("--tabstop", "--tabstop needs -L")
+	}
+	if options.MaxLineLength && (options.Follow > 0 || options.Remote != "" || options.EstimateBlocks > 0 || options.Incremental) {
+		return cliOptions{}, nil, optionError("-L", "-L can't be combined with --follow, --remote, --estimate or --incremental")
+	}
+	if options.Compat != "" && (options.Follow > 0 || options.Limit != "" || options.ByHeading != nil || options.GroupBy != "") {
+		return cliOptions{}, nil, optionError("--compat", "--compat can't be combined with --follow, --limit, --by-heading or --group-by")
+	}
+	if options.Bytewise && (options.WordSplitter != nil || options.Remote != "" || options.Incremental) {
+		return cliOptions{}, nil, optionError("--posix", "--posix can't be combined with --unicode-words, --remote or --incremental")
+	}
+	if options.WordSplitter != nil && (options.Follow > 0 || options.Remote != "" || options.EstimateBlocks > 0 || options.Incremental) {
+		return cliOptions{}, nil, optionError("--unicode-words", "--unicode-words can't be combined with --follow, --remote, --estimate or --incremental")
+	}
+	if options.NormalForm != "" && (options.Follow > 0 || options.Remote != "" || options.EstimateBlocks > 0 || options.Incremental || options.Limit != "") {
+		return cliOptions{}, nil, optionError("--normalize", "--normalize can't be combined with --follow, --remote, --estimate, --incremental or --limit")
+	}
+	if options.FrontMatter && (options.Follow > 0 || options.GroupBy != "" || options.Limit != "" || options.ByHeading != nil) {
+		return cliOptions{}, nil, optionError("--front-matter", "--front-matter can't be combined with --follow, --group-by, --limit or --by-heading")
+	}
+	if options.Staged && (options.FilesFrom != "" || options.Follow > 0 || options.Remote != "" || options.Incremental ||
+		options.Limit != "" || options.ByHeading != nil || options.FrontMatter) {
+		return cliOptions{}, nil, optionError("--staged", "--staged can't be combined with --files-from, --follow, --remote, --incremental, --limit, --by-heading or --front-matter")
+	}
+	if options.GitHub && (options.Follow > 0 || options.Remote != "") {
+		return cliOptions{}, nil, optionError("--github", "--github can't be combined with --follow or --remote")
+	}
+	// Following and --remote count on, or elsewhere; --tee and --timeout only apply to stdin
+	if options.DryRun && (options.Follow > 0 || options.Remote != "" || options.Tee || options.Timeout > 0) {
+		return cliOptions{}, nil, optionError("--dry-run", "--dry-run can't be combined with --follow, --remote, --tee or --timeout")
+	}
+	if options.MaxWordsPerFile > 0 && (options.Follow > 0 || options.Remote != "" || options.Limit != "" || options.ByHeading != nil) {
+		return cliOptions{}, nil, optionError("--max-words-per-file", "--max-words-per-file can't be combined with --follow, --remote, --limit or --by-heading")
+	}
+	if len(options.Tolerances) > 0 && options.CheckAgainst == "" {
+		return cliOptions{}, nil, optionError("--tolerance", "--tolerance needs --check or --check-against")
+	}
+	if options.UpdateBaseline && options.CheckAgainst == "" {
+		return cliOptions{}, nil, optionError("--update-baseline", "--update-baseline needs --check")
+	}
+	if options.Tee && (len(filenames) > 0 || options.FilesFrom != "") {
+		return cliOptions{}, nil, optionError("--tee", "--tee only applies to counting stdin")
+	}
+	if options.Timeout > 0 && (len(filenames) > 0 || options.FilesFrom != "" || options.Remote != "") {
+		return cliOptions{}, nil, optionError("--timeout", "--timeout only applies to counting stdin locally")
+	}
+	// Sampling would skip most of the data that should be passed on
+	if options.Tee && (options.EstimateBlocks > 0 || options.Remote != "") {
+		return cliOptions{}, nil, optionError("--tee", "--tee can't be combined with --estimate or --remote")
+	}
+
+	if options.GitTracked && !options.Recursive {
+		return cliOptions{}, nil, optionError("--git-tracked", "--git-tracked needs -r")
+	}
+	// git lists the files of the operating system, relative to the working directory
+	if options.GitTracked && (options.FSRoot != "" || options.Remote != "") {
+		return cliOptions{}, nil, optionError("--git-tracked", "--git-tracked can't be combined with --fs-root or --remote")
+	}
+	if (len(options.Include) > 0 || len(options.Exclude) > 0) && !options.Recursive && !options.Staged {
+		return cliOptions{}, nil, optionError("--include", "--include and --exclude need -r or --staged")
+	}
+
+	// Counting on a server leaves nothing local to cache, resume or sample,
+	// and metrics are the server's
+	if options.Remote != "" && (options.CacheDir != "" || options.Incremental || options.EstimateBlocks > 0 || len(options.Plugins) > 0 || options.Interval > 0) {
+		return cliOptions{}, nil, optionError("--remote", "--remote can't be combined with --cache, --incremental, --estimate, --plugin or --interval")
+	}
+
+	// If no options were provided, use the default options
+	if !hasOptions {
+		options.LineCount = true
+		options.WordCount = true
+		options.ByteCount = true
+		options.Order = []string{"lines", "words", "bytes"}
+	}
+	if options.Compat != "" {
+		options = orderForCompat(options, options.Compat)
+	}
+
+	options.CountOptions = options.Normalize()
+	if err := options.Validate(); err != nil {
+		return cliOptions{}, nil, err
+	}
+	return options, filenames, nil
+}
+
+// readFileList reads the names listed in a file, or stdin for "-", one per
+// line. Blank lines and lines starting with # are skipped.
+func readFileList(name string) ([]string, error) {
+	var data []byte
+	var err error
+	if name == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		names = append(names, line)
+	}
+	return names, nil
+}
+
+// checkExprs checks that every --expr has a name of its own and uses only
+// counts that can be counted with the other options
+func checkExprs(options cliOptions) error {
+	for i, expr := range options.Exprs {
+		if _, builtin := (wordcount.Counts{}).Get(expr.Name); builtin || slices.Contains(wordcount.Metrics(), expr.Name) ||
+			slices.ContainsFunc(options.Exprs[:i], func(e *wordcount.Expr) bool { return e.Name == expr.Name }) {
+			return optionError("--expr", "--expr name '%s' is already taken", expr.Name)
+		}
+		for _, name := range expr.Names() {
+			_, builtin := (wordcount.Counts{}).Get(name)
+			if !builtin && !slices.Contains(wordcount.Metrics(), name) {
+				return optionError("--expr", "unknown count '%s' in --expr '%s'", name, expr.Source)
+			}
+			if (name == "unique" || !builtin) && (options.Incremental || options.EstimateBlocks > 0 || options.Follow > 0) {
+				return optionError("--expr", "--expr using '%s' can't be combined with --incremental, --estimate or --follow", name)
+			}
+		}
+	}
+	return nil
+}
+
+// exprCountOptions returns the options with the counts used by the
+// expressions enabled, without adding them to the printed columns
+func exprCountOptions(options wordcount.CountOptions, exprs []*wordcount.Expr) wordcount.CountOptions {
+	order := options.Order
+	options.Order = slices.Clone(options.Order)
+	for _, expr := range exprs {
+		options.Order = append(options.Order, expr.Names()...)
+	}
+	options = options.Normalize()
+	options.Order = order
+	return options
+}
+
+// optionError returns a *wordcount.OptionError for an invalid command-line option
+func optionError(option, format string, args ...any) error {
+	return &wordcount.OptionError{Option: option, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Kinds of values a long option can take
+const (
+	noValue       = iota // --name
+	optionalValue        // --name or --name=value
+	requiredValue        // --name=value or --name value
+)
+
+// longOptionValues lists the long options that take a value; all others take none
+var longOptionValues = map[string]int{
+	"estimate":           optionalValue,
+	"cache":              requiredValue,
+	"cpuprofile":         requiredValue,
+	"memprofile":         requiredValue,
+	"trace":              requiredValue,
+	"max-memory":         requiredValue,
+	"throttle":           requiredValue,
+	"buffer-size":        requiredValue,
+	"fs-root":            requiredValue,
+	"metric":             requiredValue,
+	"plugin":             requiredValue,
+	"expr":               requiredValue,
+	"notify-url":         requiredValue,
+	"statsd":             requiredValue,
+	"otlp":               requiredValue,
+	"metrics-tag":        requiredValue,
+	"log-format":         requiredValue,
+	"log-level":          requiredValue,
+	"remote":             requiredValue,
+	"include":            requiredValue,
+	"exclude":            requiredValue,
+	"hidden":             optionalValue,
+	"max-depth":          requiredValue,
+	"group-by":           requiredValue,
+	"jobs":               requiredValue,
+	"max-filesize":       requiredValue,
+	"type":               requiredValue,
+	"files-from":         requiredValue,
+	"follow":             optionalValue,
+	"interval":           requiredValue,
+	"timeout":            requiredValue,
+	"goal":               requiredValue,
+	"db":                 requiredValue,
+	"by-heading":         requiredValue,
+	"limit":              requiredValue,
+	"normalize":          requiredValue,
+	"tabstop":            requiredValue,
+	"compat":             requiredValue,
+	"save-snapshot":      requiredValue,
+	"check-against":      requiredValue,
+	"check":              requiredValue,
+	"tolerance":          requiredValue,
+	"max-words-per-file": requiredValue,
+}
+
+// hasAnyOption checks if any counting option is enabled
+func hasAnyOption(options cliOptions) bool {
+	return options.LineCount || options.WordCount || options.ByteCount || options.CharacterCount || options.UniqueCount ||
+		options.MaxLineLength || len(options.Metrics) > 0
+}
+
+// expandHome replaces a leading "~" with the user's home directory, for
+// option values the shell didn't expand (such as --cache=~/.cache/mwc)
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// parseSize parses a byte size such as "512", "64K", "256MB" or "2GiB".
+// Suffixes are powers of 1024.
+func parseSize(value string) (int64, error) {
+	number := strings.TrimRight(strings.ToUpper(value), "IB")
+	multiplier := int64(1)
+	if suffix := strings.IndexAny(number, "KMGT"); suffix >= 0 && suffix == len(number)-1 {
+		multiplier = 1 << (10 * (strings.IndexByte("KMGT", number[suffix]) + 1))
+		number = number[:suffix]
+	}
+	size, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+	if err != nil || !(size >= 0) || math.IsInf(size, 0) || size*float64(multiplier) > math.MaxInt64 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return int64(size * float64(multiplier)), nil
+}
+
+// parseRate parses a read rate such as "50MB/s" or "512K" into bytes per second
+func parseRate(value string) (int64, error) {
+	rate, err := parseSize(strings.TrimSuffix(strings.TrimSuffix(value, "/s"), "/S"))
+	if err != nil || rate <= 0 {
+		return 0, fmt.Errorf("invalid rate %q", value)
+	}
+	return rate, nil
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// TestIllegalOption tests the handling of illegal options
+func TestIllegalOption(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		expectedErr string
+	}{
+		{
+			name:        "Single Illegal Option",
+			args:        []string{"-b"},
+			expectedErr: "illegal option -- b",
+		},
+		{
+			name:        "Multiple Options with Illegal",
+			args:        []string{"-lwcb"},
+			expectedErr: "illegal option -- b",
+		},
+		{
+			name:        "Valid and Invalid Options",
+			args:        []string{"-lw", "-x"},
+			expectedErr: "illegal option -- x",
+		},
+		{
+			name:        "Tab Stop Without Longest Line",
+			args:        []string{"--tabstop", "4"},
+			expectedErr: "--tabstop needs -L",
+		},
+		{
+			name:        "Invalid Tab Stop",
+			args:        []string{"-L", "--tabstop=0"},
+			expectedErr: "invalid number for --tabstop: '0'",
+		},
+		{
+			name:        "Invalid Word Limit",
+			args:        []string{"--max-words-per-file", "-5"},
+			expectedErr: "invalid word count for --max-words-per-file: '-5'",
+		},
+		{
+			name:        "Staged With Follow",
+			args:        []string{"--staged", "--follow", "a.txt"},
+			expectedErr: "--staged can't be combined with --files-from, --follow, --remote, --incremental, --limit, --by-heading or --front-matter",
+		},
+		{
+			name:        "Dry Run With Follow",
+			args:        []string{"--dry-run", "--follow", "a.txt"},
+			expectedErr: "--dry-run can't be combined with --follow, --remote, --tee or --timeout",
+		},
+		{
+			name:        "Unknown Compatibility Mode",
+			args:        []string{"--compat", "posix"},
+			expectedErr: "invalid mode for --compat: 'posix' (available: gnu, bsd)",
+		},
+		{
+			name:        "POSIX With Unicode Words",
+			args:        []string{"--posix", "--unicode-words"},
+			expectedErr: "--posix can't be combined with --unicode-words, --remote or --incremental",
+		},
+		{
+			name:        "Invalid Estimate Sample Count",
+			args:        []string{"--estimate=1"},
+			expectedErr: "invalid sample count for --estimate: '1'",
+		},
+		{
+			name:        "Missing Long Option Value",
+			args:        []string{"--cache"},
+			expectedErr: "option '--cache' requires an argument",
+		},
+		{
+			name:        "Buffer Size Too Small",
+			args:        []string{"--buffer-size", "16"},
+			expectedErr: "invalid size for --buffer-size: '16' (must be between 512 and 256M)",
+		},
+		{
+			name:        "Unexpected Long Option Value",
+			args:        []string{"--buffered=yes"},
+			expectedErr: "option '--buffered' doesn't allow an argument",
+		},
+		{
+			name:        "Missing File System Root",
+			args:        []string{"--fs-root=/nonexistent/mwc"},
+			expectedErr: "invalid directory for --fs-root: '/nonexistent/mwc'",
+		},
+		{
+			name:        "Unknown Metric",
+			args:        []string{"--metric", "syllables"},
+			expectedErr: "unknown metric 'syllables' (available: bidi_controls, confusables, dialogue, invalid_utf8, narration, overlong, sentences, surrogates, zero_width)",
+		},
+		{
+			name:        "Invalid Expression",
+			args:        []string{"--expr", "density=words/"},
+			expectedErr: `invalid expression "words/": unexpected end of expression`,
+		},
+		{
+			name:        "Expression With Unknown Count",
+			args:        []string{"--expr=density=words/paragraphs"},
+			expectedErr: "unknown count 'paragraphs' in --expr 'words/paragraphs'",
+		},
+		{
+			name:        "Expression Named Like A Count",
+			args:        []string{"--expr", "words=lines"},
+			expectedErr: "--expr name 'words' is already taken",
+		},
+		{
+			name:        "Expression With Metric And Estimate",
+			args:        []string{"--expr", "x=sentences", "--estimate"},
+			expectedErr: "--expr using 'sentences' can't be combined with --incremental, --estimate or --follow",
+		},
+		{
+			name:        "Metric With Estimate",
+			args:        []string{"--metric=sentences", "--estimate"},
+			expectedErr: "--metric can't be combined with --incremental or --estimate",
+		},
+		{
+			name:        "Follow Without Files",
+			args:        []string{"--follow"},
+			expectedErr: "--follow needs files to follow",
+		},
+		{
+			name:        "Follow With Invalid Interval",
+			args:        []string{"--follow=often", "app.log"},
+			expectedErr: "invalid interval for --follow: 'often'",
+		},
+		{
+			name:        "Follow With Unique",
+			args:        []string{"--follow", "--unique", "app.log"},
+			expectedErr: "--follow can't be combined with --unique or --metric",
+		},
+		{
+			name:        "Fold Case Without Unique",
+			args:        []string{"--fold-case", "-w"},
+			expectedErr: "--fold-case needs --unique",
+		},
+		{
+			name:        "Unicode Words With Estimate",
+			args:        []string{"--unicode-words", "--estimate"},
+			expectedErr: "--unicode-words can't be combined with --follow, --remote, --estimate or --incremental",
+		},
+		{
+			name:        "Invalid Normalization Form",
+			args:        []string{"--normalize", "NFX"},
+			expectedErr: "invalid form for --normalize: 'NFX' (available: NFC, NFD, NFKC, NFKD)",
+		},
+		{
+			name:        "Unrecognized Long Option",
+			args:        []string{"--bogus"},
+			expectedErr: "unrecognized option '--bogus'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := parseArgs(tt.args)
+			if err == nil {
+				t.Errorf("Expected error, but got nil")
+			} else if err.Error() != tt.expectedErr {
+				t.Errorf("Expected error: %s, but got: %s", tt.expectedErr, err.Error())
+			} else if !errors.Is(err, wordcount.ErrIllegalOption) {
+				t.Errorf("Expected error to match wordcount.ErrIllegalOption")
+			}
+		})
+	}
+}
+
+// TestMissingPlugin tests that a plugin that can't be loaded is reported before unknown metrics
+func TestMissingPlugin(t *testing.T) {
+	_, _, err := parseArgs([]string{"--metric", "syllables", "--plugin=/nonexistent/mwc.so"})
+	// The reason varies by platform and whether cgo is enabled
+	if err == nil || !strings.HasPrefix(err.Error(), "can't load plugin '/nonexistent/mwc.so': ") {
+		t.Errorf("Expected a plugin loading error, got %v", err)
+	}
+	if !errors.Is(err, wordcount.ErrIllegalOption) {
+		t.Errorf("Expected error to match wordcount.ErrIllegalOption")
+	}
+}
+
+// TestRepeatedOptions tests that counts requested more than once are reported once
+func TestRepeatedOptions(t *testing.T) {
+	options, _, err := parseArgs([]string{"-ll", "-wl", "--metric=sentences", "--metric", "sentences"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"lines", "words", "sentences"}
+	if !slices.Equal(options.Order, expected) {
+		t.Errorf("Expected order %v, got %v", expected, options.Order)
+	}
+}
+
+// TestParseSize tests parsing of byte sizes with and without suffixes
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+		wantErr  bool
+	}{
+		{"512", 512, false},
+		{"512B", 512, false},
+		{"64K", 64 * 1024, false},
+		{"64kb", 64 * 1024, false},
+		{"1.5M", 1536 * 1024, false},
+		{"2GiB", 2 * 1024 * 1024 * 1024, false},
+		{"1T", 1 << 40, false},
+		{"", 0, true},
+		{"-1M", 0, true},
+		{"lots", 0, true},
+		{"NaN", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			size, err := parseSize(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, got %d", size)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if size != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, size)
+			}
+		})
+	}
+}
+
+// TestParseRate tests parsing of read rates
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+		wantErr  bool
+	}{
+		{"50MB/s", 50 * 1024 * 1024, false},
+		{"512K", 512 * 1024, false},
+		{"1000/s", 1000, false},
+		{"0", 0, true},
+		{"fast", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			rate, err := parseRate(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, got %d", rate)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if rate != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, rate)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// countFile counts an opened file, resuming from incremental state or reusing
+// cached counts when either is enabled
+func countFile(ctx context.Context, file *os.File, options cliOptions) (wordcount.Counts, string, error) {
+	if options.Incremental {
+		counts, err := countIncremental(file, options)
+		return counts, "", err
+	}
+	return countCached(ctx, file, options)
+}
+
+// cacheEntry is the on-disk record of a file's counts in the cache directory
+type cacheEntry struct {
+	Path    string           `json:"path"`
+	Size    int64            `json:"size"`
+	ModTime int64            `json:"mtime"` // nanoseconds since the Unix epoch
+	Counts  wordcount.Counts `json:"counts"`
+}
+
+// countCached counts a file, reusing the counts stored in options.CacheDir when
+// the file's size and modification time are unchanged. On a miss every metric is
+// counted and stored, so later runs hit regardless of the options they use.
+// Cache failures are reported but never stop the file from being counted.
+// Counts interrupted by ctx are returned with the error, and not cached.
+func countCached(ctx context.Context, file *os.File, options cliOptions) (wordcount.Counts, string, error) {
+	// The cache holds the counts of files as they are
+	if transformsInput(options) {
+		counts, err := wordcount.CountContext(ctx, transformInput(file, options), options.CountOptions)
+		return counts, "", err
+	}
+	// The distinct words behind a unique count aren't cached, so files
+	// counted for unique words can't be merged into the total from the
+	// cache. Metrics and words split other than at white space aren't
+	// cached either, nor is the longest line or counting bytewise.
+	if options.CacheDir == "" || options.UniqueCount || len(options.Metrics) > 0 || options.WordSplitter != nil || options.MaxLineLength ||
+		options.Bytewise {
+		return countInput(ctx, file, options.CountOptions)
+	}
+	info, err := file.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return countInput(ctx, file, options.CountOptions)
+	}
+	path, err := filepath.Abs(file.Name())
+	if err != nil {
+		return countInput(ctx, file, options.CountOptions)
+	}
+	entryPath := filepath.Join(options.CacheDir, cacheKey(path))
+
+	if data, err := os.ReadFile(entryPath); err == nil {
+		var entry cacheEntry
+		if json.Unmarshal(data, &entry) == nil && entry.Path == path &&
+			entry.Size == info.Size() && entry.ModTime == info.ModTime().UnixNano() {
+			return selectCounts(entry.Counts, options.CountOptions), "", nil
+		}
+	}
+
+	all := options
+	all.ByteCount, all.LineCount, all.WordCount, all.CharacterCount = true, true, true, true
+	counts, note, err := countInput(ctx, file, all.CountOptions)
+	if errors.Is(err, wordcount.ErrInterrupted) {
+		return selectCounts(counts, options.CountOptions), note, err
+	}
+	if err != nil {
+		return wordcount.Counts{}, "", err
+	}
+	// Estimates are never cached, only exact counts
+	if note == "" {
+		entry := cacheEntry{Path: path, Size: info.Size(), ModTime: info.ModTime().UnixNano(), Counts: counts}
+		if err := writeJSONFile(entryPath, entry); err != nil {
+			logEvent(slog.LevelWarn, fmt.Sprintf("Error writing cache for %s: %v", file.Name(), err),
+				"writing cache failed", "file", file.Name(), "error", err.Error())
+		}
+	}
+	return selectCounts(counts, options.CountOptions), note, nil
+}
+
+// cacheKey returns the name of the cache entry for an absolute path. Entries are
+// keyed by path alone so a changed file replaces its stale entry.
+func cacheKey(path string) string {
+	sum := sha256.Sum256([]byte(path))
+	return hex.EncodeToString(sum[:]) + ".json"
+}
+
+// writeJSONFile atomically replaces the file at path with the JSON encoding of v
+func writeJSONFile(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, ".entry-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// selectCounts returns the subset of counts requested by the options
+func selectCounts(counts wordcount.Counts, options wordcount.CountOptions) wordcount.Counts {
+	var selected wordcount.Counts
+	if options.ByteCount {
+		selected.Bytes = counts.Bytes
+	}
+	if options.LineCount {
+		selected.Lines = counts.Lines
+	}
+	if options.WordCount {
+		selected.Words = counts.Words
+	}
+	if options.CharacterCount {
+		selected.Chars = counts.Chars
+	}
+	return selected
+}
+
+// incrementalState is the on-disk record of how far a file has been counted,
+// stored next to the cache entries
+type incrementalState struct {
+	Path string `json:"path"`
+	// Prefix is a hash of the first bytes of the file, used to notice when a
+	// log has been rotated and replaced by a new file that has grown past
+	// the bytes counted so far
+	Prefix string `json:"prefix"`
+	wordcount.State
+}
+
+// incrementalPrefixSize is the number of leading bytes hashed into incrementalState.Prefix
+const incrementalPrefixSize = 4096
+
+// countIncremental counts a file starting where the previous run stopped. The
+// saved state records the counts so far and whether the last byte was inside
+// a word, so a word split across runs is only counted once. A file that shrank
+// or whose leading bytes changed is counted again from the start.
+func countIncremental(file *os.File, options cliOptions) (wordcount.Counts, error) {
+	info, err := file.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return wordcount.Count(file, options.CountOptions)
+	}
+	path, err := filepath.Abs(file.Name())
+	if err != nil {
+		return wordcount.Count(file, options.CountOptions)
+	}
+	dir := options.CacheDir
+	if dir == "" {
+		if dir, err = defaultCacheDir(); err != nil {
+			return wordcount.Counts{}, fmt.Errorf("no directory for incremental state: %w", err)
+		}
+	}
+	statePath := filepath.Join(dir, strings.TrimSuffix(cacheKey(path), ".json")+".state.json")
+
+	var saved incrementalState
+	var state wordcount.State
+	if data, err := os.ReadFile(statePath); err == nil && json.Unmarshal(data, &saved) == nil &&
+		saved.Path == path && saved.Bytes <= info.Size() {
+		prefix, err := hashPrefix(file, saved.Bytes)
+		if err != nil {
+			return wordcount.Counts{}, err
+		}
+		if prefix == saved.Prefix {
+			state = saved.State
+		}
+	}
+
+	if _, err := file.Seek(state.Bytes, io.SeekStart); err != nil {
+		return wordcount.Counts{}, fmt.Errorf("error seeking file: %w", err)
+	}
+	state, err = wordcount.Resume(file, state, options.CountOptions)
+	if err != nil {
+		return wordcount.Counts{}, err
+	}
+
+	prefix, err := hashPrefix(file, state.Bytes)
+	if err == nil {
+		err = writeJSONFile(statePath, incrementalState{Path: path, Prefix: prefix, State: state})
+	}
+	if err != nil {
+		logEvent(slog.LevelWarn, fmt.Sprintf("Error saving incremental state for %s: %v", file.Name(), err),
+			"saving incremental state failed", "file", file.Name(), "error", err.Error())
+	}
+	// The saved state keeps a rune cut off at the end of the file pending for
+	// the next run; this run reports it as it stands
+	return state.Counts(options.CountOptions), nil
+}
+
+// hashPrefix hashes the first min(limit, incrementalPrefixSize) bytes of the file
+func hashPrefix(file *os.File, limit int64) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(file, 0, min(limit, incrementalPrefixSize))); err != nil {
+		return "", fmt.Errorf("error reading file: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// defaultCacheDir returns the directory used for incremental state when --cache isn't given
+func defaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "mwc"), nil
+}
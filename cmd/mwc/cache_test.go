@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// TestCache checks that cached counts are reused until the file changes
+func TestCache(t *testing.T) {
+	dir := t.TempDir()
+	cacheDir := filepath.Join(dir, "cache")
+	path := filepath.Join(dir, "file.txt")
+	if err := os.WriteFile(path, []byte("Hello, World!\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	count := func(options cliOptions) wordcount.Counts {
+		t.Helper()
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("Failed to open test file: %v", err)
+		}
+		defer file.Close()
+		counts, _, err := countCached(context.Background(), file, options)
+		if err != nil {
+			t.Fatalf("Error processing input: %v", err)
+		}
+		return counts
+	}
+
+	// The first run stores every metric, even though only lines were requested
+	count(cliOptions{CountOptions: wordcount.CountOptions{LineCount: true, Order: []string{"lines"}}, CacheDir: cacheDir})
+	abs, _ := filepath.Abs(path)
+	entryPath := filepath.Join(cacheDir, cacheKey(abs))
+	data, err := os.ReadFile(entryPath)
+	if err != nil {
+		t.Fatalf("Expected a cache entry: %v", err)
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatalf("Failed to decode cache entry: %v", err)
+	}
+	if entry.Counts.Words != 2 || entry.Counts.Chars != 14 {
+		t.Errorf("Expected all metrics to be cached, got %v", entry.Counts)
+	}
+
+	// Tamper with the entry to prove the next run reads it instead of the file
+	entry.Counts.Words = 42
+	data, _ = json.Marshal(entry)
+	if err := os.WriteFile(entryPath, data, 0644); err != nil {
+		t.Fatalf("Failed to rewrite cache entry: %v", err)
+	}
+	words := cliOptions{CountOptions: wordcount.CountOptions{WordCount: true, Order: []string{"words"}}, CacheDir: cacheDir}
+	if counts := count(words); counts.Words != 42 {
+		t.Errorf("Expected cached word count 42, got %d", counts.Words)
+	}
+	// Words split at Unicode word boundaries are counted afresh
+	unicodeWords := words
+	unicodeWords.WordSplitter = wordcount.UnicodeWords
+	if counts := count(unicodeWords); counts.Words != 2 {
+		t.Errorf("Expected Unicode word count 2, got %d", counts.Words)
+	}
+
+	// Changing the file invalidates the entry
+	if err := os.WriteFile(path, []byte("Hello, brave new World!\n"), 0644); err != nil {
+		t.Fatalf("Failed to rewrite test file: %v", err)
+	}
+	if counts := count(words); counts.Words != 4 {
+		t.Errorf("Expected recounted word count 4, got %d", counts.Words)
+	}
+}
+
+// TestIncremental checks that appended data is counted on top of the saved state
+func TestIncremental(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	options := cliOptions{
+		CountOptions: wordcount.CountOptions{LineCount: true, WordCount: true, ByteCount: true},
+		CacheDir:     filepath.Join(dir, "state"),
+		Incremental:  true,
+	}
+
+	count := func() wordcount.Counts {
+		t.Helper()
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("Failed to open test file: %v", err)
+		}
+		defer file.Close()
+		counts, _, err := countFile(context.Background(), file, options)
+		if err != nil {
+			t.Fatalf("Error processing input: %v", err)
+		}
+		return counts
+	}
+	appendTo := func(content string) {
+		t.Helper()
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatalf("Failed to open test file: %v", err)
+		}
+		defer file.Close()
+		if _, err := file.WriteString(content); err != nil {
+			t.Fatalf("Failed to append to test file: %v", err)
+		}
+	}
+
+	appendTo("first line\nsplit wo")
+	if counts := count(); counts.Words != 4 || counts.Lines != 1 {
+		t.Errorf("Expected 4 words and 1 line, got %v", counts)
+	}
+
+	// The word cut off at the end of the first run must not be counted twice
+	appendTo("rd here\n")
+	if counts := count(); counts.Words != 5 || counts.Lines != 2 || counts.Bytes != 27 {
+		t.Errorf("Expected 5 words, 2 lines and 27 bytes, got %v", counts)
+	}
+
+	// A rotated file is counted from the start
+	if err := os.WriteFile(path, []byte("rotated log file with more content\n"), 0644); err != nil {
+		t.Fatalf("Failed to rewrite test file: %v", err)
+	}
+	if counts := count(); counts.Words != 6 || counts.Lines != 1 {
+		t.Errorf("Expected 6 words and 1 line after rotation, got %v", counts)
+	}
+
+	// A truncated file is counted from the start
+	if err := os.WriteFile(path, []byte("short\n"), 0644); err != nil {
+		t.Fatalf("Failed to rewrite test file: %v", err)
+	}
+	if counts := count(); counts.Words != 1 || counts.Lines != 1 {
+		t.Errorf("Expected 1 word and 1 line after truncation, got %v", counts)
+	}
+}
+
+// TestChunkBoundariesAcrossRuns checks that a rune cut off at the end of the file is completed by the next incremental run
+func TestChunkBoundariesAcrossRuns(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	options := cliOptions{CountOptions: wordcount.CountOptions{WordCount: true, CharacterCount: true}, CacheDir: dir, Incremental: true}
+	content := []byte("世界 мир")
+
+	for _, end := range []int{1, 5, 7, len(content)} {
+		if err := os.WriteFile(path, content[:end], 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("Failed to open test file: %v", err)
+		}
+		counts, _, err := countFile(context.Background(), file, options)
+		file.Close()
+		if err != nil {
+			t.Fatalf("Error processing input: %v", err)
+		}
+		expected, err := wordcount.Count(bytes.NewReader(content[:end]), options.CountOptions)
+		if err != nil {
+			t.Fatalf("Error processing input: %v", err)
+		}
+		if counts.Words != expected.Words || counts.Chars != expected.Chars {
+			t.Errorf("After %d bytes: expected %d words and %d characters, got %d words and %d characters",
+				end, expected.Words, expected.Chars, counts.Words, counts.Chars)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// compatOrder lists the counts wc prints in the order it prints them, however
+// the options were given
+var compatOrder = []string{"lines", "words", "characters", "bytes", "max_line_length"}
+
+// orderForCompat puts the counts in the order the wc of a --compat mode
+// prints them. BSD wc prints either characters or bytes, whichever was asked
+// for last, so with "bsd" the other one is dropped. Counts wc doesn't have
+// keep their order after its own.
+func orderForCompat(options cliOptions, compat string) cliOptions {
+	if compat == "bsd" && options.ByteCount && options.CharacterCount {
+		if slices.Index(options.Order, "bytes") > slices.Index(options.Order, "characters") {
+			options.CharacterCount = false
+		} else {
+			options.ByteCount = false
+		}
+	}
+	var order []string
+	for _, name := range compatOrder {
+		if slices.Contains(options.Order, name) && *options.enabledCount(name) {
+			order = append(order, name)
+		}
+	}
+	for _, name := range options.Order {
+		if !slices.Contains(compatOrder, name) {
+			order = append(order, name)
+		}
+	}
+	options.Order = order
+	return options
+}
+
+// enabledCount returns the flag enabling one of the counts of compatOrder
+func (o *cliOptions) enabledCount(name string) *bool {
+	switch name {
+	case "lines":
+		return &o.LineCount
+	case "words":
+		return &o.WordCount
+	case "characters":
+		return &o.CharacterCount
+	case "bytes":
+		return &o.ByteCount
+	}
+	return &o.MaxLineLength
+}
+
+// compatFromArgs returns the --compat mode among command-line arguments
+// that couldn't be parsed, so usage errors can still be reported as that
+// wc reports them
+func compatFromArgs(args []string) string {
+	for i, arg := range args {
+		if value, ok := strings.CutPrefix(arg, "--compat="); ok {
+			return value
+		}
+		if arg == "--compat" && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// printUsageError reports a command-line error with the usage line, in the
+// format of the wc of a --compat mode
+func printUsageError(err error, compat string) {
+	var optionErr *wordcount.OptionError
+	switch compat {
+	case "gnu":
+		// GNU wc quotes illegal short options and points at --help
+		if errors.As(err, &optionErr) && len(optionErr.Option) == 2 && optionErr.Option[0] == '-' {
+			_, _ = fmt.Fprintf(os.Stderr, "%s: invalid option -- '%c'\n", os.Args[0], optionErr.Option[1])
+		} else {
+			_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[0], err)
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "Try '%s --help' for more information.\n", os.Args[0])
+	case "bsd":
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[0], err)
+		_, _ = fmt.Fprintf(os.Stderr, "usage: %s [-Lclmw] [file ...]\n", os.Args[0])
+	default:
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[0], err)
+		_, _ = fmt.Fprintf(os.Stderr, "usage: %s [-Lclmrw] [file ...]\n", os.Args[0])
+	}
+}
+
+// gnuNumberWidth returns the width GNU wc prints counts in: wide enough for
+// the combined size of the regular files among the inputs, and at least 7
+// when any of them, or stdin, isn't one, since its size can't be known.
+// A single count of a single input isn't padded at all.
+func gnuNumberWidth(fsys fs.FS, filenames []string, options cliOptions) int {
+	if len(options.Order) == 1 && len(filenames) <= 1 {
+		return 1
+	}
+	minWidth := 1
+	var regularTotal int64
+	stat := func(name string) (fs.FileInfo, error) { return fs.Stat(fsys, name) }
+	if len(filenames) == 0 {
+		filenames = []string{""}
+		stat = func(string) (fs.FileInfo, error) { return os.Stdin.Stat() }
+	}
+	for _, name := range filenames {
+		info, err := stat(name)
+		if err != nil {
+			continue
+		}
+		if info.Mode().IsRegular() {
+			regularTotal += info.Size()
+		} else {
+			minWidth = 7
+		}
+	}
+	return max(len(strconv.FormatInt(regularTotal, 10)), minWidth)
+}
+
+// printCompatCounts prints a row of counts as the wc of a --compat mode does:
+// GNU wc in columns of options.CompatWidth separated by a space, BSD wc in
+// columns of 7 each preceded by a space
+func printCompatCounts(w io.Writer, counts wordcount.Counts, filename string, options cliOptions) {
+	first := true
+	for _, countType := range options.Order {
+		count, ok := counts.Get(countType)
+		if !ok {
+			continue
+		}
+		switch {
+		case options.Compat == "bsd":
+			_, _ = fmt.Fprintf(w, " %7d", count)
+		case first:
+			_, _ = fmt.Fprintf(w, "%*d", options.CompatWidth, count)
+		default:
+			_, _ = fmt.Fprintf(w, " %*d", options.CompatWidth, count)
+		}
+		first = false
+	}
+	for _, expr := range options.Exprs {
+		_, _ = fmt.Fprintf(w, " %7.2f", expr.Eval(counts))
+	}
+	if filename != "" {
+		_, _ = fmt.Fprintf(w, " %s", filename)
+	}
+	_, _ = fmt.Fprintln(w)
+}
+
+// printCompatFileError reports an input that couldn't be counted as the wc
+// of a --compat mode does: "wc: name: Reason" for GNU wc, and with the
+// failed operation, "wc: name: open: Reason", for BSD wc
+func printCompatFileError(err error, compat string) {
+	var fileErr *wordcount.FileError
+	if !errors.As(err, &fileErr) {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %s\n", os.Args[0], capitalize(err.Error()))
+		return
+	}
+	op, reason := "read", fileErr.Err.Error()
+	if fileErr.Op == "open" && !errors.Is(err, errIsDirectory) {
+		op = "open"
+	}
+	var errno syscall.Errno
+	switch {
+	case errors.Is(err, errIsDirectory):
+		reason = syscall.EISDIR.Error()
+	case errors.As(err, &errno):
+		reason = errno.Error()
+	}
+	if compat == "bsd" {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %s: %s: %s\n", os.Args[0], fileErr.Path, op, capitalize(reason))
+	} else {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %s: %s\n", os.Args[0], fileErr.Path, capitalize(reason))
+	}
+}
+
+// capitalize returns s with its first letter in upper case, as C's strerror
+// describes errors
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+// TestCompat checks that --compat prints counts, totals and errors as GNU and
+// BSD wc do, and fails when an input can't be counted
+func TestCompat(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"a.txt": "one two\nthree\n", "b.txt": "four\n", "sub/c.txt": "five\n"})
+	chdir(t, dir)
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	os.Args = []string{"wc"}
+
+	tests := []struct {
+		name           string
+		args           []string
+		expectedOut    string
+		expectedErr    string
+		expectedStatus int
+	}{
+		{"GNU", []string{"--compat", "gnu", "-cl", "a.txt", "b.txt"}, " 2 14 a.txt\n 1  5 b.txt\n 3 19 total\n", "", 0},
+		{"GNU Fixed Order", []string{"--compat=gnu", "-cw", "a.txt"}, " 3 14 a.txt\n", "", 0},
+		{"GNU Single Count", []string{"--compat", "gnu", "-c", "a.txt"}, "14 a.txt\n", "", 0},
+		{"GNU Missing File", []string{"--compat", "gnu", "-w", "missing.txt", "b.txt"},
+			"1 b.txt\n1 total\n", "wc: missing.txt: No such file or directory\n", 1},
+		{"GNU Directory", []string{"--compat", "gnu", "-l", "sub", "b.txt"},
+			"      0 sub\n      1 b.txt\n      1 total\n", "wc: sub: Is a directory\n", 1},
+		{"BSD", []string{"--compat", "bsd", "a.txt", "b.txt"},
+			"       2       3      14 a.txt\n       1       1       5 b.txt\n       3       4      19 total\n", "", 0},
+		{"BSD Last Of Bytes And Characters", []string{"--compat", "bsd", "-cml", "a.txt"}, "       2      14 a.txt\n", "", 0},
+		{"BSD Missing File", []string{"--compat", "bsd", "-l", "missing.txt"}, "", "wc: missing.txt: open: No such file or directory\n", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options, filenames, err := parseArgs(tt.args)
+			if err != nil {
+				t.Fatalf("Error parsing arguments: %v", err)
+			}
+			setupLogging(options)
+			var status int
+			stdout, stderr := captureFunc(t, func() { status = run(options, filenames) })
+			if stdout != tt.expectedOut {
+				t.Errorf("Expected:\n%q\ngot:\n%q", tt.expectedOut, stdout)
+			}
+			if stderr != tt.expectedErr {
+				t.Errorf("Expected error %q, got %q", tt.expectedErr, stderr)
+			}
+			if status != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, status)
+			}
+		})
+	}
+}
+
+// TestCompatUsageError checks that command-line errors are reported as the wc
+// of the --compat mode reports them
+func TestCompatUsageError(t *testing.T) {
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	os.Args = []string{"wc"}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"GNU", []string{"--compat", "gnu", "-x"}, "wc: invalid option -- 'x'\nTry 'wc --help' for more information.\n"},
+		{"BSD", []string{"--compat=bsd", "-x"}, "wc: illegal option -- x\nusage: wc [-Lclmw] [file ...]\n"},
+		{"Default", []string{"-x"}, "wc: illegal option -- x\nusage: wc [-Lclmrw] [file ...]\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := parseArgs(tt.args)
+			if err == nil {
+				t.Fatalf("Expected an error")
+			}
+			_, stderr := captureFunc(t, func() { printUsageError(err, compatFromArgs(tt.args)) })
+			if stderr != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, stderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// diff runs mwc diff with the given arguments and returns the exit status.
+// It prints how the counts of the new file differ from those of the old one,
+// or, for two directories, those of each file that changed between them,
+// matched by their paths relative to the directories, and of all of them.
+func diff(args []string) int {
+	options, filenames, err := parseArgs(append([]string{"-r"}, args...))
+	if err == nil && !options.HelpRequested && len(filenames) != 2 {
+		err = errors.New("mwc diff requires two files or directories")
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[0], err)
+		_, _ = fmt.Fprintf(os.Stderr, "usage: %s diff [-clmw] old new\n", os.Args[0])
+		return exitUsage
+	}
+	if options.HelpRequested {
+		printDiffUsage()
+		return 0
+	}
+	if !hasAnyOption(options) {
+		options.LineCount = true
+		options.WordCount = true
+		options.ByteCount = true
+		options.Order = []string{"lines", "words", "bytes"}
+	}
+	options.CountOptions = options.Normalize()
+	setupLogging(options)
+
+	var fsys fs.FS = osFS{}
+	if options.FSRoot != "" {
+		fsys = os.DirFS(options.FSRoot)
+	}
+	oldInfo, err := fs.Stat(fsys, filenames[0])
+	var newInfo fs.FileInfo
+	if err == nil {
+		newInfo, err = fs.Stat(fsys, filenames[1])
+	}
+	if err != nil {
+		logError("diff failed", err)
+		return exitFileErrors
+	}
+	if oldInfo.IsDir() != newInfo.IsDir() {
+		logError("diff failed", fmt.Errorf("can't compare %s with %s: only one is a directory", filenames[0], filenames[1]))
+		return exitUsage
+	}
+
+	status := 0
+	fail := func(err error) {
+		printFileError(err)
+		status = exitFileErrors
+	}
+	if !oldInfo.IsDir() {
+		oldCounts, _, oldErr := countNamedFile(context.Background(), fsys, filenames[0], options)
+		newCounts, _, newErr := countNamedFile(context.Background(), fsys, filenames[1], options)
+		if err := errors.Join(oldErr, newErr); err != nil {
+			fail(err)
+			return status
+		}
+		fmt.Println(describeChange(oldCounts, newCounts, options))
+		return status
+	}
+
+	oldFiles := countTree(fsys, filenames[0], options, fail)
+	newFiles := countTree(fsys, filenames[1], options, fail)
+	printTreeChanges(oldFiles, newFiles, options)
+	return status
+}
+
+// countTree counts the files under a directory, by their slash-separated
+// paths relative to it. Files that can't be counted are reported and left out.
+func countTree(fsys fs.FS, dir string, options cliOptions, fail func(error)) map[string]wordcount.Counts {
+	files := map[string]wordcount.Counts{}
+	newWalker(fsys, options).expand(dir, func(name string) {
+		counts, _, err := countNamedFile(context.Background(), fsys, name, options)
+		if err != nil {
+			fail(err)
+			return
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			rel = name
+		}
+		files[filepath.ToSlash(rel)] = counts
+	}, fail)
+	return files
+}
+
+// printTreeChanges prints how the counts of each file that differs between
+// two directories changed, noting those only in one of them, then how the
+// total changed
+func printTreeChanges(oldFiles, newFiles map[string]wordcount.Counts, options cliOptions) {
+	var names []string
+	for name := range oldFiles {
+		names = append(names, name)
+	}
+	for name := range newFiles {
+		if _, ok := oldFiles[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var oldTotal, newTotal wordcount.Counts
+	for _, name := range names {
+		oldCounts, inOld := oldFiles[name]
+		newCounts, inNew := newFiles[name]
+		oldTotal.Add(oldCounts)
+		newTotal.Add(newCounts)
+		note := ""
+		switch {
+		case !inOld:
+			note = " (added)"
+		case !inNew:
+			note = " (removed)"
+		case !countsChanged(oldCounts, newCounts, options):
+			continue
+		}
+		fmt.Printf("%s%s: %s\n", name, note, describeChange(oldCounts, newCounts, options))
+	}
+	fmt.Printf("total: %s\n", describeChange(oldTotal, newTotal, options))
+}
+
+// countsChanged reports whether any count of the options differs between
+// two counts
+func countsChanged(oldCounts, newCounts wordcount.Counts, options cliOptions) bool {
+	for _, name := range options.Order {
+		oldCount, _ := oldCounts.Get(name)
+		if newCount, _ := newCounts.Get(name); newCount != oldCount {
+			return true
+		}
+	}
+	return false
+}
+
+// describeChange describes how each count of the options changed, such as
+// "+523 words, -2 lines, +3.1KB", with bytes in the units of formatSize
+func describeChange(oldCounts, newCounts wordcount.Counts, options cliOptions) string {
+	var parts []string
+	for _, name := range options.Order {
+		oldCount, _ := oldCounts.Get(name)
+		newCount, ok := newCounts.Get(name)
+		if !ok {
+			continue
+		}
+		change := newCount - oldCount
+		sign := "+"
+		if change < 0 {
+			sign = "-"
+		}
+		if name == "bytes" {
+			parts = append(parts, sign+formatSize(math.Abs(float64(change))))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s%d %s", sign, max(change, -change), name))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func printDiffUsage() {
+	fmt.Println("Usage: mwc diff [-lwcm] [options] old new")
+	fmt.Println("Print how the counts of the file new differ from those of the file old, such")
+	fmt.Println("as +523 words, -2 lines, +3.1KB. For two directories, print how they differ")
+	fmt.Println("for each file that changed, matching files by their paths relative to the")
+	fmt.Println("directories, then for all of them.")
+	fmt.Println("\nThe files under directories are chosen with the same options as mwc -r;")
+	fmt.Println("see mwc --help.")
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// TestDiff checks the changes printed between two files and between two
+// directories
+func TestDiff(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"old/01.md":       "It was a dark and stormy night.\n",
+		"old/02.md":       "The end.\n",
+		"old/notes/a.md":  "remember the dog\n",
+		"new/01.md":       "It was a dark and stormy night.\nThe rain fell in torrents.\n",
+		"new/02.md":       "The end.\n",
+		"new/epilogue.md": "Years later.\n",
+	})
+	oldDir, newDir := filepath.Join(dir, "old"), filepath.Join(dir, "new")
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"files", []string{filepath.Join(oldDir, "01.md"), filepath.Join(newDir, "01.md")},
+			"+1 lines, +5 words, +27B\n"},
+		{"words", []string{"-w", filepath.Join(newDir, "01.md"), filepath.Join(oldDir, "01.md")},
+			"-5 words\n"},
+		{"directories", []string{"-w", oldDir, newDir},
+			"01.md: +5 words\nepilogue.md (added): +2 words\nnotes/a.md (removed): -3 words\ntotal: +4 words\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var status int
+			stdout, stderr := captureFunc(t, func() { status = diff(tt.args) })
+			if status != 0 || stdout != tt.expected {
+				t.Errorf("Expected:\n%s\ngot %d:\n%s%s", tt.expected, status, stdout, stderr)
+			}
+		})
+	}
+
+	var status int
+	_, stderr := captureFunc(t, func() { status = diff([]string{filepath.Join(oldDir, "01.md"), newDir}) })
+	if status != 1 || stderr == "" {
+		t.Errorf("Expected an error comparing a file with a directory, got %d: %q", status, stderr)
+	}
+}
+
+// TestDescribeChange tests the description of changes in counts
+func TestDescribeChange(t *testing.T) {
+	options := cliOptions{CountOptions: wordcount.CountOptions{Order: []string{"words", "lines", "bytes"}}}
+	tests := []struct {
+		oldCounts, newCounts wordcount.Counts
+		expected             string
+	}{
+		{wordcount.Counts{Words: 100, Lines: 10, Bytes: 1000}, wordcount.Counts{Words: 623, Lines: 8, Bytes: 4200}, "+523 words, -2 lines, +3.1KB"},
+		{wordcount.Counts{Words: 5, Bytes: 300}, wordcount.Counts{Words: 5, Bytes: 100}, "+0 words, +0 lines, -200B"},
+	}
+	for _, tt := range tests {
+		if got := describeChange(tt.oldCounts, tt.newCounts, options); got != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, got)
+		}
+	}
+}
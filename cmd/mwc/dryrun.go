@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// dryRun implements --dry-run: it resolves the inputs as counting would, with
+// their globs, --files-from lists and -r, checks that each file exists, is a
+// file and can be opened, and prints its size, without reading any of it. It
+// returns the exit status.
+func dryRun(options cliOptions, filenames []string) int {
+	if len(filenames) == 0 && options.FilesFrom == "" && !options.Staged {
+		fmt.Printf("%8s stdin\n", "-")
+		return 0
+	}
+
+	var fsys fs.FS = osFS{}
+	if options.FSRoot != "" {
+		fsys = os.DirFS(options.FSRoot)
+	}
+	status := 0
+	fail := func(err error) {
+		printFileError(err)
+		status = exitFileErrors
+	}
+	files, size := 0, int64(0)
+	found := func(name string, bytes int64) {
+		fmt.Printf("%8d %s\n", bytes, name)
+		files++
+		size += bytes
+	}
+
+	if options.Staged {
+		// The files named are pathspecs limiting the staged files
+		staged, err := stagedFiles(filenames, options)
+		if err != nil {
+			logError("listing the staged files failed", err)
+			return exitFailed
+		}
+		for _, name := range staged {
+			out, err := runGit("cat-file", "-s", ":"+name)
+			if err != nil {
+				fail(&wordcount.FileError{Op: "open", Path: name, Err: err})
+				continue
+			}
+			bytes, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+			if err != nil {
+				fail(&wordcount.FileError{Op: "open", Path: name, Err: err})
+				continue
+			}
+			found(name, bytes)
+		}
+		filenames = nil
+	}
+
+	walker := newWalker(fsys, options)
+	for _, filename := range filenames {
+		if isObjectURL(filename) {
+			fmt.Printf("%8s %s (object store, not checked)\n", "-", filename)
+			continue
+		}
+		if _, ok := parseRemotePath(filename); ok {
+			fmt.Printf("%8s %s (remote, not checked)\n", "-", filename)
+			continue
+		}
+		walker.expand(filename, func(name string) {
+			info, err := checkNamedFile(fsys, name)
+			if err != nil {
+				fail(err)
+				return
+			}
+			if !info.Mode().IsRegular() {
+				// Opening a named pipe would wait for a writer
+				fmt.Printf("%8s %s (%s)\n", "-", name, fileTypeName(info.Mode()))
+				files++
+				return
+			}
+			found(name, info.Size())
+		}, fail)
+	}
+	fmt.Printf("%d files, %s (%d bytes) would be counted\n", files, formatSize(float64(size)), size)
+	return status
+}
+
+// checkNamedFile checks that a named input exists and isn't a directory, and
+// that a regular file can be opened, without reading it. It returns the
+// file's information; failures are returned as a *wordcount.FileError.
+func checkNamedFile(fsys fs.FS, name string) (fs.FileInfo, error) {
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return nil, &wordcount.FileError{Op: "open", Path: name, Err: err}
+	}
+	if info.IsDir() {
+		return nil, &wordcount.FileError{Op: "open", Path: name, Err: errIsDirectory}
+	}
+	if !info.Mode().IsRegular() {
+		return info, nil
+	}
+	file, err := openFile(fsys, name)
+	if err != nil {
+		return nil, &wordcount.FileError{Op: "open", Path: name, Err: err}
+	}
+	_ = file.Close()
+	return info, nil
+}
+
+// fileTypeName names the type of a file that isn't a regular file or a
+// directory
+func fileTypeName(mode fs.FileMode) string {
+	switch {
+	case mode&fs.ModeNamedPipe != 0:
+		return "named pipe"
+	case mode&fs.ModeSocket != 0:
+		return "socket"
+	case mode&fs.ModeCharDevice != 0:
+		return "character device"
+	case mode&fs.ModeDevice != 0:
+		return "device"
+	default:
+		return "special file"
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+// TestDryRun checks that --dry-run lists the files the inputs resolve to and
+// their sizes, and reports those that couldn't be counted
+func TestDryRun(t *testing.T) {
+	chdir(t, t.TempDir())
+	initGitRepo(t)
+	writeTree(t, ".", map[string]string{
+		"docs/a.md":     "one two three\n",
+		"docs/b.md":     "four\n",
+		"docs/c.txt":    "five six\n",
+		"notes.md":      "seven\n",
+		"files.txt":     "notes.md\ndocs/c.txt\n",
+		"docs/draft.md": "not staged\n",
+	})
+	git(t, "add", "docs/a.md", "notes.md")
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	os.Args = []string{"mwc"}
+
+	tests := []struct {
+		name           string
+		args           []string
+		expectedOut    string
+		expectedErr    string
+		expectedStatus int
+	}{
+		{"Files", []string{"--dry-run", "docs/a.md", "notes.md"},
+			"      14 docs/a.md\n       6 notes.md\n2 files, 20B (20 bytes) would be counted\n", "", 0},
+		{"Recursive", []string{"--dry-run", "-r", "--include", "*.md", "docs"},
+			"      14 docs/a.md\n       5 docs/b.md\n      11 docs/draft.md\n3 files, 30B (30 bytes) would be counted\n", "", 0},
+		{"Glob", []string{"--dry-run", "docs/*.txt"}, "       9 docs/c.txt\n1 files, 9B (9 bytes) would be counted\n", "", 0},
+		{"Files From", []string{"--dry-run", "--files-from", "files.txt"},
+			"       6 notes.md\n       9 docs/c.txt\n2 files, 15B (15 bytes) would be counted\n", "", 0},
+		{"Staged", []string{"--dry-run", "--staged"},
+			"      14 docs/a.md\n       6 notes.md\n2 files, 20B (20 bytes) would be counted\n", "", 0},
+		{"Stdin", []string{"--dry-run"}, "       - stdin\n", "", 0},
+		{"Failures", []string{"--dry-run", "missing.md", "docs", "notes.md"},
+			"       6 notes.md\n1 files, 6B (6 bytes) would be counted\n",
+			"Error opening missing.md: stat missing.md: no such file or directory\n" +
+				"Error opening docs: is a directory (use -r to count the files in it)\n", exitFileErrors},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options, filenames, err := parseArgs(tt.args)
+			if err != nil {
+				t.Fatalf("Error parsing arguments: %v", err)
+			}
+			var status int
+			stdout, stderr := captureFunc(t, func() { status = dryRun(options, filenames) })
+			if stdout != tt.expectedOut {
+				t.Errorf("Expected:\n%q\ngot:\n%q", tt.expectedOut, stdout)
+			}
+			if stderr != tt.expectedErr {
+				t.Errorf("Expected error %q, got %q", tt.expectedErr, stderr)
+			}
+			if status != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, status)
+			}
+		})
+	}
+}
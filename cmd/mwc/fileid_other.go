@@ -0,0 +1,10 @@
+//go:build !unix && !wasip1 && !windows
+
+package main
+
+import "io/fs"
+
+// fileID reports that files can't be told apart on this platform
+func fileID(fsys fs.FS, name string) (fileKey, bool) {
+	return fileKey{}, false
+}
@@ -0,0 +1,21 @@
+//go:build unix || wasip1
+
+package main
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileID returns the device and inode of a file, following symbolic links
+func fileID(fsys fs.FS, name string) (fileKey, bool) {
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return fileKey{}, false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileKey{}, false
+	}
+	return fileKey{device: uint64(stat.Dev), inode: uint64(stat.Ino)}, true
+}
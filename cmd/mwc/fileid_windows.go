@@ -0,0 +1,25 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// fileID returns the volume and file index of a file, following symbolic links
+func fileID(fsys fs.FS, name string) (fileKey, bool) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return fileKey{}, false
+	}
+	defer f.Close()
+	file, ok := f.(*os.File)
+	if !ok {
+		return fileKey{}, false
+	}
+	var data syscall.ByHandleFileInformation
+	if err := syscall.GetFileInformationByHandle(syscall.Handle(file.Fd()), &data); err != nil {
+		return fileKey{}, false
+	}
+	return fileKey{device: uint64(data.VolumeSerialNumber), inode: uint64(data.FileIndexHigh)<<32 | uint64(data.FileIndexLow)}, true
+}
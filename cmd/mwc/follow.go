@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// follow counts the named files like tail -f, with --follow: it keeps them
+// open, counts the data appended to them every --follow interval, and prints
+// the cumulative counts again whenever they grew, until it is interrupted.
+// It returns the exit status.
+func follow(options cliOptions, filenames []string) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	status := 0
+	followFiles(ctx, options, filenames, func(files []wordcount.FileCount) {
+		var total wordcount.Accumulator
+		for _, file := range files {
+			printCounts(file.Counts, file.Filename, options)
+			total.Add(file.Counts)
+		}
+		if total.Inputs() > 1 {
+			printCounts(total.Total(), "total", options)
+		}
+	}, func(err error) {
+		printFileError(err)
+		status = exitFileErrors
+	})
+	return status
+}
+
+// followedFile is a file kept open by --follow and the state of counting it
+type followedFile struct {
+	name  string
+	file  *os.File
+	state wordcount.State
+}
+
+// followFiles opens the files named by the inputs and counts them, then
+// counts the data appended to them every options.Follow until ctx is done.
+// report is called with the cumulative counts of every file first and then
+// whenever one of them grew. A file that shrank, such as a log truncated by
+// logrotate's copytruncate, is counted again from the start. Files that can't
+// be opened or read are passed to fail and no longer followed.
+func followFiles(ctx context.Context, options cliOptions, filenames []string, report func([]wordcount.FileCount), fail func(error)) {
+	var fsys fs.FS = osFS{}
+	if options.FSRoot != "" {
+		fsys = os.DirFS(options.FSRoot)
+	}
+	var files []*followedFile
+	walker := newWalker(fsys, options)
+	for _, filename := range filenames {
+		walker.expand(filename, func(name string) {
+			file, err := openFile(fsys, name)
+			if err != nil {
+				fail(&wordcount.FileError{Op: "open", Path: name, Err: err})
+				return
+			}
+			files = append(files, &followedFile{name: name, file: file})
+		}, fail)
+	}
+	defer func() {
+		for _, f := range files {
+			_ = f.file.Close()
+		}
+	}()
+
+	ticker := time.NewTicker(options.Follow)
+	defer ticker.Stop()
+	for first := true; ; first = false {
+		grown := false
+		for i := 0; i < len(files); i++ {
+			f := files[i]
+			before := f.state.Bytes
+			err := f.countAppended(options)
+			if err != nil {
+				fail(&wordcount.FileError{Op: "read", Path: f.name, Err: err})
+				_ = f.file.Close()
+				files = append(files[:i], files[i+1:]...)
+				i--
+				continue
+			}
+			grown = grown || f.state.Bytes != before
+		}
+		if first || grown {
+			counts := make([]wordcount.FileCount, len(files))
+			for i, f := range files {
+				counts[i] = wordcount.FileCount{Filename: f.name, Counts: f.state.Counts(options.CountOptions)}
+			}
+			report(counts)
+		}
+		if len(files) == 0 {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// countAppended counts the data appended to the file since it was last
+// counted, or all of it again if the file shrank
+func (f *followedFile) countAppended(options cliOptions) error {
+	info, err := f.file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < f.state.Bytes {
+		if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		f.state = wordcount.State{}
+	}
+	f.state, err = wordcount.Resume(f.file, f.state, options.CountOptions)
+	return err
+}
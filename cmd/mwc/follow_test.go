@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// TestFollowFiles checks that data appended to followed files is counted on
+// top of what was already counted, and that truncated files start over
+func TestFollowFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"app.log":   "started\n",
+		"other.log": "one two\n",
+	})
+	chdir(t, dir)
+
+	options, filenames, err := parseArgs([]string{"-lw", "--follow=10ms", "app.log", "missing.log", "other.log"})
+	if err != nil {
+		t.Fatalf("Error parsing arguments: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	reports := make(chan []wordcount.FileCount)
+	failures := make(chan error, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		followFiles(ctx, options, filenames, func(files []wordcount.FileCount) {
+			reports <- files
+		}, func(err error) {
+			failures <- err
+		})
+	}()
+	defer func() {
+		cancel()
+		for {
+			select {
+			case <-reports:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	next := func() map[string]wordcount.Counts {
+		select {
+		case files := <-reports:
+			counts := map[string]wordcount.Counts{}
+			for _, file := range files {
+				counts[file.Filename] = file.Counts
+			}
+			return counts
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for a report")
+			return nil
+		}
+	}
+	check := func(counts map[string]wordcount.Counts, name string, lines, words int64) {
+		t.Helper()
+		if got := counts[name]; got.Lines != lines || got.Words != words {
+			t.Errorf("Expected %d lines and %d words in %s, got %+v", lines, words, name, got)
+		}
+	}
+
+	counts := next()
+	if err := <-failures; err == nil || len(counts) != 2 {
+		t.Errorf("Expected missing.log to fail and two files to be followed, got %v, %v", err, counts)
+	}
+	check(counts, "app.log", 1, 1)
+	check(counts, "other.log", 1, 2)
+
+	appendFile := func(name, data string) {
+		t.Helper()
+		file, err := os.OpenFile(filepath.Join(dir, name), os.O_APPEND|os.O_WRONLY, 0)
+		if err != nil {
+			t.Fatalf("Failed to open file: %v", err)
+		}
+		defer file.Close()
+		if _, err := file.WriteString(data); err != nil {
+			t.Fatalf("Failed to append to file: %v", err)
+		}
+	}
+	// A word split across two writes is counted once
+	appendFile("app.log", "request ser")
+	check(next(), "app.log", 1, 3)
+	appendFile("app.log", "ved\n")
+	counts = next()
+	check(counts, "app.log", 2, 3)
+	check(counts, "other.log", 1, 2)
+
+	if err := os.Truncate(filepath.Join(dir, "app.log"), 0); err != nil {
+		t.Fatalf("Failed to truncate file: %v", err)
+	}
+	appendFile("app.log", "x\n")
+	for counts = next(); counts["app.log"].Lines == 0; counts = next() {
+	}
+	check(counts, "app.log", 1, 1)
+}
+
+// TestFollowPosix checks that --follow counts as --posix says, with every byte
+// a character and a no-break space inside a word
+func TestFollowPosix(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"app.log": "caf\u00e9\u00a0bar go\n"})
+	chdir(t, dir)
+
+	options, filenames, err := parseArgs([]string{"--posix", "-wm", "--follow=10ms", "app.log"})
+	if err != nil {
+		t.Fatalf("Error parsing arguments: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reports := make(chan []wordcount.FileCount, 1)
+	go followFiles(ctx, options, filenames, func(files []wordcount.FileCount) {
+		select {
+		case reports <- files:
+		default:
+		}
+	}, func(err error) {
+		t.Errorf("Unexpected error: %v", err)
+	})
+
+	select {
+	case files := <-reports:
+		if len(files) != 1 || files[0].Counts.Words != 2 || files[0].Counts.Chars != 14 {
+			t.Errorf("Expected 2 words and 14 characters, got %+v", files)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for a report")
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// runGit runs git with the arguments in the current directory and returns
+// what it wrote to stdout. Paths are printed as they are rather than with
+// their non-ASCII bytes escaped. When git fails, the error has the first line
+// it wrote to stderr, such as "fatal: not a git repository".
+func runGit(args ...string) ([]byte, error) {
+	return runGitIn("", args...)
+}
+
+// runGitIn is runGit in the directory dir, or the current one if it is empty
+func runGitIn(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-c", "core.quotePath=false"}, args...)...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && stderr.Len() > 0 {
+			line, _, _ := strings.Cut(strings.TrimSpace(stderr.String()), "\n")
+			return nil, fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(line))
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+// gitTrackedPaths returns the absolute, slash-separated paths of the files
+// git tracks under dir, as git ls-files lists them in the repository dir is
+// in, and of the directories they are in, up to dir
+func gitTrackedPaths(dir string) (map[string]bool, error) {
+	out, err := runGitIn(dir, "ls-files", "-z")
+	if err != nil {
+		return nil, err
+	}
+	top, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	top = filepath.ToSlash(top)
+	tracked := map[string]bool{}
+	for _, name := range strings.Split(string(out), "\x00") {
+		// Names are relative to dir
+		for name := path.Join(top, name); name != top && !tracked[name]; name = path.Dir(name) {
+			tracked[name] = true
+		}
+	}
+	return tracked, nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// gitAuthorCounts is the counts of the lines an author last changed
+type gitAuthorCounts struct {
+	Author string
+	Counts wordcount.Counts
+}
+
+// gitBlame implements mwc git-blame, which prints the counts of the lines of
+// the files named that each author last changed, as git blame attributes
+// them, most words first, and their total. It returns the exit status.
+func gitBlame(args []string) int {
+	values, args, err := cutValueOptions(args, "rev")
+	var options cliOptions
+	var filenames []string
+	if err == nil {
+		options, filenames, err = parseArgs(args)
+	}
+	if err == nil && !options.HelpRequested && len(filenames) == 0 {
+		err = errors.New("mwc git-blame needs files to blame")
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[0], err)
+		_, _ = fmt.Fprintf(os.Stderr, "usage: %s git-blame [--rev REV] [-clmw] file ...\n", os.Args[0])
+		return exitUsage
+	}
+	if options.HelpRequested {
+		printGitBlameUsage()
+		return 0
+	}
+	setupLogging(options)
+
+	text := map[string]*bytes.Buffer{}
+	for _, filename := range filenames {
+		blameArgs := []string{"blame", "--line-porcelain"}
+		if values["rev"] != "" {
+			blameArgs = append(blameArgs, values["rev"])
+		}
+		out, err := runGit(append(blameArgs, "--", filename)...)
+		if err != nil {
+			logError("git-blame failed", err, "file", filename)
+			return exitFailed
+		}
+		for author, lines := range parseBlame(out) {
+			if text[author] == nil {
+				text[author] = &bytes.Buffer{}
+			}
+			text[author].Write(lines)
+		}
+	}
+
+	// Authors are ranked by words and lines even when they aren't printed
+	countOptions := options.CountOptions
+	countOptions.WordCount, countOptions.LineCount = true, true
+	var authors []gitAuthorCounts
+	var total wordcount.Accumulator
+	for author, lines := range text {
+		counts, err := wordcount.CountContext(context.Background(), transformInput(lines, options), countOptions)
+		if err != nil {
+			logError("git-blame failed", err)
+			return exitFailed
+		}
+		authors = append(authors, gitAuthorCounts{Author: author, Counts: counts})
+		total.Add(counts)
+	}
+	slices.SortFunc(authors, func(a, b gitAuthorCounts) int {
+		return cmp.Or(cmp.Compare(b.Counts.Words, a.Counts.Words), cmp.Compare(b.Counts.Lines, a.Counts.Lines),
+			strings.Compare(a.Author, b.Author))
+	})
+	for _, author := range authors {
+		printCounts(author.Counts, author.Author, options)
+	}
+	printCounts(total.Total(), "total", options)
+	return 0
+}
+
+// parseBlame reads the output of git blame --line-porcelain into the lines
+// attributed to each author, with their line ends
+func parseBlame(out []byte) map[string][]byte {
+	lines := map[string][]byte{}
+	var author string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 64*1024), 64<<20)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		switch {
+		case bytes.HasPrefix(line, []byte("author ")):
+			author = string(line[len("author "):])
+		case bytes.HasPrefix(line, []byte("\t")):
+			// Each line of the file follows the headers about it, after a tab
+			lines[author] = append(append(lines[author], line[1:]...), '\n')
+		}
+	}
+	return lines
+}
+
+func printGitBlameUsage() {
+	fmt.Println("Usage: mwc git-blame [--rev REV] [-lwcm] file ...")
+	fmt.Println("Print the counts of the lines of the files that each author contributed and")
+	fmt.Println("that survive, as git blame attributes them to the last commit that changed")
+	fmt.Println("them, most words first, and their total. Uncommitted lines are attributed to")
+	fmt.Println("\"Not Committed Yet\".")
+	fmt.Println("\nOptions:")
+	fmt.Println("  --rev REV	Blame the files as they are in REV rather than the working tree")
+	fmt.Println("\nThe counts printed are chosen as for mwc; see mwc --help.")
+}
@@ -0,0 +1,47 @@
+package main
+
+import "testing"
+
+// TestGitBlame checks the counts of the surviving lines of each author that
+// mwc git-blame prints, in the working tree and at a revision
+func TestGitBlame(t *testing.T) {
+	chdir(t, t.TempDir())
+	initGitRepo(t)
+	writeTree(t, ".", map[string]string{"book.md": "The first line\nthe second line\n", "notes.md": "a note\n"})
+	git(t, "add", ".")
+	git(t, "commit", "-q", "-m", "first")
+	writeTree(t, ".", map[string]string{"book.md": "The first line\nGrace rewrote the second line\nand added a third\n"})
+	t.Setenv("GIT_AUTHOR_NAME", "Grace")
+	t.Setenv("GIT_AUTHOR_EMAIL", "grace@example.com")
+	git(t, "commit", "-q", "-a", "-m", "second")
+	writeTree(t, ".", map[string]string{"notes.md": "a note\nnot committed\n"})
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"Authors", []string{"book.md", "notes.md"},
+			"       2       9      48 Grace\n       2       5      22 Ada\n       1       2      14 Not Committed Yet\n       5      16      84 total\n"},
+		{"Words", []string{"-w", "book.md"}, "       9 Grace\n       3 Ada\n      12 total\n"},
+		{"Revision", []string{"-lw", "--rev", "HEAD~1", "book.md"}, "       2       6 Ada\n       2       6 total\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var status int
+			stdout, stderr := captureFunc(t, func() { status = gitBlame(tt.args) })
+			if status != 0 || stderr != "" {
+				t.Fatalf("Expected success, got status %d and %q", status, stderr)
+			}
+			if stdout != tt.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, stdout)
+			}
+		})
+	}
+
+	var status int
+	_, stderr := captureFunc(t, func() { status = gitBlame([]string{"missing.md"}) })
+	if status != exitFailed || stderr == "" {
+		t.Errorf("Expected a missing file to fail, got status %d and %q", status, stderr)
+	}
+}
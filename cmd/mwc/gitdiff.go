@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// gitFileChange is how one file changed in a git diff
+type gitFileChange struct {
+	Path         string
+	Note         string // " (added)", " (removed)" or " (renamed from old)"; empty otherwise
+	Binary       bool   // whether git diffs the file as binary, without lines or words
+	WordsAdded   int64
+	WordsRemoved int64
+	LinesAdded   int64
+	LinesRemoved int64
+}
+
+// gitDiff implements mwc git-diff, which prints the words and lines added and
+// removed in each file changed in the working tree, the index with --staged,
+// or between revisions, as git diff compares them. It returns the exit status.
+func gitDiff(args []string) int {
+	var paths []string
+	if i := slices.Index(args, "--"); i >= 0 {
+		args, paths = args[:i], args[i+1:]
+	}
+	staged := slices.Contains(args, "--staged") || slices.Contains(args, "--cached")
+	args = slices.DeleteFunc(slices.Clone(args), func(arg string) bool { return arg == "--staged" || arg == "--cached" })
+	options, revisions, err := parseArgs(args)
+	if err == nil && !options.HelpRequested && len(revisions) > 2 {
+		err = errors.New("mwc git-diff takes at most two revisions")
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[0], err)
+		_, _ = fmt.Fprintf(os.Stderr, "usage: %s git-diff [--staged] [rev1..rev2] [-- path ...]\n", os.Args[0])
+		return exitUsage
+	}
+	if options.HelpRequested {
+		printGitDiffUsage()
+		return 0
+	}
+	setupLogging(options)
+
+	diffArgs := []string{"diff", "--no-color", "--no-ext-diff"}
+	if staged {
+		diffArgs = append(diffArgs, "--cached")
+	}
+	diffArgs = append(diffArgs, revisions...)
+	diffArgs = append(append(diffArgs, "--"), paths...)
+	changes, err := readGitDiff(diffArgs, options.CountOptions)
+	if err != nil {
+		logError("git-diff failed", err)
+		return exitFailed
+	}
+	printGitChanges(changes)
+	return 0
+}
+
+// readGitDiff runs git diff with the arguments, before its "--", twice: for
+// the files changed and the lines added and removed in each, and for the
+// words, diffed word by word with words split at white space
+func readGitDiff(diffArgs []string, options wordcount.CountOptions) ([]gitFileChange, error) {
+	split := slices.Index(diffArgs, "--")
+	withFlags := func(flags ...string) []string {
+		return slices.Concat(diffArgs[:split], flags, diffArgs[split:])
+	}
+	numstat, err := runGit(withFlags("--numstat", "-z")...)
+	if err != nil {
+		return nil, err
+	}
+	wordDiff, err := runGit(withFlags("--no-prefix", "--unified=0", "--word-diff=porcelain", "--word-diff-regex=[^[:space:]]+")...)
+	if err != nil {
+		return nil, err
+	}
+	countWords := func(text string) int64 {
+		counts, _ := wordcount.Count(strings.NewReader(text), wordcount.CountOptions{WordCount: true, WordSplitter: options.WordSplitter})
+		return counts.Words
+	}
+	changes := parseNumstat(numstat)
+	words := parseWordDiff(wordDiff, countWords)
+	for i := range changes {
+		if counted, ok := words[changes[i].Path]; ok {
+			changes[i].WordsAdded, changes[i].WordsRemoved = counted.WordsAdded, counted.WordsRemoved
+			if counted.Note != "" {
+				changes[i].Note = counted.Note
+			}
+		}
+	}
+	return changes, nil
+}
+
+// parseNumstat reads the output of git diff --numstat -z into the files
+// changed, in order, with the lines added and removed in each. Renamed files
+// are listed by their new path.
+func parseNumstat(numstat []byte) []gitFileChange {
+	var changes []gitFileChange
+	fields := strings.Split(string(numstat), "\x00")
+	for i := 0; i < len(fields); i++ {
+		added, rest, found := strings.Cut(fields[i], "\t")
+		if !found {
+			continue
+		}
+		removed, path, _ := strings.Cut(rest, "\t")
+		change := gitFileChange{Path: path}
+		if path == "" && i+2 < len(fields) {
+			// A rename, followed by the old and new paths
+			change.Path, change.Note = fields[i+2], " (renamed from "+fields[i+1]+")"
+			i += 2
+		}
+		// Binary files have - for both
+		a, errA := strconv.ParseInt(added, 10, 64)
+		r, errR := strconv.ParseInt(removed, 10, 64)
+		change.LinesAdded, change.LinesRemoved, change.Binary = a, r, errA != nil || errR != nil
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// parseWordDiff reads the output of git diff --word-diff=porcelain
+// --no-prefix into the words added and removed by path, counting the words
+// of each added and removed run of words with countWords. Added and removed
+// files are noted as such.
+func parseWordDiff(diff []byte, countWords func(string) int64) map[string]*gitFileChange {
+	changes := map[string]*gitFileChange{}
+	var change *gitFileChange
+	var oldPath string
+	inHeader := false
+	scanner := bufio.NewScanner(bytes.NewReader(diff))
+	scanner.Buffer(make([]byte, 64*1024), 64<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "diff --git ") {
+			change, inHeader, oldPath = &gitFileChange{}, true, ""
+			continue
+		}
+		if change == nil {
+			continue
+		}
+		if inHeader {
+			switch {
+			case strings.HasPrefix(line, "@@"):
+				inHeader = false
+			case strings.HasPrefix(line, "--- "):
+				oldPath = gitDiffPath(line[4:])
+			case strings.HasPrefix(line, "+++ "):
+				change.Path = gitDiffPath(line[4:])
+				switch {
+				case oldPath == "/dev/null":
+					change.Note = " (added)"
+				case change.Path == "/dev/null":
+					change.Path, change.Note = oldPath, " (removed)"
+				}
+				changes[change.Path] = change
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "+"):
+			change.WordsAdded += countWords(line[1:])
+		case strings.HasPrefix(line, "-"):
+			change.WordsRemoved += countWords(line[1:])
+		}
+	}
+	return changes
+}
+
+// gitDiffPath returns the path of a ---/+++ line of a diff without a prefix,
+// unquoting paths git quoted for their special characters
+func gitDiffPath(name string) string {
+	name = strings.TrimSuffix(name, "\t")
+	if strings.HasPrefix(name, `"`) {
+		if unquoted, err := strconv.Unquote(name); err == nil {
+			return unquoted
+		}
+	}
+	return name
+}
+
+// printGitChanges prints the words and lines added and removed in each file
+// and in all of them, such as "docs/intro.md: +120 -30 words, +12 -4 lines"
+func printGitChanges(changes []gitFileChange) {
+	var total gitFileChange
+	for _, change := range changes {
+		if change.Binary {
+			fmt.Printf("%s%s: binary\n", change.Path, change.Note)
+			continue
+		}
+		fmt.Printf("%s%s: %s\n", change.Path, change.Note, describeGitChange(change))
+		total.WordsAdded += change.WordsAdded
+		total.WordsRemoved += change.WordsRemoved
+		total.LinesAdded += change.LinesAdded
+		total.LinesRemoved += change.LinesRemoved
+	}
+	fmt.Printf("total: %s\n", describeGitChange(total))
+}
+
+// describeGitChange describes the words and lines added and removed in a change
+func describeGitChange(change gitFileChange) string {
+	return fmt.Sprintf("+%d -%d words, +%d -%d lines", change.WordsAdded, change.WordsRemoved, change.LinesAdded, change.LinesRemoved)
+}
+
+func printGitDiffUsage() {
+	fmt.Println("Usage: mwc git-diff [--staged] [rev1..rev2] [-- path ...]")
+	fmt.Println("Print the words and lines added and removed in each file that changed, and in")
+	fmt.Println("all of them, as git diff compares them: the working tree with the index, the")
+	fmt.Println("index with HEAD with --staged, or the revisions given. Words are diffed one by")
+	fmt.Println("one, so a word changed in a long line counts as one word removed and one added.")
+	fmt.Println("\nOptions:")
+	fmt.Println("  --staged, --cached	Compare the changes staged for the next commit")
+	fmt.Println("  --unicode-words	Count the words changed as --unicode-words splits them")
+}
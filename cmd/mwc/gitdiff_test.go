@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+)
+
+// initGitRepo makes the current directory a git repository with an
+// identity to commit as, skipping the test when git isn't installed
+func initGitRepo(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	git(t, "init", "-q")
+	git(t, "config", "user.name", "Ada")
+	git(t, "config", "user.email", "ada@example.com")
+}
+
+// git runs a git command in the current directory, failing the test if it fails
+func git(t *testing.T, args ...string) {
+	t.Helper()
+	if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+}
+
+// TestGitDiff checks the words and lines mwc git-diff reports for changes in
+// the working tree, the index and between commits
+func TestGitDiff(t *testing.T) {
+	chdir(t, t.TempDir())
+	initGitRepo(t)
+	writeTree(t, ".", map[string]string{
+		"intro.md":  "The quick brown fox\njumps over\n",
+		"old.md":    "three old words\n",
+		"move.md":   "stays the same\n",
+		"image.bin": "\x00\x01",
+	})
+	git(t, "add", ".")
+	git(t, "commit", "-q", "-m", "first")
+
+	writeTree(t, ".", map[string]string{"intro.md": "The slow brown fox\njumps over the lazy dog\n"})
+	stdout, stderr := captureFunc(t, func() { gitDiff(nil) })
+	expected := "intro.md: +4 -1 words, +2 -2 lines\ntotal: +4 -1 words, +2 -2 lines\n"
+	if stdout != expected || stderr != "" {
+		t.Errorf("Expected:\n%s\ngot:\n%s%s", expected, stdout, stderr)
+	}
+
+	writeTree(t, ".", map[string]string{"new.md": "fresh words\n", "image.bin": "\x00\x02"})
+	git(t, "rm", "-q", "old.md")
+	git(t, "mv", "move.md", "moved.md")
+	git(t, "add", ".")
+	stdout, _ = captureFunc(t, func() { gitDiff([]string{"--staged"}) })
+	expected = "image.bin: binary\n" +
+		"intro.md: +4 -1 words, +2 -2 lines\n" +
+		"moved.md (renamed from move.md): +0 -0 words, +0 -0 lines\n" +
+		"new.md (added): +2 -0 words, +1 -0 lines\n" +
+		"old.md (removed): +0 -3 words, +0 -1 lines\n" +
+		"total: +6 -4 words, +3 -3 lines\n"
+	if stdout != expected {
+		t.Errorf("Expected with --staged:\n%s\ngot:\n%s", expected, stdout)
+	}
+
+	git(t, "commit", "-q", "-m", "second")
+	stdout, _ = captureFunc(t, func() { gitDiff([]string{"HEAD~1..HEAD", "--", "intro.md"}) })
+	if expected := "intro.md: +4 -1 words, +2 -2 lines\ntotal: +4 -1 words, +2 -2 lines\n"; stdout != expected {
+		t.Errorf("Expected between commits:\n%s\ngot:\n%s", expected, stdout)
+	}
+
+	var status int
+	_, stderr = captureFunc(t, func() { status = gitDiff([]string{"nosuchrev"}) })
+	if status != exitFailed || stderr == "" {
+		t.Errorf("Expected an unknown revision to fail, got status %d and %q", status, stderr)
+	}
+}
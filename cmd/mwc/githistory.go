@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// gitCommit is a commit listed by git log
+type gitCommit struct {
+	Hash    string
+	Short   string
+	Date    string // the commit date, as YYYY-MM-DD
+	Subject string
+}
+
+// gitHistory implements mwc git-history, which prints the total counts of the
+// files under --path at each commit that changed them, oldest first, as a
+// growth curve of a manuscript or documentation set. It returns the exit status.
+func gitHistory(args []string) int {
+	values, args, err := cutValueOptions(args, "path", "since", "until")
+	var options cliOptions
+	var revisions []string
+	if err == nil {
+		options, revisions, err = parseArgs(args)
+	}
+	if err == nil && !options.HelpRequested && len(revisions) > 1 {
+		err = errors.New("mwc git-history takes at most one revision")
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[0], err)
+		_, _ = fmt.Fprintf(os.Stderr, "usage: %s git-history [--path PATH] [--since DATE] [--until DATE] [rev] [-clmw]\n", os.Args[0])
+		return exitUsage
+	}
+	if options.HelpRequested {
+		printGitHistoryUsage()
+		return 0
+	}
+	setupLogging(options)
+
+	logArgs := []string{"log", "--reverse", "--format=%H%x00%h%x00%cs%x00%s"}
+	if values["since"] != "" {
+		logArgs = append(logArgs, "--since="+values["since"])
+	}
+	if values["until"] != "" {
+		logArgs = append(logArgs, "--until="+values["until"])
+	}
+	logArgs = append(append(logArgs, revisions...), "--")
+	var paths []string
+	if values["path"] != "" {
+		paths = []string{values["path"]}
+	}
+	out, err := runGit(append(logArgs, paths...)...)
+	if err != nil {
+		logError("git-history failed", err)
+		return exitFailed
+	}
+	// Most files are the same from one commit to the next, so each version of
+	// a file is only counted once
+	counted := map[string]wordcount.Counts{}
+	for _, commit := range parseGitLog(out) {
+		counts, err := countGitTree(commit.Hash, paths, options, counted)
+		if err != nil {
+			logError("git-history failed", err, "commit", commit.Hash)
+			return exitFailed
+		}
+		printCounts(counts, fmt.Sprintf("%s %s %s", commit.Date, commit.Short, commit.Subject), options)
+	}
+	return 0
+}
+
+// parseGitLog reads the commits of git log --format=%H%x00%h%x00%cs%x00%s
+func parseGitLog(out []byte) []gitCommit {
+	var commits []gitCommit
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.SplitN(line, "\x00", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		commits = append(commits, gitCommit{Hash: fields[0], Short: fields[1], Date: fields[2], Subject: fields[3]})
+	}
+	return commits
+}
+
+// countGitTree returns the total counts of the files under the paths, or all
+// files, in the tree of a commit. The counts of each blob are looked up in,
+// and added to, counted by their object name.
+func countGitTree(commit string, paths []string, options cliOptions, counted map[string]wordcount.Counts) (wordcount.Counts, error) {
+	out, err := runGit(append([]string{"ls-tree", "-r", "-z", commit, "--"}, paths...)...)
+	if err != nil {
+		return wordcount.Counts{}, err
+	}
+	var total wordcount.Accumulator
+	for _, entry := range strings.Split(string(out), "\x00") {
+		// Each entry is "mode type object\tpath"; submodules are commits, and
+		// symbolic links have no text of their own
+		info, _, _ := strings.Cut(entry, "\t")
+		fields := strings.Fields(info)
+		if len(fields) != 3 || fields[1] != "blob" || fields[0] == "120000" {
+			continue
+		}
+		object := fields[2]
+		counts, ok := counted[object]
+		if !ok {
+			data, err := runGit("cat-file", "blob", object)
+			if err != nil {
+				return wordcount.Counts{}, err
+			}
+			counts, err = wordcount.CountContext(context.Background(), transformInput(bytes.NewReader(data), options), options.CountOptions)
+			if err != nil {
+				return wordcount.Counts{}, err
+			}
+			counted[object] = counts
+		}
+		total.Add(counts)
+	}
+	return total.Total(), nil
+}
+
+func printGitHistoryUsage() {
+	fmt.Println("Usage: mwc git-history [--path PATH] [--since DATE] [--until DATE] [rev] [-lwcm]")
+	fmt.Println("Print the total counts of the files in a git repository at each commit, oldest")
+	fmt.Println("first, with its date, abbreviated hash and subject: the growth of a manuscript")
+	fmt.Println("or documentation set over time. The files are read from the commits, so the")
+	fmt.Println("working tree is left as it is.")
+	fmt.Println("\nOptions:")
+	fmt.Println("  --path PATH	Count only the files under PATH, a git pathspec such as docs/ or")
+	fmt.Println("		'*.md', at the commits that changed them")
+	fmt.Println("  --since DATE	Start at the commits made on or after DATE, such as 2024-01-01")
+	fmt.Println("  --until DATE	Stop at the commits made before DATE")
+	fmt.Println("  rev		Follow the history of rev rather than HEAD")
+	fmt.Println("\nThe counts printed are chosen as for mwc; see mwc --help.")
+}
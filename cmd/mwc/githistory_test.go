@@ -0,0 +1,54 @@
+package main
+
+import "testing"
+
+// TestGitHistory checks the counts mwc git-history prints at each commit,
+// limited to a path and a date range. The commits are made at fixed times, so
+// their hashes are the same every time.
+func TestGitHistory(t *testing.T) {
+	chdir(t, t.TempDir())
+	initGitRepo(t)
+	commit := func(date, message string, files map[string]string) {
+		t.Helper()
+		writeTree(t, ".", files)
+		t.Setenv("GIT_AUTHOR_DATE", date+"T12:00:00Z")
+		t.Setenv("GIT_COMMITTER_DATE", date+"T12:00:00Z")
+		git(t, "add", ".")
+		git(t, "commit", "-q", "-m", message)
+	}
+	commit("2023-12-30", "Start", map[string]string{"docs/intro.md": "one two\n", "notes.txt": "not counted\n"})
+	commit("2024-01-02", "Add a chapter", map[string]string{"docs/chapter.md": "three four five\n"})
+	commit("2024-01-05", "Tidy notes", map[string]string{"notes.txt": "still not counted\n"})
+	commit("2024-01-09", "Expand the intro", map[string]string{"docs/intro.md": "one two six\n"})
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"Path", []string{"-w", "--path", "docs/"},
+			"       2 2023-12-30 c967714 Start\n       5 2024-01-02 518b08b Add a chapter\n       6 2024-01-09 c641aa4 Expand the intro\n"},
+		{"Since", []string{"-lw", "--path=docs/", "--since", "2024-01-01"},
+			"       2       5 2024-01-02 518b08b Add a chapter\n       2       6 2024-01-09 c641aa4 Expand the intro\n"},
+		{"Until", []string{"-w", "--until", "2024-01-06"},
+			"       4 2023-12-30 c967714 Start\n       7 2024-01-02 518b08b Add a chapter\n       8 2024-01-05 17ee645 Tidy notes\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var status int
+			stdout, stderr := captureFunc(t, func() { status = gitHistory(tt.args) })
+			if status != 0 || stderr != "" {
+				t.Fatalf("Expected success, got status %d and %q", status, stderr)
+			}
+			if stdout != tt.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, stdout)
+			}
+		})
+	}
+
+	var status int
+	_, stderr := captureFunc(t, func() { status = gitHistory([]string{"nosuchrev"}) })
+	if status != exitFailed || stderr == "" {
+		t.Errorf("Expected an unknown revision to fail, got status %d and %q", status, stderr)
+	}
+}
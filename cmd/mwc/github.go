@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// budgetViolation is an input whose counts broke a budget, such as
+// --max-words-per-file, with what was wrong. The total has no file.
+type budgetViolation struct {
+	File    string
+	Message string
+}
+
+// printGitHubAnnotations prints a GitHub Actions ::error workflow command for
+// each budget violation, which GitHub shows on the file in the run and in
+// pull requests
+func printGitHubAnnotations(violations []budgetViolation) {
+	for _, violation := range violations {
+		properties := "title=" + escapeGitHubProperty("Word count over budget")
+		if violation.File != "" {
+			properties = "file=" + escapeGitHubProperty(violation.File) + "," + properties
+		}
+		fmt.Printf("::error %s::%s\n", properties, escapeGitHubData(violation.Message))
+	}
+}
+
+// escapeGitHubData escapes the message of a workflow command
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeGitHubProperty escapes a property value of a workflow command
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// writeGitHubSummary appends a Markdown table of the counts of each input and
+// their total, and a list of the budget violations, to the job summary file
+// GitHub Actions names in $GITHUB_STEP_SUMMARY. Outside GitHub Actions there
+// is no job summary, and nothing is written.
+func writeGitHubSummary(report runReport, violations []budgetViolation, options cliOptions) error {
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		logEvent(slog.LevelDebug, fmt.Sprintf("%s: GITHUB_STEP_SUMMARY isn't set; not writing a job summary", os.Args[0]),
+			"no job summary")
+		return nil
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = file.WriteString(gitHubSummary(report, violations, options))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// gitHubSummary returns the Markdown of the job summary of a run
+func gitHubSummary(report runReport, violations []budgetViolation, options cliOptions) string {
+	var b strings.Builder
+	b.WriteString("### Word counts\n\n| File |")
+	for _, count := range options.Order {
+		fmt.Fprintf(&b, " %s |", capitalize(strings.ReplaceAll(count, "_", " ")))
+	}
+	b.WriteString("\n| --- |")
+	b.WriteString(strings.Repeat(" ---: |", len(options.Order)))
+	b.WriteString("\n")
+	row := func(label string, counts wordcount.Counts) {
+		fmt.Fprintf(&b, "| %s |", label)
+		for _, count := range options.Order {
+			value, _ := counts.Get(count)
+			fmt.Fprintf(&b, " %d |", value)
+		}
+		b.WriteString("\n")
+	}
+	for _, file := range report.Files {
+		row(escapeMarkdownCell(file.Filename), file.Counts)
+	}
+	row("**Total**", report.Total)
+
+	if len(violations) > 0 {
+		fmt.Fprintf(&b, "\n**%d over budget:**\n\n", len(violations))
+		for _, violation := range violations {
+			if violation.File == "" {
+				fmt.Fprintf(&b, "- %s\n", violation.Message)
+			} else {
+				fmt.Fprintf(&b, "- `%s`: %s\n", violation.File, violation.Message)
+			}
+		}
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// escapeMarkdownCell escapes the characters of a file name that would end or
+// format a Markdown table cell
+func escapeMarkdownCell(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "|", `\|`, "*", `\*`, "_", `\_`, "`", "\\`").Replace(s)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestGitHub checks the annotations and job summary --github writes for the
+// files over budget
+func TestGitHub(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"docs/a.md": "one two three\n", "docs/b_c.md": "four\n"})
+	chdir(t, dir)
+	summary := filepath.Join(dir, "summary.md")
+	t.Setenv("GITHUB_STEP_SUMMARY", summary)
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	os.Args = []string{"mwc"}
+
+	options, filenames, err := parseArgs([]string{"-lw", "--github", "--max-words-per-file", "2", "docs/a.md", "docs/b_c.md"})
+	if err != nil {
+		t.Fatalf("Error parsing arguments: %v", err)
+	}
+	var status int
+	stdout, _ := captureFunc(t, func() { status = run(options, filenames) })
+	expected := "       1       3 docs/a.md\n       1       1 docs/b_c.md\n       2       4 total\n" +
+		"::error file=docs/a.md,title=Word count over budget::3 words, 1 over the --max-words-per-file limit of 2\n"
+	if stdout != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout)
+	}
+	if status != exitBudget {
+		t.Errorf("Expected status %d, got %d", exitBudget, status)
+	}
+	data, err := os.ReadFile(summary)
+	if err != nil {
+		t.Fatalf("Expected a job summary: %v", err)
+	}
+	expected = "### Word counts\n\n" +
+		"| File | Lines | Words |\n" +
+		"| --- | ---: | ---: |\n" +
+		"| docs/a.md | 1 | 3 |\n" +
+		"| docs/b\\_c.md | 1 | 1 |\n" +
+		"| **Total** | 2 | 4 |\n\n" +
+		"**1 over budget:**\n\n" +
+		"- `docs/a.md`: 3 words, 1 over the --max-words-per-file limit of 2\n\n"
+	if string(data) != expected {
+		t.Errorf("Expected the job summary:\n%s\ngot:\n%s", expected, data)
+	}
+}
+
+// TestEscapeGitHub checks the escaping of workflow command messages and properties
+func TestEscapeGitHub(t *testing.T) {
+	if got, expected := escapeGitHubData("100% done\nnext"), "100%25 done%0Anext"; got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+	if got, expected := escapeGitHubProperty("a:b,c.md"), "a%3Ab%2Cc.md"; got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
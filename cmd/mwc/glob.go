@@ -0,0 +1,198 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// errNoMatch is the error of a glob pattern that matched no files
+var errNoMatch = errors.New("no files match the pattern")
+
+// isGlob reports whether a name is a glob pattern, so that it can be expanded
+// when no file has that name
+func isGlob(name string) bool {
+	return strings.ContainsAny(name, "*?[{") && validGlob(filepath.ToSlash(name))
+}
+
+// validGlob reports whether a slash-separated glob pattern is well-formed
+func validGlob(pattern string) bool {
+	for _, pattern := range expandBraces(pattern) {
+		for _, segment := range strings.Split(pattern, "/") {
+			if _, err := path.Match(segment, ""); err != nil {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// matchGlob reports whether a slash-separated name matches a glob pattern.
+// Within a path segment, * matches any run of characters, ? any one
+// character and [a-z] a character class, as for path.Match; a segment of
+// just ** matches any number of segments, including none; and {a,b} matches
+// either alternative.
+func matchGlob(pattern, name string) bool {
+	names := strings.Split(name, "/")
+	for _, pattern := range expandBraces(pattern) {
+		if matchSegments(strings.Split(pattern, "/"), names) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments matches the segments of a name against those of a pattern
+// without braces
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for len(pattern) > 0 && pattern[0] == "**" {
+				pattern = pattern[1:]
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// expandBraces returns the patterns a pattern with {a,b} alternatives stands
+// for, such as *.md and *.txt for *.{md,txt}. Braces may nest; an unmatched
+// brace is literal.
+func expandBraces(pattern string) []string {
+	start := -1
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] == '\\' {
+			i++
+		} else if pattern[i] == '{' {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return []string{pattern}
+	}
+	depth := 0
+	alternatives := []string{}
+	from := start + 1
+	for i := start; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case ',':
+			if depth == 1 {
+				alternatives = append(alternatives, pattern[from:i])
+				from = i + 1
+			}
+		case '}':
+			depth--
+			if depth == 0 {
+				alternatives = append(alternatives, pattern[from:i])
+				var patterns []string
+				for _, alternative := range alternatives {
+					patterns = append(patterns, expandBraces(pattern[:start]+alternative+pattern[i+1:])...)
+				}
+				return patterns
+			}
+		}
+	}
+	// The brace is never closed, so it is literal; later braces may still be
+	// alternatives
+	var patterns []string
+	for _, rest := range expandBraces(pattern[start+1:]) {
+		patterns = append(patterns, pattern[:start+1]+rest)
+	}
+	return patterns
+}
+
+// expandGlob calls visit with the name of every regular file in fsys that
+// matches a glob pattern, in lexical order, and with directories too when
+// dirs is set. Only the directories the pattern can reach are read, starting
+// from its leading segments without wildcards. Directories that can't be read
+// are reported to fail and skipped. As with -r, hidden files and directories
+// are skipped unless hidden is set or the pattern names them with a segment
+// starting with a dot, such as .github/**/*.yml.
+func expandGlob(fsys fs.FS, pattern string, dirs, hidden bool, visit func(name string, dir bool), fail func(err error)) {
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+	literal := 0
+	for literal < len(segments)-1 && !strings.ContainsAny(segments[literal], `*?[{\`) {
+		literal++
+	}
+	root, rest := strings.Join(segments[:literal], "/"), strings.Join(segments[literal:], "/")
+	switch {
+	case literal == 0:
+		root = "."
+	case root == "":
+		root = "/"
+	}
+	// Without **, names deeper than the pattern can't match
+	depth := 0
+	for _, pattern := range expandBraces(rest) {
+		if strings.Contains("/"+pattern+"/", "/**/") {
+			depth = -1
+			break
+		}
+		depth = max(depth, strings.Count(pattern, "/")+1)
+	}
+
+	_ = fs.WalkDir(fsys, root, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			fail(&wordcount.FileError{Op: "open", Path: name, Err: err})
+			return nil
+		}
+		if name == root {
+			return nil
+		}
+		if !hidden && isHidden(entry.Name()) && !namesHidden(rest, entry.Name()) {
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		relative := relativeName(root, name)
+		matched := matchGlob(rest, relative)
+		switch {
+		case entry.IsDir() && matched && dirs:
+			visit(name, true)
+			return fs.SkipDir
+		case entry.IsDir() && depth >= 0 && strings.Count(relative, "/")+1 >= depth:
+			return fs.SkipDir
+		case entry.Type().IsRegular() && matched:
+			visit(name, false)
+		}
+		return nil
+	})
+}
+
+// namesHidden reports whether a glob pattern names a hidden file or directory
+// explicitly, with a segment starting with a dot that matches its name
+func namesHidden(pattern, name string) bool {
+	for _, pattern := range expandBraces(pattern) {
+		for _, segment := range strings.Split(pattern, "/") {
+			if strings.HasPrefix(segment, ".") {
+				if ok, _ := path.Match(segment, name); ok {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
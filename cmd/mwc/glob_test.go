@@ -0,0 +1,122 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestMatchGlob tests matching names against glob patterns
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		matched bool
+	}{
+		{"*.md", "a.md", true},
+		{"*.md", "docs/a.md", false},
+		{"**/*.md", "a.md", true},
+		{"**/*.md", "docs/guide/a.md", true},
+		{"**/*.md", "docs/a.txt", false},
+		{"docs/**", "docs/a/b.md", true},
+		{"docs/**/b.md", "docs/b.md", true},
+		{"docs/**/**/b.md", "docs/x/y/b.md", true},
+		{"docs/*/b.md", "docs/x/y/b.md", false},
+		{"a?.md", "ab.md", true},
+		{"[ab].md", "c.md", false},
+		{"*.{md,txt}", "notes.txt", true},
+		{"*.{md,txt}", "notes.go", false},
+		{"{docs,src/{a,b}}/*", "src/b/x", true},
+		{"{docs,src/{a,b}}/*", "src/c/x", false},
+		{`\*.md`, "*.md", true},
+		{`\*.md`, "a.md", false},
+		{"{a.md", "{a.md", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			if matched := matchGlob(tt.pattern, tt.name); matched != tt.matched {
+				t.Errorf("Expected %v, got %v", tt.matched, matched)
+			}
+		})
+	}
+}
+
+// TestExpandBraces tests expanding {a,b} alternatives
+func TestExpandBraces(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		expected []string
+	}{
+		{"*.md", []string{"*.md"}},
+		{"*.{md,txt}", []string{"*.md", "*.txt"}},
+		{"{a,b}{1,2}", []string{"a1", "a2", "b1", "b2"}},
+		{"{a,{b,c}d}", []string{"a", "bd", "cd"}},
+		{"x{,y}", []string{"x", "xy"}},
+		{"{a", []string{"{a"}},
+		{"{a{b,c}", []string{"{ab", "{ac"}},
+		{`\{a,b}`, []string{`\{a,b}`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			if got := expandBraces(tt.pattern); !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestGlobInputs checks that glob patterns naming no file are expanded
+func TestGlobInputs(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"README.md":          "one two\n",
+		"docs/a.md":          "one\n",
+		"docs/a.txt":         "not counted\n",
+		"docs/guide/b.md":    "three more words\n",
+		"literal[1].md":      "four words in here\n",
+		"packages/x/docs.md": "five\n",
+	})
+	chdir(t, dir)
+
+	stdout, stderr := captureOutput(t, []string{"-w", "**/*.md"})
+	expected := "       2 README.md\n       1 docs/a.md\n       3 docs/guide/b.md\n       4 literal[1].md\n       1 packages/x/docs.md\n      11 total\n"
+	if stdout != expected || stderr != "" {
+		t.Errorf("Expected:\n%s\ngot:\n%s%s", expected, stdout, stderr)
+	}
+
+	// A file whose name looks like a pattern is counted as it is
+	stdout, _ = captureOutput(t, []string{"-w", "literal[1].md", "docs/*.{md,txt}"})
+	expected = "       4 literal[1].md\n       1 docs/a.md\n       2 docs/a.txt\n       7 total\n"
+	if stdout != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout)
+	}
+
+	// With -r, directories that match are counted recursively
+	stdout, _ = captureOutput(t, []string{"-rw", "pack*", filepath.Join(dir, "docs", "g*")})
+	expected = "       1 packages/x/docs.md\n       3 " + filepath.ToSlash(filepath.Join(dir, "docs", "guide", "b.md")) + "\n       4 total\n"
+	if stdout != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout)
+	}
+
+	// ** skips hidden directories, unless they are named or --hidden is given
+	writeTree(t, dir, map[string]string{"tr/a.md": "one\n", "tr/.hid/h.md": "two words\n", "tr/.h.md": "three more words\n"})
+	for _, tt := range []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"-w", "tr/**/*.md"}, "       1 tr/a.md\n"},
+		{[]string{"-w", "tr/.hid/**/*.md"}, "       2 tr/.hid/h.md\n"},
+		{[]string{"-w", "tr/{.hid,x}/*.md"}, "       2 tr/.hid/h.md\n"},
+		{[]string{"-w", "--hidden", "tr/**/*.md"}, "       3 tr/.h.md\n       2 tr/.hid/h.md\n       1 tr/a.md\n       6 total\n"},
+	} {
+		if stdout, stderr = captureOutput(t, tt.args); stdout != tt.expected || stderr != "" {
+			t.Errorf("%q: expected:\n%s\ngot:\n%s%s", tt.args, tt.expected, stdout, stderr)
+		}
+	}
+
+	stdout, stderr = captureOutput(t, []string{"-w", "*.go", "README.md"})
+	if stdout != "       2 README.md\n" || !strings.Contains(stderr, "Error opening *.go: no files match the pattern") {
+		t.Errorf("Expected the pattern matching nothing to be reported, got %q, %q", stdout, stderr)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// goalRateDays is the number of most recent days of history the daily rate
+// projecting when a goal will be reached is averaged over
+const goalRateDays = 7
+
+// printGoal prints the progress towards the --goal word count: the words so
+// far, the percentage of the goal, and the words still to go. With a --db
+// history recorded by mwc daemon, it also projects when the goal will be
+// reached at the average daily growth over the last goalRateDays days of it.
+func printGoal(words int64, options cliOptions, now time.Time) {
+	percent := float64(words) / float64(options.Goal) * 100
+	if words >= options.Goal {
+		fmt.Printf("Goal reached: %d of %d words (%.1f%%)\n", words, options.Goal, percent)
+		return
+	}
+	remaining := options.Goal - words
+	fmt.Printf("Goal: %d of %d words (%.1f%%), %d to go\n", words, options.Goal, percent, remaining)
+	if options.HistoryDB == "" {
+		return
+	}
+	records, err := readHistory(options.HistoryDB)
+	if err != nil {
+		logError("reading history failed", err, "db", options.HistoryDB)
+		return
+	}
+	// The growth between the first record of the last goalRateDays days and
+	// the last record, per day
+	var first, last *historyRecord
+	for i := range records {
+		if records[i].Time.After(now.AddDate(0, 0, -goalRateDays)) && first == nil {
+			first = &records[i]
+		}
+		last = &records[i]
+	}
+	if first == nil || last.Counts.Words <= first.Counts.Words {
+		fmt.Println("No growth recorded to project from")
+		return
+	}
+	span := max(last.Time.Sub(first.Time).Hours()/24, 1)
+	rate := float64(last.Counts.Words-first.Counts.Words) / span
+	days := int(math.Ceil(float64(remaining) / rate))
+	fmt.Printf("At %.0f words a day, done in %d days, around %s\n", rate, days, now.AddDate(0, 0, days).Format("2006-01-02"))
+}
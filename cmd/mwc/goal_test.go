@@ -0,0 +1,53 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestPrintGoal tests reporting the progress towards a goal, projected from
+// the history when there is one
+func TestPrintGoal(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"book/01.md": "one two three\n"})
+	db := filepath.Join(dir, "history.jsonl")
+	options, filenames, err := parseArgs([]string{"-r", filepath.Join(dir, "book")})
+	if err != nil {
+		t.Fatalf("Error parsing arguments: %v", err)
+	}
+	// 3 words a day over the last two days
+	start := time.Date(2024, 11, 1, 12, 0, 0, 0, time.Local)
+	for day, text := range []string{"one two three\n", "one two three four five six\n", "a b c d e f g h i\n"} {
+		writeTree(t, dir, map[string]string{"book/01.md": text})
+		if err := recordHistory(db, options, filenames, start.AddDate(0, 0, day)); err != nil {
+			t.Fatalf("Error recording history: %v", err)
+		}
+	}
+	now := start.AddDate(0, 0, 2)
+
+	tests := []struct {
+		name     string
+		words    int64
+		options  cliOptions
+		expected string
+	}{
+		{"Without History", 45105, cliOptions{Goal: 50000}, "Goal: 45105 of 50000 words (90.2%), 4895 to go\n"},
+		{"Reached", 50100, cliOptions{Goal: 50000, HistoryDB: db}, "Goal reached: 50100 of 50000 words (100.2%)\n"},
+		{"Projected", 9, cliOptions{Goal: 20, HistoryDB: db},
+			"Goal: 9 of 20 words (45.0%), 11 to go\nAt 3 words a day, done in 4 days, around 2024-11-07\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, _ := captureFunc(t, func() { printGoal(tt.words, tt.options, now) })
+			if stdout != tt.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, stdout)
+			}
+		})
+	}
+
+	stdout, _ := captureOutput(t, []string{"-l", "--goal", "10", filepath.Join(dir, "book", "01.md")})
+	if expected := "       1 " + filepath.Join(dir, "book", "01.md") + "\nGoal: 9 of 10 words (90.0%), 1 to go\n"; stdout != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// groupNode is a directory or file in the tree of the files counted, for
+// --group-by
+type groupNode struct {
+	name     string
+	file     bool
+	counts   wordcount.Counts // of the file, or the sum of the files under the directory
+	children map[string]*groupNode
+}
+
+// printGroups prints the counts of the files grouped by directory, with
+// --group-by. With "dir", each directory gets a row with the sum of the files
+// under it, labelled with its path and a trailing slash. With "tree", the
+// directories and their files are printed as an indented tree. Directories
+// are printed before their contents, in lexical order, and a directory
+// holding nothing but one subdirectory shares its row.
+func printGroups(files []wordcount.FileCount, groupBy string, options cliOptions) {
+	root := &groupNode{children: map[string]*groupNode{}}
+	for _, file := range files {
+		node := root
+		segments := strings.Split(filepath.ToSlash(file.Filename), "/")
+		for i, segment := range segments {
+			child := node.children[segment]
+			if child == nil {
+				child = &groupNode{name: segment, file: i == len(segments)-1, children: map[string]*groupNode{}}
+				node.children[segment] = child
+			}
+			child.counts.Add(file.Counts)
+			node = child
+		}
+	}
+
+	var printNode func(node *groupNode, path string, depth int)
+	printNode = func(node *groupNode, path string, depth int) {
+		for _, name := range sortedNames(node.children) {
+			child := node.children[name]
+			label := child.name
+			if child.file {
+				if groupBy == "tree" {
+					printCounts(child.counts, strings.Repeat("  ", depth)+label, options)
+				}
+				continue
+			}
+			for len(child.children) == 1 {
+				only := child.children[sortedNames(child.children)[0]]
+				if only.file {
+					break
+				}
+				child = only
+				label += "/" + child.name
+			}
+			if groupBy == "tree" {
+				printCounts(child.counts, strings.Repeat("  ", depth)+label+"/", options)
+			} else {
+				printCounts(child.counts, path+label+"/", options)
+			}
+			printNode(child, path+label+"/", depth+1)
+		}
+	}
+	printNode(root, "", 0)
+}
+
+// sortedNames returns the names of the children of a node in lexical order
+func sortedNames(children map[string]*groupNode) []string {
+	names := make([]string, 0, len(children))
+	for name := range children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
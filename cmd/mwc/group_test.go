@@ -0,0 +1,48 @@
+package main
+
+import "testing"
+
+// TestGroupBy checks that --group-by prints subtotals by directory and trees
+func TestGroupBy(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"docs/index.md":           "one\n",
+		"docs/guide/install.md":   "one two\n",
+		"docs/guide/usage.md":     "one two three\n",
+		"docs/api/v1/ref/call.md": "one two three four\n",
+	})
+	chdir(t, dir)
+
+	tests := []struct {
+		groupBy  string
+		expected string
+	}{
+		{"dir", "      10 docs/\n" +
+			"       4 docs/api/v1/ref/\n" +
+			"       5 docs/guide/\n" +
+			"      10 total\n"},
+		{"tree", "      10 docs/\n" +
+			"       4   api/v1/ref/\n" +
+			"       4     call.md\n" +
+			"       5   guide/\n" +
+			"       2     install.md\n" +
+			"       3     usage.md\n" +
+			"       1   index.md\n" +
+			"      10 total\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.groupBy, func(t *testing.T) {
+			stdout, stderr := captureOutput(t, []string{"-rw", "--group-by", tt.groupBy, "docs"})
+			if stdout != tt.expected || stderr != "" {
+				t.Errorf("Expected:\n%s\ngot:\n%s%s", tt.expected, stdout, stderr)
+			}
+		})
+	}
+
+	if _, _, err := parseArgs([]string{"--group-by=file"}); err == nil {
+		t.Errorf("Expected an unknown grouping to be rejected")
+	}
+	if _, _, err := parseArgs([]string{"--unique", "--group-by=dir"}); err == nil {
+		t.Errorf("Expected --group-by with --unique to be rejected")
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Failed authentications allowed from each address: a burst of
+// authFailureBurst, then authFailureRate a second, so tokens can't be guessed
+const (
+	authFailureRate  = 10.0 / 60
+	authFailureBurst = 10
+)
+
+// guard wraps a handler of mwc serve with the server's protections: requests
+// must carry one of the tokens, addresses that failed to authenticate too
+// often are turned away before their token is checked, each client is held
+// to the rate limit, and request bodies are cut off at the size limit
+func (s *server) guard(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		address := remoteHost(r)
+		if len(s.tokens) > 0 {
+			if retry := s.authFailures.wait(address, time.Now()); retry > 0 {
+				writeRateLimited(w, retry, errors.New("too many failed authentication attempts"))
+				return
+			}
+		}
+		client, ok := s.authenticate(r)
+		if !ok {
+			s.authFailures.allow(address, time.Now())
+			w.Header().Set("WWW-Authenticate", `Bearer realm="mwc"`)
+			writeJSONError(w, http.StatusUnauthorized, errors.New("missing or invalid token"))
+			return
+		}
+		if s.limiter != nil {
+			if allowed, retry := s.limiter.allow(client, time.Now()); !allowed {
+				writeRateLimited(w, retry, errors.New("rate limit exceeded"))
+				return
+			}
+		}
+		if s.maxBody > 0 {
+			if r.ContentLength > s.maxBody {
+				writeJSONError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("request body is larger than %d bytes", s.maxBody))
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, s.maxBody)
+		}
+		handler(w, r)
+	}
+}
+
+// writeRateLimited writes a 429 response telling the client when to retry
+func writeRateLimited(w http.ResponseWriter, retry time.Duration, err error) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.Seconds()))))
+	writeJSONError(w, http.StatusTooManyRequests, err)
+}
+
+// remoteHost returns the IP address of the client of a request
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// authenticate checks the token of a request, sent as a bearer token or an
+// X-API-Key header, and returns the client it identifies for rate limiting:
+// the token, or the remote address when no tokens are required
+func (s *server) authenticate(r *http.Request) (string, bool) {
+	if len(s.tokens) == 0 {
+		return remoteHost(r), true
+	}
+	token := r.Header.Get("X-API-Key")
+	if bearer, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); found {
+		token = strings.TrimSpace(bearer)
+	}
+	if token == "" {
+		return "", false
+	}
+	valid := 0
+	for _, accepted := range s.tokens {
+		// Compare every token in constant time, so timing reveals nothing
+		valid |= subtle.ConstantTimeCompare([]byte(token), []byte(accepted))
+	}
+	return "token:" + token, valid == 1
+}
+
+// bodyErrorStatus returns the status of a response to a request whose body
+// couldn't be read: 413 when it exceeded the size limit, 400 otherwise
+func bodyErrorStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// readTokens reads the tokens of --auth-tokens, one per line. Blank lines
+// and lines starting with # are ignored.
+func readTokens(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var tokens []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			tokens = append(tokens, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("%s has no tokens", path)
+	}
+	return tokens, nil
+}
+
+// parseRequestRate parses a request rate such as "10/s", "600/m" or "5" (per
+// second) into requests per second
+func parseRequestRate(value string) (float64, error) {
+	number, unit, _ := strings.Cut(value, "/")
+	seconds := map[string]float64{"": 1, "s": 1, "m": 60, "h": 3600}[unit]
+	count, err := strconv.ParseFloat(number, 64)
+	if err != nil || seconds == 0 || !(count > 0) || math.IsInf(count, 0) {
+		return 0, fmt.Errorf("invalid rate %q", value)
+	}
+	return count / seconds, nil
+}
+
+// clientLimiter limits the request rate of each client with a token bucket
+// per client, holding up to a second's worth of requests (at least one)
+type clientLimiter struct {
+	mu        sync.Mutex
+	rate      float64 // requests per second
+	burst     float64
+	clients   map[string]*requestBucket
+	lastPrune time.Time
+}
+
+type requestBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newClientLimiter(rate float64) *clientLimiter {
+	return &clientLimiter{rate: rate, burst: max(rate, 1), clients: map[string]*requestBucket{}}
+}
+
+// allow takes a request from the client's bucket, returning whether it was
+// allowed and, if not, how long until it would be
+func (l *clientLimiter) allow(client string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	bucket := l.refill(client, now)
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// wait returns how long until the client would be allowed a request, or 0 if
+// it would be now, without taking one
+func (l *clientLimiter) wait(client string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	bucket := l.refill(client, now)
+	if bucket.tokens < 1 {
+		return time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+	}
+	return 0
+}
+
+// refill returns the client's bucket with the requests it has earned since
+// it was last used
+func (l *clientLimiter) refill(client string, now time.Time) *requestBucket {
+	l.prune(now)
+	bucket := l.clients[client]
+	if bucket == nil {
+		bucket = &requestBucket{tokens: l.burst, last: now}
+		l.clients[client] = bucket
+	}
+	bucket.tokens = math.Min(l.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate)
+	bucket.last = now
+	return bucket
+}
+
+// prune forgets clients whose buckets have refilled, at most once a minute,
+// so clients that have gone away don't hold memory
+func (l *clientLimiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < time.Minute {
+		return
+	}
+	l.lastPrune = now
+	for client, bucket := range l.clients {
+		if bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate >= l.burst {
+			delete(l.clients, client)
+		}
+	}
+}
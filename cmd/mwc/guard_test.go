@@ -0,0 +1,161 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestServeGuard tests token authentication and the body size limit of mwc serve
+func TestServeGuard(t *testing.T) {
+	s := newServer()
+	s.tokens = []string{"alpha", "beta"}
+	s.maxBody = 16
+	server := httptest.NewServer(s.routes())
+	defer server.Close()
+
+	tests := []struct {
+		name           string
+		method, path   string
+		header         [2]string
+		body           io.Reader
+		expectedStatus int
+	}{
+		{"No Token", "POST", "/count", [2]string{}, strings.NewReader("one two"), http.StatusUnauthorized},
+		{"Invalid Token", "POST", "/count", [2]string{"Authorization", "Bearer gamma"}, strings.NewReader("one two"), http.StatusUnauthorized},
+		{"Bearer Token", "POST", "/count", [2]string{"Authorization", "Bearer alpha"}, strings.NewReader("one two"), http.StatusOK},
+		{"API Key", "POST", "/count", [2]string{"X-API-Key", "beta"}, strings.NewReader("one two"), http.StatusOK},
+		{"Metrics Without Token", "GET", "/metrics", [2]string{}, nil, http.StatusUnauthorized},
+		{"Health Without Token", "GET", "/healthz", [2]string{}, nil, http.StatusOK},
+		{"Body Too Large", "POST", "/count", [2]string{"X-API-Key", "beta"}, strings.NewReader(strings.Repeat("word ", 10)), http.StatusRequestEntityTooLarge},
+		// Without a Content-Length, the body is cut off while it is counted
+		{"Streamed Body Too Large", "POST", "/count", [2]string{"X-API-Key", "beta"},
+			io.MultiReader(strings.NewReader(strings.Repeat("word ", 10))), http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, server.URL+tt.path, tt.body)
+			if tt.header[0] != "" {
+				req.Header.Set(tt.header[0], tt.header[1])
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Error sending request: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+			if resp.StatusCode == http.StatusUnauthorized && resp.Header.Get("WWW-Authenticate") == "" {
+				t.Errorf("Expected a WWW-Authenticate header")
+			}
+		})
+	}
+}
+
+// TestServeRateLimit tests that each client is limited separately
+func TestServeRateLimit(t *testing.T) {
+	s := newServer()
+	s.tokens = []string{"alpha", "beta"}
+	s.limiter = newClientLimiter(1.0 / 60)
+	server := httptest.NewServer(s.routes())
+	defer server.Close()
+
+	for i, expected := range []struct {
+		token  string
+		status int
+	}{{"alpha", http.StatusOK}, {"alpha", http.StatusTooManyRequests}, {"beta", http.StatusOK}} {
+		req, _ := http.NewRequest("POST", server.URL+"/count", strings.NewReader("one"))
+		req.Header.Set("X-API-Key", expected.token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Error sending request: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != expected.status {
+			t.Errorf("Request %d: expected status %d, got %d", i+1, expected.status, resp.StatusCode)
+		}
+		if resp.StatusCode == http.StatusTooManyRequests && resp.Header.Get("Retry-After") != "60" {
+			t.Errorf("Expected to retry after 60 seconds, got %q", resp.Header.Get("Retry-After"))
+		}
+	}
+}
+
+// TestServeAuthFailures tests that an address that keeps failing to
+// authenticate is turned away, even with a valid token, so tokens can't be guessed
+func TestServeAuthFailures(t *testing.T) {
+	s := newServer()
+	s.tokens = []string{"alpha"}
+	server := httptest.NewServer(s.routes())
+	defer server.Close()
+
+	send := func(token string) *http.Response {
+		req, _ := http.NewRequest("POST", server.URL+"/count", strings.NewReader("one"))
+		req.Header.Set("X-API-Key", token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Error sending request: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	for i := 0; i < authFailureBurst; i++ {
+		if resp := send("guess"); resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("Attempt %d: expected status %d, got %d", i+1, http.StatusUnauthorized, resp.StatusCode)
+		}
+	}
+	for _, token := range []string{"guess", "alpha"} {
+		resp := send(token)
+		if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "6" {
+			t.Errorf("Token %s: expected status %d with Retry-After 6, got %d with %q",
+				token, http.StatusTooManyRequests, resp.StatusCode, resp.Header.Get("Retry-After"))
+		}
+	}
+}
+
+// TestClientLimiter tests refilling and pruning the buckets of clients
+func TestClientLimiter(t *testing.T) {
+	limiter := newClientLimiter(2)
+	start := time.Now()
+	for i, expected := range []bool{true, true, false} {
+		if allowed, _ := limiter.allow("a", start); allowed != expected {
+			t.Errorf("Request %d: expected allowed %v", i+1, expected)
+		}
+	}
+	if allowed, _ := limiter.allow("a", start.Add(500*time.Millisecond)); !allowed {
+		t.Errorf("Expected a request to be allowed once the bucket refilled")
+	}
+	limiter.allow("b", start.Add(2*time.Minute))
+	if _, found := limiter.clients["a"]; found || len(limiter.clients) != 1 {
+		t.Errorf("Expected the idle client to be pruned, got %v", limiter.clients)
+	}
+}
+
+// TestParseServeGuardArgs tests the options of mwc serve protecting the server
+func TestParseServeGuardArgs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens")
+	if err := os.WriteFile(path, []byte("# CI\nalpha\n\n  beta  \n"), 0o600); err != nil {
+		t.Fatalf("Error writing tokens: %v", err)
+	}
+	options, err := parseServeArgs([]string{"--auth-tokens", path, "--rate-limit=600/m", "--max-body", "1M"})
+	if err != nil {
+		t.Fatalf("Error parsing arguments: %v", err)
+	}
+	if strings.Join(options.AuthTokens, ",") != "alpha,beta" || options.RateLimit != 10 || options.MaxBody != 1<<20 {
+		t.Errorf("Unexpected options %+v", options)
+	}
+	if options, _ := parseServeArgs(nil); options.MaxBody != defaultMaxBody {
+		t.Errorf("Expected --max-body to default to %d, got %d", defaultMaxBody, options.MaxBody)
+	}
+	for _, args := range [][]string{{"--rate-limit", "fast"}, {"--rate-limit", "10/d"}, {"--max-body", "0"},
+		{"--auth-tokens", filepath.Join(t.TempDir(), "missing")}} {
+		if _, err := parseServeArgs(args); err == nil {
+			t.Errorf("Expected an error for %q", args)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// headingRule is how --by-heading finds the headings that start sections:
+// lines matching pattern, which for a Markdown level aren't inside fenced
+// code blocks
+type headingRule struct {
+	pattern  *regexp.Regexp
+	markdown bool
+}
+
+// parseHeadingRule parses a --by-heading value: a Markdown heading level,
+// as a number from 1 to 6 or as many #s, which splits at headings of that
+// level or above, or otherwise a regular expression matching heading lines
+func parseHeadingRule(value string) (*headingRule, error) {
+	level, err := strconv.Atoi(value)
+	if strings.Trim(value, "#") == "" {
+		level, err = len(value), nil
+	}
+	if err == nil {
+		if level < 1 || level > 6 {
+			return nil, fmt.Errorf("invalid Markdown heading level %d", level)
+		}
+		return &headingRule{pattern: regexp.MustCompile(fmt.Sprintf(`^ {0,3}#{1,%d}(\s|$)`, level)), markdown: true}, nil
+	}
+	pattern, err := regexp.Compile(value)
+	if err != nil {
+		return nil, err
+	}
+	return &headingRule{pattern: pattern}, nil
+}
+
+// section is the text of an input from a heading up to the next, heading
+// included. The text before the first heading is a section without one.
+type section struct {
+	heading string
+	text    string
+}
+
+// splitSections splits text at the headings the rule finds
+func splitSections(text string, rule *headingRule) []section {
+	var sections []section
+	current := section{}
+	start := 0
+	fence := ""
+	for offset := 0; offset < len(text); {
+		end := strings.IndexByte(text[offset:], '\n')
+		if end < 0 {
+			end = len(text)
+		} else {
+			end += offset + 1
+		}
+		line := strings.TrimRight(text[offset:end], "\r\n")
+		if rule.markdown {
+			// Lines in fenced code blocks, such as comments in shell
+			// scripts, aren't headings
+			trimmed := strings.TrimLeft(line, " ")
+			switch {
+			case fence == "" && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")):
+				fence = trimmed[:3]
+			case fence != "" && strings.HasPrefix(trimmed, fence):
+				fence = ""
+			}
+		}
+		if fence == "" && rule.pattern.MatchString(line) {
+			if offset > start || current.heading != "" {
+				current.text = text[start:offset]
+				sections = append(sections, current)
+			}
+			current, start = section{heading: headingTitle(line, rule)}, offset
+		}
+		offset = end
+	}
+	if len(text) > start || current.heading != "" {
+		current.text = text[start:]
+		sections = append(sections, current)
+	}
+	return sections
+}
+
+// headingTitle returns the title of a heading line, without the #s of a
+// Markdown heading
+func headingTitle(line string, rule *headingRule) string {
+	line = strings.TrimSpace(line)
+	if rule.markdown {
+		line = strings.TrimSpace(strings.TrimRight(strings.TrimLeft(line, "#"), "#"))
+	}
+	return line
+}
+
+// countByHeading counts stdin or the named files section by section with
+// --by-heading, printing a row for each section, labelled with the input's
+// name and the heading, then one for the whole input and a total for several.
+// It returns the exit status.
+func countByHeading(options cliOptions, filenames []string) int {
+	countOptions := options.CountOptions
+	if len(options.Exprs) > 0 {
+		countOptions = exprCountOptions(countOptions, options.Exprs)
+	}
+	// Unique words shared between sections or inputs are counted once in the
+	// rows of the inputs and the total
+	inputOptions := countOptions
+	if options.UniqueCount {
+		inputOptions.UniqueTotal = wordcount.NewUniqueWords(options.MaxMemory)
+	}
+	var total wordcount.Accumulator
+	countText := func(name, text string) error {
+		prefix := ""
+		if name != "" {
+			prefix = name + ": "
+		}
+		for _, s := range splitSections(text, options.ByHeading) {
+			counts, err := wordcount.Count(sectionReader(s.text, options), countOptions)
+			if err != nil {
+				return err
+			}
+			heading := s.heading
+			if heading == "" {
+				heading = "(before the first heading)"
+			}
+			printCounts(counts, prefix+heading, options)
+		}
+		counts, err := wordcount.Count(sectionReader(text, options), inputOptions)
+		if err != nil {
+			return err
+		}
+		if name != "" {
+			printCounts(counts, name, options)
+		}
+		total.Add(counts)
+		return nil
+	}
+
+	status := 0
+	fail := func(err error) {
+		printFileError(err)
+		status = exitFileErrors
+	}
+	if len(filenames) == 0 && options.FilesFrom == "" {
+		data, err := io.ReadAll(os.Stdin)
+		if err == nil {
+			err = countText("", string(data))
+		}
+		if err != nil {
+			fail(err)
+		}
+	} else {
+		var fsys fs.FS = osFS{}
+		if options.FSRoot != "" {
+			fsys = os.DirFS(options.FSRoot)
+		}
+		walker := newWalker(fsys, options)
+		for _, filename := range filenames {
+			walker.expand(filename, func(name string) {
+				data, err := readNamedFile(fsys, name)
+				if err == nil {
+					err = countText(name, string(data))
+				}
+				if err != nil {
+					fail(err)
+				}
+			}, fail)
+		}
+	}
+
+	if total.Inputs() > 1 || len(filenames) == 0 && options.FilesFrom == "" && status == 0 {
+		counts := total.Total()
+		if inputOptions.UniqueTotal != nil {
+			counts.Unique = inputOptions.UniqueTotal.Count()
+		}
+		printCounts(counts, "total", options)
+	}
+	return status
+}
+
+// sectionReader returns a reader of the text of a section, or of a whole
+// input, as transformInput changes it
+func sectionReader(text string, options cliOptions) io.Reader {
+	return transformInput(strings.NewReader(text), options)
+}
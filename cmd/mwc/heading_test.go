@@ -0,0 +1,68 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestSplitSections tests splitting text at Markdown headings and at lines
+// matching a regular expression
+func TestSplitSections(t *testing.T) {
+	book := "Title page\n# One\nIt was night.\n```sh\n# not a heading\n```\n## Scene\nRain.\n# Two #\nThe end."
+	tests := []struct {
+		name     string
+		rule     string
+		text     string
+		headings []string
+		texts    []string
+	}{
+		{"level 1", "#", book, []string{"", "One", "Two"},
+			[]string{"Title page\n", "# One\nIt was night.\n```sh\n# not a heading\n```\n## Scene\nRain.\n", "# Two #\nThe end."}},
+		{"level 2", "2", book, []string{"", "One", "Scene", "Two"},
+			[]string{"Title page\n", "# One\nIt was night.\n```sh\n# not a heading\n```\n", "## Scene\nRain.\n", "# Two #\nThe end."}},
+		{"heading first", "1", "# Only\r\ntext\r\n", []string{"Only"}, []string{"# Only\r\ntext\r\n"}},
+		{"hashtags", "1", "#notaheading\n", []string{""}, []string{"#notaheading\n"}},
+		{"regexp", `^CHAPTER [IVX]+`, "CHAPTER I\nCall me.\nCHAPTER II\n", []string{"CHAPTER I", "CHAPTER II"},
+			[]string{"CHAPTER I\nCall me.\n", "CHAPTER II\n"}},
+		{"empty", "1", "", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := parseHeadingRule(tt.rule)
+			if err != nil {
+				t.Fatalf("Error parsing %q: %v", tt.rule, err)
+			}
+			var headings, texts []string
+			for _, s := range splitSections(tt.text, rule) {
+				headings = append(headings, s.heading)
+				texts = append(texts, s.text)
+			}
+			if !slices.Equal(headings, tt.headings) || !slices.Equal(texts, tt.texts) {
+				t.Errorf("Expected %q %q, got %q %q", tt.headings, tt.texts, headings, texts)
+			}
+		})
+	}
+
+	for _, value := range []string{"", "0", "7", "#######", "[unclosed"} {
+		if _, err := parseHeadingRule(value); err == nil {
+			t.Errorf("Expected %q to be rejected", value)
+		}
+	}
+}
+
+// TestByHeading checks the rows of --by-heading for files
+func TestByHeading(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"book.md":  "Title page\n# One\nIt was night.\n# Two\nThe end.\n",
+		"notes.md": "no headings here\n",
+	})
+	chdir(t, dir)
+
+	stdout, stderr := captureOutput(t, []string{"-w", "--by-heading", "1", "book.md", "notes.md"})
+	expected := "       2 book.md: (before the first heading)\n       5 book.md: One\n       4 book.md: Two\n      11 book.md\n" +
+		"       3 notes.md: (before the first heading)\n       3 notes.md\n      14 total\n"
+	if stdout != expected || stderr != "" {
+		t.Errorf("Expected:\n%s\ngot:\n%s%s", expected, stdout, stderr)
+	}
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// historyRecord is a line of the history file written by mwc daemon: the
+// total counts of the watched files at a point in time
+type historyRecord struct {
+	Time   time.Time        `json:"time"`
+	Files  int              `json:"files"`
+	Counts wordcount.Counts `json:"counts"`
+	Dir    string           `json:"dir,omitempty"`  // the working directory of mwc log
+	Args   []string         `json:"args,omitempty"` // the arguments mwc log counted the files with
+}
+
+// daemon runs mwc daemon with the given arguments and returns the exit
+// status. It watches the files under --watch PATH and any other paths given,
+// as mwc watch does, and appends their total counts to the --db history file
+// when it starts and whenever they change, until it is interrupted.
+func daemon(args []string) int {
+	values, args, err := cutValueOptions(args, "watch", "db", "poll")
+	poll := time.Second
+	var options cliOptions
+	var filenames []string
+	if err == nil && values["poll"] != "" {
+		if d, parseErr := time.ParseDuration(values["poll"]); parseErr != nil || d <= 0 {
+			err = optionError("--poll", "invalid duration for --poll: '%s'", values["poll"])
+		} else {
+			poll = d
+		}
+	}
+	if err == nil {
+		options, filenames, err = parseArgs(append([]string{"-r"}, args...))
+		// mwc trend reports words, so they are always recorded
+		options.Order = append(options.Order, "words")
+		options.CountOptions = options.Normalize()
+	}
+	if values["watch"] != "" {
+		filenames = append([]string{values["watch"]}, filenames...)
+	}
+	if err == nil && !options.HelpRequested && (len(filenames) == 0 || values["db"] == "") {
+		err = optionError("--db", "mwc daemon requires --watch PATH and --db FILE")
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[0], err)
+		_, _ = fmt.Fprintf(os.Stderr, "usage: %s daemon --watch PATH --db FILE [--poll DURATION] [-clmw]\n", os.Args[0])
+		return exitUsage
+	}
+	if options.HelpRequested {
+		printDaemonUsage()
+		return 0
+	}
+	setupLogging(options)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	status := 0
+	watchInputs(ctx, options, filenames, poll, func([]string) {
+		if err := recordHistory(values["db"], options, filenames, time.Now()); err != nil {
+			logError("recording history failed", err, "db", values["db"])
+			status = exitFailed
+		}
+	})
+	return status
+}
+
+// recordHistory counts the files under the inputs and appends their total to
+// the history file db
+func recordHistory(db string, options cliOptions, filenames []string, now time.Time) error {
+	return appendHistory(db, countHistory(options, filenames, now))
+}
+
+// countHistory counts the files under the inputs into a history record
+func countHistory(options cliOptions, filenames []string, now time.Time) historyRecord {
+	var fsys fs.FS = osFS{}
+	if options.FSRoot != "" {
+		fsys = os.DirFS(options.FSRoot)
+	}
+	record := historyRecord{Time: now}
+	walker := newWalker(fsys, options)
+	for _, filename := range filenames {
+		walker.expand(filename, func(name string) {
+			counts, _, err := countNamedFile(context.Background(), fsys, name, options)
+			if err != nil {
+				printFileError(err)
+				return
+			}
+			record.Files++
+			record.Counts.Add(counts)
+		}, printFileError)
+	}
+	return record
+}
+
+// appendHistory appends a record to the history file db
+func appendHistory(db string, record historyRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(db, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// readHistory reads the records of a history file, in the order they were written
+func readHistory(db string) ([]historyRecord, error) {
+	file, err := os.Open(db)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var records []historyRecord
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record historyRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", db, line, err)
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// trendRow is the growth of the words over a day or week
+type trendRow struct {
+	Start  time.Time // the day, or the Monday starting the week
+	Words  int64     // the last words recorded in the period
+	Change int64     // the growth since the end of the previous period
+}
+
+// trendRows sums up the history by period, "day" or "week", in local time.
+// The growth of the first period is counted from the first record.
+func trendRows(records []historyRecord, period string) []trendRow {
+	var rows []trendRow
+	var previous int64
+	for i, record := range records {
+		t := record.Time.Local()
+		start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+		if period == "week" {
+			// Weeks start on Monday
+			start = start.AddDate(0, 0, -(int(start.Weekday())+6)%7)
+		}
+		if i == 0 {
+			previous = record.Counts.Words
+		}
+		if len(rows) == 0 || !rows[len(rows)-1].Start.Equal(start) {
+			if len(rows) > 0 {
+				previous = rows[len(rows)-1].Words
+			}
+			rows = append(rows, trendRow{Start: start})
+		}
+		row := &rows[len(rows)-1]
+		row.Words = record.Counts.Words
+		row.Change = record.Counts.Words - previous
+	}
+	return rows
+}
+
+// trend runs mwc trend with the given arguments and returns the exit status.
+// It prints, for each day or week of the --db history file, the words
+// recorded at its end and their growth over it.
+func trend(args []string) int {
+	values, args, err := cutValueOptions(args, "db", "period")
+	period := values["period"]
+	if period == "" {
+		period = "day"
+	}
+	var options cliOptions
+	if err == nil && period != "day" && period != "week" {
+		err = optionError("--period", "invalid period for --period: '%s' (available: day, week)", period)
+	}
+	if err == nil {
+		var filenames []string
+		options, filenames, err = parseArgs(args)
+		if err == nil && len(filenames) > 0 {
+			err = optionError(filenames[0], "unexpected argument '%s'", filenames[0])
+		}
+	}
+	if err == nil && !options.HelpRequested && values["db"] == "" {
+		err = optionError("--db", "mwc trend requires --db FILE")
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[0], err)
+		_, _ = fmt.Fprintf(os.Stderr, "usage: %s trend --db FILE [--period day|week]\n", os.Args[0])
+		return exitUsage
+	}
+	if options.HelpRequested {
+		printTrendUsage()
+		return 0
+	}
+	setupLogging(options)
+
+	records, err := readHistory(values["db"])
+	if err != nil {
+		logError("reading history failed", err, "db", values["db"])
+		return exitFileErrors
+	}
+	for _, row := range trendRows(records, period) {
+		fmt.Printf("%8d %+8d %s\n", row.Words, row.Change, row.Start.Format("2006-01-02"))
+	}
+	return 0
+}
+
+func printDaemonUsage() {
+	fmt.Println("Usage: mwc daemon --watch PATH --db FILE [--poll DURATION] [-lwcm] [options]")
+	fmt.Println("Watch the files under PATH and append their total counts to the history")
+	fmt.Println("file FILE, as a line of JSON, when starting and whenever they change,")
+	fmt.Println("until interrupted. mwc trend prints the growth the history records.")
+	fmt.Println("\nOptions:")
+	fmt.Println("  --poll DURATION	How often to check the files for changes (default 1s)")
+	fmt.Println("\nThe files are chosen with the same options as mwc -r; see mwc --help.")
+}
+
+func printTrendUsage() {
+	fmt.Println("Usage: mwc trend --db FILE [--period day|week]")
+	fmt.Println("Print the words recorded by mwc daemon in the history file FILE at the end")
+	fmt.Println("of each day or week, with their growth over it, and the date it started.")
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestRecordHistory checks that each record appends the total counts of the
+// watched files to the history file
+func TestRecordHistory(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"book/01.md": "It was a dark and stormy night.\n",
+		"book/02.md": "The end.\n",
+	})
+	db := filepath.Join(dir, "history.jsonl")
+	options, filenames, err := parseArgs([]string{"-r", "-w", filepath.Join(dir, "book")})
+	if err != nil {
+		t.Fatalf("Error parsing arguments: %v", err)
+	}
+
+	first := time.Date(2024, 11, 1, 9, 0, 0, 0, time.UTC)
+	if err := recordHistory(db, options, filenames, first); err != nil {
+		t.Fatalf("Error recording history: %v", err)
+	}
+	writeTree(t, dir, map[string]string{"book/03.md": "Epilogue in three words.\n"})
+	if err := recordHistory(db, options, filenames, first.Add(time.Hour)); err != nil {
+		t.Fatalf("Error recording history: %v", err)
+	}
+
+	records, err := readHistory(db)
+	if err != nil {
+		t.Fatalf("Error reading history: %v", err)
+	}
+	if len(records) != 2 || records[0].Files != 2 || records[0].Counts.Words != 9 ||
+		records[1].Files != 3 || records[1].Counts.Words != 13 || !records[1].Time.Equal(first.Add(time.Hour)) {
+		t.Errorf("Unexpected records: %+v", records)
+	}
+}
+
+// TestTrendRows tests summing up the history by day and by week
+func TestTrendRows(t *testing.T) {
+	at := func(day, hour int, words int64) historyRecord {
+		record := historyRecord{Time: time.Date(2024, 11, day, hour, 0, 0, 0, time.Local)}
+		record.Counts.Words = words
+		return record
+	}
+	// November 1st, 2024 is a Friday
+	records := []historyRecord{at(1, 9, 1000), at(1, 18, 1500), at(2, 10, 1700), at(4, 9, 1650), at(4, 20, 2400)}
+
+	tests := []struct {
+		period   string
+		expected []trendRow
+	}{
+		{"day", []trendRow{
+			{time.Date(2024, 11, 1, 0, 0, 0, 0, time.Local), 1500, 500},
+			{time.Date(2024, 11, 2, 0, 0, 0, 0, time.Local), 1700, 200},
+			{time.Date(2024, 11, 4, 0, 0, 0, 0, time.Local), 2400, 700},
+		}},
+		{"week", []trendRow{
+			{time.Date(2024, 10, 28, 0, 0, 0, 0, time.Local), 1700, 700},
+			{time.Date(2024, 11, 4, 0, 0, 0, 0, time.Local), 2400, 700},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.period, func(t *testing.T) {
+			rows := trendRows(records, tt.period)
+			if len(rows) != len(tt.expected) {
+				t.Fatalf("Expected %d rows, got %+v", len(tt.expected), rows)
+			}
+			for i, row := range rows {
+				if !row.Start.Equal(tt.expected[i].Start) || row.Words != tt.expected[i].Words || row.Change != tt.expected[i].Change {
+					t.Errorf("Row %d: expected %+v, got %+v", i, tt.expected[i], row)
+				}
+			}
+		})
+	}
+}
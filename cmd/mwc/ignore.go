@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// ignoreRule is a pattern of a .gitignore or .wcignore file
+type ignoreRule struct {
+	base     string   // directory of the file the pattern is in
+	segments []string // the pattern split at its slashes; names at any depth start with **
+	negate   bool     // the pattern started with !, so it re-includes what it matches
+	dirOnly  bool     // the pattern ended with /, so it only matches directories
+}
+
+// ignoreRules are the patterns of the ignore files found while walking a
+// directory. Names are matched in the namespace the rules were loaded in: the
+// absolute, slash-separated path for files of the operating system, and the
+// name in fsys for --fs-root.
+type ignoreRules struct {
+	fsys  fs.FS
+	git   bool // .gitignore files apply, not just .wcignore files
+	rules []ignoreRule
+}
+
+// newIgnoreRules returns the rules for walking root, whose name in the rules'
+// namespace is full: those of the ignore files in the directories above root,
+// up to the top of the git repository, and of .git/info/exclude. Outside a
+// git repository, the .wcignore files of every directory above root apply.
+// gitIgnore is the --gitignore option: .gitignore files apply inside git
+// repositories unless it is "off", and outside them too if it is "on".
+func newIgnoreRules(fsys fs.FS, full, gitIgnore string) *ignoreRules {
+	top := ""
+	for dir := full; ; dir = path.Dir(dir) {
+		if _, err := fs.Stat(fsys, path.Join(dir, ".git")); err == nil {
+			top = dir
+			break
+		}
+		if path.Dir(dir) == dir {
+			break
+		}
+	}
+	r := &ignoreRules{fsys: fsys, git: gitIgnore == "on" || top != "" && gitIgnore != "off"}
+	if top != "" && r.git {
+		r.loadFile(path.Join(top, ".git", "info", "exclude"), top)
+	}
+	// The directories above root, from the top down; root's own files are
+	// loaded as it is walked
+	var dirs []string
+	for dir := full; dir != top && path.Dir(dir) != dir; {
+		dir = path.Dir(dir)
+		dirs = append(dirs, dir)
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		r.load(dirs[i])
+	}
+	return r
+}
+
+// load adds the rules of the ignore files in a directory, if it has any.
+// Patterns of .wcignore come after those of .gitignore, so they can
+// re-include what git ignores.
+func (r *ignoreRules) load(dir string) {
+	if r.git {
+		r.loadFile(path.Join(dir, ".gitignore"), dir)
+	}
+	r.loadFile(path.Join(dir, ".wcignore"), dir)
+}
+
+// loadFile adds the rules of an ignore file whose patterns are relative to the
+// directory base. A file that can't be read has no rules.
+func (r *ignoreRules) loadFile(name, base string) {
+	data, err := fs.ReadFile(r.fsys, name)
+	if err != nil {
+		return
+	}
+	r.rules = append(r.rules, parseIgnoreRules(data, base)...)
+}
+
+// parseIgnoreRules parses the patterns of a file in the gitignore format
+func parseIgnoreRules(data []byte, base string) []ignoreRule {
+	var rules []ignoreRule
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		pattern := strings.TrimSuffix(string(line), "\r")
+		// Trailing spaces are dropped unless escaped with a backslash
+		for strings.HasSuffix(pattern, " ") && !strings.HasSuffix(pattern, `\ `) {
+			pattern = pattern[:len(pattern)-1]
+		}
+		if pattern == "" || pattern[0] == '#' {
+			continue
+		}
+		rule := ignoreRule{base: base}
+		if pattern[0] == '!' {
+			rule.negate = true
+			pattern = pattern[1:]
+		} else if strings.HasPrefix(pattern, `\#`) || strings.HasPrefix(pattern, `\!`) {
+			pattern = pattern[1:]
+		}
+		if strings.HasSuffix(pattern, "/") {
+			rule.dirOnly = true
+			pattern = strings.TrimRight(pattern, "/")
+		}
+		if pattern == "" {
+			continue
+		}
+		// A pattern with a slash before its end is relative to base; one
+		// without matches names at any depth below it
+		if !strings.Contains(pattern, "/") {
+			pattern = "**/" + pattern
+		}
+		rule.segments = strings.Split(strings.TrimPrefix(pattern, "/"), "/")
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// ignored reports whether the rules ignore a file or directory, named in
+// their namespace. As in git, the last rule that matches wins.
+func (r *ignoreRules) ignored(full string, dir bool) bool {
+	ignored := false
+	for _, rule := range r.rules {
+		if rule.dirOnly && !dir {
+			continue
+		}
+		var relative string
+		switch {
+		case rule.base == ".":
+			relative = full
+		case rule.base == "/" && strings.HasPrefix(full, "/"):
+			relative = full[1:]
+		case strings.HasPrefix(full, rule.base+"/"):
+			relative = full[len(rule.base)+1:]
+		default:
+			continue
+		}
+		if matchSegments(rule.segments, strings.Split(relative, "/")) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
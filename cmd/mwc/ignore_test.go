@@ -0,0 +1,170 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestIgnoreRules tests matching names against gitignore patterns
+func TestIgnoreRules(t *testing.T) {
+	rules := &ignoreRules{rules: parseIgnoreRules([]byte(`# build output
+build/
+*.log
+!keep.log
+/TODO
+docs/**/draft.md
+\#notes
+`+"trailing.txt   \nescaped.txt\\ \n"), "/repo")}
+	rules.rules = append(rules.rules, parseIgnoreRules([]byte("*.tmp\n!keep.log\n"), "/repo/sub")...)
+
+	tests := []struct {
+		name    string
+		dir     bool
+		ignored bool
+	}{
+		{"/repo/build", true, true},
+		{"/repo/src/build", true, true},
+		{"/repo/build", false, false},
+		{"/repo/app.log", false, true},
+		{"/repo/logs/app.log", false, true},
+		{"/repo/keep.log", false, false},
+		{"/repo/TODO", false, true},
+		{"/repo/src/TODO", false, false},
+		{"/repo/docs/draft.md", false, true},
+		{"/repo/docs/a/b/draft.md", false, true},
+		{"/repo/draft.md", false, false},
+		{"/repo/#notes", false, true},
+		{"/repo/trailing.txt", false, true},
+		{"/repo/escaped.txt ", false, true},
+		{"/repo/a.tmp", false, false},
+		{"/repo/sub/a.tmp", false, true},
+		{"/other/app.log", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ignored := rules.ignored(tt.name, tt.dir); ignored != tt.ignored {
+				t.Errorf("Expected %v, got %v", tt.ignored, ignored)
+			}
+		})
+	}
+}
+
+// TestGitIgnore checks that recursive runs inside a git repository skip what
+// its .gitignore files ignore, unless --no-gitignore is given
+func TestGitIgnore(t *testing.T) {
+	dir := t.TempDir()
+	repo := filepath.Join(dir, "repo")
+	writeTree(t, repo, map[string]string{
+		".git/HEAD":              "ref: refs/heads/main\n",
+		".git/info/exclude":      "*.bak\n",
+		".gitignore":             "node_modules/\n*.log\n",
+		"docs/a.md":              "one\n",
+		"docs/a.md.bak":          "old\n",
+		"docs/debug.log":         "noise\n",
+		"docs/guide/.gitignore":  "draft.md\n",
+		"docs/guide/draft.md":    "unfinished\n",
+		"docs/guide/intro.md":    "two words\n",
+		"docs/node_modules/x.md": "dependency\n",
+	})
+	chdir(t, filepath.Join(repo, "docs"))
+
+	stdout, stderr := captureOutput(t, []string{"-rw", "."})
+	expected := "       1 a.md\n       2 guide/intro.md\n       3 total\n"
+	if stdout != expected || stderr != "" {
+		t.Errorf("Expected:\n%s\ngot:\n%s%s", expected, stdout, stderr)
+	}
+
+	stdout, _ = captureOutput(t, []string{"-rw", "--no-gitignore", "guide"})
+	expected = "       1 guide/draft.md\n       2 guide/intro.md\n       3 total\n"
+	if stdout != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout)
+	}
+
+	// Outside a git repository, .gitignore files only apply with --gitignore
+	if err := os.RemoveAll(filepath.Join(repo, ".git")); err != nil {
+		t.Fatalf("Failed to remove .git: %v", err)
+	}
+	stdout, _ = captureOutput(t, []string{"-rw", "guide"})
+	if expected = "       1 guide/draft.md\n       2 guide/intro.md\n       3 total\n"; stdout != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout)
+	}
+	stdout, _ = captureOutput(t, []string{"-rw", "--gitignore", "guide"})
+	if expected = "       2 guide/intro.md\n"; stdout != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout)
+	}
+}
+
+// TestGitTracked checks that --git-tracked counts only the files git tracks,
+// including those its .gitignore files ignore but were added anyway
+func TestGitTracked(t *testing.T) {
+	dir := t.TempDir()
+	chdir(t, dir)
+	initGitRepo(t)
+	writeTree(t, ".", map[string]string{
+		".gitignore":         "*.log\n",
+		"docs/a.md":          "one\n",
+		"docs/kept.log":      "two words\n",
+		"docs/generated.md":  "not tracked\n",
+		"docs/out/report.md": "not tracked either\n",
+		"docs/guide/b.md":    "three more words\n",
+		"notes.md":           "outside docs\n",
+	})
+	git(t, "add", ".gitignore", "docs/a.md", "docs/guide/b.md", "notes.md")
+	git(t, "add", "-f", "docs/kept.log")
+	chdir(t, filepath.Join(dir, "docs"))
+
+	stdout, stderr := captureOutput(t, []string{"-rw", "--git-tracked", "."})
+	expected := "       1 a.md\n       3 guide/b.md\n       2 kept.log\n       6 total\n"
+	if stdout != expected || stderr != "" {
+		t.Errorf("Expected:\n%s\ngot:\n%s%s", expected, stdout, stderr)
+	}
+	stdout, _ = captureOutput(t, []string{"-rw", "--git-tracked", "guide", ".."})
+	expected = "       3 guide/b.md\n       1 ../docs/a.md\n       3 ../docs/guide/b.md\n       2 ../docs/kept.log\n       2 ../notes.md\n      11 total\n"
+	if stdout != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout)
+	}
+
+	// Outside a git repository, there is nothing git tracks
+	outside := t.TempDir()
+	writeTree(t, outside, map[string]string{"a.md": "one\n"})
+	_, stderr = captureOutput(t, []string{"-rw", "--git-tracked", outside})
+	if !strings.Contains(stderr, "not a git repository") {
+		t.Errorf("Expected an error outside a git repository, got %q", stderr)
+	}
+}
+
+// TestWCIgnore checks that .wcignore files apply to recursive runs with or
+// without git, and can re-include what git ignores
+func TestWCIgnore(t *testing.T) {
+	dir := t.TempDir()
+	project := filepath.Join(dir, "project")
+	writeTree(t, project, map[string]string{
+		".wcignore":               "fixtures/\n*.generated.md\n",
+		"docs/a.md":               "one\n",
+		"docs/api.generated.md":   "generated reference\n",
+		"docs/fixtures/x.md":      "fixture\n",
+		"docs/out/.wcignore":      "!*.generated.md\n",
+		"docs/out/b.generated.md": "kept despite the pattern\n",
+	})
+	chdir(t, project)
+
+	stdout, stderr := captureOutput(t, []string{"-rw", "docs"})
+	expected := "       1 docs/a.md\n       4 docs/out/b.generated.md\n       5 total\n"
+	if stdout != expected || stderr != "" {
+		t.Errorf("Expected:\n%s\ngot:\n%s%s", expected, stdout, stderr)
+	}
+
+	// Inside a git repository, .wcignore comes after .gitignore
+	writeTree(t, project, map[string]string{
+		".git/HEAD":       "ref: refs/heads/main\n",
+		"docs/.gitignore": "*.md\n",
+		"docs/.wcignore":  "!a.md\n",
+	})
+	stdout, _ = captureOutput(t, []string{"-rw", "docs"})
+	expected = "       1 docs/a.md\n       4 docs/out/b.generated.md\n       5 total\n"
+	if stdout != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout)
+	}
+}
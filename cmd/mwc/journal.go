@@ -0,0 +1,233 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// logWords runs mwc log with the given arguments and returns the exit status.
+// It appends the total counts of the files under the paths given to the --db
+// journal, a history file like mwc daemon's. Without paths, it counts those of
+// the last mwc log with the same journal, so it can run from cron.
+func logWords(args []string) int {
+	values, args, err := cutValueOptions(args, "db")
+	var options cliOptions
+	var filenames []string
+	dir := ""
+	if err == nil {
+		options, filenames, err = parseArgs(append([]string{"-r"}, args...))
+	}
+	if err == nil && !options.HelpRequested && values["db"] == "" {
+		err = optionError("--db", "mwc log requires --db FILE")
+	}
+	if err == nil && !options.HelpRequested && len(filenames) == 0 {
+		// Count what the last mwc log did, from where it ran
+		var last historyRecord
+		last, err = lastLogged(values["db"])
+		if err == nil {
+			args, dir = last.Args, last.Dir
+			options, filenames, err = parseArgs(append([]string{"-r"}, args...))
+			filenames = resolveLogged(dir, filenames)
+		}
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[0], err)
+		_, _ = fmt.Fprintf(os.Stderr, "usage: %s log --db FILE [-clmw] [path ...]\n", os.Args[0])
+		return exitUsage
+	}
+	if options.HelpRequested {
+		printLogUsage()
+		return 0
+	}
+	setupLogging(options)
+	if dir == "" {
+		if dir, err = os.Getwd(); err != nil {
+			logError("log failed", err)
+			return exitFailed
+		}
+	}
+
+	// mwc report reports words, so they are always recorded
+	options.Order = append(options.Order, "words")
+	options.CountOptions = options.Normalize()
+	record := countHistory(options, filenames, time.Now())
+	record.Dir, record.Args = dir, args
+	if err := appendHistory(values["db"], record); err != nil {
+		logError("logging the counts failed", err, "db", values["db"])
+		return exitFailed
+	}
+	words, files := "words", "files"
+	if record.Counts.Words == 1 {
+		words = "word"
+	}
+	if record.Files == 1 {
+		files = "file"
+	}
+	fmt.Printf("Logged %d %s in %d %s\n", record.Counts.Words, words, record.Files, files)
+	return 0
+}
+
+// lastLogged returns the last record of the journal db written by mwc log
+func lastLogged(db string) (historyRecord, error) {
+	records, err := readHistory(db)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return historyRecord{}, err
+	}
+	for i := len(records) - 1; i >= 0; i-- {
+		if len(records[i].Args) > 0 {
+			return records[i], nil
+		}
+	}
+	return historyRecord{}, errors.New("no paths given or logged before in " + db)
+}
+
+// resolveLogged makes the local paths of an earlier mwc log relative to the
+// directory it ran in
+func resolveLogged(dir string, filenames []string) []string {
+	resolved := make([]string, len(filenames))
+	for i, name := range filenames {
+		resolved[i] = name
+		if _, remote := parseRemotePath(name); !remote && !isObjectURL(name) && !filepath.IsAbs(name) && name != "-" {
+			resolved[i] = filepath.Join(dir, name)
+		}
+	}
+	return resolved
+}
+
+// writingReport sums up the words written each day of a report period
+type writingReport struct {
+	Days    []trendRow // each day of the period up to today, including those nothing was written on
+	Written int64      // the words written over the period
+	Active  int        // the days of the period words were written on
+	Best    trendRow   // the day of the period the most words were written on
+	Streak  int        // the days in a row words were written on, up to today or yesterday
+	Longest int        // the longest run of such days in the period
+}
+
+// newWritingReport sums up the journal records over the period, "week" or
+// "month", that now is in, in local time
+func newWritingReport(records []historyRecord, period string, now time.Time) writingReport {
+	now = now.Local()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	start := today.AddDate(0, 0, 1-today.Day())
+	if period == "week" {
+		start = today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	}
+
+	rows := trendRows(records, "day")
+	written := map[time.Time]int64{}
+	for _, row := range rows {
+		written[row.Start] = row.Change
+	}
+	var report writingReport
+	var words int64
+	next := 0
+	run := 0
+	for day := start; !day.After(today); day = day.AddDate(0, 0, 1) {
+		// The words at the end of the day are the last recorded by then
+		for ; next < len(rows) && !rows[next].Start.After(day); next++ {
+			words = rows[next].Words
+		}
+		row := trendRow{Start: day, Words: words, Change: written[day]}
+		report.Days = append(report.Days, row)
+		report.Written += row.Change
+		if row.Change > 0 {
+			report.Active++
+			run++
+			report.Longest = max(report.Longest, run)
+		} else {
+			run = 0
+		}
+		if row.Change > report.Best.Change {
+			report.Best = row
+		}
+	}
+
+	// Today doesn't break the streak before anything is written
+	day := today
+	if written[day] <= 0 {
+		day = day.AddDate(0, 0, -1)
+	}
+	for ; written[day] > 0; day = day.AddDate(0, 0, -1) {
+		report.Streak++
+	}
+	return report
+}
+
+// reportWords runs mwc report with the given arguments and returns the exit
+// status. It prints the words written each day of this week or month
+// according to the --db journal, and sums them up.
+func reportWords(args []string) int {
+	values, args, err := cutValueOptions(args, "db", "period")
+	period := values["period"]
+	if period == "" {
+		period = "month"
+	}
+	var options cliOptions
+	if err == nil && period != "week" && period != "month" {
+		err = optionError("--period", "invalid period for --period: '%s' (available: week, month)", period)
+	}
+	if err == nil {
+		var filenames []string
+		options, filenames, err = parseArgs(args)
+		if err == nil && len(filenames) > 0 {
+			err = optionError(filenames[0], "unexpected argument '%s'", filenames[0])
+		}
+	}
+	if err == nil && !options.HelpRequested && values["db"] == "" {
+		err = optionError("--db", "mwc report requires --db FILE")
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[0], err)
+		_, _ = fmt.Fprintf(os.Stderr, "usage: %s report --db FILE [--period week|month]\n", os.Args[0])
+		return exitUsage
+	}
+	if options.HelpRequested {
+		printReportUsage()
+		return 0
+	}
+	setupLogging(options)
+
+	records, err := readHistory(values["db"])
+	if err != nil {
+		logError("reading the journal failed", err, "db", values["db"])
+		return exitFileErrors
+	}
+	printWritingReport(newWritingReport(records, period, time.Now()))
+	return 0
+}
+
+// printWritingReport prints the words at the end of each day and written on
+// it, then the totals, best day and streaks
+func printWritingReport(report writingReport) {
+	for _, row := range report.Days {
+		fmt.Printf("%8d %+8d %s\n", row.Words, row.Change, row.Start.Format("2006-01-02"))
+	}
+	fmt.Printf("Written: %d words on %d of %d days, %.0f a day\n",
+		report.Written, report.Active, len(report.Days), float64(report.Written)/float64(len(report.Days)))
+	if report.Best.Change > 0 {
+		fmt.Printf("Best day: %s, %d words\n", report.Best.Start.Format("2006-01-02"), report.Best.Change)
+	}
+	fmt.Printf("Streak: %d days, longest %d days\n", report.Streak, report.Longest)
+}
+
+func printLogUsage() {
+	fmt.Println("Usage: mwc log --db FILE [-lwcm] [options] [path ...]")
+	fmt.Println("Append the total counts of the files under the paths to the journal FILE,")
+	fmt.Println("as a line of JSON. Without paths, count those of the last mwc log with the")
+	fmt.Println("same journal, from the directory it ran in, so it can run daily from cron.")
+	fmt.Println("mwc report sums up the words written each day the journal records.")
+	fmt.Println("\nThe files are chosen with the same options as mwc -r; see mwc --help.")
+}
+
+func printReportUsage() {
+	fmt.Println("Usage: mwc report --db FILE [--period week|month]")
+	fmt.Println("Print the words at the end of each day of this week or month (the default)")
+	fmt.Println("and written on it, according to the journal FILE written by mwc log or mwc")
+	fmt.Println("daemon, then the words written in all, the best day, and the current and")
+	fmt.Println("longest streaks of days words were written on.")
+}
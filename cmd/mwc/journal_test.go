@@ -0,0 +1,94 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestLogWords checks that mwc log appends the counts of the paths given to
+// the journal, and counts the same paths again when given none
+func TestLogWords(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"book/01.md":  "It was a dark and stormy night.\n",
+		"book/02.txt": "not counted\n",
+	})
+	db := filepath.Join(dir, "journal.jsonl")
+	chdir(t, dir)
+
+	var status int
+	stdout, stderr := captureFunc(t, func() { status = logWords([]string{"--db", db, "--include", "*.md", "book"}) })
+	if status != 0 || stdout != "Logged 7 words in 1 file\n" {
+		t.Fatalf("Expected the counts to be logged, got %d: %q %q", status, stdout, stderr)
+	}
+
+	writeTree(t, dir, map[string]string{"book/02.md": "The end.\n"})
+	chdir(t, t.TempDir())
+	stdout, stderr = captureFunc(t, func() { status = logWords([]string{"--db", db}) })
+	if status != 0 || stdout != "Logged 9 words in 2 files\n" {
+		t.Fatalf("Expected the same paths to be logged again, got %d: %q %q", status, stdout, stderr)
+	}
+
+	records, err := readHistory(db)
+	if err != nil {
+		t.Fatalf("Error reading the journal: %v", err)
+	}
+	if len(records) != 2 || records[1].Dir != records[0].Dir || len(records[1].Args) != 3 {
+		t.Errorf("Unexpected records: %+v", records)
+	}
+
+	_, stderr = captureFunc(t, func() { status = logWords([]string{"--db", filepath.Join(dir, "new.jsonl")}) })
+	if status != 1 || stderr == "" {
+		t.Errorf("Expected an error for a new journal without paths, got %d: %q", status, stderr)
+	}
+}
+
+// TestNewWritingReport tests summing up the words written each day of a week
+// and a month
+func TestNewWritingReport(t *testing.T) {
+	at := func(day, hour int, words int64) historyRecord {
+		record := historyRecord{Time: time.Date(2024, 11, day, hour, 0, 0, 0, time.Local)}
+		record.Counts.Words = words
+		return record
+	}
+	// November 1st, 2024 is a Friday
+	records := []historyRecord{
+		at(1, 9, 1000), at(1, 18, 1500), at(2, 10, 1700), at(4, 9, 1650),
+		at(4, 20, 2400), at(5, 21, 2500), at(6, 21, 2450),
+	}
+	now := time.Date(2024, 11, 7, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		period  string
+		days    int
+		written int64
+		active  int
+		best    int
+		streak  int
+		longest int
+	}{
+		{"month", 7, 1450, 4, 4, 0, 2},
+		{"week", 4, 750, 2, 4, 0, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.period, func(t *testing.T) {
+			report := newWritingReport(records, tt.period, now)
+			if len(report.Days) != tt.days || report.Written != tt.written || report.Active != tt.active ||
+				report.Best.Start.Day() != tt.best || report.Best.Change != 700 ||
+				report.Streak != tt.streak || report.Longest != tt.longest {
+				t.Errorf("Unexpected report: %+v", report)
+			}
+			// The words carry over to days nothing was recorded on
+			if last := report.Days[len(report.Days)-1]; last.Words != 2450 || last.Change != 0 {
+				t.Errorf("Unexpected last day: %+v", last)
+			}
+		})
+	}
+
+	// The streak counts up to yesterday while nothing is written today
+	report := newWritingReport(records[:6], "month", time.Date(2024, 11, 6, 12, 0, 0, 0, time.Local))
+	if report.Streak != 2 {
+		t.Errorf("Expected a streak of 2 days, got %+v", report)
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// kafka runs mwc kafka with the given arguments and returns the exit status.
+// It consumes a topic with kcat and reports the messages and counts of each
+// partition and their total, once the end of the topic is reached or, with
+// --interval, every interval until it is interrupted.
+func kafka(args []string) int {
+	values, args, err := cutValueOptions(args, "brokers", "topic", "from", "interval")
+	var options cliOptions
+	var interval time.Duration
+	if err == nil {
+		var filenames []string
+		options, filenames, err = parseArgs(args)
+		switch {
+		case err != nil || options.HelpRequested:
+		case len(filenames) > 0:
+			err = optionError(filenames[0], "unexpected argument '%s'", filenames[0])
+		case values["brokers"] == "" || values["topic"] == "":
+			err = optionError("--topic", "mwc kafka requires --brokers and --topic")
+		case values["interval"] != "":
+			interval, err = time.ParseDuration(values["interval"])
+			if err != nil || interval <= 0 {
+				err = optionError("--interval", "invalid duration for --interval: '%s'", values["interval"])
+			}
+		}
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[0], err)
+		_, _ = fmt.Fprintf(os.Stderr, "usage: %s kafka --brokers HOSTS --topic TOPIC [--from OFFSET] [--interval DURATION] [-clmw]\n", os.Args[0])
+		return exitUsage
+	}
+	if options.HelpRequested {
+		printKafkaUsage()
+		return 0
+	}
+	from := values["from"]
+	if from == "" {
+		from = "beginning"
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	topic := newTopicCounts(values["topic"], options)
+	kcatArgs := []string{"-C", "-q", "-b", values["brokers"], "-t", values["topic"], "-o", from, "-f", "%p %S\\n%s"}
+	if interval == 0 {
+		// Stop at the end of every partition
+		kcatArgs = append(kcatArgs, "-e")
+	} else {
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					topic.print()
+					fmt.Println()
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	err = consumeKafka(ctx, kcatArgs, topic.add)
+	topic.print()
+	if err != nil && ctx.Err() == nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[0], err)
+		return exitFailed
+	}
+	return 0
+}
+
+// consumeKafka runs kcat with the given arguments and counts every message it
+// prints, until kcat exits or ctx is done. kcat reads its broker settings,
+// such as credentials, from its usual configuration file.
+func consumeKafka(ctx context.Context, args []string, add func(partition int, payload io.Reader) error) error {
+	path, err := exec.LookPath("kcat")
+	if err != nil {
+		// Older releases are named kafkacat
+		if path, err = exec.LookPath("kafkacat"); err != nil {
+			return fmt.Errorf("mwc kafka needs kcat (https://github.com/edenhill/kcat) on PATH")
+		}
+	}
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.StdoutPipe()
+	if err == nil {
+		err = cmd.Start()
+	}
+	if err != nil {
+		return err
+	}
+	readErr := readKafkaMessages(stdout, add)
+	if readErr != nil {
+		_ = cmd.Process.Kill()
+	}
+	if err := cmd.Wait(); err != nil && readErr == nil {
+		return fmt.Errorf("kcat: %v", err)
+	}
+	return readErr
+}
+
+// readKafkaMessages reads messages printed by kcat with the format
+// "%p %S\n%s": a header with the partition and payload size, then the payload
+func readKafkaMessages(r io.Reader, add func(partition int, payload io.Reader) error) error {
+	reader := bufio.NewReader(r)
+	for {
+		header, err := reader.ReadString('\n')
+		if err == io.EOF && header == "" {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading kcat output: %v", err)
+		}
+		var partition int
+		var size int64
+		if _, err := fmt.Sscanf(header, "%d %d\n", &partition, &size); err != nil {
+			return fmt.Errorf("unexpected kcat output %q", header)
+		}
+		// Messages with a null payload have a size of -1
+		payload := io.LimitReader(reader, max(size, 0))
+		if err := add(partition, payload); err != nil {
+			return err
+		}
+		if _, _ = io.Copy(io.Discard, payload); payload.(*io.LimitedReader).N > 0 {
+			return fmt.Errorf("reading kcat output: %v", io.ErrUnexpectedEOF)
+		}
+	}
+}
+
+// partitionCounts are the counts of the messages of one partition
+type partitionCounts struct {
+	messages int64
+	total    wordcount.Accumulator
+	unique   *wordcount.UniqueWords
+}
+
+// topicCounts are the counts of a topic by partition. It is safe for
+// concurrent use, so it can be reported while messages are being counted.
+type topicCounts struct {
+	mu         sync.Mutex
+	topic      string
+	options    cliOptions
+	counting   wordcount.CountOptions // options plus the counts used by expressions
+	partitions map[int]*partitionCounts
+}
+
+func newTopicCounts(topic string, options cliOptions) *topicCounts {
+	counting := options.CountOptions
+	if options.Throttle > 0 {
+		counting.RateLimiter = wordcount.NewRateLimiter(options.Throttle)
+	}
+	if len(options.Exprs) > 0 {
+		counting = exprCountOptions(counting, options.Exprs)
+	}
+	return &topicCounts{topic: topic, options: options, counting: counting, partitions: map[int]*partitionCounts{}}
+}
+
+// add counts a message of a partition
+func (t *topicCounts) add(partition int, payload io.Reader) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p := t.partitions[partition]
+	if p == nil {
+		p = &partitionCounts{}
+		if t.options.UniqueCount {
+			p.unique = wordcount.NewUniqueWords(t.options.MaxMemory)
+		}
+		t.partitions[partition] = p
+	}
+	options := t.counting
+	options.UniqueTotal = p.unique
+	counts, err := wordcount.Count(payload, options)
+	if err != nil {
+		return fmt.Errorf("counting message of partition %d: %v", partition, err)
+	}
+	p.messages++
+	p.total.Add(counts)
+	return nil
+}
+
+// print prints a row for each partition, starting with its number of
+// messages, and a total row
+func (t *topicCounts) print() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	numbers := make([]int, 0, len(t.partitions))
+	for number := range t.partitions {
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+
+	var messages int64
+	var total wordcount.Accumulator
+	var unique *wordcount.UniqueWords
+	if t.options.UniqueCount {
+		unique = wordcount.NewUniqueWords(t.options.MaxMemory)
+	}
+	for _, number := range numbers {
+		p := t.partitions[number]
+		counts := p.total.Total()
+		if p.unique != nil {
+			counts.Unique = p.unique.Count()
+			unique.Merge(p.unique)
+		}
+		fmt.Printf("%8d", p.messages)
+		printCounts(counts, t.topic+"-"+strconv.Itoa(number), t.options)
+		messages += p.messages
+		total.Add(counts)
+	}
+	counts := total.Total()
+	if unique != nil {
+		counts.Unique = unique.Count()
+	}
+	fmt.Printf("%8d", messages)
+	printCounts(counts, "total", t.options)
+}
+
+func printKafkaUsage() {
+	fmt.Println("Usage: mwc kafka --brokers HOSTS --topic TOPIC [options] [-lwcm]")
+	fmt.Println("Consume a Kafka topic with kcat and print the messages and counts of each")
+	fmt.Println("partition, labelled TOPIC-PARTITION, and their total.")
+	fmt.Println("\nOptions:")
+	fmt.Println("  --brokers HOSTS	Comma-separated bootstrap brokers, such as localhost:9092")
+	fmt.Println("  --topic TOPIC	Topic to consume")
+	fmt.Println("  --from OFFSET	Where to start: beginning (default), end, stored, or an offset")
+	fmt.Println("  --interval DURATION	Consume continuously, printing the counts so far every")
+	fmt.Println("		DURATION until interrupted, instead of stopping at the end")
+	fmt.Println("\nThe counts are selected with the same options as mwc; see mwc --help.")
+}
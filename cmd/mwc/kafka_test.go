@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestKafka consumes a topic with a fake kcat printing three messages of two partitions
+func TestKafka(t *testing.T) {
+	bin := t.TempDir()
+	script := `#!/bin/sh
+echo "$@" > "$(dirname "$0")/args"
+printf '0 13\nHello, World!1 12\none\ntwo\nsix\n0 -1\n'
+`
+	if err := os.WriteFile(filepath.Join(bin, "kcat"), []byte(script), 0o755); err != nil {
+		t.Fatalf("Error writing fake kcat: %v", err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	var status int
+	stdout, stderr := captureFunc(t, func() {
+		status = kafka([]string{"--brokers", "localhost:9092", "--topic=events", "-lw", "--unique"})
+	})
+	expected := "" +
+		"       2       0       2       2 events-0\n" +
+		"       1       3       3       3 events-1\n" +
+		"       3       3       5       5 total\n"
+	if status != 0 || stdout != expected || stderr != "" {
+		t.Errorf("Expected status 0 and output %q, got %d, %q and %q", expected, status, stdout, stderr)
+	}
+	args, _ := os.ReadFile(filepath.Join(bin, "args"))
+	if !strings.Contains(string(args), "-b localhost:9092 -t events -o beginning") || !strings.HasSuffix(string(args), " -e\n") {
+		t.Errorf("Unexpected kcat arguments %q", args)
+	}
+
+	_, stderr = captureFunc(t, func() { status = kafka([]string{"--topic", "events"}) })
+	if status != 1 || !strings.Contains(stderr, "mwc kafka requires --brokers and --topic") {
+		t.Errorf("Expected a missing option error, got %d and %q", status, stderr)
+	}
+}
+
+// TestReadKafkaMessagesTruncated checks that a message cut short is an error
+func TestReadKafkaMessagesTruncated(t *testing.T) {
+	err := readKafkaMessages(strings.NewReader("0 10\nshort"), newTopicCounts("events", cliOptions{}).add)
+	if err == nil || !strings.Contains(err.Error(), "unexpected EOF") {
+		t.Errorf("Expected an unexpected EOF error, got %v", err)
+	}
+}
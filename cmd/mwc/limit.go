@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/rivo/uniseg"
+)
+
+// tweetLimit is the most weighted characters a post on X may have
+const tweetLimit = 280
+
+// tweetURLLength is the weighted characters a link in a post counts as,
+// whatever its length, as X shortens every link to t.co
+const tweetURLLength = 23
+
+// tweetURL matches the links in a post
+var tweetURL = regexp.MustCompile(`https?://\S+`)
+
+// tweetLength returns the weighted length of a post on X, following its
+// counting rules: characters in the ranges of Latin and other scripts, and
+// some punctuation, count as one, others, such as CJK characters, as two,
+// an emoji as two however many code points it is made of, and a link as
+// tweetURLLength. Text isn't normalized first.
+func tweetLength(text string) int {
+	length := 0
+	rest := 0
+	for _, link := range tweetURL.FindAllStringIndex(text, -1) {
+		length += tweetTextLength(text[rest:link[0]]) + tweetURLLength
+		rest = link[1]
+	}
+	return length + tweetTextLength(text[rest:])
+}
+
+// tweetTextLength returns the weighted length of text without links
+func tweetTextLength(text string) int {
+	length := 0
+	graphemes := uniseg.NewGraphemes(text)
+	for graphemes.Next() {
+		runes := graphemes.Runes()
+		if isEmojiSequence(runes) {
+			length += 2
+			continue
+		}
+		for _, r := range runes {
+			switch {
+			case r <= 0x10FF, r >= 0x2000 && r <= 0x200D, r >= 0x2010 && r <= 0x201F, r >= 0x2032 && r <= 0x2037:
+				length++
+			default:
+				length += 2
+			}
+		}
+	}
+	return length
+}
+
+// isEmojiSequence reports whether the runes of a grapheme cluster make up
+// an emoji of several code points, such as a flag, a keycap or a family
+// joined by zero-width joiners
+func isEmojiSequence(runes []rune) bool {
+	if len(runes) < 2 {
+		return false
+	}
+	for _, r := range runes {
+		if r == 0x200D || r == 0xFE0F || r == 0x20E3 || r >= 0x1F000 && r <= 0x1FAFF {
+			return true
+		}
+	}
+	return false
+}
+
+// gsm7 holds the characters of the GSM 03.38 default alphabet, which take
+// one septet, and gsm7Extension those of its extension table, which take two
+const (
+	gsm7 = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+		"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+	gsm7Extension = "\f^{}\\[~]|€"
+)
+
+// smsLength returns the encoding an SMS with the text would be sent in,
+// "GSM-7" if the GSM alphabet has all of its characters and "UCS-2"
+// otherwise, its length in septets or UTF-16 code units, and the number of
+// segments it would be sent as
+func smsLength(text string) (encoding string, length, segments int) {
+	encoding = "GSM-7"
+	for _, r := range text {
+		switch {
+		case strings.ContainsRune(gsm7, r):
+			length++
+		case strings.ContainsRune(gsm7Extension, r):
+			length += 2
+		default:
+			encoding = "UCS-2"
+		}
+	}
+	if encoding == "UCS-2" {
+		length = len(utf16.Encode([]rune(text)))
+	}
+	single, multi := smsSegmentSize(encoding)
+	switch {
+	case length == 0:
+		return encoding, 0, 0
+	case length <= single:
+		return encoding, length, 1
+	}
+	return encoding, length, (length + multi - 1) / multi
+}
+
+// smsSegmentSize returns how many septets or code units an SMS in the
+// encoding holds, and each segment of a longer one, which carries a header
+// for the segments to be joined up
+func smsSegmentSize(encoding string) (single, multi int) {
+	if encoding == "UCS-2" {
+		return 70, 67
+	}
+	return 160, 153
+}
+
+// checkLimits checks the inputs against the --limit, printing whether each
+// of their lines fits in a post on X ("tweet") or in one SMS ("sms"), and
+// how many posts or SMS segments each input would need. It returns the exit
+// status.
+func checkLimits(options cliOptions, filenames []string) int {
+	if len(filenames) == 0 && options.FilesFrom == "" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			printFileError(err)
+			return exitFileErrors
+		}
+		printLimits("stdin", string(data), options.Limit)
+		return 0
+	}
+
+	var fsys fs.FS = osFS{}
+	if options.FSRoot != "" {
+		fsys = os.DirFS(options.FSRoot)
+	}
+	status := 0
+	fail := func(err error) {
+		printFileError(err)
+		status = exitFileErrors
+	}
+	walker := newWalker(fsys, options)
+	for _, filename := range filenames {
+		walker.expand(filename, func(name string) {
+			data, err := readNamedFile(fsys, name)
+			if err != nil {
+				fail(err)
+				return
+			}
+			printLimits(name, string(data), options.Limit)
+		}, fail)
+	}
+	return status
+}
+
+// printLimits prints the length of each line of an input against the limit,
+// and whether it fits, then how many posts or SMS segments the input needs:
+// a post for each line that isn't blank, or more for those too long, or the
+// segments of the whole input sent as one SMS
+func printLimits(name, text, limit string) {
+	posts := 0
+	for i, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		length, most, encoding := tweetLength(line), tweetLimit, ""
+		if limit == "sms" {
+			encoding, length, _ = smsLength(line)
+			most, _ = smsSegmentSize(encoding)
+			encoding = " " + encoding
+		}
+		fit := "fits"
+		if length > most {
+			fit = fmt.Sprintf("too long by %d", length-most)
+		}
+		fmt.Printf("%6d %5d/%d%s %s\n", i+1, length, most, encoding, fit)
+		posts += (length + most - 1) / most
+	}
+	if limit == "tweet" {
+		noun := "posts"
+		if posts == 1 {
+			noun = "post"
+		}
+		fmt.Printf("%s: %d %s\n", name, posts, noun)
+		return
+	}
+	// The newline ending the input isn't sent, as it isn't on its last line
+	encoding, length, segments := smsLength(strings.TrimSuffix(strings.TrimSuffix(text, "\n"), "\r"))
+	unit := "septets"
+	if encoding == "UCS-2" {
+		unit = "code units"
+	}
+	noun := "segments"
+	if segments == 1 {
+		noun = "segment"
+	}
+	fmt.Printf("%s: %d %s in %s (%d %s)\n", name, segments, noun, encoding, length, unit)
+}
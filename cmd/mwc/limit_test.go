@@ -0,0 +1,114 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestTweetLength tests the weighted length of posts on X
+func TestTweetLength(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected int
+	}{
+		{"empty", "", 0},
+		{"latin", "Hello, world!", 13},
+		{"accents", "Café déjà vu", 12},
+		{"curly quotes", "“quoted”", 8},
+		{"cjk", "你好世界", 8},
+		{"emoji", "hi 😀", 5},
+		{"zwj family", "👨‍👩‍👧", 2},
+		{"flag", "🇫🇷", 2},
+		{"keycap", "1️⃣", 2},
+		{"link", "see https://example.com/a/very/long/path/indeed?query=1 now", 4 + tweetURLLength + 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tweetLength(tt.text); got != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestSMSLength tests the encoding, length and segments of SMS
+func TestSMSLength(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		encoding string
+		length   int
+		segments int
+	}{
+		{"empty", "", "GSM-7", 0, 0},
+		{"gsm", "Hello @ 5£", "GSM-7", 10, 1},
+		{"extension", "{€}", "GSM-7", 6, 1},
+		{"full", strings.Repeat("a", 160), "GSM-7", 160, 1},
+		{"two segments", strings.Repeat("a", 161), "GSM-7", 161, 2},
+		{"ucs-2", "Привет", "UCS-2", 6, 1},
+		{"surrogates", "😀", "UCS-2", 2, 1},
+		{"ucs-2 segments", strings.Repeat("é", 60) + "ç" + strings.Repeat("x", 10), "UCS-2", 71, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoding, length, segments := smsLength(tt.text)
+			if encoding != tt.encoding || length != tt.length || segments != tt.segments {
+				t.Errorf("Expected %s %d %d, got %s %d %d", tt.encoding, tt.length, tt.segments, encoding, length, segments)
+			}
+		})
+	}
+}
+
+// TestLimit checks the report of --limit for a file
+func TestLimit(t *testing.T) {
+	dir := t.TempDir()
+	long := strings.Repeat("word ", 60)
+	writeTree(t, dir, map[string]string{"posts.txt": "Short post\n\n" + long + "\n"})
+	name := filepath.Join(dir, "posts.txt")
+
+	stdout, stderr := captureOutput(t, []string{"--limit", "tweet", name})
+	expected := "     1    10/280 fits\n     2     0/280 fits\n     3   300/280 too long by 20\n" + name + ": 3 posts\n"
+	if stdout != expected || stderr != "" {
+		t.Errorf("Expected:\n%s\ngot:\n%s%s", expected, stdout, stderr)
+	}
+
+	stdout, _ = captureOutput(t, []string{"--limit=sms", name})
+	expected = "     1    10/160 GSM-7 fits\n     2     0/160 GSM-7 fits\n     3   300/160 GSM-7 too long by 140\n" +
+		name + ": 3 segments in GSM-7 (312 septets)\n"
+	if stdout != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout)
+	}
+}
+
+// TestLimitSingular checks that --limit names a single post or segment in
+// the singular
+func TestLimitSingular(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"post.txt": "Short post\n"})
+	name := filepath.Join(dir, "post.txt")
+
+	stdout, _ := captureOutput(t, []string{"--limit", "tweet", name})
+	if expected := name + ": 1 post\n"; !strings.HasSuffix(stdout, expected) {
+		t.Errorf("Expected a suffix of %q, got %q", expected, stdout)
+	}
+	stdout, _ = captureOutput(t, []string{"--limit", "sms", name})
+	if expected := name + ": 1 segment in GSM-7 (10 septets)\n"; !strings.HasSuffix(stdout, expected) {
+		t.Errorf("Expected a suffix of %q, got %q", expected, stdout)
+	}
+}
+
+// TestLimitSMSBoundary checks that a line of exactly 160 septets, ended by a
+// newline, fits in one SMS
+func TestLimitSMSBoundary(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"sms.txt": strings.Repeat("a", 160) + "\r\n"})
+	name := filepath.Join(dir, "sms.txt")
+
+	stdout, _ := captureOutput(t, []string{"--limit", "sms", name})
+	expected := "     1   160/160 GSM-7 fits\n" + name + ": 1 segment in GSM-7 (160 septets)\n"
+	if stdout != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout)
+	}
+}
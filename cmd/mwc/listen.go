@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// Defaults of mwc listen: how many connections are counted at once without
+// --jobs, and how long a connection may send nothing before it is closed
+const (
+	defaultListenConnections = 64
+	defaultIdleTimeout       = time.Minute
+)
+
+// listenTCP runs mwc listen with the given arguments and returns the exit
+// status. It counts the data of every TCP connection accepted on the --tcp
+// address until the connection is closed, and prints a row for it labelled
+// with the remote address. The other arguments select the counts as for mwc.
+func listenTCP(args []string) int {
+	values, args, err := cutValueOptions(args, "tcp", "idle-timeout")
+	address := values["tcp"]
+	idleTimeout := defaultIdleTimeout
+	if value, found := values["idle-timeout"]; err == nil && found {
+		idleTimeout, err = time.ParseDuration(value)
+		if err != nil || idleTimeout <= 0 {
+			err = optionError("--idle-timeout", "invalid duration for --idle-timeout: '%s'", value)
+		}
+	}
+	var options cliOptions
+	if err == nil {
+		var filenames []string
+		options, filenames, err = parseArgs(args)
+		if err == nil && len(filenames) > 0 {
+			err = optionError(filenames[0], "unexpected argument '%s'", filenames[0])
+		}
+	}
+	if err == nil && address == "" && !options.HelpRequested {
+		err = optionError("--tcp", "mwc listen requires --tcp ADDR")
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[0], err)
+		_, _ = fmt.Fprintf(os.Stderr, "usage: %s listen --tcp ADDR [--idle-timeout DURATION] [-clmw]\n", os.Args[0])
+		return exitUsage
+	}
+	if options.HelpRequested {
+		printListenUsage()
+		return 0
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[0], err)
+		return exitFailed
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "%s: listening on %s\n", os.Args[0], listener.Addr())
+	var mu sync.Mutex
+	err = countConnections(listener, options, idleTimeout, func(label string, counts wordcount.Counts, err error) {
+		// Rows of connections closing at the same time mustn't interleave
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error processing %s: %v\n", label, err)
+			return
+		}
+		printCounts(counts, label, options)
+	})
+	_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[0], err)
+	return exitFailed
+}
+
+// cutValueOptions removes the named long options, which take a value, from
+// the arguments, returning their values by name. The remaining arguments are
+// left for parseArgs.
+func cutValueOptions(args []string, names ...string) (map[string]string, []string, error) {
+	values := map[string]string{}
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(args[i], "--"), "=")
+		if !strings.HasPrefix(args[i], "--") || !slices.Contains(names, name) {
+			rest = append(rest, args[i])
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, nil, optionError("--"+name, "option '--%s' requires an argument", name)
+			}
+			i++
+			value = args[i]
+		}
+		values[name] = value
+	}
+	return values, rest, nil
+}
+
+// countConnections accepts connections until the listener fails, counting
+// each one concurrently and reporting its counts once it is closed. At most
+// --jobs connections are counted at once; others wait to be accepted. A
+// connection that sends nothing for idleTimeout fails with a timeout.
+func countConnections(listener net.Listener, options cliOptions, idleTimeout time.Duration, report func(label string, counts wordcount.Counts, err error)) error {
+	if options.Throttle > 0 {
+		options.RateLimiter = wordcount.NewRateLimiter(options.Throttle)
+	}
+	countOptions := options.CountOptions
+	if len(options.Exprs) > 0 {
+		countOptions = exprCountOptions(countOptions, options.Exprs)
+	}
+	slots := options.Jobs
+	if slots <= 0 {
+		slots = defaultListenConnections
+	}
+	counting := make(chan struct{}, slots)
+	for {
+		counting <- struct{}{}
+		conn, err := listener.Accept()
+		if err != nil {
+			<-counting
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+			return err
+		}
+		go func() {
+			defer func() { <-counting }()
+			defer conn.Close()
+			counts, err := wordcount.Count(idleConn{conn, idleTimeout}, countOptions)
+			report(conn.RemoteAddr().String(), counts, err)
+		}()
+	}
+}
+
+// idleConn reads from a connection, failing once it has sent nothing for the
+// timeout
+type idleConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (c idleConn) Read(p []byte) (int, error) {
+	if err := c.Conn.SetReadDeadline(time.Now().Add(c.timeout)); err != nil {
+		return 0, err
+	}
+	return c.Conn.Read(p)
+}
+
+func printListenUsage() {
+	fmt.Println("Usage: mwc listen --tcp ADDR [-lwcm] [options]")
+	fmt.Println("Count the data of each TCP connection accepted on ADDR, printing a row")
+	fmt.Println("labelled with the remote address when the connection is closed.")
+	fmt.Println("\nOptions:")
+	fmt.Println("  --tcp ADDR	Listen on ADDR, such as :7000")
+	fmt.Println("  --idle-timeout DURATION	Close connections that send nothing for DURATION")
+	fmt.Println("		(default 1m)")
+	fmt.Println("  --jobs N	Count at most N connections at once (default 64); others")
+	fmt.Println("		wait to be accepted")
+	fmt.Println("\nThe counts are selected with the same options as mwc; see mwc --help.")
+}
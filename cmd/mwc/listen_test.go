@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// TestCountConnections checks that each connection is counted until it is closed
+func TestCountConnections(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening: %v", err)
+	}
+	options, _, err := parseArgs([]string{"-w", "--metric", "sentences"})
+	if err != nil {
+		t.Fatalf("Error parsing arguments: %v", err)
+	}
+	reports := make(chan wordcount.FileCount)
+	go func() {
+		_ = countConnections(listener, options, time.Minute, func(label string, counts wordcount.Counts, err error) {
+			if err != nil {
+				t.Errorf("Error counting %s: %v", label, err)
+			}
+			reports <- wordcount.FileCount{Filename: label, Counts: counts}
+		})
+	}()
+	defer listener.Close()
+
+	for _, data := range []string{"Hello there. General Kenobi!", ""} {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("Error connecting: %v", err)
+		}
+		_, _ = conn.Write([]byte(data))
+		_ = conn.Close()
+
+		report := <-reports
+		if report.Filename != conn.LocalAddr().String() {
+			t.Errorf("Expected the row to be labelled %s, got %s", conn.LocalAddr(), report.Filename)
+		}
+		expected := wordcount.Counts{Words: 4, Metrics: map[string]int64{"sentences": 2}}
+		if data == "" {
+			expected = wordcount.Counts{Metrics: map[string]int64{"sentences": 0}}
+		}
+		if !report.Counts.Equal(expected) {
+			t.Errorf("Expected %+v, got %+v", expected, report.Counts)
+		}
+	}
+}
+
+// TestCountConnectionsLimits checks that at most --jobs connections are
+// counted at once, and that an idle connection times out
+func TestCountConnectionsLimits(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening: %v", err)
+	}
+	options, _, err := parseArgs([]string{"-w", "--jobs", "1"})
+	if err != nil {
+		t.Fatalf("Error parsing arguments: %v", err)
+	}
+	type report struct {
+		label string
+		words int64
+		err   error
+	}
+	reports := make(chan report)
+	go func() {
+		_ = countConnections(listener, options, 200*time.Millisecond, func(label string, counts wordcount.Counts, err error) {
+			reports <- report{label, counts.Words, err}
+		})
+	}()
+	defer listener.Close()
+
+	dial := func(data string) net.Conn {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("Error connecting: %v", err)
+		}
+		_, _ = conn.Write([]byte(data))
+		return conn
+	}
+	idle := dial("one two")
+	defer idle.Close()
+	// Let the first connection be accepted before the second is made
+	time.Sleep(50 * time.Millisecond)
+	closed := dial("three")
+	_ = closed.Close()
+
+	first := <-reports
+	if first.label != idle.LocalAddr().String() || !errors.Is(first.err, os.ErrDeadlineExceeded) {
+		t.Errorf("Expected the idle connection %s to time out first, got %+v", idle.LocalAddr(), first)
+	}
+	second := <-reports
+	if second.label != closed.LocalAddr().String() || second.err != nil || second.words != 1 {
+		t.Errorf("Expected the closed connection %s to be counted next, got %+v", closed.LocalAddr(), second)
+	}
+}
+
+// TestCutValueOptions checks that --tcp is taken out of the counting options
+func TestCutValueOptions(t *testing.T) {
+	for _, args := range [][]string{{"-w", "--tcp", ":7000", "-l"}, {"-w", "--tcp=:7000", "-l"}} {
+		values, rest, err := cutValueOptions(args, "tcp")
+		if err != nil || values["tcp"] != ":7000" || len(rest) != 2 || rest[0] != "-w" || rest[1] != "-l" {
+			t.Errorf("cutValueOptions(%q) = %q, %q, %v", args, values, rest, err)
+		}
+	}
+	if _, _, err := cutValueOptions([]string{"--tcp"}, "tcp"); err == nil || err.Error() != "option '--tcp' requires an argument" {
+		t.Errorf("Expected a missing argument error, got %v", err)
+	}
+}
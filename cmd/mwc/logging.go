@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// Diagnostics are written with logEvent. By default they are the plain
+// messages mwc has always written to stderr; --log-format text or json turns
+// them into leveled, structured records that log pipelines can ingest.
+var (
+	// structuredLog writes the records of --log-format; nil for plain messages
+	structuredLog *slog.Logger
+	// logLevel is the least severe level written, set with --log-level
+	logLevel = slog.LevelInfo
+)
+
+// setupLogging configures diagnostics from the --log-format and --log-level options
+func setupLogging(options cliOptions) {
+	logLevel = options.LogLevel
+	handlerOptions := &slog.HandlerOptions{Level: options.LogLevel}
+	switch options.LogFormat {
+	case "text":
+		structuredLog = slog.New(slog.NewTextHandler(os.Stderr, handlerOptions))
+	case "json":
+		structuredLog = slog.New(slog.NewJSONHandler(os.Stderr, handlerOptions))
+	default:
+		structuredLog = nil
+	}
+}
+
+// logEvent writes a diagnostic at a level: as a record with the message msg
+// and the attributes with --log-format, or as the plain text otherwise
+func logEvent(level slog.Level, plain, msg string, attrs ...any) {
+	if structuredLog != nil {
+		structuredLog.Log(context.Background(), level, msg, attrs...)
+		return
+	}
+	if level >= logLevel {
+		_, _ = fmt.Fprintln(os.Stderr, plain)
+	}
+}
+
+// logError writes an error that isn't about a particular file, such as a
+// webhook that couldn't be notified
+func logError(msg string, err error, attrs ...any) {
+	logEvent(slog.LevelError, fmt.Sprintf("%s: %v", os.Args[0], err), msg, append(attrs, "error", err.Error())...)
+}
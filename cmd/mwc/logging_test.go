@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestLogFormat checks the records of --log-format json, and that --log-level filters them
+func TestLogFormat(t *testing.T) {
+	defer setupLogging(cliOptions{})
+
+	_, stderr := captureOutput(t, []string{"-w", "--log-format", "json", "--log-level", "debug", "testdata/test1.txt", "testdata/missing.txt"})
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(stderr), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Expected a JSON record, got %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	expected := [][3]string{
+		{"DEBUG", "opening file", "testdata/test1.txt"},
+		{"DEBUG", "counted file", "testdata/test1.txt"},
+		{"DEBUG", "opening file", "testdata/missing.txt"},
+		{"ERROR", "skipping file", "testdata/missing.txt"},
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("Expected %d records, got %q", len(expected), stderr)
+	}
+	for i, e := range expected {
+		if records[i]["level"] != e[0] || records[i]["msg"] != e[1] || records[i]["file"] != e[2] {
+			t.Errorf("Expected a %s record %q for %s, got %v", e[0], e[1], e[2], records[i])
+		}
+	}
+	if counts, ok := records[1]["counts"].(map[string]any); !ok || counts["words"] != 2.0 {
+		t.Errorf("Expected the counts of the file, got %v", records[1]["counts"])
+	}
+	if records[3]["op"] != "open" || !strings.Contains(records[3]["error"].(string), "no such file") {
+		t.Errorf("Expected the open error, got %v", records[3])
+	}
+
+	_, stderr = captureOutput(t, []string{"-w", "--log-format", "text", "--log-level", "warn", "testdata/test1.txt", "testdata/missing.txt"})
+	if !strings.HasPrefix(stderr, "time=") || !strings.Contains(stderr, `level=ERROR msg="skipping file" file=testdata/missing.txt op=open`) ||
+		strings.Count(stderr, "\n") != 1 {
+		t.Errorf("Expected a single text record for the missing file, got %q", stderr)
+	}
+
+	for _, args := range [][]string{{"--log-format", "xml"}, {"--log-level", "loud"}} {
+		if _, _, err := parseArgs(args); err == nil {
+			t.Errorf("Expected an error for %q", args)
+		}
+	}
+}
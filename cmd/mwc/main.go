@@ -0,0 +1,1001 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/signal"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"strings"
+	"time"
+
+	"github.com/mvk059/word-count/wordcount"
+	"golang.org/x/text/unicode/norm"
+)
+
+// cliOptions holds the command-line flags: the counting options plus the
+// flags controlling how inputs are read and how results are reported
+type cliOptions struct {
+	wordcount.CountOptions
+	HelpRequested   bool
+	Buffered        bool              // Print file rows only after every file has been counted
+	CacheDir        string            // Directory caching counts by path, size and modification time
+	Incremental     bool              // Count only the bytes appended to files since the previous run
+	CPUProfile      string            // File to write a pprof CPU profile to
+	MemProfile      string            // File to write a pprof heap profile to
+	Trace           string            // File to write a runtime execution trace to
+	Throttle        int64             // Maximum read rate in bytes per second across all inputs; 0 means unlimited
+	Stats           bool              // Report wall time and throughput per file to stderr
+	FSRoot          string            // Directory that file names are resolved in; names can't leave it
+	Plugins         []string          // Go plugins loaded to register more metrics
+	Exprs           []*wordcount.Expr // Derived counts printed after the counted ones
+	NotifyURL       string            // Webhook the results are posted to as JSON when the run finishes
+	StatsD          string            // StatsD address the results are sent to as metrics
+	OTLPURL         string            // OTLP/HTTP endpoint the results are sent to as metrics
+	MetricTags      [][2]string       // Tags added to the metrics sent, such as profile=nightly
+	LogFormat       string            // Format of diagnostics: "" for plain messages, "text" or "json"
+	LogLevel        slog.Level        // Least severe diagnostics written
+	Remote          string            // URL of an mwc server that counts the inputs instead
+	Recursive       bool              // Count the files under directories named as inputs
+	Include         []string          // Patterns of the files counted under directories; empty for all
+	Exclude         []string          // Patterns of the files and directories skipped under directories
+	GitIgnore       string            // When .gitignore files apply under directories: "" inside git repositories, "on" or "off"
+	GitTracked      bool              // Count only the files under directories that git tracks
+	Hidden          bool              // Count hidden files and directories under directories
+	FollowSymlinks  bool              // Follow symbolic links under directories
+	DedupHardlinks  bool              // Count files reached under several names, such as hard links, once
+	MaxDepth        int               // Deepest level of directories counted, with 1 for the files directly in them; 0 means unlimited
+	GroupBy         string            // "dir" to print subtotals by directory, "tree" to print them as a tree; "" for file rows
+	Jobs            int               // Number of files counted at once; 0 or 1 counts them one at a time
+	MaxFileSize     int64             // Size of the largest files counted under directories; 0 means unlimited
+	Type            string            // "text" to count only the files under directories that look like text
+	FilesFrom       string            // File listing the inputs one per line, or "-" for stdin
+	Follow          time.Duration     // How often followed files are checked for appended data; 0 doesn't follow
+	Interval        time.Duration     // How often the counts of stdin so far are reported on stderr; 0 for never
+	NoProgress      bool              // Never show a progress line while counting large files
+	Tee             bool              // Copy stdin to stdout while counting it, printing the counts to stderr
+	Timeout         time.Duration     // How long stdin is read for at most; 0 means until it ends
+	Goal            int64             // Word count whose progress is printed after the counts; 0 for none
+	HistoryDB       string            // History file recorded by mwc daemon, projecting when the goal is reached
+	SaveSnapshot    string            // File the counts of each input and the total are saved to as JSON
+	CheckAgainst    string            // Snapshot the counts are compared with, failing on drift beyond the tolerances
+	Tolerances      []tolerance       // How far counts may drift from the --check-against snapshot
+	UpdateBaseline  bool              // Save the counts as the --check baseline instead of checking them
+	Limit           string            // "tweet" or "sms" to check each line against the length of a post or SMS
+	ByHeading       *headingRule      // Headings to count the sections of each input between; nil for none
+	ExcludeQuotes   bool              // Leave quotations and block quotes out of the counts
+	NormalForm      string            // Unicode normalization form to count the text in, such as "NFC"; "" for none
+	FrontMatter     bool              // Label files with the title and author from their front matter
+	Compat          string            // "gnu" or "bsd" to print counts and errors as that wc does; "" for mwc's own way
+	CompatWidth     int               // Column width of the counts with --compat gnu, from the sizes of the inputs
+	Staged          bool              // Count the files staged in git, as they are staged, instead of the inputs
+	GitHub          bool              // Write a job summary and annotate the files over budget in GitHub Actions
+	MaxWordsPerFile int64             // Most words a file may have before mwc fails; 0 means unlimited
+	DryRun          bool              // Print the files that would be counted and their sizes, without reading them
+}
+
+func main() {
+	// A command is the first argument; after "--", or an option, the
+	// arguments are inputs, so "mwc -- diff" counts a file named diff
+	if len(os.Args) > 1 {
+		switch args := os.Args[2:]; os.Args[1] {
+		case "serve":
+			os.Exit(serve(args))
+		case "listen":
+			os.Exit(listenTCP(args))
+		case "kafka":
+			os.Exit(kafka(args))
+		case "watch":
+			os.Exit(watch(args))
+		case "session":
+			os.Exit(session(args))
+		case "daemon":
+			os.Exit(daemon(args))
+		case "trend":
+			os.Exit(trend(args))
+		case "diff":
+			os.Exit(diff(args))
+		case "log":
+			os.Exit(logWords(args))
+		case "report":
+			os.Exit(reportWords(args))
+		case "summary":
+			os.Exit(summary(args))
+		case "git-diff":
+			os.Exit(gitDiff(args))
+		case "git-history":
+			os.Exit(gitHistory(args))
+		case "git-blame":
+			os.Exit(gitBlame(args))
+		case "selftest":
+			os.Exit(selftest(args))
+		}
+	}
+	if status := countMain(os.Args[1:]); status != 0 {
+		os.Exit(status)
+	}
+}
+
+// countMain counts what the command-line arguments name, as mwc without a
+// command does, and returns the exit status
+func countMain(args []string) int {
+	// Parse command-line arguments
+	options, filenames, err := parseArgs(args)
+	if err != nil {
+		// If there's an error (e.g., illegal option), print the error and usage
+		printUsageError(err, compatFromArgs(args))
+		return exitUsage
+	}
+
+	// If no options are provided, use default options (equivalent to -lwc)
+	// This ensures default behavior even when reading from stdin
+	if !hasAnyOption(options) {
+		options.LineCount = true
+		options.WordCount = true
+		options.ByteCount = true
+		options.Order = []string{"lines", "words", "bytes"}
+	}
+
+	// Check if help is requested
+	if options.HelpRequested {
+		printUsage()
+		return 0
+	}
+
+	setupLogging(options)
+	stopProfiling, err := startProfiling(options)
+	if err != nil {
+		logError("profiling failed", err)
+		return exitFailed
+	}
+	var status int
+	if options.DryRun {
+		status = dryRun(options, filenames)
+	} else if options.Follow > 0 {
+		status = follow(options, filenames)
+	} else if options.Limit != "" {
+		status = checkLimits(options, filenames)
+	} else if options.ByHeading != nil {
+		status = countByHeading(options, filenames)
+	} else {
+		status = run(options, filenames)
+	}
+	if err := stopProfiling(); err != nil {
+		logError("profiling failed", err)
+		status = exitFailed
+	}
+	return status
+}
+
+// Exit statuses of counting, listed in --help, so that scripts can tell why
+// mwc failed without reading stderr. They are part of its interface, and
+// don't change. With --compat, failing inputs exit with 1, as wc does.
+const (
+	exitUsage       = 1 // the command line is invalid
+	exitFileErrors  = 2 // some inputs couldn't be counted; the others were
+	exitBudget      = 3 // inputs broke a budget: --max-words-per-file, or drift from --check
+	exitInterrupted = 4 // Ctrl-C or --timeout stopped counting; the counts printed are partial
+	exitFailed      = 5 // anything else failed, such as saving a snapshot or sending metrics
+)
+
+// timedOutNote follows the counts of stdin when --timeout was reached
+const timedOutNote = " (timed out)"
+
+// interruptedNote follows the name of an input whose counting Ctrl-C stopped
+const interruptedNote = " (interrupted)"
+
+// run counts stdin or the named files and prints the report, returning the
+// exit status. Ctrl-C stops it early with the counts so far, marked as
+// partial, and exitInterrupted.
+func run(options cliOptions, filenames []string) int {
+	if options.Remote != "" {
+		return runRemote(options, filenames)
+	}
+	if options.Throttle > 0 {
+		options.RateLimiter = wordcount.NewRateLimiter(options.Throttle)
+	}
+	if options.UniqueCount {
+		options.UniqueTotal = wordcount.NewUniqueWords(options.MaxMemory)
+		defer func() {
+			if options.UniqueTotal.Approximate() {
+				logEvent(slog.LevelWarn, fmt.Sprintf("%s: unique word counts exceeded --max-memory and are approximate (±%.1f%%)",
+					os.Args[0], wordcount.ApproximateUniqueError*100),
+					"unique word counts are approximate", "max_memory", options.MaxMemory, "error_margin", wordcount.ApproximateUniqueError)
+			}
+		}()
+	}
+
+	// Statistics and interval reports need bytes and lines, goals words, and
+	// expressions the counts they use, even when they aren't printed
+	countOptions := options
+	if options.Stats || options.Interval > 0 {
+		countOptions.ByteCount, countOptions.LineCount = true, true
+	}
+	if options.Goal > 0 || options.MaxWordsPerFile > 0 {
+		countOptions.WordCount = true
+	}
+	if len(options.Exprs) > 0 {
+		countOptions.CountOptions = exprCountOptions(countOptions.CountOptions, options.Exprs)
+	}
+	if options.Compat == "gnu" {
+		fsys := fs.FS(osFS{})
+		if options.FSRoot != "" {
+			fsys = os.DirFS(options.FSRoot)
+		}
+		options.CompatWidth = gnuNumberWidth(fsys, filenames, options)
+	}
+	runStart := time.Now()
+	status := 0
+	report := runReport{Files: []wordcount.FileCount{}, Started: runStart}
+	// SIGUSR1, or SIGINFO from Ctrl-T, prints the counts so far
+	progress := newRunStatus()
+	defer notifyStatus(func() { progress.print(options) })()
+	// Ctrl-C stops counting and prints what was counted until then; a second
+	// one kills mwc as usual
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	context.AfterFunc(ctx, stop)
+	interrupted, timedOut := false, false
+
+	// Process input based on whether filenames are provided; an empty
+	// --files-from list counts nothing rather than stdin
+	if len(filenames) == 0 && options.FilesFrom == "" && !options.Staged {
+		// No filenames provided, read from stdin
+		stopReports := func() {}
+		var intervalHooks *wordcount.Hooks
+		if options.Interval > 0 {
+			intervalHooks, stopReports = reportEvery(options.Interval, options)
+		}
+		countOptions.Hooks = joinHooks(intervalHooks, progress.track("stdin"))
+		// With --tee, stdin is copied to stdout as it is counted, and the
+		// counts go to stderr
+		out := io.Writer(os.Stdout)
+		if options.Tee {
+			out = os.Stderr
+		}
+		countStdin := func() (wordcount.Counts, string, error) {
+			if !options.Tee && !transformsInput(options) {
+				return countInput(ctx, os.Stdin, countOptions.CountOptions)
+			}
+			var stdin io.Reader = os.Stdin
+			if options.Tee {
+				stdin = io.TeeReader(stdin, os.Stdout)
+			}
+			counts, err := wordcount.CountContext(ctx, transformInput(stdin, options), countOptions.CountOptions)
+			return counts, "", err
+		}
+		var counts wordcount.Counts
+		var note string
+		var err error
+		if options.Timeout > 0 {
+			if isTerminal(os.Stdin) {
+				logEvent(slog.LevelInfo, fmt.Sprintf("%s: reading stdin from the terminal for up to %v", os.Args[0], options.Timeout),
+					"reading stdin from the terminal", "timeout", options.Timeout)
+			}
+			counts, note, timedOut, err = countWithTimeout(options.Timeout, countStdin)
+			if timedOut {
+				counts, note = progress.current("stdin"), timedOutNote
+			}
+		} else {
+			counts, note, err = countStdin()
+		}
+		stopReports()
+		progress.finish("stdin", counts, err)
+		if errors.Is(err, wordcount.ErrInterrupted) {
+			interrupted, note, err = true, interruptedNote, nil
+		}
+		if err != nil {
+			logEvent(slog.LevelError, fmt.Sprintf("Error processing stdin: %v", err),
+				"skipping input", "file", "stdin", "op", "count", "error", err.Error())
+			report.Errors = append(report.Errors, "stdin: "+err.Error())
+			status = exitFileErrors
+		} else {
+			printCountsTo(out, counts, strings.TrimSpace(note), options)
+			if options.Stats {
+				printStats("stdin", counts, time.Since(runStart))
+			}
+			report.add("stdin", note, wordcount.FrontMatter{}, counts, time.Since(runStart))
+			report.Total = counts
+		}
+	} else {
+		// Process each file provided, printing its row as soon as it is counted
+		// unless buffered output was requested
+		var fileCounts []wordcount.FileCount
+		var total wordcount.Accumulator
+		failed := 0 // inputs that couldn't be counted, and have no row
+		estimated := false
+		var fsys fs.FS = osFS{}
+		if options.FSRoot != "" {
+			fsys = os.DirFS(options.FSRoot)
+		}
+		addFile := func(filename string, meta wordcount.FrontMatter, counts wordcount.Counts, note string, elapsed time.Duration) {
+			logEvent(slog.LevelDebug, fmt.Sprintf("%s: counted %s in %v", os.Args[0], filename, elapsed.Round(time.Microsecond)),
+				"counted file", "file", filename, "duration", elapsed, "counts", counts)
+			estimated = estimated || note != "" && note != interruptedNote
+			total.Add(counts)
+			report.add(filename, note, meta, counts, elapsed)
+			if options.GroupBy != "" {
+				fileCounts = append(fileCounts, wordcount.FileCount{Filename: filename, Counts: counts})
+			} else if options.Buffered {
+				fileCounts = append(fileCounts, wordcount.FileCount{Filename: frontMatterLabel(filename, meta) + note, Counts: counts})
+			} else {
+				printCounts(counts, frontMatterLabel(filename, meta)+note, options)
+			}
+			if options.Stats {
+				printStats(filename, counts, elapsed)
+			}
+		}
+		fileFailed := func(err error) {
+			report.Errors = append(report.Errors, err.Error())
+			if options.Compat == "" {
+				failed++
+				printFileError(err)
+				status = exitFileErrors
+				return
+			}
+			printCompatFileError(err, options.Compat)
+			status = 1 // as wc exits
+			// GNU wc opens a directory before failing to read it, and
+			// prints zero counts for it
+			var fileErr *wordcount.FileError
+			if options.Compat == "gnu" && errors.Is(err, errIsDirectory) && errors.As(err, &fileErr) {
+				addFile(fileErr.Path, wordcount.FrontMatter{}, wordcount.Counts{}, "", 0)
+			} else {
+				failed++
+			}
+		}
+		// Progress lines are for people watching a terminal, and only make
+		// sense for one file at a time
+		showLine := !options.NoProgress && options.Jobs <= 1 && options.LogFormat == "" && isTerminal(os.Stderr)
+		count := func(filename string) func() {
+			// Files not started before Ctrl-C are left out
+			if ctx.Err() != nil {
+				return func() {}
+			}
+			fileStart := time.Now()
+			fileOptions := countOptions
+			var lineHooks *wordcount.Hooks
+			clearLine := func() {}
+			if showLine {
+				lineHooks, clearLine = showProgress(fsys, filename)
+				if lineHooks != nil {
+					fileOptions.ByteCount = true
+				}
+			}
+			fileOptions.Hooks = joinHooks(lineHooks, progress.track(filename))
+			counts, note, err := countNamedFile(ctx, fsys, filename, fileOptions)
+			clearLine()
+			var meta wordcount.FrontMatter
+			if options.FrontMatter && err == nil {
+				meta = readFileFrontMatter(fsys, filename)
+			}
+			progress.finish(filename, counts, err)
+			elapsed := time.Since(fileStart)
+			if errors.Is(err, wordcount.ErrInterrupted) {
+				return func() { addFile(filename, meta, counts, interruptedNote, elapsed) }
+			}
+			if err != nil {
+				return func() { fileFailed(err) }
+			}
+			return func() { addFile(filename, meta, counts, note, elapsed) }
+		}
+		// With --jobs, files are counted by a pool of goroutines while the
+		// inputs are still being walked, and reported in the order they were found
+		submit := func(run func() func()) { run()() }
+		then := func(report func()) { report() }
+		var pool *orderedPool
+		if options.Jobs > 1 {
+			pool = newOrderedPool(options.Jobs)
+			submit, then = pool.submit, pool.then
+		}
+		files := newWalker(fsys, options)
+		if options.Staged {
+			// The files named are pathspecs limiting the staged files counted
+			staged, err := stagedFiles(filenames, options)
+			if err != nil {
+				logError("listing the staged files failed", err)
+				report.Errors = append(report.Errors, err.Error())
+				status = exitFailed
+			}
+			for _, name := range staged {
+				if ctx.Err() != nil {
+					break
+				}
+				submit(func() func() {
+					fileStart := time.Now()
+					counts, err := countStaged(ctx, name, countOptions)
+					elapsed := time.Since(fileStart)
+					if errors.Is(err, wordcount.ErrInterrupted) {
+						return func() { addFile(name, wordcount.FrontMatter{}, counts, interruptedNote, elapsed) }
+					}
+					if err != nil {
+						return func() { fileFailed(err) }
+					}
+					return func() { addFile(name, wordcount.FrontMatter{}, counts, "", elapsed) }
+				})
+			}
+			filenames = nil
+		}
+		for _, filename := range filenames {
+			if ctx.Err() != nil {
+				break
+			}
+			if isObjectURL(filename) {
+				countObjects(ctx, filename, countOptions.CountOptions, submit, func(name string, counts wordcount.Counts, elapsed time.Duration, err error) {
+					if errors.Is(err, wordcount.ErrInterrupted) {
+						addFile(name, wordcount.FrontMatter{}, counts, interruptedNote, elapsed)
+						return
+					}
+					if err != nil {
+						fileFailed(err)
+						return
+					}
+					addFile(name, wordcount.FrontMatter{}, counts, "", elapsed)
+				})
+				continue
+			}
+			if remote, ok := parseRemotePath(filename); ok {
+				submit(func() func() {
+					fileStart := time.Now()
+					counts, err := countRemote(filename, remote, countOptions.CountOptions)
+					elapsed := time.Since(fileStart)
+					if err != nil {
+						return func() { fileFailed(err) }
+					}
+					return func() { addFile(filename, wordcount.FrontMatter{}, counts, "", elapsed) }
+				})
+				continue
+			}
+			files.expand(filename, func(name string) {
+				submit(func() func() { return count(name) })
+			}, func(err error) {
+				then(func() { fileFailed(err) })
+			})
+		}
+		if pool != nil {
+			pool.wait()
+		}
+
+		// Print buffered counts for each file
+		if options.GroupBy != "" {
+			printGroups(fileCounts, options.GroupBy, options)
+		} else {
+			for _, fc := range fileCounts {
+				printCounts(fc.Counts, fc.Filename, options)
+			}
+		}
+
+		// Print total if there's more than one file, or what was counted
+		// before Ctrl-C
+		interrupted = ctx.Err() != nil
+		totalCounts := total.Total()
+		if options.UniqueTotal != nil {
+			// Words shared between files are only counted once in the total
+			totalCounts.Unique = options.UniqueTotal.Count()
+		}
+		// wc prints a total when it was given several files, even if some
+		// couldn't be counted
+		inputs := total.Inputs()
+		if options.Compat != "" {
+			inputs += failed
+		}
+		if inputs > 1 || interrupted {
+			label := "total"
+			if estimated {
+				label += " (estimated)"
+			}
+			if interrupted {
+				label += " (partial)"
+			}
+			printCounts(totalCounts, label, options)
+		}
+		if options.Stats && total.Inputs() > 1 {
+			printStats("total", totalCounts, time.Since(runStart))
+		}
+		report.Total = totalCounts
+	}
+
+	if options.Goal > 0 {
+		printGoal(report.Total.Words, options, time.Now())
+	}
+	// Inputs over the budgets of --max-words-per-file and --check-against
+	var violations []budgetViolation
+	if options.MaxWordsPerFile > 0 {
+		if over := checkWordLimit(report, options.MaxWordsPerFile); len(over) > 0 {
+			violations = append(violations, over...)
+			status = exitBudget
+		}
+	}
+	if options.UpdateBaseline {
+		if err := updateBaseline(options.CheckAgainst, report, options); err != nil {
+			logError("updating the baseline failed", err, "snapshot", options.CheckAgainst)
+			status = exitFailed
+		} else {
+			logEvent(slog.LevelInfo, fmt.Sprintf("%s: updated the baseline %s", os.Args[0], options.CheckAgainst),
+				"updated the baseline", "snapshot", options.CheckAgainst)
+		}
+	} else if options.CheckAgainst != "" {
+		baseline, err := readSnapshot(options.CheckAgainst)
+		if err == nil {
+			options.Tolerances, err = snapshotTolerances(baseline, options)
+		}
+		if err != nil {
+			logError("checking the snapshot failed", err, "snapshot", options.CheckAgainst)
+			status = exitFailed
+		} else if drifted := checkSnapshot(baseline, newSnapshot(report, options), options); len(drifted) > 0 {
+			noun := "counts"
+			if len(drifted) == 1 {
+				noun = "count"
+			}
+			logEvent(slog.LevelWarn, fmt.Sprintf("%s: %d %s drifted from %s beyond the tolerances", os.Args[0], len(drifted), noun, options.CheckAgainst),
+				"snapshot drifted", "snapshot", options.CheckAgainst, "drifted", len(drifted))
+			violations = append(violations, drifted...)
+			status = exitBudget
+		}
+	}
+	if options.GitHub {
+		printGitHubAnnotations(violations)
+		if err := writeGitHubSummary(report, violations, options); err != nil {
+			logError("writing the job summary failed", err)
+			status = exitFailed
+		}
+	}
+	if options.SaveSnapshot != "" {
+		if err := writeSnapshot(options.SaveSnapshot, newSnapshot(report, options)); err != nil {
+			logError("saving the snapshot failed", err, "snapshot", options.SaveSnapshot)
+			status = exitFailed
+		}
+	}
+
+	if interrupted {
+		logEvent(slog.LevelWarn, fmt.Sprintf("%s: interrupted; the counts are partial", os.Args[0]), "interrupted")
+		status = exitInterrupted
+	}
+	if timedOut {
+		logEvent(slog.LevelWarn, fmt.Sprintf("%s: stdin timed out after %v; the counts are partial", os.Args[0], options.Timeout),
+			"timed out", "timeout", options.Timeout)
+		status = exitInterrupted
+	}
+
+	if err := emitMetrics(options, report, time.Since(runStart)); err != nil {
+		logError("sending metrics failed", err)
+		status = exitFailed
+	}
+	if options.NotifyURL != "" {
+		if err := notify(options.NotifyURL, report, time.Since(runStart)); err != nil {
+			logError("notifying failed", err, "url", options.NotifyURL)
+			status = exitFailed
+		}
+	}
+	return status
+}
+
+// osFS opens files by operating system path, like os.Open
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+// Stat gets a file's information without opening it, which for a named pipe
+// would wait for a writer
+func (osFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+// openFile opens a named input file in fsys
+func openFile(fsys fs.FS, filename string) (*os.File, error) {
+	if _, ok := fsys.(osFS); !ok && !fs.ValidPath(filename) {
+		return nil, fmt.Errorf("%s is not a path inside --fs-root", filename)
+	}
+	f, err := fsys.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	file, ok := f.(*os.File)
+	if !ok {
+		_ = f.Close()
+		return nil, fmt.Errorf("%s is not an operating system file", filename)
+	}
+	return file, nil
+}
+
+// countNamedFile opens and counts a named file. Failures are returned as a
+// *wordcount.FileError; when ctx interrupts counting, with the partial counts.
+func countNamedFile(ctx context.Context, fsys fs.FS, filename string, options cliOptions) (wordcount.Counts, string, error) {
+	logEvent(slog.LevelDebug, fmt.Sprintf("%s: opening %s", os.Args[0], filename), "opening file", "file", filename)
+	file, err := openFile(fsys, filename)
+	if err != nil {
+		return wordcount.Counts{}, "", &wordcount.FileError{Op: "open", Path: filename, Err: err}
+	}
+	defer file.Close()
+	if info, err := file.Stat(); err == nil && info.IsDir() {
+		return wordcount.Counts{}, "", &wordcount.FileError{Op: "open", Path: filename, Err: errIsDirectory}
+	}
+	counts, note, err := countFile(ctx, file, options)
+	if errors.Is(err, wordcount.ErrInterrupted) {
+		// The counts of the data read until then
+		return counts, note, &wordcount.FileError{Op: "count", Path: filename, Err: err}
+	}
+	if err != nil {
+		return wordcount.Counts{}, "", &wordcount.FileError{Op: "count", Path: filename, Err: err}
+	}
+	return counts, note, nil
+}
+
+// readNamedFile reads the whole of a file, for reports that need more than
+// its counts. Errors are returned as a *wordcount.FileError.
+func readNamedFile(fsys fs.FS, filename string) ([]byte, error) {
+	file, err := openFile(fsys, filename)
+	if err != nil {
+		return nil, &wordcount.FileError{Op: "open", Path: filename, Err: err}
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, &wordcount.FileError{Op: "read", Path: filename, Err: err}
+	}
+	return data, nil
+}
+
+// readFileFrontMatter reads the front matter of a file for --front-matter.
+// Files that can't be read just go without a title, as their counts were
+// read already.
+func readFileFrontMatter(fsys fs.FS, filename string) wordcount.FrontMatter {
+	file, err := openFile(fsys, filename)
+	if err != nil {
+		return wordcount.FrontMatter{}
+	}
+	defer file.Close()
+	meta, _ := wordcount.ReadFrontMatter(file)
+	return meta
+}
+
+// frontMatterLabel labels a file's row with the title and author from its
+// front matter, such as "01.md: The Storm (Jane Doe)"
+func frontMatterLabel(filename string, meta wordcount.FrontMatter) string {
+	switch {
+	case meta.Title != "" && meta.Author != "":
+		return fmt.Sprintf("%s: %s (%s)", filename, meta.Title, meta.Author)
+	case meta.Title != "":
+		return filename + ": " + meta.Title
+	case meta.Author != "":
+		return fmt.Sprintf("%s: (%s)", filename, meta.Author)
+	}
+	return filename
+}
+
+// countWithTimeout returns the results of count, or reports that it timed
+// out if it takes longer than timeout. A read blocked on stdin can't be
+// interrupted, so count is left running; the run ends soon after anyway.
+func countWithTimeout(timeout time.Duration, count func() (wordcount.Counts, string, error)) (wordcount.Counts, string, bool, error) {
+	type result struct {
+		counts wordcount.Counts
+		note   string
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		counts, note, err := count()
+		done <- result{counts, note, err}
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.counts, r.note, false, r.err
+	case <-timer.C:
+		return wordcount.Counts{}, "", true, nil
+	}
+}
+
+// printFileError reports a file that couldn't be counted and is skipped
+func printFileError(err error) {
+	var fileErr *wordcount.FileError
+	if !errors.As(err, &fileErr) {
+		logError("skipping input", err)
+		return
+	}
+	action := "processing"
+	if fileErr.Op == "open" {
+		action = "opening"
+	}
+	logEvent(slog.LevelError, fmt.Sprintf("Error %s %s: %v", action, fileErr.Path, fileErr.Err),
+		"skipping file", "file", fileErr.Path, "op", fileErr.Op, "error", fileErr.Err.Error())
+}
+
+// countInput counts the input exactly, or samples it when an estimate was requested
+// and the input is a regular file large enough for sampling to pay off. The
+// returned note is empty for exact counts and describes the margin of error otherwise.
+func countInput(ctx context.Context, file *os.File, options wordcount.CountOptions) (wordcount.Counts, string, error) {
+	if options.EstimateBlocks > 0 {
+		info, err := file.Stat()
+		if err == nil && info.Mode().IsRegular() && info.Size() > 2*int64(options.EstimateBlocks)*wordcount.EstimateBlockSize {
+			counts, margin, err := wordcount.Estimate(file, info.Size(), options)
+			if err != nil {
+				return wordcount.Counts{}, "", err
+			}
+			return counts, fmt.Sprintf(" (estimated ±%.1f%% at 95%% confidence)", margin*100), nil
+		}
+	}
+	counts, err := wordcount.CountContext(ctx, file, options)
+	return counts, "", err
+}
+
+// normalForms are the Unicode normalization forms --normalize accepts
+var normalForms = map[string]norm.Form{"NFC": norm.NFC, "NFD": norm.NFD, "NFKC": norm.NFKC, "NFKD": norm.NFKD}
+
+// transformsInput reports whether inputs are changed before they are
+// counted, with --normalize or --exclude-quotes
+func transformsInput(options cliOptions) bool {
+	return options.NormalForm != "" || options.ExcludeQuotes
+}
+
+// transformInput returns a reader of the text of an input as it is counted:
+// normalized with --normalize, then without its quotations with
+// --exclude-quotes
+func transformInput(r io.Reader, options cliOptions) io.Reader {
+	if options.NormalForm != "" {
+		r = normalForms[options.NormalForm].Reader(r)
+	}
+	if options.ExcludeQuotes {
+		r = wordcount.NewQuoteFilter(r)
+	}
+	return r
+}
+
+// printCounts outputs the counts in the order of the options, followed by
+// the derived counts of the expressions
+func printCounts(counts wordcount.Counts, filename string, options cliOptions) {
+	printCountsTo(os.Stdout, counts, filename, options)
+}
+
+// printCountsTo is printCounts writing to w
+func printCountsTo(w io.Writer, counts wordcount.Counts, filename string, options cliOptions) {
+	if options.Compat != "" {
+		printCompatCounts(w, counts, filename, options)
+		return
+	}
+	for _, countType := range options.Order {
+		if count, ok := counts.Get(countType); ok {
+			_, _ = fmt.Fprintf(w, "%8d", count)
+		}
+	}
+	for _, expr := range options.Exprs {
+		_, _ = fmt.Fprintf(w, "%8.2f", expr.Eval(counts))
+	}
+	if filename != "" {
+		_, _ = fmt.Fprintf(w, " %s", filename)
+	}
+	_, _ = fmt.Fprintln(w)
+}
+
+// printStats reports the wall time and throughput of counting an input to stderr
+func printStats(name string, counts wordcount.Counts, elapsed time.Duration) {
+	seconds := max(elapsed.Seconds(), 1e-9)
+	logEvent(slog.LevelInfo, fmt.Sprintf("%s: stats: %s: %d bytes, %d lines in %v (%s/s, %.0f lines/s)",
+		os.Args[0], name, counts.Bytes, counts.Lines, elapsed.Round(time.Microsecond),
+		formatSize(float64(counts.Bytes)/seconds), float64(counts.Lines)/seconds),
+		"stats", "file", name, "bytes", counts.Bytes, "lines", counts.Lines, "duration", elapsed,
+		"bytes_per_second", float64(counts.Bytes)/seconds, "lines_per_second", float64(counts.Lines)/seconds)
+}
+
+// formatSize formats a number of bytes with a power-of-1024 suffix, as accepted by parseSize
+func formatSize(size float64) string {
+	const units = "KMGT"
+	if size < 1024 {
+		return fmt.Sprintf("%.0fB", size)
+	}
+	unit := -1
+	for size >= 1024 && unit < len(units)-1 {
+		size /= 1024
+		unit++
+	}
+	return fmt.Sprintf("%.1f%cB", size, units[unit])
+}
+
+// startProfiling starts the CPU profile and execution trace requested by the
+// options. The returned function stops them and writes the heap profile.
+func startProfiling(options cliOptions) (func() error, error) {
+	var stops []func() error
+	stop := func() error {
+		var firstErr error
+		for i := len(stops) - 1; i >= 0; i-- {
+			if err := stops[i](); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	}
+
+	if options.CPUProfile != "" {
+		f, err := os.Create(options.CPUProfile)
+		if err != nil {
+			return nil, fmt.Errorf("cpu profile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("cpu profile: %w", err)
+		}
+		stops = append(stops, func() error {
+			pprof.StopCPUProfile()
+			return f.Close()
+		})
+	}
+
+	if options.Trace != "" {
+		f, err := os.Create(options.Trace)
+		if err != nil {
+			_ = stop()
+			return nil, fmt.Errorf("trace: %w", err)
+		}
+		if err := trace.Start(f); err != nil {
+			_ = f.Close()
+			_ = stop()
+			return nil, fmt.Errorf("trace: %w", err)
+		}
+		stops = append(stops, func() error {
+			trace.Stop()
+			return f.Close()
+		})
+	}
+
+	if options.MemProfile != "" {
+		// The heap profile is a snapshot, so it's taken when profiling stops
+		stops = append(stops, func() error {
+			f, err := os.Create(options.MemProfile)
+			if err != nil {
+				return fmt.Errorf("memory profile: %w", err)
+			}
+			runtime.GC() // get up-to-date statistics
+			err = pprof.WriteHeapProfile(f)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return fmt.Errorf("memory profile: %w", err)
+			}
+			return nil
+		})
+	}
+
+	return stop, nil
+}
+
+// printUsage displays the usage information for the command
+func printUsage() {
+	fmt.Println("Usage: mwc [-lwcmLr] [file ...]")
+	fmt.Println("Count lines, words, bytes, and characters in input files or stdin.")
+	fmt.Println("\nOptions:")
+	fmt.Println("  -l    		Count lines")
+	fmt.Println("  -w    		Count words")
+	fmt.Println("  -c    		Count bytes")
+	fmt.Println("  -m    		Count characters")
+	fmt.Println("  -L, --max-line-length	Print the display width of the longest line")
+	fmt.Println("  --tabstop N	With -L, put tab stops every N columns (default 8)")
+	fmt.Println("  -r, -R		Count every file under the directories named, recursively")
+	fmt.Println("  --include PATTERN	With -r, count only files matching PATTERN, such as '*.go'")
+	fmt.Println("  --exclude PATTERN	With -r, skip files and directories matching PATTERN, such as 'vendor/**'")
+	fmt.Println("  --gitignore, --no-gitignore	With -r, always or never skip what .gitignore files ignore")
+	fmt.Println("		(by default, they apply inside git repositories)")
+	fmt.Println("  --git-tracked	With -r, count only the files git tracks, as git ls-files lists them")
+	fmt.Println("  --hidden=skip|include	With -r, skip (default) or count hidden files and directories")
+	fmt.Println("  --follow-symlinks	With -r, follow symbolic links instead of skipping them")
+	fmt.Println("  --dedup-hardlinks	Count files with several names, such as hard links, once")
+	fmt.Println("  --max-depth N	With -r, count files at most N levels down; 1 counts only the top level")
+	fmt.Println("  --max-filesize SIZE	With -r, skip files larger than SIZE (e.g. 100M) with a warning")
+	fmt.Println("  --staged	Count the files staged in git, as they are staged; the files named")
+	fmt.Println("		limit which are counted")
+	fmt.Println("  --max-words-per-file N	Fail, naming them, if any files have more than N words")
+	fmt.Println("  --github	In GitHub Actions, write the counts to the job summary and annotate")
+	fmt.Println("		the files over --max-words-per-file or drifted from --check-against")
+	fmt.Println("  --dry-run	Print the files that would be counted and their sizes, checking")
+	fmt.Println("		that they can be opened, without reading them")
+	fmt.Println("  --follow[=INTERVAL]	Keep counting data appended to the files, printing the counts")
+	fmt.Println("		again whenever they grow (checked every INTERVAL, default 1s)")
+	fmt.Println("  --interval DURATION	When counting stdin, report the counts so far and the rates on")
+	fmt.Println("		stderr every DURATION, such as 5s")
+	fmt.Println("  --no-progress	Don't show a progress line on the terminal while counting large files")
+	fmt.Println("  --tee		Copy stdin to stdout unchanged while counting it, and print the")
+	fmt.Println("		counts to stderr, for use in the middle of a pipeline")
+	fmt.Println("  --timeout DURATION	Stop reading stdin after DURATION and print the counts so far,")
+	fmt.Println("		exiting with status 4")
+	fmt.Println("  --goal N	Print the progress of the words counted towards a goal of N words")
+	fmt.Println("  --db FILE	With --goal, project when it will be reached from the history mwc daemon")
+	fmt.Println("		recorded in FILE")
+	fmt.Println("  --save-snapshot FILE	Save the counts of each input and the total to FILE as JSON")
+	fmt.Println("  --check FILE	Check the counts against the baseline FILE, as --check-against does,")
+	fmt.Println("		with the tolerances it lists as well as those given")
+	fmt.Println("  --update-baseline	With --check, save the counts as the new baseline instead")
+	fmt.Println("  --check-against FILE	Print how the counts changed since the snapshot FILE on stderr,")
+	fmt.Println("		exiting with status 3 if any drifted beyond the tolerances")
+	fmt.Println("  --tolerance [COUNT=]AMOUNT	How far counts, or only COUNT, may drift from the snapshot,")
+	fmt.Println("		such as 5%, 200 or bytes=2KB; repeatable (default: no change)")
+	fmt.Println("  --by-heading LEVEL|REGEXP	Count each section of the inputs between headings: Markdown")
+	fmt.Println("		headings of LEVEL (1-6, or #, ## and so on) or above, or lines matching REGEXP")
+	fmt.Println("  --limit tweet|sms	Instead of counting, print whether each line fits in a post on X or")
+	fmt.Println("		an SMS, and how many posts or SMS segments each input needs")
+	fmt.Println("  --files-from FILE	Count the files listed in FILE, one per line (- for stdin); # starts a comment")
+	fmt.Println("  --type text	With -r, count only files whose contents look like text")
+	fmt.Println("  --group-by dir|tree	Print a subtotal for each directory, or an indented tree of them")
+	fmt.Println("		and their files, after every file has been counted")
+	fmt.Println("  --front-matter	Label files with the title and author from their YAML front matter")
+	fmt.Println("  --unicode-words	Split words at Unicode word boundaries (UAX #29) instead of at")
+	fmt.Println("		white space, so punctuation isn't a word and scripts without spaces split")
+	fmt.Println("  --compat gnu|bsd	Print the counts and errors exactly as GNU or BSD wc does, and")
+	fmt.Println("		exit with status 1 when an input can't be counted")
+	fmt.Println("  --posix		Count as wc does with LC_ALL=C: every byte is a character and only")
+	fmt.Println("		ASCII white space separates words")
+	fmt.Println("  --normalize NFC|NFD	Normalize the text to a Unicode normalization form before")
+	fmt.Println("		counting it, so composed and decomposed characters count the same")
+	fmt.Println("  --exclude-quotes	Leave text inside quotation marks and block quotes out of the")
+	fmt.Println("		counts, as quoted material doesn't count towards academic word limits")
+	fmt.Println("  --suspicious-chars	Count zero-width characters, bidirectional controls and")
+	fmt.Println("		Cyrillic or Greek letters posing as Latin ones, to find hidden characters")
+	fmt.Println("  --encoding-errors	Count ill-formed UTF-8 sequences, and of them overlong encodings")
+	fmt.Println("		and encoded surrogates, alongside the other counts")
+	fmt.Println("  --dialogue	Count the words inside quotation marks and the others separately, and")
+	fmt.Println("		print the share of dialogue, for fiction")
+	fmt.Println("  --unique	Count distinct words")
+	fmt.Println("  --fold-case	With --unique, count words that differ only in case as one, with")
+	fmt.Println("		full Unicode case folding")
+	fmt.Println("  --metric NAME	Count a registered metric, such as sentences")
+	fmt.Println("  --plugin FILE	Load a Go plugin registering more metrics")
+	fmt.Println("  --expr NAME=EXPR	Print a count derived from others, such as density=words/lines")
+	fmt.Println("  --max-memory SIZE	Approximate unique word counts beyond SIZE of memory (e.g. 256M)")
+	fmt.Println("  --buffered	Print file rows only after all files are counted")
+	fmt.Println("  --estimate[=N]	Estimate counts of large files from N sampled blocks (default 64)")
+	fmt.Println("  --buffer-size SIZE	Read input in chunks of SIZE bytes (512 to 256M)")
+	fmt.Println("  --throttle RATE	Limit reads to RATE bytes per second (e.g. 50MB/s)")
+	fmt.Println("  --jobs N	Count up to N files at once, still printing them in order")
+	fmt.Println("  --cache DIR	Reuse counts of files unchanged since they were cached in DIR")
+	fmt.Println("  --incremental	Count only data appended to files since the previous run")
+	fmt.Println("  --stats	Report time and throughput per file on stderr")
+	fmt.Println("  --fs-root DIR	Resolve file names inside DIR; names can't refer outside it")
+	fmt.Println("  --remote URL	Count the inputs on the mwc serve server at URL")
+	fmt.Println("  --notify-url URL	POST the results as JSON to URL when the run finishes")
+	fmt.Println("  --statsd HOST:PORT	Send the counts and timings to StatsD over UDP")
+	fmt.Println("  --otlp URL	Send the counts and timings to an OTLP/HTTP collector")
+	fmt.Println("  --metrics-tag KEY=VALUE	Tag the metrics sent, such as profile=nightly")
+	fmt.Println("  --log-format FORMAT	Write diagnostics as text or json log records")
+	fmt.Println("  --log-level LEVEL	Write diagnostics from LEVEL up: debug, info (default), warn or error")
+	fmt.Println("  --cpuprofile FILE	Write a CPU profile to FILE")
+	fmt.Println("  --memprofile FILE	Write a heap profile to FILE")
+	fmt.Println("  --trace FILE	Write an execution trace to FILE")
+	fmt.Println("  -h, --help	Display this help message")
+	fmt.Println("\nCommands:")
+	fmt.Println("  mwc serve	Serve counts over HTTP; see mwc serve --help")
+	fmt.Println("  mwc listen	Count TCP connections; see mwc listen --help")
+	fmt.Println("  mwc kafka	Count the messages of a Kafka topic; see mwc kafka --help")
+	fmt.Println("  mwc watch	Recount files whenever they change; see mwc watch --help")
+	fmt.Println("  mwc session	Track the words written in a sprint; see mwc session --help")
+	fmt.Println("  mwc daemon	Record the counts of files over time; see mwc daemon --help")
+	fmt.Println("  mwc trend	Print the daily or weekly growth recorded; see mwc trend --help")
+	fmt.Println("  mwc diff	Print how the counts of two files or directories differ; see mwc diff --help")
+	fmt.Println("  mwc log	Append today's counts of files to a journal; see mwc log --help")
+	fmt.Println("  mwc report	Print the words written each day, streaks and best day; see mwc report --help")
+	fmt.Println("  mwc summary	Print words, pages, reading time and readability together; see mwc summary --help")
+	fmt.Println("  mwc git-diff	Print the words and lines added and removed in a git diff; see mwc git-diff --help")
+	fmt.Println("  mwc git-history	Print the counts of files at each git commit; see mwc git-history --help")
+	fmt.Println("  mwc git-blame	Print the surviving words and lines of each author; see mwc git-blame --help")
+	fmt.Println("  mwc selftest	Compare the counts of files with the system's wc; see mwc selftest --help")
+	fmt.Println("To count a file named like a command, put -- or any option before it, as in")
+	fmt.Println("mwc -- diff; after --, every argument is a file name.")
+	fmt.Println("\nExit status:")
+	fmt.Println("  0	Everything was counted")
+	fmt.Println("  1	The command line is invalid")
+	fmt.Println("  2	Some inputs couldn't be counted; the others were")
+	fmt.Println("  3	Inputs broke a budget: --max-words-per-file, or drifted from --check")
+	fmt.Println("  4	Ctrl-C or --timeout stopped counting; the counts printed are partial")
+	fmt.Println("  5	Anything else failed, such as saving a snapshot or sending metrics")
+	fmt.Println("The commands exit with these statuses too; mwc selftest exits with 3 when a")
+	fmt.Println("count differs from wc's.")
+	fmt.Println("\nIf no options are specified, mwc behaves as if -lwc were specified.")
+	fmt.Println("If no filename is provided, mwc reads from standard input.")
+}
@@ -0,0 +1,910 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// TestStandardInput simulates reading from standard input
+func TestStandardInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		args     []string
+		expected map[string]int64
+		order    []string
+	}{
+		{
+			name:     "Standard Input - Single Line",
+			input:    "Hello, World!",
+			args:     []string{"-l"},
+			expected: map[string]int64{"lines": 0},
+			order:    []string{"lines"},
+		},
+		{
+			name:     "Standard Input - Single Line with Newline",
+			input:    "Hello, World!\n",
+			args:     []string{"-l"},
+			expected: map[string]int64{"lines": 1},
+			order:    []string{"lines"},
+		},
+		{
+			name:     "Standard Input - Multiple Lines",
+			input:    "Hello, World!\nGoodbye, World!",
+			args:     []string{"-l"},
+			expected: map[string]int64{"lines": 1},
+			order:    []string{"lines"},
+		},
+		{
+			name:     "Standard Input - Multiple Lines with Newline",
+			input:    "Hello, World!\nGoodbye, World!\n",
+			args:     []string{"-l"},
+			expected: map[string]int64{"lines": 2},
+			order:    []string{"lines"},
+		},
+		{
+			name:     "Standard Input - Default Options",
+			input:    "Hello, World!\nGoodbye, World!\n",
+			args:     []string{},
+			expected: map[string]int64{"lines": 2, "words": 4, "bytes": 30},
+			order:    []string{"lines", "words", "bytes"},
+		},
+		{
+			name:     "Standard Input - All Options",
+			input:    "Hello, World!\nGoodbye, World!\n",
+			args:     []string{"-lwcm"},
+			expected: map[string]int64{"lines": 2, "words": 4, "bytes": 30, "characters": 30},
+			order:    []string{"lines", "words", "bytes", "characters"},
+		},
+		{
+			name:     "Standard Input - Custom Order",
+			input:    "Hello, World!\nGoodbye, World!\n",
+			args:     []string{"-wlc"},
+			expected: map[string]int64{"words": 4, "lines": 2, "bytes": 30},
+			order:    []string{"words", "lines", "bytes"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create a pipe to simulate stdin
+			r, w, err := os.Pipe()
+			if err != nil {
+				t.Fatalf("Error creating pipe: %v", err)
+			}
+
+			// Save the original stdin and args
+			oldStdin := os.Stdin
+			oldArgs := os.Args
+
+			// Replace stdin with our pipe and set args
+			os.Stdin = r
+			os.Args = append([]string{"mwc"}, tt.args...)
+
+			// Write the test input to the pipe
+			go func() {
+				defer w.Close()
+				_, _ = w.Write([]byte(tt.input))
+			}()
+
+			// Capture stdout
+			oldStdout := os.Stdout
+			r2, w2, _ := os.Pipe()
+			os.Stdout = w2
+
+			// Run main
+			main()
+
+			// Restore stdout and close the write end of the pipe
+			w2.Close()
+			os.Stdout = oldStdout
+
+			// Read captured output
+			var buf bytes.Buffer
+			_, _ = io.Copy(&buf, r2)
+			output := strings.TrimSpace(buf.String())
+
+			// Restore the original stdin and args
+			os.Stdin = oldStdin
+			os.Args = oldArgs
+
+			// Parse the output
+			fields := strings.Fields(output)
+			if len(fields) != len(tt.expected) {
+				t.Fatalf("Expected %d fields, got %d", len(tt.expected), len(fields))
+			}
+
+			// Check the counts
+			for i, countType := range tt.order {
+				expectedCount := tt.expected[countType]
+				actualCount, err := strconv.ParseInt(fields[i], 10, 64)
+				if err != nil {
+					t.Fatalf("Error parsing count for %s: %v", countType, err)
+				}
+				if actualCount != expectedCount {
+					t.Errorf("Expected %s count: %d, got: %d", countType, expectedCount, actualCount)
+				}
+			}
+		})
+	}
+}
+
+// TestMultipleFiles tests processing multiple files
+func TestMultipleFiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected []struct {
+			lines int
+			words int
+			bytes int
+			file  string
+		}
+	}{
+		{
+			name: "Two Files",
+			files: map[string]string{
+				"file1.txt": "Hello, World!\n",
+				"file2.txt": "Goodbye, World!\n",
+			},
+			expected: []struct {
+				lines int
+				words int
+				bytes int
+				file  string
+			}{
+				{1, 2, 14, "file1.txt"},
+				{1, 2, 16, "file2.txt"},
+				{2, 4, 30, "total"},
+			},
+		},
+		{
+			name: "Three Files",
+			files: map[string]string{
+				"file1.txt": "Hello, World!\n",
+				"file2.txt": "Goodbye, World!\n",
+				"file3.txt": "Test file.\n",
+			},
+			expected: []struct {
+				lines int
+				words int
+				bytes int
+				file  string
+			}{
+				{1, 2, 14, "file1.txt"},
+				{1, 2, 16, "file2.txt"},
+				{1, 2, 11, "file3.txt"},
+				{3, 6, 41, "total"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create a temporary directory for test files
+			tmpDir, err := os.MkdirTemp("", "wc_test")
+			if err != nil {
+				t.Fatalf("Failed to create temp directory: %v", err)
+			}
+			defer os.RemoveAll(tmpDir)
+
+			// Create test files
+			var filenames []string
+			for filename, content := range tt.files {
+				path := filepath.Join(tmpDir, filename)
+				err := os.WriteFile(path, []byte(content), 0644)
+				if err != nil {
+					t.Fatalf("Failed to write test file %s: %v", filename, err)
+				}
+				filenames = append(filenames, path)
+			}
+
+			// Sort filenames to ensure consistent order
+			sort.Strings(filenames)
+
+			// Capture stdout
+			oldStdout := os.Stdout
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+
+			// Run main with test files
+			os.Args = append([]string{"mwc"}, filenames...)
+			main()
+
+			// Restore stdout
+			w.Close()
+			os.Stdout = oldStdout
+
+			// Read captured output
+			var buf bytes.Buffer
+			io.Copy(&buf, r)
+			output := strings.TrimSpace(buf.String())
+			lines := strings.Split(output, "\n")
+
+			// Check output
+			if len(lines) != len(tt.expected) {
+				t.Fatalf("Expected %d lines of output, got %d", len(tt.expected), len(lines))
+			}
+			for i, expected := range tt.expected {
+				parts := strings.Fields(lines[i])
+				if len(parts) != 4 {
+					t.Errorf("Line %d: expected 4 fields, got %d", i+1, len(parts))
+					continue
+				}
+
+				actualLines, _ := strconv.Atoi(parts[0])
+				actualWords, _ := strconv.Atoi(parts[1])
+				actualBytes, _ := strconv.Atoi(parts[2])
+				actualFile := filepath.Base(parts[3])
+
+				if actualLines != expected.lines || actualWords != expected.words || actualBytes != expected.bytes || actualFile != expected.file {
+					t.Errorf("Line %d: expected %d %d %d %s, got %d %d %d %s",
+						i+1, expected.lines, expected.words, expected.bytes, expected.file,
+						actualLines, actualWords, actualBytes, actualFile)
+				}
+			}
+		})
+	}
+}
+
+// captureMain runs main with the given arguments and returns what it wrote to stdout
+func captureMain(t *testing.T, args []string) string {
+	t.Helper()
+	stdout, _ := captureOutput(t, args)
+	return stdout
+}
+
+// captureOutput runs main with the given arguments and returns what it wrote to stdout and stderr
+func captureOutput(t *testing.T, args []string) (string, string) {
+	t.Helper()
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	os.Args = append([]string{"mwc"}, args...)
+	return captureFunc(t, func() { countMain(args) })
+}
+
+// captureFunc runs f and returns what it wrote to stdout and stderr
+func captureFunc(t *testing.T, f func()) (string, string) {
+	t.Helper()
+
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	defer func() { os.Stdout, os.Stderr = oldStdout, oldStderr }()
+
+	capture := func(f **os.File) (func() string, error) {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return nil, err
+		}
+		*f = w
+		output := make(chan string)
+		go func() {
+			var buf bytes.Buffer
+			_, _ = io.Copy(&buf, r)
+			output <- buf.String()
+		}()
+		return func() string {
+			w.Close()
+			return <-output
+		}, nil
+	}
+	stdout, err := capture(&os.Stdout)
+	if err != nil {
+		t.Fatalf("Error creating pipe: %v", err)
+	}
+	stderr, err := capture(&os.Stderr)
+	if err != nil {
+		t.Fatalf("Error creating pipe: %v", err)
+	}
+
+	f()
+
+	return stdout(), stderr()
+}
+
+// TestBufferedOutput checks that buffered and streamed output print the same report
+func TestBufferedOutput(t *testing.T) {
+	dir := t.TempDir()
+	var filenames []string
+	for i, content := range []string{"Hello, World!\n", "Goodbye, World!\n", "Test file.\n"} {
+		path := filepath.Join(dir, "file"+strconv.Itoa(i)+".txt")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+		filenames = append(filenames, path)
+	}
+	// A missing file must not stop the other rows from being printed
+	filenames = append(filenames[:1], append([]string{filepath.Join(dir, "missing.txt")}, filenames[1:]...)...)
+
+	streamed := captureMain(t, filenames)
+	buffered := captureMain(t, append([]string{"--buffered"}, filenames...))
+	if streamed != buffered {
+		t.Errorf("Expected identical output, got streamed:\n%s\nbuffered:\n%s", streamed, buffered)
+	}
+	if lines := strings.Count(streamed, "\n"); lines != 4 {
+		t.Errorf("Expected 4 lines of output, got %d:\n%s", lines, streamed)
+	}
+}
+
+// TestEstimateSmallInput checks that inputs too small to sample are counted exactly
+func TestEstimateSmallInput(t *testing.T) {
+	options := wordcount.CountOptions{WordCount: true, EstimateBlocks: wordcount.DefaultEstimateBlocks}
+	file, err := os.Open("testdata/test1.txt")
+	if err != nil {
+		t.Fatalf("Failed to open test file: %v", err)
+	}
+	defer file.Close()
+
+	counts, note, err := countInput(context.Background(), file, options)
+	if err != nil {
+		t.Fatalf("Error processing input: %v", err)
+	}
+	if note != "" {
+		t.Errorf("Expected an exact count, got note %q", note)
+	}
+	if counts.Words != 2 {
+		t.Errorf("Expected 2 words, got %d", counts.Words)
+	}
+}
+
+// TestProfiling checks that the requested profiles and trace are written
+func TestProfiling(t *testing.T) {
+	dir := t.TempDir()
+	options := cliOptions{
+		CPUProfile: filepath.Join(dir, "cpu.pprof"),
+		MemProfile: filepath.Join(dir, "mem.pprof"),
+		Trace:      filepath.Join(dir, "trace.out"),
+	}
+
+	stop, err := startProfiling(options)
+	if err != nil {
+		t.Fatalf("Error starting profiling: %v", err)
+	}
+	if _, err := wordcount.Count(strings.NewReader(strings.Repeat("The quick brown fox jumps over the lazy dog.\n", 20000)), wordcount.CountOptions{WordCount: true}); err != nil {
+		t.Fatalf("Error processing input: %v", err)
+	}
+	if err := stop(); err != nil {
+		t.Fatalf("Error stopping profiling: %v", err)
+	}
+
+	for _, path := range []string{options.CPUProfile, options.MemProfile, options.Trace} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Errorf("Expected %s to be written: %v", filepath.Base(path), err)
+		} else if info.Size() == 0 {
+			t.Errorf("Expected %s to be non-empty", filepath.Base(path))
+		}
+	}
+}
+
+// TestUniqueWordsTotal checks that words shared between files are counted once in the total
+func TestUniqueWordsTotal(t *testing.T) {
+	dir := t.TempDir()
+	var filenames []string
+	for i, content := range []string{"red green blue\n", "green blue yellow\n"} {
+		path := filepath.Join(dir, "file"+strconv.Itoa(i)+".txt")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+		filenames = append(filenames, path)
+	}
+
+	output := captureMain(t, append([]string{"--unique"}, filenames...))
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines of output, got %d:\n%s", len(lines), output)
+	}
+	for i, expected := range []string{"3", "3", "4"} {
+		if fields := strings.Fields(lines[i]); fields[0] != expected {
+			t.Errorf("Line %d: expected %s unique words, got %s", i+1, expected, fields[0])
+		}
+	}
+}
+
+// TestStats checks that statistics go to stderr without changing the report
+func TestStats(t *testing.T) {
+	stdout, stderr := captureOutput(t, []string{"--stats", "-w", "testdata/test1.txt", "testdata/test2.txt"})
+
+	if expected := captureMain(t, []string{"-w", "testdata/test1.txt", "testdata/test2.txt"}); stdout != expected {
+		t.Errorf("Expected report:\n%s\ngot:\n%s", expected, stdout)
+	}
+	lines := strings.Split(strings.TrimSpace(stderr), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines of statistics, got %d:\n%s", len(lines), stderr)
+	}
+	for i, expected := range []string{"testdata/test1.txt: 14 bytes, 1 lines in ", "testdata/test2.txt: 16 bytes, 1 lines in ", "total: 30 bytes, 2 lines in "} {
+		if !strings.Contains(lines[i], "stats: "+expected) || !strings.Contains(lines[i], "lines/s)") {
+			t.Errorf("Line %d: expected statistics for %q, got %q", i+1, expected, lines[i])
+		}
+	}
+}
+
+// TestFormatSize tests formatting of byte sizes
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		size     float64
+		expected string
+	}{
+		{0, "0B"},
+		{1023, "1023B"},
+		{1024, "1.0KB"},
+		{1536 * 1024, "1.5MB"},
+		{3 << 40, "3.0TB"},
+		{5000 << 40, "5000.0TB"},
+	}
+
+	for _, tt := range tests {
+		if actual := formatSize(tt.size); actual != tt.expected {
+			t.Errorf("formatSize(%v): expected %s, got %s", tt.size, tt.expected, actual)
+		}
+	}
+}
+
+// TestFSRoot checks that file names are resolved inside --fs-root and can't leave it
+func TestFSRoot(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+	for path, content := range map[string]string{
+		"root/a.txt":     "Hello, World!\n",
+		"root/sub/b.txt": "Goodbye, World!\n",
+		"secret.txt":     "outside the root\n",
+	} {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+	}
+
+	stdout, stderr := captureOutput(t, []string{"--fs-root", root, "-w", "a.txt", "sub/b.txt", "../secret.txt"})
+	expected := "       2 a.txt\n       2 sub/b.txt\n       4 total\n"
+	if stdout != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout)
+	}
+	if !strings.Contains(stderr, "../secret.txt is not a path inside --fs-root") {
+		t.Errorf("Expected the path outside the root to be rejected, got %q", stderr)
+	}
+}
+
+// TestMetric checks that registered metrics are printed in the order requested and summed in the total
+func TestMetric(t *testing.T) {
+	dir := t.TempDir()
+	var filenames []string
+	for i, content := range []string{"One. Two three.\n", "Four!\n"} {
+		path := filepath.Join(dir, "file"+strconv.Itoa(i)+".txt")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+		filenames = append(filenames, path)
+	}
+
+	output := captureMain(t, append([]string{"--metric", "sentences", "-w"}, filenames...))
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines of output, got %d:\n%s", len(lines), output)
+	}
+	for i, expected := range [][2]string{{"2", "3"}, {"1", "1"}, {"3", "4"}} {
+		if fields := strings.Fields(lines[i]); fields[0] != expected[0] || fields[1] != expected[1] {
+			t.Errorf("Line %d: expected %s sentences and %s words, got %q", i+1, expected[0], expected[1], lines[i])
+		}
+	}
+}
+
+// TestExpr checks that derived counts are printed after the counts and computed from the totals
+func TestExpr(t *testing.T) {
+	dir := t.TempDir()
+	var filenames []string
+	for i, content := range []string{"One. Two three.\n", "Four!\n"} {
+		path := filepath.Join(dir, "file"+strconv.Itoa(i)+".txt")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+		filenames = append(filenames, path)
+	}
+
+	// Words and sentences are counted for the expression without being printed
+	output := captureMain(t, append([]string{"--expr", "wps=words/sentences", "-l"}, filenames...))
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines of output, got %d:\n%s", len(lines), output)
+	}
+	for i, expected := range [][2]string{{"1", "1.50"}, {"1", "1.00"}, {"2", "1.33"}} {
+		if fields := strings.Fields(lines[i]); fields[0] != expected[0] || fields[1] != expected[1] {
+			t.Errorf("Line %d: expected %s lines and %s words per sentence, got %q", i+1, expected[0], expected[1], lines[i])
+		}
+	}
+}
+
+// TestDialogue checks that --dialogue prints the dialogue and narration words
+// and the share of dialogue, computed from the totals in the total
+func TestDialogue(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"01.md": "\"Go away,\" she said. \"Now.\"\nHe left.\n",
+		"02.md": "“Wait!”\n",
+	})
+	chdir(t, dir)
+
+	stdout, stderr := captureOutput(t, []string{"-w", "--dialogue", "01.md", "02.md"})
+	expected := "       7       3       4    0.43 01.md\n       1       1       0    1.00 02.md\n       8       4       4    0.50 total\n"
+	if stdout != expected || stderr != "" {
+		t.Errorf("Expected:\n%s\ngot:\n%s%s", expected, stdout, stderr)
+	}
+}
+
+// TestSuspiciousChars checks the columns --suspicious-chars adds
+func TestSuspiciousChars(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"clean.txt":    "Pay the invoice.\n",
+		"contract.txt": "Pay p\u0430ypal.com\u200b \u202eexcept\u202c today.\n",
+	})
+	chdir(t, dir)
+
+	stdout, stderr := captureOutput(t, []string{"--suspicious-chars", "clean.txt", "contract.txt"})
+	expected := "       0       0       0 clean.txt\n       1       2       1 contract.txt\n       1       2       1 total\n"
+	if stdout != expected || stderr != "" {
+		t.Errorf("Expected:\n%s\ngot:\n%s%s", expected, stdout, stderr)
+	}
+}
+
+// TestEncodingErrors checks that --encoding-errors counts ill-formed UTF-8
+// alongside the other counts, and saves it in snapshots
+func TestEncodingErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"clean.txt": "caf\u00e9\n",
+		"dump.csv":  "id,name\n1,caf\xe9\n2,\xc0\xaf\xed\xa0\x80\n",
+	})
+	chdir(t, dir)
+
+	stdout, stderr := captureOutput(t, []string{"-l", "--encoding-errors", "--save-snapshot", "snapshot.json", "clean.txt", "dump.csv"})
+	expected := "       1       0       0       0 clean.txt\n       3       3       1       1 dump.csv\n       4       3       1       1 total\n"
+	if stdout != expected || stderr != "" {
+		t.Errorf("Expected:\n%s\ngot:\n%s%s", expected, stdout, stderr)
+	}
+	saved, err := readSnapshot("snapshot.json")
+	if err != nil {
+		t.Fatalf("Error reading the snapshot: %v", err)
+	}
+	if invalid, _ := saved.Files["dump.csv"].Get("invalid_utf8"); invalid != 3 {
+		t.Errorf("Expected 3 invalid sequences in the snapshot, got %d", invalid)
+	}
+}
+
+// TestExcludeQuotes checks that --exclude-quotes leaves quotations and block
+// quotes out of the counts of files and stdin
+func TestExcludeQuotes(t *testing.T) {
+	dir := t.TempDir()
+	essay := "As Smith wrote, \"the end is near,\" and it was.\n> A block quote\nDone.\n"
+	writeTree(t, dir, map[string]string{"essay.md": essay})
+	chdir(t, dir)
+
+	stdout, stderr := captureOutput(t, []string{"-lw", "--exclude-quotes", "essay.md"})
+	if expected := "       3       7 essay.md\n"; stdout != expected || stderr != "" {
+		t.Errorf("Expected %q, got %q %q", expected, stdout, stderr)
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Error creating pipe: %v", err)
+	}
+	oldStdin := os.Stdin
+	defer func() { os.Stdin = oldStdin }()
+	os.Stdin = r
+	go func() {
+		_, _ = w.WriteString(essay)
+		_ = w.Close()
+	}()
+	stdout, _ = captureOutput(t, []string{"-w", "--exclude-quotes"})
+	if expected := "       7\n"; stdout != expected {
+		t.Errorf("Expected %q for stdin, got %q", expected, stdout)
+	}
+}
+
+// TestUnicodeWords checks that --unicode-words splits words at Unicode word
+// boundaries, for unique words too
+func TestUnicodeWords(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"mixed.txt": "Hello , world\u00a0— 你好世界 can't stop\n"})
+	chdir(t, dir)
+
+	stdout, _ := captureOutput(t, []string{"-w", "mixed.txt"})
+	if expected := "       7 mixed.txt\n"; stdout != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout)
+	}
+	stdout, _ = captureOutput(t, []string{"-w", "--unique", "--unicode-words", "mixed.txt"})
+	if expected := "       8       8 mixed.txt\n"; stdout != expected {
+		t.Errorf("Expected %q with --unicode-words, got %q", expected, stdout)
+	}
+}
+
+// TestMaxLineLength checks that -L expands tabs to --tabstop and that its
+// total is the longest line of all the inputs
+func TestMaxLineLength(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"code.go": "if x {\n\treturn\n}\n", "wide.txt": "\u4f60\u597d world\n"})
+	chdir(t, dir)
+
+	stdout, _ := captureOutput(t, []string{"-lL", "code.go", "wide.txt"})
+	if expected := "       3      14 code.go\n       1      10 wide.txt\n       4      14 total\n"; stdout != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout)
+	}
+	stdout, _ = captureOutput(t, []string{"-L", "--tabstop", "4", "code.go", "wide.txt"})
+	if expected := "      10 code.go\n      10 wide.txt\n      10 total\n"; stdout != expected {
+		t.Errorf("Expected with --tabstop 4:\n%s\ngot:\n%s", expected, stdout)
+	}
+}
+
+// TestPOSIX checks that --posix counts as LC_ALL=C wc does, with every byte
+// a character and only ASCII white space separating words
+func TestPOSIX(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"mixed.txt": "caf\u00e9\u00a0bar \xff\n"})
+	chdir(t, dir)
+
+	stdout, _ := captureOutput(t, []string{"-wmc", "mixed.txt"})
+	if expected := "       3      11      13 mixed.txt\n"; stdout != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout)
+	}
+	stdout, _ = captureOutput(t, []string{"-wmc", "--posix", "mixed.txt"})
+	if expected := "       1      13      13 mixed.txt\n"; stdout != expected {
+		t.Errorf("Expected %q with --posix, got %q", expected, stdout)
+	}
+}
+
+// TestFoldCase checks that --fold-case counts words differing in case once,
+// in the total too
+func TestFoldCase(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"a.txt": "Straße the\n", "b.txt": "STRASSE The\n"})
+	chdir(t, dir)
+
+	stdout, _ := captureOutput(t, []string{"--unique", "--fold-case", "a.txt", "b.txt"})
+	if expected := "       2 a.txt\n       2 b.txt\n       2 total\n"; stdout != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout)
+	}
+}
+
+// TestNormalize checks that composed and decomposed text count the same once
+// normalized
+func TestNormalize(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"composed.txt": "caf\u00e9\n", "decomposed.txt": "cafe\u0301\n"})
+	chdir(t, dir)
+
+	stdout, _ := captureOutput(t, []string{"-mc", "composed.txt", "decomposed.txt"})
+	if expected := "       5       6 composed.txt\n       6       7 decomposed.txt\n      11      13 total\n"; stdout != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout)
+	}
+	stdout, _ = captureOutput(t, []string{"-mc", "--normalize", "NFC", "composed.txt", "decomposed.txt"})
+	if expected := "       5       6 composed.txt\n       5       6 decomposed.txt\n      10      12 total\n"; stdout != expected {
+		t.Errorf("Expected with NFC:\n%s\ngot:\n%s", expected, stdout)
+	}
+	stdout, _ = captureOutput(t, []string{"-m", "--normalize=nfd", "composed.txt", "decomposed.txt"})
+	if expected := "       6 composed.txt\n       6 decomposed.txt\n      12 total\n"; stdout != expected {
+		t.Errorf("Expected with NFD:\n%s\ngot:\n%s", expected, stdout)
+	}
+}
+
+// TestFrontMatter checks that --front-matter labels files with their title and
+// author, in the rows and in the report posted to --notify-url
+func TestFrontMatter(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"01.md": "---\ntitle: The Storm\nauthor: Jane Doe\n---\nIt was night.\n",
+		"02.md": "No front matter here.\n",
+	})
+	chdir(t, dir)
+
+	reports := make(chan runReport, 1)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var report runReport
+		if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+			t.Errorf("Error decoding report: %v", err)
+		}
+		reports <- report
+	}))
+	defer webhook.Close()
+
+	stdout, stderr := captureOutput(t, []string{"-w", "--front-matter", "--notify-url", webhook.URL, "01.md", "02.md"})
+	expected := "      11 01.md: The Storm (Jane Doe)\n       4 02.md\n      15 total\n"
+	if stdout != expected || stderr != "" {
+		t.Errorf("Expected:\n%s\ngot:\n%s%s", expected, stdout, stderr)
+	}
+	report := <-reports
+	if len(report.Files) != 2 || report.Files[0].Filename != "01.md" || report.Files[0].Title != "The Storm" ||
+		report.Files[0].Author != "Jane Doe" || report.Files[1].Title != "" {
+		t.Errorf("Expected the title and author of 01.md, got %+v", report.Files)
+	}
+}
+
+// TestFileErrors checks that files that can't be opened are reported and skipped
+func TestFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(path, []byte("Hello, World!\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	missing := filepath.Join(dir, "missing.txt")
+
+	_, _, err := countNamedFile(context.Background(), osFS{}, missing, cliOptions{})
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected fs.ErrNotExist, got %v", err)
+	}
+
+	stdout, stderr := captureOutput(t, []string{"-w", missing, path})
+	if stdout != "       2 "+path+"\n" {
+		t.Errorf("Expected only the readable file to be counted, got %q", stdout)
+	}
+	if !strings.HasPrefix(stderr, "Error opening "+missing+": ") {
+		t.Errorf("Expected an error opening the missing file, got %q", stderr)
+	}
+}
+
+// TestFilesFrom checks that --files-from counts the files it lists after those
+// on the command line, skipping blank lines and comments
+func TestFilesFrom(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"a.txt":          "one\n",
+		"b c.txt":        "two words\n",
+		"docs/d.md":      "three more words\n",
+		"list.txt":       "# generated by the build\nb c.txt\r\n\ndocs/d.md\n",
+		"empty-list.txt": "# nothing to count\n",
+	})
+	chdir(t, dir)
+
+	stdout, stderr := captureOutput(t, []string{"-w", "--files-from", "list.txt", "a.txt"})
+	expected := "       1 a.txt\n       2 b c.txt\n       3 docs/d.md\n       6 total\n"
+	if stdout != expected || stderr != "" {
+		t.Errorf("Expected:\n%s\ngot:\n%s%s", expected, stdout, stderr)
+	}
+
+	if stdout, _ = captureOutput(t, []string{"-w", "--files-from=empty-list.txt"}); stdout != "" {
+		t.Errorf("Expected an empty list to count nothing, got %q", stdout)
+	}
+
+	if _, _, err := parseArgs([]string{"--files-from", "missing.txt"}); err == nil || !strings.Contains(err.Error(), "can't read the file list 'missing.txt'") {
+		t.Errorf("Expected a missing list to be rejected, got %v", err)
+	}
+}
+
+// TestEndOfOptions checks that the arguments after "--" are counted as files,
+// even when they are named like a command or an option
+func TestEndOfOptions(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"diff": "one two\n", "-w": "three\n"})
+	chdir(t, dir)
+
+	stdout, stderr := captureOutput(t, []string{"-l", "--", "diff", "-w"})
+	expected := "       1 diff\n       1 -w\n       2 total\n"
+	if stdout != expected || stderr != "" {
+		t.Errorf("Expected:\n%s\ngot:\n%s%s", expected, stdout, stderr)
+	}
+}
+
+// TestTee checks that --tee passes stdin through to stdout and prints the
+// counts to stderr
+func TestTee(t *testing.T) {
+	input := "first line\nsecond line\n"
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Error creating pipe: %v", err)
+	}
+	oldStdin := os.Stdin
+	defer func() { os.Stdin = oldStdin }()
+	os.Stdin = r
+	go func() {
+		_, _ = w.WriteString(input)
+		_ = w.Close()
+	}()
+
+	stdout, stderr := captureOutput(t, []string{"--tee", "-lw"})
+	if stdout != input {
+		t.Errorf("Expected stdin to be passed through, got %q", stdout)
+	}
+	if stderr != "       2       4\n" {
+		t.Errorf("Expected the counts on stderr, got %q", stderr)
+	}
+
+	for _, args := range [][]string{{"--tee", "a.txt"}, {"--tee", "--estimate"}} {
+		if _, _, err := parseArgs(args); err == nil {
+			t.Errorf("Expected %q to be rejected", args)
+		}
+	}
+}
+
+// TestTimeout checks that --timeout stops waiting for stdin and prints the
+// counts so far
+func TestTimeout(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Error creating pipe: %v", err)
+	}
+	defer w.Close()
+	oldStdin := os.Stdin
+	defer func() { os.Stdin = oldStdin }()
+	os.Stdin = r
+	// The writer never closes the pipe
+	if _, err := w.WriteString("one two three\n"); err != nil {
+		t.Fatalf("Error writing to pipe: %v", err)
+	}
+
+	options, filenames, err := parseArgs([]string{"-lw", "--timeout", "100ms"})
+	if err != nil {
+		t.Fatalf("Error parsing arguments: %v", err)
+	}
+	var status int
+	stdout, stderr := captureFunc(t, func() { status = run(options, filenames) })
+	if status != exitInterrupted {
+		t.Errorf("Expected exit status %d, got %d", exitInterrupted, status)
+	}
+	if stdout != "       1       3 (timed out)\n" {
+		t.Errorf("Expected the counts so far, got %q", stdout)
+	}
+	if !strings.Contains(stderr, "stdin timed out after 100ms") {
+		t.Errorf("Expected the timeout to be reported, got %q", stderr)
+	}
+}
+
+// TestExitStatus checks the exit statuses of the causes of failure
+func TestExitStatus(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"a.txt": "one two three\n"})
+	chdir(t, dir)
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	os.Args = []string{"mwc"}
+
+	tests := []struct {
+		name     string
+		command  func(args []string) int // countMain if nil
+		args     []string
+		expected int
+	}{
+		{"Success", nil, []string{"a.txt"}, 0},
+		{"Usage Error", nil, []string{"-x", "a.txt"}, exitUsage},
+		{"File Error", nil, []string{"a.txt", "missing.txt"}, exitFileErrors},
+		{"Budget", nil, []string{"--max-words-per-file", "2", "a.txt"}, exitBudget},
+		{"Failure", nil, []string{"--save-snapshot", filepath.Join("a.txt", "snapshot.json"), "a.txt"}, exitFailed},
+		{"Compat File Error", nil, []string{"--compat", "gnu", "missing.txt"}, 1},
+		{"Summary Usage Error", summary, []string{"--wpm", "fast"}, exitUsage},
+		{"Summary File Error", summary, []string{"missing.txt"}, exitFileErrors},
+		{"Diff Usage Error", diff, []string{"a.txt"}, exitUsage},
+		{"Diff File Error", diff, []string{"a.txt", "missing.txt"}, exitFileErrors},
+		{"Selftest Usage Error", selftest, []string{"--unique"}, exitUsage},
+		{"Trend File Error", trend, []string{"--db", "missing.db"}, exitFileErrors},
+		{"Report File Error", reportWords, []string{"--db", "missing.db"}, exitFileErrors},
+		{"Git History Failure", gitHistory, []string{"no-such-revision"}, exitFailed},
+		{"Serve Failure", serve, []string{"--listen", "unix:" + filepath.Join(dir, "missing", "mwc.sock")}, exitFailed},
+		{"Listen Usage Error", listenTCP, nil, exitUsage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command := tt.command
+			if command == nil {
+				command = countMain
+			}
+			var status int
+			captureFunc(t, func() { status = command(tt.args) })
+			if status != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, status)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// notifyTimeout bounds how long posting to --notify-url may take
+const notifyTimeout = 30 * time.Second
+
+// runReport is the JSON document posted to --notify-url when a run finishes.
+// Text summarizes the run for chat webhooks, such as Slack's, that only show text.
+type runReport struct {
+	Text     string                `json:"text"`
+	Files    []wordcount.FileCount `json:"files"`
+	Total    wordcount.Counts      `json:"total"`
+	Errors   []string              `json:"errors,omitempty"`
+	Started  time.Time             `json:"started"`
+	Duration float64               `json:"duration_seconds"`
+
+	names   []string        // names of the files, without notes about estimates
+	elapsed []time.Duration // time taken to count each file
+}
+
+// add records the counts of an input that took elapsed to count, with the
+// title and author from its front matter
+func (r *runReport) add(name, note string, meta wordcount.FrontMatter, counts wordcount.Counts, elapsed time.Duration) {
+	r.Files = append(r.Files, wordcount.FileCount{Filename: name + note, Title: meta.Title, Author: meta.Author, Counts: counts})
+	r.names = append(r.names, name)
+	r.elapsed = append(r.elapsed, elapsed)
+}
+
+// notify posts the report of a run that took elapsed to a webhook
+func notify(url string, report runReport, elapsed time.Duration) error {
+	report.Duration = elapsed.Seconds()
+	report.Text = fmt.Sprintf("mwc counted %d inputs in %v: %s", len(report.Files), elapsed.Round(time.Millisecond), report.Total)
+	if len(report.Errors) > 0 {
+		report.Text += fmt.Sprintf(" (%d failed)", len(report.Errors))
+	}
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: notifyTimeout}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("notifying: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notifying %s: %s", url, resp.Status)
+	}
+	return nil
+}
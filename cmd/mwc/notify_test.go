@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestNotify checks the results posted to --notify-url, and that a failing webhook fails the run
+func TestNotify(t *testing.T) {
+	reports := make(chan runReport, 1)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var report runReport
+		if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+			t.Errorf("Error decoding report: %v", err)
+		}
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected a JSON body, got %q", r.Header.Get("Content-Type"))
+		}
+		reports <- report
+	}))
+	defer webhook.Close()
+
+	_, _ = captureOutput(t, []string{"-w", "--notify-url", webhook.URL, "testdata/test1.txt", "testdata/missing.txt", "testdata/test2.txt"})
+	report := <-reports
+	if len(report.Files) != 2 || report.Files[0].Filename != "testdata/test1.txt" || report.Files[0].Counts.Words != 2 {
+		t.Errorf("Expected the counts of the two readable files, got %+v", report.Files)
+	}
+	if report.Total.Words != report.Files[0].Counts.Words+report.Files[1].Counts.Words {
+		t.Errorf("Expected the total of the files, got %+v", report.Total)
+	}
+	if len(report.Errors) != 1 || !strings.Contains(report.Errors[0], "missing.txt") {
+		t.Errorf("Expected the missing file to be reported, got %q", report.Errors)
+	}
+	if !strings.HasPrefix(report.Text, "mwc counted 2 inputs in ") || !strings.HasSuffix(report.Text, " (1 failed)") {
+		t.Errorf("Unexpected summary %q", report.Text)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	options, filenames, err := parseArgs([]string{"-w", "--notify-url", failing.URL, "testdata/test1.txt"})
+	if err != nil {
+		t.Fatalf("Error parsing arguments: %v", err)
+	}
+	var status int
+	_, stderr := captureFunc(t, func() { status = run(options, filenames) })
+	if status != exitFailed || !strings.Contains(stderr, "500 Internal Server Error") {
+		t.Errorf("Expected exitFailed and the webhook's error, got %d and %q", status, stderr)
+	}
+
+	if _, _, err := parseArgs([]string{"--notify-url", "ftp://example.com"}); err == nil {
+		t.Errorf("Expected an error for a URL that isn't HTTP")
+	}
+}
@@ -0,0 +1,516 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// objectStore reads the objects of a cloud object store over its HTTP API
+type objectStore interface {
+	// list returns the keys of the objects in bucket whose keys start with prefix
+	list(ctx context.Context, bucket, prefix string) ([]string, error)
+	// open streams the object with the given key
+	open(ctx context.Context, bucket, key string) (io.ReadCloser, error)
+}
+
+// objectSchemes maps the schemes of object URLs to their stores
+var objectSchemes = map[string]func() (objectStore, error){
+	"s3": newS3Store,
+	"gs": newGCSStore,
+	"az": newAzureStore,
+}
+
+// isObjectURL reports whether an input names an object-store object or prefix,
+// such as s3://bucket/key or gs://bucket/logs/
+func isObjectURL(name string) bool {
+	scheme, _, found := strings.Cut(name, "://")
+	return found && objectSchemes[scheme] != nil
+}
+
+// countObjects counts the object named by an object URL, or every object under
+// it when the URL ends in "/" or names a whole bucket. Each object is counted
+// by a job given to submit, which runs it, on the --jobs pool if there is one,
+// and then calls the function the job returns to report the object's counts,
+// in the order the objects were submitted. Objects are streamed rather than
+// downloaded first. Once ctx is done, no more objects are started, and those
+// being counted stop with the counts read so far and an ErrInterrupted error.
+func countObjects(ctx context.Context, name string, options wordcount.CountOptions, submit func(count func() func()),
+	report func(name string, counts wordcount.Counts, elapsed time.Duration, err error)) {
+	fail := func(err error) {
+		submit(func() func() {
+			return func() { report(name, wordcount.Counts{}, 0, &wordcount.FileError{Op: "open", Path: name, Err: err}) }
+		})
+	}
+	scheme, rest, _ := strings.Cut(name, "://")
+	bucket, key, _ := strings.Cut(rest, "/")
+	if bucket == "" {
+		fail(fmt.Errorf("missing bucket"))
+		return
+	}
+	store, err := objectSchemes[scheme]()
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	keys := []string{key}
+	if key == "" || strings.HasSuffix(key, "/") {
+		keys, err = store.list(ctx, bucket, key)
+		if err == nil && len(keys) == 0 {
+			err = fmt.Errorf("no objects under this prefix")
+		}
+		if err != nil && ctx.Err() == nil {
+			fail(err)
+		}
+		if err != nil {
+			return
+		}
+	}
+	for _, key := range keys {
+		if ctx.Err() != nil {
+			break
+		}
+		objectName := scheme + "://" + bucket + "/" + key
+		submit(func() func() {
+			start := time.Now()
+			body, err := store.open(ctx, bucket, key)
+			if err != nil {
+				return func() {
+					report(objectName, wordcount.Counts{}, 0, &wordcount.FileError{Op: "open", Path: objectName, Err: err})
+				}
+			}
+			counts, err := wordcount.CountContext(ctx, canceledBody{body, ctx}, options)
+			_ = body.Close()
+			elapsed := time.Since(start)
+			if err != nil {
+				err = &wordcount.FileError{Op: "count", Path: objectName, Err: err}
+			}
+			return func() { report(objectName, counts, elapsed, err) }
+		})
+	}
+}
+
+// canceledBody reads the body of a response to a request made with ctx. Once
+// ctx is done, reading the body fails; the failure is dropped, so that
+// CountContext stops as interrupted, with the counts read so far.
+type canceledBody struct {
+	io.Reader
+	ctx context.Context
+}
+
+func (b canceledBody) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	if err != nil && err != io.EOF && b.ctx.Err() != nil {
+		return n, nil
+	}
+	return n, err
+}
+
+// getObject sends a request to an object store, returning an error for any
+// response but 200 OK
+func getObject(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if message := storeErrorMessage(body); message != "" {
+			return nil, fmt.Errorf("%s: %s", resp.Status, message)
+		}
+		return nil, fmt.Errorf("%s", resp.Status)
+	}
+	return resp, nil
+}
+
+// storeErrorMessage extracts the message of an error response, which is XML
+// for S3 and Azure and JSON for Google Cloud Storage
+func storeErrorMessage(body []byte) string {
+	var xmlError struct {
+		Message string
+	}
+	if xml.Unmarshal(body, &xmlError) == nil && xmlError.Message != "" {
+		return strings.TrimSpace(xmlError.Message)
+	}
+	var jsonError struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &jsonError) == nil {
+		return jsonError.Error.Message
+	}
+	return ""
+}
+
+// s3Store reads from Amazon S3, or an S3-compatible store such as MinIO, with
+// the credentials and settings of the usual AWS environment variables
+type s3Store struct {
+	endpoint     string // custom endpoint, addressed path-style; empty for AWS
+	region       string
+	accessKey    string // empty for anonymous requests to public buckets
+	secretKey    string
+	sessionToken string
+}
+
+func newS3Store() (objectStore, error) {
+	store := &s3Store{
+		endpoint:     firstEnv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"),
+		region:       firstEnv("AWS_REGION", "AWS_DEFAULT_REGION"),
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if store.region == "" {
+		store.region = "us-east-1"
+	}
+	return store, nil
+}
+
+// firstEnv returns the value of the first of the environment variables that is set
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// get sends a signed GET request for a key of a bucket, or for the bucket
+// itself when key is empty
+func (s *s3Store) get(ctx context.Context, bucket, key string, query url.Values) (*http.Response, error) {
+	base := "https://" + bucket + ".s3." + s.region + ".amazonaws.com/"
+	if s.endpoint != "" {
+		base = strings.TrimSuffix(s.endpoint, "/") + "/" + bucket
+		if key != "" {
+			base += "/"
+		}
+	}
+	rawURL := base + uriEncode(key, true)
+	if len(query) > 0 {
+		rawURL += "?" + canonicalQuery(query)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.accessKey != "" {
+		signV4(req, "s3", s.region, s.accessKey, s.secretKey, s.sessionToken, time.Now())
+	}
+	return getObject(req)
+}
+
+func (s *s3Store) list(ctx context.Context, bucket, prefix string) ([]string, error) {
+	var keys []string
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+	for {
+		resp, err := s.get(ctx, bucket, "", query)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Contents []struct {
+				Key string
+			}
+			IsTruncated           bool
+			NextContinuationToken string
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid object listing: %v", err)
+		}
+		for _, object := range page.Contents {
+			// Keys ending in "/" are folder markers rather than data
+			if !strings.HasSuffix(object.Key, "/") {
+				keys = append(keys, object.Key)
+			}
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return keys, nil
+		}
+		query.Set("continuation-token", page.NextContinuationToken)
+	}
+}
+
+func (s *s3Store) open(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	resp, err := s.get(ctx, bucket, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// emptyPayloadHash is the SHA-256 of the empty body of GET requests
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// signV4 signs a request without a body with AWS Signature Version 4, signing
+// the host and every header already set on the request
+func signV4(req *http.Request, service, region, accessKey, secretKey, sessionToken string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	if req.Header.Get("X-Amz-Content-Sha256") == "" {
+		req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	}
+	if sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sessionToken)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		for i := range values {
+			values[i] = strings.TrimSpace(values[i])
+		}
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method, path, canonicalQuery(req.URL.Query()), canonicalHeaders.String(), signedHeaders,
+		req.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + secretKey)
+	for _, part := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery encodes query parameters sorted by name, as Signature
+// Version 4 requires
+func canonicalQuery(query url.Values) string {
+	var params []string
+	for name, values := range query {
+		for _, value := range values {
+			params = append(params, uriEncode(name, false)+"="+uriEncode(value, false))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// uriEncode percent-encodes every byte but the unreserved characters of
+// RFC 3986, and "/" when keepSlash is set
+func uriEncode(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' && keepSlash {
+			b.WriteByte(c)
+		} else {
+			_, _ = fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// gcsStore reads from Google Cloud Storage with its JSON API, authorized with
+// the OAuth access token in GOOGLE_OAUTH_ACCESS_TOKEN (such as the output of
+// gcloud auth print-access-token) or anonymously for public buckets
+type gcsStore struct {
+	endpoint string
+	token    string
+}
+
+func newGCSStore() (objectStore, error) {
+	store := &gcsStore{endpoint: "https://storage.googleapis.com", token: os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")}
+	// Emulators such as fake-gcs-server are found as the client libraries find them
+	if host := os.Getenv("STORAGE_EMULATOR_HOST"); host != "" {
+		store.endpoint = strings.TrimSuffix(host, "/")
+		if !strings.Contains(host, "://") {
+			store.endpoint = "http://" + store.endpoint
+		}
+	}
+	return store, nil
+}
+
+// get sends an authorized GET request for a path of the JSON API
+func (s *gcsStore) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.endpoint+"/storage/v1/b/"+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	return getObject(req)
+}
+
+func (s *gcsStore) list(ctx context.Context, bucket, prefix string) ([]string, error) {
+	var keys []string
+	query := url.Values{"prefix": {prefix}, "fields": {"items(name),nextPageToken"}}
+	for {
+		resp, err := s.get(ctx, url.PathEscape(bucket)+"/o", query)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Items []struct {
+				Name string `json:"name"`
+			} `json:"items"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid object listing: %v", err)
+		}
+		for _, object := range page.Items {
+			if !strings.HasSuffix(object.Name, "/") {
+				keys = append(keys, object.Name)
+			}
+		}
+		if page.NextPageToken == "" {
+			return keys, nil
+		}
+		query.Set("pageToken", page.NextPageToken)
+	}
+}
+
+func (s *gcsStore) open(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	resp, err := s.get(ctx, url.PathEscape(bucket)+"/o/"+url.PathEscape(key), url.Values{"alt": {"media"}})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// azureStore reads from Azure Blob Storage. az://CONTAINER/BLOB URLs name
+// containers of the account in AZURE_STORAGE_ACCOUNT, authorized with the SAS
+// token in AZURE_STORAGE_SAS_TOKEN, or of the account and endpoint of the
+// connection string in AZURE_STORAGE_CONNECTION_STRING.
+type azureStore struct {
+	endpoint string
+	sasToken string // query parameters of a shared access signature, without "?"
+}
+
+func newAzureStore() (objectStore, error) {
+	account := os.Getenv("AZURE_STORAGE_ACCOUNT")
+	store := &azureStore{sasToken: os.Getenv("AZURE_STORAGE_SAS_TOKEN")}
+	if connection := os.Getenv("AZURE_STORAGE_CONNECTION_STRING"); connection != "" {
+		for _, setting := range strings.Split(connection, ";") {
+			name, value, _ := strings.Cut(setting, "=")
+			switch name {
+			case "AccountName":
+				account = value
+			case "BlobEndpoint":
+				store.endpoint = strings.TrimSuffix(value, "/")
+			case "SharedAccessSignature":
+				store.sasToken = value
+			case "AccountKey":
+				return nil, fmt.Errorf("account keys aren't supported; use a connection string with a SharedAccessSignature")
+			}
+		}
+	}
+	if store.endpoint == "" {
+		if account == "" {
+			return nil, fmt.Errorf("set AZURE_STORAGE_ACCOUNT or AZURE_STORAGE_CONNECTION_STRING to read az:// URLs")
+		}
+		store.endpoint = "https://" + account + ".blob.core.windows.net"
+	}
+	store.sasToken = strings.TrimPrefix(store.sasToken, "?")
+	return store, nil
+}
+
+// get sends a GET request for a path of the account with the SAS token
+func (s *azureStore) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	rawQuery := query.Encode()
+	if s.sasToken != "" {
+		if rawQuery != "" {
+			rawQuery += "&"
+		}
+		rawQuery += s.sasToken
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.endpoint+"/"+path+"?"+rawQuery, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Ms-Version", "2021-08-06")
+	return getObject(req)
+}
+
+func (s *azureStore) list(ctx context.Context, container, prefix string) ([]string, error) {
+	var keys []string
+	query := url.Values{"restype": {"container"}, "comp": {"list"}, "prefix": {prefix}}
+	for {
+		resp, err := s.get(ctx, url.PathEscape(container), query)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Blobs []struct {
+				Name string
+			} `xml:"Blobs>Blob"`
+			NextMarker string
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid blob listing: %v", err)
+		}
+		for _, blob := range page.Blobs {
+			if !strings.HasSuffix(blob.Name, "/") {
+				keys = append(keys, blob.Name)
+			}
+		}
+		if page.NextMarker == "" {
+			return keys, nil
+		}
+		query.Set("marker", page.NextMarker)
+	}
+}
+
+func (s *azureStore) open(ctx context.Context, container, blob string) (io.ReadCloser, error) {
+	resp, err := s.get(ctx, url.PathEscape(container)+"/"+uriEncode(blob, true), nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"plugin"
+	"slices"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// loadPlugin opens a Go plugin built with -buildmode=plugin. Loading runs the
+// plugin's init functions, which register its metrics with
+// wordcount.RegisterMetric, so they can be requested with --metric like the
+// built-in ones. A plugin that registers nothing is reported as an error,
+// since it was most likely built for another purpose.
+func loadPlugin(path string) error {
+	before := wordcount.Metrics()
+	if _, err := plugin.Open(path); err != nil {
+		return err
+	}
+	if slices.Equal(before, wordcount.Metrics()) {
+		return fmt.Errorf("%s registers no metrics", path)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+// orderedPool runs work on a bounded number of goroutines and reports the
+// results in the order the work was submitted, one at a time on a goroutine
+// of its own, so inputs can be counted in parallel while their rows are still
+// printed in order. Results are held back until those submitted before them
+// are reported, and submitting blocks while too many are waiting.
+type orderedPool struct {
+	work    chan poolWork
+	results chan chan func()
+	done    chan struct{}
+}
+
+// poolWork is work submitted to an orderedPool and the channel its result is sent on
+type poolWork struct {
+	run    func() func()
+	result chan func()
+}
+
+// newOrderedPool starts a pool running work on the given number of goroutines
+func newOrderedPool(workers int) *orderedPool {
+	p := &orderedPool{
+		work:    make(chan poolWork),
+		results: make(chan chan func(), 4*workers),
+		done:    make(chan struct{}),
+	}
+	for i := 0; i < workers; i++ {
+		go func() {
+			for work := range p.work {
+				work.result <- work.run()
+			}
+		}()
+	}
+	go func() {
+		defer close(p.done)
+		for result := range p.results {
+			(<-result)()
+		}
+	}()
+	return p
+}
+
+// submit runs work on one of the pool's goroutines. The function it returns
+// reports the result, and is called once the results of the work submitted
+// before it have been reported.
+func (p *orderedPool) submit(run func() func()) {
+	result := make(chan func(), 1)
+	p.results <- result
+	p.work <- poolWork{run: run, result: result}
+}
+
+// then calls report once the results of the work submitted before it have
+// been reported
+func (p *orderedPool) then(report func()) {
+	result := make(chan func(), 1)
+	result <- report
+	p.results <- result
+}
+
+// wait waits until every result has been reported. Nothing can be submitted
+// afterwards.
+func (p *orderedPool) wait() {
+	close(p.work)
+	close(p.results)
+	<-p.done
+}
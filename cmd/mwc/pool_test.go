@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestOrderedPool checks that results are reported in the order the work was
+// submitted, whatever order it finishes in
+func TestOrderedPool(t *testing.T) {
+	pool := newOrderedPool(4)
+	var reported []int
+	for i := 0; i < 100; i++ {
+		if i%10 == 0 {
+			pool.then(func() { reported = append(reported, -i) })
+			continue
+		}
+		pool.submit(func() func() {
+			time.Sleep(time.Duration(rand.Intn(1000)) * time.Microsecond)
+			return func() { reported = append(reported, i) }
+		})
+	}
+	pool.wait()
+	for i, n := range reported {
+		expected := i
+		if i%10 == 0 {
+			expected = -i
+		}
+		if n != expected {
+			t.Fatalf("Expected result %d to be %d, got %v", i, expected, reported)
+		}
+	}
+}
+
+// TestJobs checks that counting files in parallel prints the same rows, in
+// the same order, as counting them one at a time
+func TestJobs(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{}
+	for i := 0; i < 60; i++ {
+		files[fmt.Sprintf("dir%d/file%02d.txt", i%3, i)] = strings.Repeat("word ", i) + "\n"
+	}
+	writeTree(t, dir, files)
+	chdir(t, dir)
+
+	sequential, _ := captureOutput(t, []string{"-rlw", ".", "missing.txt"})
+	for _, jobs := range []int{2, 8} {
+		stdout, stderr := captureOutput(t, []string{"-rlw", "--jobs", strconv.Itoa(jobs), ".", "missing.txt"})
+		if stdout != sequential {
+			t.Errorf("With %d jobs, expected:\n%s\ngot:\n%s", jobs, sequential, stdout)
+		}
+		if !strings.Contains(stderr, "Error opening missing.txt") {
+			t.Errorf("With %d jobs, expected the missing file to be reported, got %q", jobs, stderr)
+		}
+	}
+	if _, _, err := parseArgs([]string{"--jobs", "4", "--unique"}); err == nil {
+		t.Errorf("Expected --jobs with --unique to be rejected")
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// reportEvery prints the counts of the input so far to stderr every interval,
+// with --interval, like dd's status=progress. It returns the hooks that keep
+// track of the counts, to be set on the options the input is counted with,
+// and the function stopping the reports once counting is done.
+func reportEvery(interval time.Duration, options cliOptions) (*wordcount.Hooks, func()) {
+	var mu sync.Mutex
+	var current wordcount.Counts
+	hooks := &wordcount.Hooks{
+		OnProgress: func(_ string, counts wordcount.Counts) {
+			mu.Lock()
+			current = counts
+			mu.Unlock()
+		},
+	}
+
+	start := time.Now()
+	ticker := time.NewTicker(interval)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			mu.Lock()
+			counts := current
+			mu.Unlock()
+			printInterval(counts, time.Since(start), options)
+		}
+	}()
+	return hooks, func() {
+		ticker.Stop()
+		close(stop)
+		<-done
+	}
+}
+
+// printInterval reports the counts of the input so far and the average rates
+// since counting started
+func printInterval(counts wordcount.Counts, elapsed time.Duration, options cliOptions) {
+	seconds := max(elapsed.Seconds(), 1e-9)
+	logEvent(slog.LevelInfo, fmt.Sprintf("%s: %s in %v (%s/s, %.0f lines/s)",
+		os.Args[0], describeCounts(counts, options), elapsed.Round(time.Second),
+		formatSize(float64(counts.Bytes)/seconds), float64(counts.Lines)/seconds),
+		"progress", "file", "stdin", "counts", counts, "duration", elapsed,
+		"bytes_per_second", float64(counts.Bytes)/seconds, "lines_per_second", float64(counts.Lines)/seconds)
+}
+
+// describeCounts lists the counts selected by the options with their names,
+// such as "12 lines, 80 words"
+func describeCounts(counts wordcount.Counts, options cliOptions) string {
+	var parts []string
+	for _, name := range options.Order {
+		if count, ok := counts.Get(name); ok {
+			parts = append(parts, fmt.Sprintf("%d %s", count, name))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// progressMinSize is the size of the smallest files a progress line is shown
+// for; smaller ones are counted too quickly for it to help
+const progressMinSize = 64 << 20
+
+// progressRedraw is how often the progress line is redrawn at most, and how
+// long counting a file takes before it is first drawn
+const progressRedraw = 200 * time.Millisecond
+
+// isTerminal reports whether a file is a terminal
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// showProgress draws a progress line on stderr while a large regular file is
+// counted, like pv: how much of it has been read, the read rate and the time
+// left, estimated from the file's size. It returns the hooks drawing the line,
+// to be set on the options the file is counted with, and the function
+// clearing the line once counting is done, so the file's row takes its place.
+// Files smaller than progressMinSize get no hooks.
+func showProgress(fsys fs.FS, name string) (*wordcount.Hooks, func()) {
+	info, err := fs.Stat(fsys, name)
+	if err != nil || !info.Mode().IsRegular() || info.Size() < progressMinSize {
+		return nil, func() {}
+	}
+	return drawProgress(os.Stderr, name, info.Size())
+}
+
+// drawProgress draws the progress of counting a file of the given size on w
+func drawProgress(w io.Writer, name string, size int64) (*wordcount.Hooks, func()) {
+	start := time.Now()
+	drawn, shown := start, false
+	hooks := &wordcount.Hooks{
+		OnProgress: func(_ string, counts wordcount.Counts) {
+			if time.Since(drawn) < progressRedraw {
+				return
+			}
+			drawn, shown = time.Now(), true
+			_, _ = fmt.Fprintf(w, "\r%s\x1b[K", progressLine(name, counts.Bytes, size, time.Since(start)))
+		},
+	}
+	return hooks, func() {
+		if shown {
+			_, _ = fmt.Fprint(w, "\r\x1b[K")
+		}
+	}
+}
+
+// progressLine describes how far counting a file has got
+func progressLine(name string, done, size int64, elapsed time.Duration) string {
+	rate := float64(done) / max(elapsed.Seconds(), 1e-9)
+	eta := "--:--"
+	if done > 0 && done <= size {
+		eta = formatETA(time.Duration(float64(size-done) / rate * float64(time.Second)))
+	}
+	return fmt.Sprintf("%s: %3.0f%% %s/%s %s/s ETA %s", name, float64(done)/float64(max(size, 1))*100,
+		formatSize(float64(done)), formatSize(float64(size)), formatSize(rate), eta)
+}
+
+// formatETA formats the time left as minutes and seconds, with hours when
+// there are any
+func formatETA(d time.Duration) string {
+	seconds := int64(d.Round(time.Second).Seconds())
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
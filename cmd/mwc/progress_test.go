@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// TestInterval checks that --interval reports the counts of stdin so far
+// while it is still being read
+func TestInterval(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Error creating pipe: %v", err)
+	}
+	oldStdin := os.Stdin
+	defer func() { os.Stdin = oldStdin }()
+	os.Stdin = r
+
+	go func() {
+		_, _ = w.WriteString("one two\n")
+		// Leave time for a report before the rest of the stream arrives
+		time.Sleep(100 * time.Millisecond)
+		_, _ = w.WriteString("three\n")
+		_ = w.Close()
+	}()
+	stdout, stderr := captureOutput(t, []string{"-w", "--interval", "10ms"})
+	if stdout != "       3\n" {
+		t.Errorf("Expected the final count of 3 words, got %q", stdout)
+	}
+	if !strings.Contains(stderr, "mwc: 2 words in 0s (") {
+		t.Errorf("Expected a report of the first 2 words on stderr, got %q", stderr)
+	}
+
+	if _, _, err := parseArgs([]string{"--interval", "5s", "book.txt"}); err == nil {
+		t.Errorf("Expected --interval with files to be rejected")
+	}
+}
+
+// TestProgressLine tests describing how far counting a file has got
+func TestProgressLine(t *testing.T) {
+	tests := []struct {
+		done, size int64
+		elapsed    time.Duration
+		expected   string
+	}{
+		{0, 200 << 20, 0, "big.log:   0% 0B/200.0MB 0B/s ETA --:--"},
+		{50 << 20, 200 << 20, time.Second, "big.log:  25% 50.0MB/200.0MB 50.0MB/s ETA 0:03"},
+		{100 << 20, 200 << 20, 10 * time.Second, "big.log:  50% 100.0MB/200.0MB 10.0MB/s ETA 0:10"},
+		{1 << 20, 8 << 30, time.Second, "big.log:   0% 1.0MB/8.0GB 1.0MB/s ETA 2:16:31"},
+	}
+	for _, tt := range tests {
+		if got := progressLine("big.log", tt.done, tt.size, tt.elapsed); got != tt.expected {
+			t.Errorf("progressLine(%d, %d, %v) = %q, expected %q", tt.done, tt.size, tt.elapsed, got, tt.expected)
+		}
+	}
+}
+
+// TestDrawProgress checks that the progress line is only drawn once counting
+// takes a while, and is cleared afterwards
+func TestDrawProgress(t *testing.T) {
+	var buf bytes.Buffer
+	hooks, clearLine := drawProgress(&buf, "big.log", 100)
+	hooks.OnProgress("", wordcount.Counts{Bytes: 10})
+	clearLine()
+	if buf.Len() != 0 {
+		t.Errorf("Expected nothing to be drawn for a quick count, got %q", buf.String())
+	}
+
+	hooks, clearLine = drawProgress(&buf, "big.log", 100)
+	time.Sleep(progressRedraw)
+	hooks.OnProgress("", wordcount.Counts{Bytes: 50})
+	hooks.OnProgress("", wordcount.Counts{Bytes: 60})
+	clearLine()
+	if got := buf.String(); !strings.HasPrefix(got, "\rbig.log:  50% 50B/100B ") || !strings.HasSuffix(got, "\x1b[K\r\x1b[K") || strings.Count(got, "\r") != 2 {
+		t.Errorf("Expected the line to be drawn once and cleared, got %q", got)
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Histogram buckets: request latency in seconds, and words per request
+var (
+	durationBuckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30}
+	wordBuckets     = []float64{10, 100, 1000, 10_000, 100_000, 1_000_000, 10_000_000}
+)
+
+// serverMetrics collects the metrics of mwc serve and serves them in the
+// Prometheus text exposition format
+type serverMetrics struct {
+	mu        sync.Mutex
+	requests  map[[2]string]int64   // requests by path and status code
+	durations map[string]*histogram // request latency by path
+	bytes     int64                 // request body bytes read
+	inFlight  int64                 // requests being served
+	words     *histogram            // words counted per request, when words are counted
+}
+
+func newServerMetrics() *serverMetrics {
+	return &serverMetrics{
+		requests:  map[[2]string]int64{},
+		durations: map[string]*histogram{},
+		words:     newHistogram(wordBuckets),
+	}
+}
+
+// histogram counts observations in cumulative buckets
+type histogram struct {
+	bounds []float64
+	counts []int64 // observations at or below each bound
+	count  int64
+	sum    float64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]int64, len(bounds))}
+}
+
+func (h *histogram) observe(value float64) {
+	for i, bound := range h.bounds {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += value
+}
+
+// write writes the histogram's series, with labels such as `path="/count"`
+func (h *histogram) write(w io.Writer, name, labels string) {
+	prefix := labels
+	if prefix != "" {
+		prefix += ","
+	}
+	for i, bound := range h.bounds {
+		_, _ = fmt.Fprintf(w, "%s_bucket{%sle=\"%s\"} %d\n", name, prefix, formatFloat(bound), h.counts[i])
+	}
+	_, _ = fmt.Fprintf(w, "%s_bucket{%sle=\"+Inf\"} %d\n", name, prefix, h.count)
+	if labels != "" {
+		labels = "{" + labels + "}"
+	}
+	_, _ = fmt.Fprintf(w, "%s_sum%s %s\n", name, labels, formatFloat(h.sum))
+	_, _ = fmt.Fprintf(w, "%s_count%s %d\n", name, labels, h.count)
+}
+
+// formatFloat formats a sample value as Prometheus expects
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// instrument wraps a handler to count its requests, their latency and the
+// bytes read from their bodies
+func (m *serverMetrics) instrument(path string, handler http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		m.mu.Lock()
+		m.inFlight++
+		m.mu.Unlock()
+		body := &countingReader{reader: r.Body}
+		r.Body = body
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler(recorder, r)
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.inFlight--
+		m.requests[[2]string{path, strconv.Itoa(recorder.status)}]++
+		if m.durations[path] == nil {
+			m.durations[path] = newHistogram(durationBuckets)
+		}
+		m.durations[path].observe(time.Since(start).Seconds())
+		m.bytes += body.n
+	})
+}
+
+// requestsInFlight returns the number of requests being served
+func (m *serverMetrics) requestsInFlight() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.inFlight
+}
+
+// observeWords records the words counted for a request
+func (m *serverMetrics) observeWords(words int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.words.observe(float64(words))
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format
+func (m *serverMetrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var b strings.Builder
+
+	b.WriteString("# HELP mwc_requests_total Requests served, by path and status code.\n")
+	b.WriteString("# TYPE mwc_requests_total counter\n")
+	keys := make([][2]string, 0, len(m.requests))
+	for key := range m.requests {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i][0] < keys[j][0] || (keys[i][0] == keys[j][0] && keys[i][1] < keys[j][1])
+	})
+	for _, key := range keys {
+		_, _ = fmt.Fprintf(&b, "mwc_requests_total{code=%q,path=%q} %d\n", key[1], key[0], m.requests[key])
+	}
+
+	b.WriteString("# HELP mwc_request_duration_seconds Time taken to serve requests, by path.\n")
+	b.WriteString("# TYPE mwc_request_duration_seconds histogram\n")
+	paths := make([]string, 0, len(m.durations))
+	for path := range m.durations {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		m.durations[path].write(&b, "mwc_request_duration_seconds", fmt.Sprintf("path=%q", path))
+	}
+
+	b.WriteString("# HELP mwc_requests_in_flight Requests being served.\n")
+	b.WriteString("# TYPE mwc_requests_in_flight gauge\n")
+	_, _ = fmt.Fprintf(&b, "mwc_requests_in_flight %d\n", m.inFlight)
+
+	b.WriteString("# HELP mwc_bytes_processed_total Bytes read from request bodies.\n")
+	b.WriteString("# TYPE mwc_bytes_processed_total counter\n")
+	_, _ = fmt.Fprintf(&b, "mwc_bytes_processed_total %d\n", m.bytes)
+
+	b.WriteString("# HELP mwc_request_words Words counted per request that counts words.\n")
+	b.WriteString("# TYPE mwc_request_words histogram\n")
+	m.words.write(&b, "mwc_request_words", "")
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = io.WriteString(w, b.String())
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	reader io.ReadCloser
+	n      int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+func (r *countingReader) Close() error {
+	return r.reader.Close()
+}
+
+// statusRecorder records the status code a handler responds with
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
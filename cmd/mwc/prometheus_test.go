@@ -0,0 +1,67 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestServeMetrics checks that requests, bytes and words show up in the Prometheus metrics
+func TestServeMetrics(t *testing.T) {
+	server := httptest.NewServer(newServer().routes())
+	defer server.Close()
+	for _, query := range []string{"?count=words", "?count=words", "?count=lines", "?count=syllables"} {
+		resp, err := http.Post(server.URL+"/count"+query, "text/plain", strings.NewReader("one two three\n"))
+		if err != nil {
+			t.Fatalf("Error posting: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	resp, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("Error getting metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Errorf("Expected the Prometheus text format, got %s", contentType)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	for _, expected := range []string{
+		`mwc_requests_total{code="200",path="/count"} 3`,
+		`mwc_requests_total{code="400",path="/count"} 1`,
+		`mwc_request_duration_seconds_count{path="/count"} 4`,
+		`mwc_request_duration_seconds_bucket{path="/count",le="+Inf"} 4`,
+		// The body of the rejected request isn't read
+		"mwc_requests_in_flight 0",
+		"mwc_bytes_processed_total 42",
+		`mwc_request_words_bucket{le="10"} 2`,
+		"mwc_request_words_sum 6",
+		"mwc_request_words_count 2",
+	} {
+		if !strings.Contains(string(body), expected+"\n") {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", expected, body)
+		}
+	}
+}
+
+// TestHistogram checks that histogram buckets are cumulative
+func TestHistogram(t *testing.T) {
+	h := newHistogram([]float64{1, 10})
+	for _, value := range []float64{0.5, 5, 50} {
+		h.observe(value)
+	}
+	var b strings.Builder
+	h.write(&b, "x", `path="/"`)
+	expected := `x_bucket{path="/",le="1"} 1
+x_bucket{path="/",le="10"} 2
+x_bucket{path="/",le="+Inf"} 3
+x_sum{path="/"} 55.5
+x_count{path="/"} 3
+`
+	if b.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, b.String())
+	}
+}
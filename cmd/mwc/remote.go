@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// runRemote counts stdin or the named files on the mwc server at
+// options.Remote and prints its results, returning the exit status. Stdin is
+// streamed to POST /count and files are streamed as one multipart upload to
+// POST /count/files, so nothing is buffered locally and unique words shared
+// between files are still counted once in the total. The server's token, if it
+// requires one, is read from MWC_REMOTE_TOKEN.
+func runRemote(options cliOptions, filenames []string) int {
+	query := url.Values{"count": {strings.Join(remoteCounts(options), ",")}}
+	if len(filenames) == 0 && options.FilesFrom == "" {
+		var counts wordcount.Counts
+		if err := postRemote(options.Remote, "/count?"+query.Encode(), "text/plain", os.Stdin, &counts); err != nil {
+			logError("counting remotely failed", err, "url", options.Remote)
+			return exitFailed
+		}
+		printCounts(counts, "", options)
+		return 0
+	}
+
+	var fsys fs.FS = osFS{}
+	if options.FSRoot != "" {
+		fsys = os.DirFS(options.FSRoot)
+	}
+	// The server only sees base names, so the files it counted are labelled
+	// with the names that were uploaded, in order
+	var uploaded []string
+	failed := false // whether files couldn't be uploaded, and were left out
+	fail := func(err error) {
+		printFileError(err)
+		failed = true
+	}
+	done := make(chan struct{})
+	body, bodyWriter := io.Pipe()
+	form := multipart.NewWriter(bodyWriter)
+	go func() {
+		defer close(done)
+		var uploadErr error
+		upload := func(filename string) {
+			if uploadErr != nil {
+				return
+			}
+			file, err := openFile(fsys, filename)
+			if err != nil {
+				fail(&wordcount.FileError{Op: "open", Path: filename, Err: err})
+				return
+			}
+			part, err := form.CreateFormFile("file", filename)
+			if err == nil {
+				_, err = io.Copy(part, file)
+			}
+			_ = file.Close()
+			if err != nil {
+				uploadErr = &wordcount.FileError{Op: "upload", Path: filename, Err: err}
+				return
+			}
+			uploaded = append(uploaded, filename)
+		}
+		files := newWalker(fsys, options)
+		for _, filename := range filenames {
+			files.expand(filename, upload, fail)
+		}
+		if uploadErr != nil {
+			_ = bodyWriter.CloseWithError(uploadErr)
+			return
+		}
+		_ = bodyWriter.CloseWithError(form.Close())
+	}()
+
+	var response filesResponse
+	err := postRemote(options.Remote, "/count/files?"+query.Encode(), form.FormDataContentType(), body, &response)
+	_ = body.Close()
+	<-done
+	if err == nil && len(response.Files) != len(uploaded) {
+		err = fmt.Errorf("the server counted %d files, not %d", len(response.Files), len(uploaded))
+	}
+	if err != nil {
+		logError("counting remotely failed", err, "url", options.Remote)
+		return exitFailed
+	}
+	for i, file := range response.Files {
+		printCounts(file.Counts, uploaded[i], options)
+	}
+	if len(response.Files) > 1 {
+		printCounts(response.Total, "total", options)
+	}
+	if failed {
+		return exitFileErrors
+	}
+	return 0
+}
+
+// remoteCounts returns the names of the counts to request from the server:
+// the printed counts and those the expressions use
+func remoteCounts(options cliOptions) []string {
+	names := slices.Clone(options.Order)
+	for _, expr := range options.Exprs {
+		for _, name := range expr.Names() {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// postRemote posts a body to a path of the mwc server at base, decoding its
+// JSON response into result
+func postRemote(base, path, contentType string, body io.Reader, result any) error {
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(base, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if token := os.Getenv("MWC_REMOTE_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&failure) == nil && failure.Error != "" {
+			return fmt.Errorf("%s: %s", resp.Status, failure.Error)
+		}
+		return fmt.Errorf("%s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("invalid response: %v", err)
+	}
+	return nil
+}
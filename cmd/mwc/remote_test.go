@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestRemote checks that counting on a server prints what counting locally does
+func TestRemote(t *testing.T) {
+	s := newServer()
+	s.tokens = []string{"s3cr3t"}
+	server := httptest.NewServer(s.routes())
+	defer server.Close()
+	t.Setenv("MWC_REMOTE_TOKEN", "s3cr3t")
+
+	for _, args := range [][]string{
+		{"testdata/test1.txt"},
+		{"-lw", "--unique", "testdata/test1.txt", "testdata/test2.txt", "testdata/missing.txt", "testdata/test3.txt"},
+		{"-w", "--expr", "avg=bytes/words", "testdata/test1.txt", "testdata/test2.txt"},
+	} {
+		local, localErr := captureOutput(t, args)
+		remote, remoteErr := captureOutput(t, append([]string{"--remote", server.URL}, args...))
+		if remote != local || remoteErr != localErr {
+			t.Errorf("%q: expected %q and %q remotely, got %q and %q", args, local, localErr, remote, remoteErr)
+		}
+	}
+
+	// Stdin is streamed to POST /count
+	stdin, err := os.Open("testdata/test1.txt")
+	if err != nil {
+		t.Fatalf("Error opening test file: %v", err)
+	}
+	defer stdin.Close()
+	oldStdin := os.Stdin
+	defer func() { os.Stdin = oldStdin }()
+	os.Stdin = stdin
+	options, _, _ := parseArgs([]string{"-w", "--remote", server.URL})
+	if stdout, _ := captureFunc(t, func() { run(options, nil) }); stdout != "       2\n" {
+		t.Errorf("Expected 2 words from stdin, got %q", stdout)
+	}
+
+	t.Setenv("MWC_REMOTE_TOKEN", "wrong")
+	var status int
+	_, stderr := captureFunc(t, func() { status = run(options, []string{"testdata/test1.txt"}) })
+	if status != exitFailed || !strings.Contains(stderr, "401 Unauthorized: missing or invalid token") {
+		t.Errorf("Expected the server's error, got %d and %q", status, stderr)
+	}
+
+	if _, _, err := parseArgs([]string{"--remote", server.URL, "--cache", t.TempDir()}); err == nil {
+		t.Errorf("Expected --remote and --cache to be refused together")
+	}
+}
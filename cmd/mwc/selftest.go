@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// selftest implements mwc selftest, which counts the files under the paths
+// given, or the current directory, with both mwc and the system's wc, and
+// reports every count on which they differ. It returns the exit status:
+// exitBudget if any count differed, or exitFileErrors if a file couldn't be
+// counted.
+func selftest(args []string) int {
+	options, paths, err := parseArgs(append([]string{"-r"}, args...))
+	if err == nil && !options.HelpRequested {
+		for _, count := range options.Order {
+			if !slices.Contains([]string{"lines", "words", "characters", "bytes", "max_line_length"}, count) {
+				err = optionError("--"+count, "mwc selftest only compares the counts wc has: -l, -w, -m, -c and -L")
+				break
+			}
+		}
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[0], err)
+		_, _ = fmt.Fprintf(os.Stderr, "usage: %s selftest [-Lclmw] [path ...]\n", os.Args[0])
+		return exitUsage
+	}
+	if options.HelpRequested {
+		printSelftestUsage()
+		return 0
+	}
+	setupLogging(options)
+	wc, err := exec.LookPath("wc")
+	if err != nil {
+		logError("selftest failed", errors.New("there is no wc on the PATH to compare with"))
+		return exitFailed
+	}
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	status := 0
+	checked, differed := 0, 0
+	var fsys fs.FS = osFS{}
+	files := newWalker(fsys, options)
+	for _, path := range paths {
+		files.expand(path, func(name string) {
+			mine, _, err := countNamedFile(context.Background(), fsys, name, options)
+			if err != nil {
+				printFileError(err)
+				status = exitFileErrors
+				return
+			}
+			theirs, err := countWithWC(wc, name, options.Order)
+			if err != nil {
+				printFileError(&wordcount.FileError{Op: "count", Path: name, Err: err})
+				status = exitFileErrors
+				return
+			}
+			checked++
+			same := true
+			for _, count := range options.Order {
+				if n, _ := mine.Get(count); n != theirs[count] {
+					fmt.Printf("%s: %s: mwc %d, wc %d\n", name, count, n, theirs[count])
+					same = false
+				}
+			}
+			if !same {
+				differed++
+				status = exitBudget
+			}
+		}, func(err error) {
+			printFileError(err)
+			status = exitFileErrors
+		})
+	}
+	fmt.Printf("%d files checked against %s: %d the same, %d different\n", checked, wc, checked-differed, differed)
+	return status
+}
+
+// countWithWC counts a file with the wc at the path given, returning the
+// counts it printed by name. wc prints lines, words and bytes in that order
+// however they are asked for; characters and the longest line are counted
+// separately, since BSD wc only prints one of bytes and characters.
+func countWithWC(wc, filename string, order []string) (map[string]int64, error) {
+	counts := map[string]int64{}
+	run := func(flag string, names ...string) error {
+		file, err := os.Open(filename)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		cmd := exec.Command(wc, flag)
+		// Reading stdin, wc prints the counts without a name
+		cmd.Stdin = file
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return fmt.Errorf("wc %s: %v %s", flag, err, strings.TrimSpace(stderr.String()))
+		}
+		fields := strings.Fields(string(out))
+		if len(fields) != len(names) {
+			return fmt.Errorf("wc %s printed %q", flag, out)
+		}
+		for i, name := range names {
+			n, err := strconv.ParseInt(fields[i], 10, 64)
+			if err != nil {
+				return fmt.Errorf("wc %s printed %q", flag, out)
+			}
+			counts[name] = n
+		}
+		return nil
+	}
+	flag, names := "-", []string{}
+	for _, count := range []struct{ name, flag string }{{"lines", "l"}, {"words", "w"}, {"bytes", "c"}} {
+		if slices.Contains(order, count.name) {
+			flag += count.flag
+			names = append(names, count.name)
+		}
+	}
+	if len(names) > 0 {
+		if err := run(flag, names...); err != nil {
+			return nil, err
+		}
+	}
+	if slices.Contains(order, "characters") {
+		if err := run("-m", "characters"); err != nil {
+			return nil, err
+		}
+	}
+	if slices.Contains(order, "max_line_length") {
+		if err := run("-L", "max_line_length"); err != nil {
+			return nil, err
+		}
+	}
+	return counts, nil
+}
+
+func printSelftestUsage() {
+	fmt.Println("Usage: mwc selftest [-Llwcm] [options] [path ...]")
+	fmt.Println("Count the files under the paths, or the current directory, with both mwc and")
+	fmt.Println("the system's wc, and print every count on which they differ, then how many")
+	fmt.Println("files were the same. Exits with status 3 if any count differed. wc counts")
+	fmt.Println("words and characters as its locale says, so compare LC_ALL=C with --posix.")
+	fmt.Println("\nThe files and counts are chosen as for mwc -r; see mwc --help. Without")
+	fmt.Println("options, lines, words and bytes are compared, as wc counts them by default.")
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestSelftest checks that mwc selftest reports the counts on which mwc and
+// wc differ, using a stand-in wc that miscounts lines and characters
+func TestSelftest(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the stand-in wc is a shell script")
+	}
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"bin/wc":         "#!/bin/sh\ncase $1 in\n-lwc) echo '  1  3  14' ;;\n-wc) echo ' 3 14' ;;\n-w) echo 3 ;;\n-m) echo 99 ;;\nesac\n",
+		"corpus/a.txt":   "one two\nthree\n",
+		"corpus/b/c.txt": "one two three four\n",
+	})
+	if err := os.Chmod(filepath.Join(dir, "bin", "wc"), 0o755); err != nil {
+		t.Fatalf("Failed to make wc executable: %v", err)
+	}
+	t.Setenv("PATH", filepath.Join(dir, "bin"))
+	chdir(t, dir)
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	os.Args = []string{"mwc"}
+	wc := filepath.Join(dir, "bin", "wc")
+
+	tests := []struct {
+		name           string
+		args           []string
+		expected       string
+		expectedStatus int
+	}{
+		{"All Counts", []string{"-lwmc", "corpus/a.txt"},
+			"corpus/a.txt: lines: mwc 2, wc 1\ncorpus/a.txt: characters: mwc 14, wc 99\n" +
+				"1 files checked against " + wc + ": 0 the same, 1 different\n", exitBudget},
+		{"Words", []string{"-w", "corpus"},
+			"corpus/b/c.txt: words: mwc 4, wc 3\n2 files checked against " + wc + ": 1 the same, 1 different\n", exitBudget},
+		{"Default Counts", []string{"corpus/b/c.txt"},
+			"corpus/b/c.txt: words: mwc 4, wc 3\ncorpus/b/c.txt: bytes: mwc 19, wc 14\n" +
+				"1 files checked against " + wc + ": 0 the same, 1 different\n", exitBudget},
+		{"Same", []string{"-wc", "corpus/a.txt"}, "1 files checked against " + wc + ": 1 the same, 0 different\n", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var status int
+			stdout, stderr := captureFunc(t, func() { status = selftest(tt.args) })
+			if stdout != tt.expected || stderr != "" {
+				t.Errorf("Expected:\n%s\ngot:\n%s%s", tt.expected, stdout, stderr)
+			}
+			if status != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, status)
+			}
+		})
+	}
+
+	var status int
+	_, stderr := captureFunc(t, func() { status = selftest([]string{"--unique", "corpus"}) })
+	if status != 1 || !strings.Contains(stderr, "only compares the counts wc has") {
+		t.Errorf("Expected counts wc doesn't have to be rejected, got status %d and %q", status, stderr)
+	}
+}
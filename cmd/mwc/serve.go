@@ -0,0 +1,361 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// serveOptions holds the flags of mwc serve
+type serveOptions struct {
+	HelpRequested   bool
+	Listen          string        // Address to listen on, such as ":8080" or "unix:/run/mwc.sock"
+	ShutdownTimeout time.Duration // How long requests in flight may take to finish on shutdown
+	AuthTokens      []string      // Bearer tokens or API keys accepted; none means no authentication
+	RateLimit       float64       // Requests per second allowed per client; 0 means unlimited
+	MaxBody         int64         // Largest request body in bytes
+}
+
+// Protections of mwc serve that apply whatever the options
+const (
+	defaultMaxBody = 100 << 20 // default of --max-body
+	// serveMaxMemory is the memory unique words may take per request before
+	// they are approximated
+	serveMaxMemory = 64 << 20
+	// Connections must send their headers within serveReadHeaderTimeout and
+	// their whole request within serveReadTimeout, and idle ones are closed
+	// after serveIdleTimeout, so slow clients can't hold them open
+	serveReadHeaderTimeout = 10 * time.Second
+	serveReadTimeout       = 5 * time.Minute
+	serveIdleTimeout       = 2 * time.Minute
+)
+
+// serveValueOptions lists the long options of mwc serve that take a value
+var serveValueOptions = []string{"listen", "shutdown-timeout", "auth-tokens", "rate-limit", "max-body"}
+
+// parseServeArgs processes the arguments following "mwc serve"
+func parseServeArgs(args []string) (serveOptions, error) {
+	options := serveOptions{Listen: ":8080", ShutdownTimeout: 30 * time.Second, MaxBody: defaultMaxBody}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		if strings.HasPrefix(arg, "--") && slices.Contains(serveValueOptions, name) && !hasValue {
+			if i+1 >= len(args) {
+				return serveOptions{}, optionError("--"+name, "option '--%s' requires an argument", name)
+			}
+			i++
+			value = args[i]
+		}
+		switch {
+		case arg == "-h" || arg == "--help":
+			options.HelpRequested = true
+		case strings.HasPrefix(arg, "--") && name == "listen":
+			options.Listen = value
+		case strings.HasPrefix(arg, "--") && name == "shutdown-timeout":
+			timeout, err := time.ParseDuration(value)
+			if err != nil || timeout < 0 {
+				return serveOptions{}, optionError("--shutdown-timeout", "invalid duration for --shutdown-timeout: '%s'", value)
+			}
+			options.ShutdownTimeout = timeout
+		case strings.HasPrefix(arg, "--") && name == "auth-tokens":
+			tokens, err := readTokens(expandHome(value))
+			if err != nil {
+				return serveOptions{}, optionError("--auth-tokens", "can't read tokens for --auth-tokens: %v", err)
+			}
+			options.AuthTokens = tokens
+		case strings.HasPrefix(arg, "--") && name == "rate-limit":
+			rate, err := parseRequestRate(value)
+			if err != nil {
+				return serveOptions{}, optionError("--rate-limit", "invalid rate for --rate-limit: '%s'", value)
+			}
+			options.RateLimit = rate
+		case strings.HasPrefix(arg, "--") && name == "max-body":
+			size, err := parseSize(value)
+			if err != nil || size == 0 {
+				return serveOptions{}, optionError("--max-body", "invalid size for --max-body: '%s'", value)
+			}
+			options.MaxBody = size
+		case strings.HasPrefix(arg, "-"):
+			return serveOptions{}, optionError(arg, "unrecognized option '%s'", arg)
+		default:
+			return serveOptions{}, optionError(arg, "unexpected argument '%s'", arg)
+		}
+	}
+	return options, nil
+}
+
+// serve runs mwc serve with the given arguments and returns the exit status
+func serve(args []string) int {
+	options, err := parseServeArgs(args)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[0], err)
+		_, _ = fmt.Fprintf(os.Stderr, "usage: %s serve [--listen ADDR] [--shutdown-timeout DURATION] [--auth-tokens FILE] [--rate-limit RATE] [--max-body SIZE]\n", os.Args[0])
+		return exitUsage
+	}
+	if options.HelpRequested {
+		printServeUsage()
+		return 0
+	}
+
+	listener, err := listen(options.Listen)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[0], err)
+		return exitFailed
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "%s: serving on %s\n", os.Args[0], listener.Addr())
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	s := newServer()
+	s.tokens, s.maxBody = options.AuthTokens, options.MaxBody
+	if options.RateLimit > 0 {
+		s.limiter = newClientLimiter(options.RateLimit)
+	}
+	if err := s.run(ctx, listener, options.ShutdownTimeout); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[0], err)
+		return exitFailed
+	}
+	return 0
+}
+
+// listen listens on a TCP address, or on a Unix domain socket for an address
+// of the form "unix:PATH". A socket file left behind by a server that is no
+// longer running is replaced.
+func listen(address string) (net.Listener, error) {
+	path, isUnix := strings.CutPrefix(address, "unix:")
+	if !isUnix {
+		return net.Listen("tcp", address)
+	}
+	listener, err := net.Listen("unix", path)
+	if err == nil || !errors.Is(err, syscall.EADDRINUSE) {
+		return listener, err
+	}
+	if conn, dialErr := net.Dial("unix", path); dialErr == nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	if removeErr := os.Remove(path); removeErr != nil {
+		return nil, err
+	}
+	return net.Listen("unix", path)
+}
+
+// server holds the state shared by the handlers of mwc serve
+type server struct {
+	metrics      *serverMetrics
+	draining     atomic.Bool    // whether the server is shutting down
+	tokens       []string       // tokens accepted by guard; none means no authentication
+	limiter      *clientLimiter // nil means unlimited
+	authFailures *clientLimiter // failed authentications, by address
+	maxBody      int64          // 0 means unlimited
+}
+
+func newServer() *server {
+	authFailures := newClientLimiter(authFailureRate)
+	authFailures.burst = authFailureBurst
+	return &server{metrics: newServerMetrics(), authFailures: authFailures}
+}
+
+// routes returns the handler of mwc serve
+func (s *server) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("POST /count", s.metrics.instrument("/count", s.guard(s.handleCount)))
+	mux.Handle("POST /count/files", s.metrics.instrument("/count/files", s.guard(s.handleCountFiles)))
+	mux.Handle("GET /metrics", s.guard(s.metrics.ServeHTTP))
+	mux.HandleFunc("GET /healthz", s.handleHealth)
+	mux.HandleFunc("GET /readyz", s.handleReady)
+	return mux
+}
+
+// run serves on the listener until ctx is done, then shuts down gracefully:
+// /readyz starts failing, no new connections are accepted, and requests in
+// flight get up to timeout to finish before their connections are closed
+func (s *server) run(ctx context.Context, listener net.Listener, timeout time.Duration) error {
+	httpServer := &http.Server{
+		Handler:           s.routes(),
+		ReadHeaderTimeout: serveReadHeaderTimeout,
+		ReadTimeout:       serveReadTimeout,
+		IdleTimeout:       serveIdleTimeout,
+	}
+	served := make(chan error, 1)
+	go func() { served <- httpServer.Serve(listener) }()
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+	s.draining.Store(true)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		_ = httpServer.Close()
+		return fmt.Errorf("requests still in flight after %v were cut off", timeout)
+	}
+	return nil
+}
+
+// handleHealth reports that the server is alive, for liveness probes
+func (s *server) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	_, _ = io.WriteString(w, "ok\n")
+}
+
+// handleReady reports whether the server accepts new requests, for
+// readiness probes and load balancers; it fails once shutdown has begun
+func (s *server) handleReady(w http.ResponseWriter, _ *http.Request) {
+	if s.draining.Load() {
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	_, _ = io.WriteString(w, "ok\n")
+}
+
+// handleCount counts the request body and responds with the counts as JSON.
+// The counts are selected by count query parameters naming built-in counts
+// or metrics, such as ?count=words&count=sentences or ?count=words,sentences;
+// without any, lines, words and bytes are counted.
+func (s *server) handleCount(w http.ResponseWriter, r *http.Request) {
+	options, err := queryCountOptions(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	counts, err := wordcount.CountContext(r.Context(), r.Body, options)
+	if err != nil {
+		writeJSONError(w, bodyErrorStatus(err), err)
+		return
+	}
+	if options.WordCount {
+		s.metrics.observeWords(counts.Words)
+	}
+	writeJSON(w, http.StatusOK, counts)
+}
+
+// filesResponse is the response of POST /count/files
+type filesResponse struct {
+	Files []wordcount.FileCount `json:"files"`
+	Total wordcount.Counts      `json:"total"`
+}
+
+// handleCountFiles counts every file uploaded in a multipart/form-data body
+// and responds with the counts of each file and their total, as JSON. Form
+// fields that aren't files are ignored. As with mwc and several files, unique
+// words shared between files are counted once in the total.
+func (s *server) handleCountFiles(w http.ResponseWriter, r *http.Request) {
+	options, err := queryCountOptions(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	parts, err := r.MultipartReader()
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	if options.UniqueCount {
+		options.UniqueTotal = wordcount.NewUniqueWords(serveMaxMemory)
+	}
+
+	response := filesResponse{Files: []wordcount.FileCount{}}
+	var total wordcount.Accumulator
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			writeJSONError(w, bodyErrorStatus(err), err)
+			return
+		}
+		if part.FileName() == "" {
+			continue
+		}
+		counts, err := wordcount.CountContext(r.Context(), part, options)
+		if err != nil {
+			writeJSONError(w, bodyErrorStatus(err), &wordcount.FileError{Op: "count", Path: part.FileName(), Err: err})
+			return
+		}
+		response.Files = append(response.Files, wordcount.FileCount{Filename: part.FileName(), Counts: counts})
+		total.Add(counts)
+	}
+	response.Total = total.Total()
+	if options.WordCount {
+		s.metrics.observeWords(response.Total.Words)
+	}
+	if options.UniqueTotal != nil {
+		response.Total.Unique = options.UniqueTotal.Count()
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// queryCountOptions builds the counting options from the query parameters of a request
+func queryCountOptions(r *http.Request) (wordcount.CountOptions, error) {
+	var options wordcount.CountOptions
+	for _, value := range r.URL.Query()["count"] {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if _, builtin := (wordcount.Counts{}).Get(name); !builtin && !slices.Contains(wordcount.Metrics(), name) {
+				return options, fmt.Errorf("unknown count '%s' (available: %s)", name, availableCounts())
+			}
+			options.Order = append(options.Order, name)
+		}
+	}
+	options = options.Normalize()
+	options.MaxMemory = serveMaxMemory
+	return options, options.Validate()
+}
+
+// availableCounts lists the names the count query parameter accepts
+func availableCounts() string {
+	return strings.Join(append([]string{"lines", "words", "characters", "bytes", "max_line_length", "unique"}, wordcount.Metrics()...), ", ")
+}
+
+// writeJSON writes v as the JSON response with the given status
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeJSONError writes err as a JSON error response, {"error": "..."}
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func printServeUsage() {
+	fmt.Println("Usage: mwc serve [options]")
+	fmt.Println("Serve counts over HTTP.")
+	fmt.Println("\nOptions:")
+	fmt.Println("  --listen ADDR	Listen on ADDR (default :8080), or on a Unix socket with unix:PATH")
+	fmt.Println("  --shutdown-timeout DURATION	Time requests may take to finish on SIGTERM (default 30s)")
+	fmt.Println("  --auth-tokens FILE	Require a bearer token or API key listed in FILE, one per line")
+	fmt.Println("  --rate-limit RATE	Allow each client RATE requests, such as 10/s or 600/m")
+	fmt.Println("  --max-body SIZE	Refuse request bodies larger than SIZE (default 100M)")
+	fmt.Println("  -h, --help	Display this help message")
+	fmt.Println("\nEndpoints:")
+	fmt.Println("  POST /count	Count the request body and respond with its counts.")
+	fmt.Println("  POST /count/files	Count each file of a multipart/form-data upload and")
+	fmt.Println("		respond with the counts of every file and their total.")
+	fmt.Println("  GET /metrics	Metrics of the server in the Prometheus text format.")
+	fmt.Println("  GET /healthz	Liveness check; always ok while the server runs.")
+	fmt.Println("  GET /readyz	Readiness check; fails with 503 once shutdown has begun.")
+	fmt.Println("\nSelect counts with ?count=NAME, such as ?count=words,sentences.")
+	fmt.Println("With --auth-tokens, every endpoint but /healthz and /readyz requires a token,")
+	fmt.Println("sent as Authorization: Bearer TOKEN or X-API-Key: TOKEN. After 10 failed")
+	fmt.Println("attempts, an address may try once every 6 seconds.")
+	fmt.Println("\nConnections must send their headers within 10s and their request within 5m,")
+	fmt.Println("and are closed after 2m idle. Unique words are approximated beyond 64M of")
+	fmt.Println("memory per request.")
+}
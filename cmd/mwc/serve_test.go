@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// TestServeCount tests counting request bodies with the counts selected by the query
+func TestServeCount(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		body           string
+		expectedStatus int
+		expected       wordcount.Counts
+		expectedErr    string
+	}{
+		{"Default Counts", "", "Hello, World!\n", http.StatusOK, wordcount.Counts{Lines: 1, Words: 2, Bytes: 14}, ""},
+		{"Selected Counts", "?count=words&count=characters", "Hello, 世界\n", http.StatusOK, wordcount.Counts{Words: 2, Chars: 10}, ""},
+		{"Metric", "?count=words,sentences", "One. Two!", http.StatusOK,
+			wordcount.Counts{Words: 2, Metrics: map[string]int64{"sentences": 2}}, ""},
+		{"Unknown Count", "?count=syllables", "One.", http.StatusBadRequest, wordcount.Counts{},
+			"unknown count 'syllables' (available: lines, words, characters, bytes, max_line_length, unique, bidi_controls, confusables, dialogue, invalid_utf8, narration, overlong, sentences, surrogates, zero_width)"},
+	}
+
+	server := httptest.NewServer(newServer().routes())
+	defer server.Close()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Post(server.URL+"/count"+tt.query, "text/plain", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("Error posting: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+			var result struct {
+				wordcount.Counts
+				Error string `json:"error"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+				t.Fatalf("Error decoding response: %v", err)
+			}
+			if !result.Counts.Equal(tt.expected) || result.Error != tt.expectedErr {
+				t.Errorf("Expected %+v and error %q, got %+v and %q", tt.expected, tt.expectedErr, result.Counts, result.Error)
+			}
+		})
+	}
+
+	resp, err := http.Get(server.URL + "/count")
+	if err != nil {
+		t.Fatalf("Error getting: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected GET to be refused with %d, got %d", http.StatusMethodNotAllowed, resp.StatusCode)
+	}
+}
+
+// TestServeUniqueMemory checks that the unique words of a body too large to
+// hold in serveMaxMemory are approximated
+func TestServeUniqueMemory(t *testing.T) {
+	// With the overhead of the set, these words take about 85M
+	const distinct = 1_500_000
+	body, writer := io.Pipe()
+	go func() {
+		buffered := bufio.NewWriter(writer)
+		for i := 0; i < distinct; i++ {
+			fmt.Fprintf(buffered, "w%07d ", i)
+		}
+		_ = buffered.Flush()
+		_ = writer.Close()
+	}()
+
+	server := httptest.NewServer(newServer().routes())
+	defer server.Close()
+	resp, err := http.Post(server.URL+"/count?count=unique", "text/plain", body)
+	if err != nil {
+		t.Fatalf("Error posting: %v", err)
+	}
+	defer resp.Body.Close()
+	var result wordcount.Counts
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Error decoding response: %v", err)
+	}
+	diff := math.Abs(float64(result.Unique-distinct)) / distinct
+	if result.Unique == distinct || diff > 4*wordcount.ApproximateUniqueError {
+		t.Errorf("Expected about %d unique words, approximated, got %d", distinct, result.Unique)
+	}
+}
+
+// TestParseServeArgs tests the options of mwc serve
+func TestParseServeArgs(t *testing.T) {
+	if options, err := parseServeArgs(nil); err != nil || options.Listen != ":8080" {
+		t.Errorf("Expected to listen on :8080 by default, got %q, %v", options.Listen, err)
+	}
+	if options, err := parseServeArgs([]string{"--listen=127.0.0.1:9000"}); err != nil || options.Listen != "127.0.0.1:9000" {
+		t.Errorf("Expected to listen on 127.0.0.1:9000, got %q, %v", options.Listen, err)
+	}
+	if _, err := parseServeArgs([]string{"--listen"}); err == nil || err.Error() != "option '--listen' requires an argument" {
+		t.Errorf("Expected a missing argument error, got %v", err)
+	}
+	if _, err := parseServeArgs([]string{"-w"}); err == nil || err.Error() != "unrecognized option '-w'" {
+		t.Errorf("Expected an unrecognized option error, got %v", err)
+	}
+}
+
+// TestServeCountFiles tests counting the files of a multipart upload and their total
+func TestServeCountFiles(t *testing.T) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for _, file := range [][2]string{{"a.txt", "one two\n"}, {"b.txt", "two three four\n"}} {
+		part, err := form.CreateFormFile("file", file[0])
+		if err != nil {
+			t.Fatalf("Error creating form file: %v", err)
+		}
+		_, _ = part.Write([]byte(file[1]))
+	}
+	_ = form.WriteField("comment", "not counted")
+	_ = form.Close()
+
+	server := httptest.NewServer(newServer().routes())
+	defer server.Close()
+	resp, err := http.Post(server.URL+"/count/files?count=lines,words,unique", form.FormDataContentType(), &body)
+	if err != nil {
+		t.Fatalf("Error posting: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	var result filesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Error decoding response: %v", err)
+	}
+
+	expected := []wordcount.FileCount{
+		{Filename: "a.txt", Counts: wordcount.Counts{Lines: 1, Words: 2, Unique: 2}},
+		{Filename: "b.txt", Counts: wordcount.Counts{Lines: 1, Words: 3, Unique: 3}},
+	}
+	if len(result.Files) != len(expected) {
+		t.Fatalf("Expected %d files, got %+v", len(expected), result.Files)
+	}
+	for i := range expected {
+		if result.Files[i].Filename != expected[i].Filename || !result.Files[i].Counts.Equal(expected[i].Counts) {
+			t.Errorf("Expected %+v, got %+v", expected[i], result.Files[i])
+		}
+	}
+	// "two" is in both files but counted once
+	if expectedTotal := (wordcount.Counts{Lines: 2, Words: 5, Unique: 4}); !result.Total.Equal(expectedTotal) {
+		t.Errorf("Expected total %+v, got %+v", expectedTotal, result.Total)
+	}
+
+	resp, err = http.Post(server.URL+"/count/files", "text/plain", strings.NewReader("not a form"))
+	if err != nil {
+		t.Fatalf("Error posting: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a body that isn't a form, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+}
+
+// TestServeUnixSocket tests serving on a Unix domain socket, replacing a stale socket file
+func TestServeUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mwc.sock")
+	stale, err := listen("unix:" + path)
+	if err != nil {
+		t.Fatalf("Error listening: %v", err)
+	}
+	// Leave the socket file behind, as a server that was killed would
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	_ = stale.Close()
+
+	listener, err := listen("unix:" + path)
+	if err != nil {
+		t.Fatalf("Error listening over a stale socket: %v", err)
+	}
+	server := &http.Server{Handler: newServer().routes()}
+	go func() { _ = server.Serve(listener) }()
+	defer server.Close()
+
+	if _, err := listen("unix:" + path); err == nil {
+		t.Errorf("Expected a socket in use not to be replaced")
+	}
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	resp, err := client.Post("http://mwc/count?count=words", "text/plain", strings.NewReader("Hello, World!"))
+	if err != nil {
+		t.Fatalf("Error posting: %v", err)
+	}
+	defer resp.Body.Close()
+	var counts wordcount.Counts
+	if err := json.NewDecoder(resp.Body).Decode(&counts); err != nil || counts.Words != 2 {
+		t.Errorf("Expected 2 words, got %+v, %v", counts, err)
+	}
+}
+
+// TestServeShutdown tests the health endpoints and that shutdown lets requests in flight finish
+func TestServeShutdown(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening: %v", err)
+	}
+	s := newServer()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stopped := make(chan error, 1)
+	go func() { stopped <- s.run(ctx, listener, 5*time.Second) }()
+	url := "http://" + listener.Addr().String()
+	// A connection dialed but not yet used counts as active during shutdown
+	// for a few seconds, so don't let the client keep spare connections
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+	for _, path := range []string{"/healthz", "/readyz"} {
+		resp, err := client.Get(url + path)
+		if err != nil {
+			t.Fatalf("Error getting %s: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected %s to be ok, got %d", path, resp.StatusCode)
+		}
+	}
+
+	// Start a request whose body is still being sent when shutdown begins
+	body, bodyWriter := io.Pipe()
+	responses := make(chan *http.Response, 1)
+	go func() {
+		resp, err := client.Post(url+"/count?count=words", "text/plain", body)
+		if err != nil {
+			t.Errorf("Error posting: %v", err)
+		}
+		responses <- resp
+	}()
+	_, _ = bodyWriter.Write([]byte("one two "))
+	for s.metrics.requestsInFlight() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	for !s.draining.Load() {
+		time.Sleep(time.Millisecond)
+	}
+	recorder := httptest.NewRecorder()
+	s.routes().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected /readyz to fail while shutting down, got %d", recorder.Code)
+	}
+	_, _ = bodyWriter.Write([]byte("three"))
+	_ = bodyWriter.Close()
+
+	resp := <-responses
+	if resp == nil {
+		t.Fatal("Expected the request in flight to be answered")
+	}
+	defer resp.Body.Close()
+	var counts wordcount.Counts
+	if err := json.NewDecoder(resp.Body).Decode(&counts); err != nil || counts.Words != 3 {
+		t.Errorf("Expected 3 words, got %+v, %v", counts, err)
+	}
+	if err := <-stopped; err != nil {
+		t.Errorf("Expected a clean shutdown, got %v", err)
+	}
+}
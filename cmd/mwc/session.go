@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// sessionState is a writing session started with mwc session start: the
+// words in each file when it started, and the arguments to count them with
+type sessionState struct {
+	Dir     string           `json:"dir"`
+	Started time.Time        `json:"started"`
+	Args    []string         `json:"args"`
+	Words   map[string]int64 `json:"words"`
+}
+
+// session runs mwc session with the given arguments and returns the exit
+// status. "start" records the words in each file under the paths given, or
+// the current directory; "status" reports the words added and removed since;
+// "stop" reports them too and ends the session. There is one session per
+// working directory.
+func session(args []string) int {
+	var options cliOptions
+	var filenames []string
+	err := optionError("session", "mwc session requires start, status or stop")
+	action := ""
+	if len(args) > 0 && (args[0] == "-h" || args[0] == "--help") {
+		printSessionUsage()
+		return 0
+	}
+	if len(args) > 0 && (args[0] == "start" || args[0] == "status" || args[0] == "stop") {
+		action = args[0]
+		options, filenames, err = parseSessionArgs(args[1:])
+	}
+	if err == nil && action != "start" && len(filenames) > 0 {
+		err = optionError(filenames[0], "unexpected argument '%s'", filenames[0])
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[0], err)
+		_, _ = fmt.Fprintf(os.Stderr, "usage: %s session start [options] [path ...] | status | stop\n", os.Args[0])
+		return exitUsage
+	}
+	if options.HelpRequested {
+		printSessionUsage()
+		return 0
+	}
+	setupLogging(options)
+
+	dir, err := os.Getwd()
+	if err == nil {
+		err = startOrReportSession(action, dir, options, filenames, args[1:])
+	}
+	if err != nil {
+		logError("session failed", err)
+		return exitFailed
+	}
+	return 0
+}
+
+// startOrReportSession carries out a session action in the working directory
+// dir, with the options and filenames parsed from args
+func startOrReportSession(action, dir string, options cliOptions, filenames, args []string) error {
+	path, err := sessionPath(options.CacheDir, dir)
+	if err != nil {
+		return err
+	}
+	if action == "start" {
+		words := sessionWords(options, filenames)
+		if err := writeJSONFile(path, sessionState{Dir: dir, Started: time.Now(), Args: args, Words: words}); err != nil {
+			return fmt.Errorf("error saving the session: %w", err)
+		}
+		var total int64
+		for _, count := range words {
+			total += count
+		}
+		fmt.Printf("Session started with %d words in %d files\n", total, len(words))
+		return nil
+	}
+
+	var state sessionState
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return errors.New("no session started in this directory (use mwc session start)")
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &state)
+	}
+	if err != nil {
+		return fmt.Errorf("error reading the session: %w", err)
+	}
+	startOptions, filenames, err := parseSessionArgs(state.Args)
+	if err != nil {
+		return fmt.Errorf("error reading the session: %w", err)
+	}
+	printSessionChanges(state, sessionWords(startOptions, filenames), time.Now())
+	if action == "stop" {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("error ending the session: %w", err)
+		}
+	}
+	return nil
+}
+
+// parseSessionArgs parses the arguments of a session action, which walks
+// directories like mwc -r
+func parseSessionArgs(args []string) (cliOptions, []string, error) {
+	return parseArgs(append([]string{"-r"}, args...))
+}
+
+// sessionPath returns the file a session in the working directory dir is
+// kept in, in the cache directory
+func sessionPath(cacheDir, dir string) (string, error) {
+	if cacheDir == "" {
+		var err error
+		if cacheDir, err = defaultCacheDir(); err != nil {
+			return "", fmt.Errorf("no directory for the session: %w", err)
+		}
+	}
+	return filepath.Join(cacheDir, strings.TrimSuffix(cacheKey(dir), ".json")+".session.json"), nil
+}
+
+// sessionWords counts the words in each file under the paths, or the current
+// directory, as mwc -w would. Files that can't be counted are reported and
+// left out.
+func sessionWords(options cliOptions, filenames []string) map[string]int64 {
+	options.CountOptions = wordcount.CountOptions{Order: []string{"words"}, BufferSize: options.BufferSize}.Normalize()
+	if len(filenames) == 0 {
+		filenames = []string{"."}
+	}
+	var fsys fs.FS = osFS{}
+	if options.FSRoot != "" {
+		fsys = os.DirFS(options.FSRoot)
+	}
+	words := map[string]int64{}
+	walker := newWalker(fsys, options)
+	for _, filename := range filenames {
+		walker.expand(filename, func(name string) {
+			counts, _, err := countNamedFile(context.Background(), fsys, name, options)
+			if err != nil {
+				printFileError(err)
+				return
+			}
+			words[name] = counts.Words
+		}, printFileError)
+	}
+	return words
+}
+
+// printSessionChanges prints the change in words of each file that changed
+// since the session started, then the total change, and a summary of the
+// words added and removed
+func printSessionChanges(state sessionState, words map[string]int64, now time.Time) {
+	names := make([]string, 0, len(words))
+	for name := range words {
+		names = append(names, name)
+	}
+	for name := range state.Words {
+		if _, ok := words[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var added, removed, total int64
+	for _, name := range names {
+		change := words[name] - state.Words[name]
+		if change == 0 {
+			continue
+		}
+		if change > 0 {
+			added += change
+		} else {
+			removed -= change
+		}
+		fmt.Printf("%+8d %s\n", change, name)
+	}
+	for _, count := range words {
+		total += count
+	}
+	fmt.Printf("%+8d total\n", added-removed)
+	fmt.Printf("%d words added and %d removed in %v, %d words in all\n",
+		added, removed, now.Sub(state.Started).Round(time.Second), total)
+}
+
+func printSessionUsage() {
+	fmt.Println("Usage: mwc session start [options] [path ...]")
+	fmt.Println("       mwc session status")
+	fmt.Println("       mwc session stop")
+	fmt.Println("Track the words written in a sprint. start records the words in each file")
+	fmt.Println("under the paths, or the current directory; status prints the words added")
+	fmt.Println("and removed in each file since, and stop prints them and ends the session.")
+	fmt.Println("\nstart takes the options of mwc -r, such as --include, to choose the files;")
+	fmt.Println("see mwc --help. Sessions are kept per directory in --cache DIR, or the")
+	fmt.Println("user cache directory.")
+}
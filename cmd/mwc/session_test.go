@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSession checks that a session reports the words added and removed in
+// each file since it started, until it is stopped
+func TestSession(t *testing.T) {
+	dir := t.TempDir()
+	cache := filepath.Join(dir, "cache")
+	book := filepath.Join(dir, "book")
+	writeTree(t, book, map[string]string{
+		"01.md":    "It was a dark and stormy night.\n",
+		"02.md":    "The end.\n",
+		"notes.md": "remember the dog\n",
+		"todo.txt": "not counted\n",
+	})
+	chdir(t, book)
+
+	var status int
+	stdout, stderr := captureFunc(t, func() { status = session([]string{"start", "--cache", cache, "--include", "*.md"}) })
+	if status != 0 || stdout != "Session started with 12 words in 3 files\n" {
+		t.Fatalf("Expected the session to start, got %d: %q %q", status, stdout, stderr)
+	}
+
+	writeTree(t, book, map[string]string{
+		"01.md":    "It was a dark and stormy night. The rain fell in torrents.\n",
+		"03.md":    "Epilogue.\n",
+		"todo.txt": "still not counted at all\n",
+	})
+	if err := os.Remove(filepath.Join(book, "notes.md")); err != nil {
+		t.Fatalf("Failed to remove file: %v", err)
+	}
+	stdout, _ = captureFunc(t, func() { status = session([]string{"status", "--cache", cache}) })
+	expected := "      +5 01.md\n      +1 03.md\n      -3 notes.md\n      +3 total\n6 words added and 3 removed in "
+	if status != 0 || !strings.HasPrefix(stdout, expected) || !strings.HasSuffix(stdout, ", 15 words in all\n") {
+		t.Errorf("Expected:\n%s...\ngot:\n%s", expected, stdout)
+	}
+
+	stdout, _ = captureFunc(t, func() { status = session([]string{"stop", "--cache", cache}) })
+	if status != 0 || !strings.HasPrefix(stdout, expected) {
+		t.Errorf("Expected stop to report the session, got:\n%s", stdout)
+	}
+	_, stderr = captureFunc(t, func() { status = session([]string{"status", "--cache", cache}) })
+	if status != exitFailed || !strings.Contains(stderr, "no session started in this directory") {
+		t.Errorf("Expected the session to be over, got %d: %q", status, stderr)
+	}
+}
+
+// TestPrintSessionChanges tests the summary of a session
+func TestPrintSessionChanges(t *testing.T) {
+	started := time.Date(2024, 11, 1, 9, 0, 0, 0, time.UTC)
+	state := sessionState{Started: started, Words: map[string]int64{"a.md": 100, "b.md": 50}}
+	stdout, _ := captureFunc(t, func() {
+		printSessionChanges(state, map[string]int64{"a.md": 100, "b.md": 50}, started.Add(90*time.Second))
+	})
+	if expected := "      +0 total\n0 words added and 0 removed in 1m30s, 150 words in all\n"; stdout != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout)
+	}
+}
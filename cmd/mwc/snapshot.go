@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// snapshot is the JSON document written by --save-snapshot: the names of the
+// counts taken, the counts of each input by name, and their total. A baseline
+// for --check may also have the tolerances it is checked with, as --tolerance
+// values.
+type snapshot struct {
+	Tolerances []string                    `json:"tolerances,omitempty"`
+	Counts     []string                    `json:"counts,omitempty"` // as in CountOptions.Order; missing from older snapshots
+	Files      map[string]wordcount.Counts `json:"files"`
+	Total      wordcount.Counts            `json:"total"`
+}
+
+// tolerance is how far a count may drift from a snapshot without failing
+// --check-against: by Amount, or by Percent of the count in the snapshot
+type tolerance struct {
+	Count   string // the count it applies to, or "" for those without their own
+	Amount  int64
+	Percent float64
+}
+
+// parseTolerance parses a --tolerance value, [COUNT=]AMOUNT, where AMOUNT is
+// a number, a size such as 2KB, or a percentage such as 5%
+func parseTolerance(value string) (tolerance, error) {
+	var t tolerance
+	amount := value
+	if count, rest, found := strings.Cut(value, "="); found {
+		t.Count, amount = count, rest
+	}
+	if percent, isPercent := strings.CutSuffix(amount, "%"); isPercent {
+		p, err := strconv.ParseFloat(percent, 64)
+		if err != nil || !(p >= 0) || math.IsInf(p, 0) {
+			return tolerance{}, fmt.Errorf("invalid percentage %q", amount)
+		}
+		t.Percent = p
+		return t, nil
+	}
+	size, err := parseSize(amount)
+	if err != nil {
+		return tolerance{}, err
+	}
+	t.Amount = size
+	return t, nil
+}
+
+// allows reports whether a count may change from old to new under the
+// tolerances, the last one given for the count or, failing that, for all
+// counts. Without one, any change is drift.
+func allows(tolerances []tolerance, count string, oldCount, newCount int64) bool {
+	var general, own *tolerance
+	for i := range tolerances {
+		switch tolerances[i].Count {
+		case count:
+			own = &tolerances[i]
+		case "":
+			general = &tolerances[i]
+		}
+	}
+	if own == nil {
+		own = general
+	}
+	change := max(newCount-oldCount, oldCount-newCount)
+	switch {
+	case own == nil:
+		return change == 0
+	case own.Percent > 0:
+		return float64(change) <= own.Percent/100*math.Abs(float64(oldCount))
+	}
+	return change <= own.Amount
+}
+
+// newSnapshot returns the snapshot of the counts of a run
+func newSnapshot(report runReport, options cliOptions) snapshot {
+	s := snapshot{Counts: options.Order, Files: map[string]wordcount.Counts{}, Total: report.Total}
+	for i, name := range report.names {
+		s.Files[name] = report.Files[i].Counts
+	}
+	return s
+}
+
+// readSnapshot reads a snapshot written by --save-snapshot
+func readSnapshot(path string) (snapshot, error) {
+	var s snapshot
+	data, err := os.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(data, &s)
+	}
+	if err != nil {
+		return snapshot{}, fmt.Errorf("error reading the snapshot: %w", err)
+	}
+	return s, nil
+}
+
+// snapshotTolerances returns the tolerances of a snapshot, followed by those
+// given with --tolerance, which take precedence
+func snapshotTolerances(s snapshot, options cliOptions) ([]tolerance, error) {
+	var tolerances []tolerance
+	for _, value := range s.Tolerances {
+		t, err := parseTolerance(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tolerance in the snapshot: '%s'", value)
+		}
+		tolerances = append(tolerances, t)
+	}
+	return append(tolerances, options.Tolerances...), nil
+}
+
+// updateBaseline saves the counts of a run as the --check baseline, keeping
+// the tolerances of the one it replaces
+func updateBaseline(path string, report runReport, options cliOptions) error {
+	s := newSnapshot(report, options)
+	if old, err := readSnapshot(path); err == nil {
+		s.Tolerances = old.Tolerances
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return writeSnapshot(path, s)
+}
+
+// writeSnapshot writes a snapshot to path, indented, so that changes to it
+// are easy to review when it is committed
+func writeSnapshot(path string, s snapshot) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// checkSnapshot reports on stderr how the counts of each input that changed
+// since the baseline snapshot, and their total, changed, warning of those
+// that drifted by more than the --tolerance options allow. Only the counts
+// taken for the snapshot as well as now are compared. It returns the inputs
+// that drifted, with the total as an input without a name.
+func checkSnapshot(baseline, current snapshot, options cliOptions) []budgetViolation {
+	if baseline.Counts != nil {
+		options.Order = slices.DeleteFunc(slices.Clone(options.Order), func(count string) bool {
+			return !slices.Contains(baseline.Counts, count)
+		})
+		if len(options.Order) == 0 {
+			logEvent(slog.LevelWarn, fmt.Sprintf("%s: %s has none of the counts being taken, so nothing was compared", os.Args[0], options.CheckAgainst),
+				"snapshot has no common counts", "snapshot", options.CheckAgainst, "counts", strings.Join(baseline.Counts, ","))
+			return nil
+		}
+	}
+	var names []string
+	for name := range baseline.Files {
+		names = append(names, name)
+	}
+	for name := range current.Files {
+		if _, ok := baseline.Files[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var drifted []budgetViolation
+	// check reports how the counts of an input changed, and whether they drifted
+	check := func(name, note string, oldCounts, newCounts wordcount.Counts) bool {
+		if !countsChanged(oldCounts, newCounts, options) {
+			return false
+		}
+		level, verdict := slog.LevelInfo, "within tolerance"
+		for _, count := range options.Order {
+			oldCount, _ := oldCounts.Get(count)
+			newCount, _ := newCounts.Get(count)
+			if !allows(options.Tolerances, count, oldCount, newCount) {
+				level, verdict = slog.LevelWarn, "drifted"
+				break
+			}
+		}
+		change := describeChange(oldCounts, newCounts, options)
+		logEvent(level, fmt.Sprintf("%s: %s%s: %s, %s", os.Args[0], name, note, change, verdict),
+			"snapshot change", "file", name, "change", change, "drifted", level == slog.LevelWarn)
+		return level == slog.LevelWarn
+	}
+	violation := func(oldCounts, newCounts wordcount.Counts) string {
+		return fmt.Sprintf("%s, beyond the tolerances of %s", describeChange(oldCounts, newCounts, options), options.CheckAgainst)
+	}
+	for _, name := range names {
+		oldCounts, inBaseline := baseline.Files[name]
+		newCounts, inCurrent := current.Files[name]
+		note := ""
+		switch {
+		case !inBaseline:
+			note = " (added)"
+		case !inCurrent:
+			note = " (removed)"
+		}
+		if check(name, note, oldCounts, newCounts) {
+			drifted = append(drifted, budgetViolation{File: name, Message: violation(oldCounts, newCounts)})
+		}
+	}
+	if check("total", "", baseline.Total, current.Total) {
+		drifted = append(drifted, budgetViolation{Message: "total " + violation(baseline.Total, current.Total)})
+	}
+	return drifted
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestAllows tests the tolerances counts may drift by
+func TestAllows(t *testing.T) {
+	parse := func(values ...string) []tolerance {
+		var tolerances []tolerance
+		for _, value := range values {
+			tol, err := parseTolerance(value)
+			if err != nil {
+				t.Fatalf("Error parsing tolerance %q: %v", value, err)
+			}
+			tolerances = append(tolerances, tol)
+		}
+		return tolerances
+	}
+	tests := []struct {
+		name       string
+		tolerances []string
+		count      string
+		old, new   int64
+		expected   bool
+	}{
+		{"no change", nil, "words", 100, 100, true},
+		{"no tolerance", nil, "words", 100, 101, false},
+		{"amount", []string{"5"}, "lines", 100, 95, true},
+		{"over amount", []string{"5"}, "lines", 100, 106, false},
+		{"percent", []string{"5%"}, "words", 200, 210, true},
+		{"over percent", []string{"5%"}, "words", 200, 189, false},
+		{"size", []string{"bytes=2KB"}, "bytes", 10000, 12048, true},
+		{"own tolerance first", []string{"words=1", "50%"}, "words", 100, 102, false},
+		{"other count's tolerance", []string{"bytes=1K"}, "words", 100, 102, false},
+		{"last tolerance", []string{"1%", "10%"}, "words", 100, 110, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := allows(parse(tt.tolerances...), tt.count, tt.old, tt.new); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	for _, value := range []string{"", "words=", "-5%", "five", "x%"} {
+		if _, err := parseTolerance(value); err == nil {
+			t.Errorf("Expected %q to be rejected", value)
+		}
+	}
+}
+
+// TestCheckAgainst checks that a run is compared with a saved snapshot and
+// exits with exitBudget when a count drifts beyond the tolerances
+func TestCheckAgainst(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"docs/api.md":   "one two three four five six seven eight nine ten\n",
+		"docs/guide.md": "read me\n",
+	})
+	chdir(t, dir)
+
+	if _, stderr := captureOutput(t, []string{"-rw", "--save-snapshot", "baseline.json", "docs"}); stderr != "" {
+		t.Fatalf("Expected the snapshot to be saved, got %q", stderr)
+	}
+	// The snapshot is meant to be committed, like a baseline
+	if info, err := os.Stat("baseline.json"); err != nil || info.Mode().Perm() != 0o644 {
+		t.Errorf("Expected a snapshot readable by all, got %v, %v", info, err)
+	}
+	if data, _ := os.ReadFile("baseline.json"); !strings.HasPrefix(string(data), "{\n  \"counts\"") || !strings.HasSuffix(string(data), "}\n") {
+		t.Errorf("Expected an indented snapshot ending in a newline, got:\n%s", data)
+	}
+
+	// Counts that weren't taken for the snapshot aren't compared
+	options, filenames, err := parseArgs([]string{"-r", "--check-against", "baseline.json", "docs"})
+	if err != nil {
+		t.Fatalf("Error parsing arguments: %v", err)
+	}
+	var status int
+	if _, stderr := captureFunc(t, func() { status = run(options, filenames) }); status != 0 || stderr != "" {
+		t.Errorf("Expected no drift with other counts, got status %d and %q", status, stderr)
+	}
+	if _, stderr := captureOutput(t, []string{"-rl", "--check-against", "baseline.json", "docs"}); !strings.Contains(stderr, "none of the counts") {
+		t.Errorf("Expected a warning that no counts were compared, got %q", stderr)
+	}
+
+	writeTree(t, dir, map[string]string{
+		"docs/api.md": "one two three four five six seven eight nine ten eleven\n",
+		"docs/new.md": "hello\n",
+	})
+	tests := []struct {
+		name       string
+		tolerances []string
+		status     int
+		expected   []string
+	}{
+		{"total drifted", []string{"--tolerance", "words=1"}, exitBudget, []string{
+			"docs/api.md: +1 words, within tolerance",
+			"docs/new.md (added): +1 words, within tolerance",
+			"total: +2 words, drifted",
+			"1 count drifted from baseline.json beyond the tolerances",
+		}},
+		{"within", []string{"--tolerance", "2"}, 0, []string{
+			"total: +2 words, within tolerance",
+		}},
+		{"drifted", nil, exitBudget, []string{
+			"docs/api.md: +1 words, drifted",
+			"3 counts drifted from baseline.json beyond the tolerances",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options, filenames, err := parseArgs(append([]string{"-rw", "--check-against", "baseline.json", "docs"}, tt.tolerances...))
+			if err != nil {
+				t.Fatalf("Error parsing arguments: %v", err)
+			}
+			var status int
+			_, stderr := captureFunc(t, func() { status = run(options, filenames) })
+			for _, line := range tt.expected {
+				if !strings.Contains(stderr, line) {
+					t.Errorf("Expected %q in:\n%s", line, stderr)
+				}
+			}
+			if status != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, status)
+			}
+		})
+	}
+
+	if _, _, err := parseArgs([]string{"--tolerance", "5%", "docs"}); err == nil {
+		t.Errorf("Expected --tolerance without --check-against to be rejected")
+	}
+}
+
+// TestCheckBaseline checks that --update-baseline saves a baseline keeping
+// its tolerances, and that --check applies them
+func TestCheckBaseline(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"docs/a.md": "one two three four five six seven eight nine ten\n"})
+	chdir(t, dir)
+	check := func(args ...string) (int, string) {
+		t.Helper()
+		options, filenames, err := parseArgs(append([]string{"-w", "--check", ".mwc-baseline.json"}, args...))
+		if err != nil {
+			t.Fatalf("Error parsing arguments: %v", err)
+		}
+		var status int
+		_, stderr := captureFunc(t, func() { status = run(options, filenames) })
+		return status, stderr
+	}
+
+	if status, stderr := check("--update-baseline", "docs/a.md"); status != 0 {
+		t.Fatalf("Expected the baseline to be saved, got status %d and %q", status, stderr)
+	}
+	data, err := os.ReadFile(".mwc-baseline.json")
+	if err != nil {
+		t.Fatalf("Expected a baseline: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "{\n  \"counts\": [\n    \"words\"\n  ],\n  \"files\": {\n    \"docs/a.md\": {\n") || !strings.Contains(string(data), "\"words\": 10,\n") {
+		t.Errorf("Expected an indented baseline, got:\n%s", data)
+	}
+
+	// Tolerances added to the baseline are kept when it is updated
+	withTolerances := strings.Replace(string(data), "{\n", "{\n  \"tolerances\": [\"words=10%\"],\n", 1)
+	writeTree(t, dir, map[string]string{".mwc-baseline.json": withTolerances})
+	if status, _ := check("--update-baseline", "docs/a.md"); status != 0 {
+		t.Fatalf("Expected the baseline to be updated, got status %d", status)
+	}
+	if data, _ := os.ReadFile(".mwc-baseline.json"); !strings.Contains(string(data), "\"tolerances\": [\n    \"words=10%\"\n  ]") {
+		t.Errorf("Expected the tolerances to be kept, got:\n%s", data)
+	}
+
+	writeTree(t, dir, map[string]string{"docs/a.md": "one two three four five six seven eight nine ten eleven\n"})
+	if status, stderr := check("docs/a.md"); status != 0 {
+		t.Errorf("Expected a change within the baseline's tolerance to pass, got status %d and %q", status, stderr)
+	}
+	if status, _ := check("--tolerance", "words=0", "docs/a.md"); status != exitBudget {
+		t.Errorf("Expected --tolerance to take precedence over the baseline's, got status %d", status)
+	}
+	writeTree(t, dir, map[string]string{"docs/a.md": "one two three four five six seven eight nine ten eleven twelve\n"})
+	if status, _ := check("docs/a.md"); status != exitBudget {
+		t.Errorf("Expected a change beyond the baseline's tolerance to drift, got status %d", status)
+	}
+
+	// A baseline saved with -w is checked with the default counts by words alone
+	options, filenames, err := parseArgs([]string{"--check", ".mwc-baseline.json", "docs/a.md"})
+	if err != nil {
+		t.Fatalf("Error parsing arguments: %v", err)
+	}
+	var status int
+	_, stderr := captureFunc(t, func() { status = run(options, filenames) })
+	if status != exitBudget || !strings.Contains(stderr, "docs/a.md: +2 words, drifted") || strings.Contains(stderr, "lines") {
+		t.Errorf("Expected only words to be compared, got status %d and %q", status, stderr)
+	}
+	writeTree(t, dir, map[string]string{"docs/a.md": "one two three four five six seven eight nine ten\n"})
+	if _, stderr := captureFunc(t, func() { status = run(options, filenames) }); status != 0 {
+		t.Errorf("Expected no drift with the default counts, got status %d and %q", status, stderr)
+	}
+
+	if _, _, err := parseArgs([]string{"--update-baseline", "docs"}); err == nil {
+		t.Errorf("Expected --update-baseline without --check to be rejected")
+	}
+}
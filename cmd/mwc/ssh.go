@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strings"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// remoteFile is a file on a host reachable over SSH
+type remoteFile struct {
+	host string // [user@]host, as passed to ssh
+	port string // empty for ssh's default
+	path string // absolute, or relative to the remote home directory
+}
+
+// parseRemotePath recognizes remote inputs written as in scp, [user@]host:path,
+// or as sftp://[user@]host[:port]/path URLs, where a path starting with /~/ is
+// relative to the home directory. As with scp, a colon after a slash, as in
+// ./a:b, names a local file.
+func parseRemotePath(name string) (remoteFile, bool) {
+	if rest, isURL := strings.CutPrefix(name, "sftp://"); isURL {
+		u, err := url.Parse("sftp://" + rest)
+		if err != nil || u.Hostname() == "" || u.Path == "" || u.Path == "/" {
+			return remoteFile{}, false
+		}
+		host := u.Hostname()
+		if u.User != nil {
+			host = u.User.Username() + "@" + host
+		}
+		path, inHome := strings.CutPrefix(u.Path, "/~/")
+		if !inHome {
+			path = u.Path
+		}
+		return remoteFile{host: host, port: u.Port(), path: path}, true
+	}
+
+	host, path, found := strings.Cut(name, ":")
+	// A single letter before the colon is a Windows drive, such as C:\notes.txt,
+	// and other URLs aren't SSH hosts
+	if !found || len(host) < 2 || path == "" || strings.ContainsAny(host, "/\\") || strings.HasPrefix(path, "//") {
+		return remoteFile{}, false
+	}
+	if strings.HasPrefix(path, "~/") {
+		path = path[2:]
+	}
+	return remoteFile{host: host, path: path}, true
+}
+
+// countRemote counts a remote file streamed over SSH by running cat on the
+// host, so nothing is copied to local disk. ssh is run without prompting, so
+// hosts need key-based authentication, such as from ssh-agent or ~/.ssh/config.
+// Failures are returned as a *wordcount.FileError for name.
+func countRemote(name string, file remoteFile, options wordcount.CountOptions) (wordcount.Counts, error) {
+	args := []string{"-o", "BatchMode=yes"}
+	if file.port != "" {
+		args = append(args, "-p", file.port)
+	}
+	// The remote shell parses the command, so the path is quoted for it
+	args = append(args, "--", file.host, "cat -- "+shellQuote(file.path))
+	cmd := exec.Command("ssh", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err == nil {
+		err = cmd.Start()
+	}
+	if err != nil {
+		return wordcount.Counts{}, &wordcount.FileError{Op: "open", Path: name, Err: err}
+	}
+	counts, countErr := wordcount.Count(stdout, options)
+	if countErr != nil {
+		_ = cmd.Process.Kill()
+	}
+	waitErr := cmd.Wait()
+	if countErr != nil {
+		return wordcount.Counts{}, &wordcount.FileError{Op: "count", Path: name, Err: countErr}
+	}
+	if waitErr != nil {
+		// Most failures are the remote file failing to open
+		err := fmt.Errorf("ssh %s: %v", file.host, waitErr)
+		var exitErr *exec.ExitError
+		if errors.As(waitErr, &exitErr) && stderr.Len() > 0 {
+			// The last line says why, such as "cat: app.log: No such file or directory"
+			lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+			err = fmt.Errorf("ssh %s: %s", file.host, strings.TrimSpace(lines[len(lines)-1]))
+		}
+		return wordcount.Counts{}, &wordcount.FileError{Op: "open", Path: name, Err: err}
+	}
+	return counts, nil
+}
+
+// shellQuote quotes a string for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
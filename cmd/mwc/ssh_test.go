@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// TestParseRemotePath tests recognizing remote inputs
+func TestParseRemotePath(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected remoteFile
+		remote   bool
+	}{
+		{"ops@web1:/var/log/app.log", remoteFile{host: "ops@web1", path: "/var/log/app.log"}, true},
+		{"web1:app.log", remoteFile{host: "web1", path: "app.log"}, true},
+		{"web1:~/app.log", remoteFile{host: "web1", path: "app.log"}, true},
+		{"sftp://ops@web1:2222/var/log/app.log", remoteFile{host: "ops@web1", port: "2222", path: "/var/log/app.log"}, true},
+		{"sftp://web1/~/logs/app.log", remoteFile{host: "web1", path: "logs/app.log"}, true},
+		{"sftp://web1/", remoteFile{}, false},
+		{"./a:b.txt", remoteFile{}, false},
+		{"dir/a:b.txt", remoteFile{}, false},
+		{`C:\notes.txt`, remoteFile{}, false},
+		{"web1:", remoteFile{}, false},
+		{"https://example.com/a.txt", remoteFile{}, false},
+		{"notes.txt", remoteFile{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, remote := parseRemotePath(tt.name)
+			if got != tt.expected || remote != tt.remote {
+				t.Errorf("Expected %+v, %v, got %+v, %v", tt.expected, tt.remote, got, remote)
+			}
+		})
+	}
+}
+
+// TestCountRemote counts remote files with a fake ssh that runs the remote command locally
+func TestCountRemote(t *testing.T) {
+	bin := t.TempDir()
+	home := t.TempDir()
+	script := `#!/bin/sh
+while [ "$1" != "--" ]; do echo "$1" >> "$HOME/args"; shift; done
+echo "$2" >> "$HOME/args"
+cd "$HOME" && exec sh -c "$3"
+`
+	if err := os.WriteFile(filepath.Join(bin, "ssh"), []byte(script), 0o755); err != nil {
+		t.Fatalf("Error writing fake ssh: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(home, "it's.log"), []byte("one two\nthree\n"), 0o644); err != nil {
+		t.Fatalf("Error writing remote file: %v", err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("HOME", home)
+
+	options := wordcount.CountOptions{LineCount: true, WordCount: true}
+	file, _ := parseRemotePath("sftp://ops@web1:2222/~/it's.log")
+	counts, err := countRemote("web1", file, options)
+	if err != nil || !counts.Equal(wordcount.Counts{Lines: 2, Words: 3}) {
+		t.Errorf("Expected 2 lines and 3 words, got %+v, %v", counts, err)
+	}
+	args, _ := os.ReadFile(filepath.Join(home, "args"))
+	if expected := "-o\nBatchMode=yes\n-p\n2222\nops@web1\n"; string(args) != expected {
+		t.Errorf("Expected ssh arguments %q, got %q", expected, args)
+	}
+
+	_, err = countRemote("web1:missing.log", remoteFile{host: "web1", path: "missing.log"}, options)
+	fileErr, ok := err.(*wordcount.FileError)
+	if !ok || fileErr.Op != "open" || !strings.Contains(err.Error(), "missing.log: No such file or directory") {
+		t.Errorf("Expected an open error for the missing file, got %v", err)
+	}
+}
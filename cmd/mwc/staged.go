@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// stagedFiles returns the files added, copied, modified or renamed in the git
+// index, relative to the top of the repository, limited to the pathspecs given
+// and to the --include and --exclude patterns. Deleted files have nothing left
+// to count.
+func stagedFiles(pathspecs []string, options cliOptions) ([]string, error) {
+	out, err := runGit(append([]string{"diff", "--cached", "--name-only", "-z", "--diff-filter=ACMR", "--"}, pathspecs...)...)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, name := range strings.Split(string(out), "\x00") {
+		if name == "" || matchFilters(options.Exclude, name) ||
+			len(options.Include) > 0 && !matchFilters(options.Include, name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// countStaged counts a file as it is staged in the git index, which may
+// differ from the file in the working tree. Failures are returned as a
+// *wordcount.FileError.
+func countStaged(ctx context.Context, name string, options cliOptions) (wordcount.Counts, error) {
+	// A path after ":" is relative to the top of the repository
+	data, err := runGit("show", ":"+name)
+	if err != nil {
+		return wordcount.Counts{}, &wordcount.FileError{Op: "open", Path: name, Err: err}
+	}
+	counts, err := wordcount.CountContext(ctx, transformInput(bytes.NewReader(data), options), options.CountOptions)
+	if err != nil {
+		return counts, &wordcount.FileError{Op: "read", Path: name, Err: err}
+	}
+	return counts, nil
+}
+
+// checkWordLimit reports each file of a run with more words than the
+// --max-words-per-file limit, and returns them
+func checkWordLimit(report runReport, limit int64) []budgetViolation {
+	var over []budgetViolation
+	for i, file := range report.Files {
+		if file.Counts.Words <= limit {
+			continue
+		}
+		message := fmt.Sprintf("%d words, %d over the --max-words-per-file limit of %d", file.Counts.Words, file.Counts.Words-limit, limit)
+		over = append(over, budgetViolation{File: report.names[i], Message: message})
+		logEvent(slog.LevelError, fmt.Sprintf("%s: %s has %s", os.Args[0], report.names[i], message),
+			"file over the word limit", "file", report.names[i], "words", file.Counts.Words, "limit", limit)
+	}
+	return over
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+// TestStaged checks that --staged counts the files staged in git as they are
+// staged, and that --max-words-per-file fails naming the files over the limit
+func TestStaged(t *testing.T) {
+	chdir(t, t.TempDir())
+	initGitRepo(t)
+	writeTree(t, ".", map[string]string{
+		"docs/short.md": "one two\n",
+		"docs/long.md":  "one two three four five\n",
+		"notes.txt":     "just some notes\n",
+		"gone.md":       "soon deleted\n",
+	})
+	git(t, "add", ".")
+	git(t, "commit", "-q", "-m", "first")
+	writeTree(t, ".", map[string]string{
+		"docs/short.md": "one two three\n",
+		"docs/long.md":  "one two three four five six\n",
+		"notes.txt":     "more notes\n",
+		"new.md":        "a new file\n",
+	})
+	git(t, "add", "docs/short.md", "docs/long.md", "new.md")
+	git(t, "rm", "-q", "gone.md")
+	// Unstaged changes aren't counted
+	writeTree(t, ".", map[string]string{"docs/short.md": "one two three and a lot more\n"})
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	os.Args = []string{"mwc"}
+
+	tests := []struct {
+		name           string
+		args           []string
+		expectedOut    string
+		expectedErr    string
+		expectedStatus int
+	}{
+		{"Staged", []string{"-w", "--staged"},
+			"       6 docs/long.md\n       3 docs/short.md\n       3 new.md\n      12 total\n", "", 0},
+		{"Pathspec", []string{"-w", "--staged", "docs"}, "       6 docs/long.md\n       3 docs/short.md\n       9 total\n", "", 0},
+		{"Include", []string{"-w", "--staged", "--include", "short.md"}, "       3 docs/short.md\n", "", 0},
+		{"Under Limit", []string{"-w", "--staged", "--max-words-per-file", "6"},
+			"       6 docs/long.md\n       3 docs/short.md\n       3 new.md\n      12 total\n", "", 0},
+		{"Over Limit", []string{"-l", "--staged", "--max-words-per-file", "3"},
+			"       1 docs/long.md\n       1 docs/short.md\n       1 new.md\n       3 total\n",
+			"mwc: docs/long.md has 6 words, 3 over the --max-words-per-file limit of 3\n", exitBudget},
+		{"Files Over Limit", []string{"-w", "--max-words-per-file", "2", "notes.txt", "docs/short.md"},
+			"       2 notes.txt\n       7 docs/short.md\n       9 total\n",
+			"mwc: docs/short.md has 7 words, 5 over the --max-words-per-file limit of 2\n", exitBudget},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options, filenames, err := parseArgs(tt.args)
+			if err != nil {
+				t.Fatalf("Error parsing arguments: %v", err)
+			}
+			var status int
+			stdout, stderr := captureFunc(t, func() { status = run(options, filenames) })
+			if stdout != tt.expectedOut {
+				t.Errorf("Expected:\n%q\ngot:\n%q", tt.expectedOut, stdout)
+			}
+			if stderr != tt.expectedErr {
+				t.Errorf("Expected error %q, got %q", tt.expectedErr, stderr)
+			}
+			if status != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, status)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// runStatus keeps the counts accumulated so far during a run, so they can be
+// reported on request while it goes on: the sum of the inputs counted, plus
+// what has been read of those still being counted.
+type runStatus struct {
+	mu       sync.Mutex
+	start    time.Time
+	inputs   int
+	done     wordcount.Counts
+	counting map[string]wordcount.Counts
+}
+
+// newRunStatus starts keeping the counts of a run
+func newRunStatus() *runStatus {
+	return &runStatus{start: time.Now(), counting: map[string]wordcount.Counts{}}
+}
+
+// track returns the hooks keeping the counts of an input up to date while it
+// is counted
+func (s *runStatus) track(name string) *wordcount.Hooks {
+	return &wordcount.Hooks{
+		OnProgress: func(_ string, counts wordcount.Counts) {
+			s.mu.Lock()
+			s.counting[name] = counts
+			s.mu.Unlock()
+		},
+	}
+}
+
+// finish records that an input has been counted, or has failed
+func (s *runStatus) finish(name string, counts wordcount.Counts, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.counting, name)
+	if err == nil {
+		s.inputs++
+		s.done.Add(counts)
+	}
+}
+
+// current returns the counts of an input still being counted, as far as it
+// has been read
+func (s *runStatus) current(name string) wordcount.Counts {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.counting[name]
+}
+
+// print reports the counts so far on stderr
+func (s *runStatus) print(options cliOptions) {
+	s.mu.Lock()
+	counts := s.done
+	for _, partial := range s.counting {
+		counts.Add(partial)
+	}
+	inputs, counting := s.inputs, len(s.counting)
+	s.mu.Unlock()
+
+	elapsed := time.Since(s.start)
+	logEvent(slog.LevelInfo, fmt.Sprintf("%s: so far: %s in %d inputs counted and %d being counted, after %v",
+		os.Args[0], describeCounts(counts, options), inputs, counting, elapsed.Round(time.Second)),
+		"status", "counts", counts, "inputs", inputs, "counting", counting, "duration", elapsed)
+}
+
+// notifyStatus calls report whenever one of statusSignals is received, until
+// the returned function is called. Platforms without such signals never call it.
+func notifyStatus(report func()) func() {
+	if len(statusSignals) == 0 {
+		return func() {}
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, statusSignals...)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-signals:
+				report()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+		<-stopped
+	}
+}
+
+// joinHooks returns hooks calling each of the non-nil hooks in turn, or nil
+// if there are none
+func joinHooks(hooks ...*wordcount.Hooks) *wordcount.Hooks {
+	var joined []*wordcount.Hooks
+	for _, h := range hooks {
+		if h != nil {
+			joined = append(joined, h)
+		}
+	}
+	switch len(joined) {
+	case 0:
+		return nil
+	case 1:
+		return joined[0]
+	}
+	return &wordcount.Hooks{
+		OnFileStart: func(name string) {
+			for _, h := range joined {
+				if h.OnFileStart != nil {
+					h.OnFileStart(name)
+				}
+			}
+		},
+		OnProgress: func(name string, counts wordcount.Counts) {
+			for _, h := range joined {
+				if h.OnProgress != nil {
+					h.OnProgress(name, counts)
+				}
+			}
+		},
+		OnFileDone: func(name string, counts wordcount.Counts, err error) {
+			for _, h := range joined {
+				if h.OnFileDone != nil {
+					h.OnFileDone(name, counts, err)
+				}
+			}
+		},
+	}
+}
@@ -0,0 +1,10 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package main
+
+import "syscall"
+
+// Ctrl-T sends SIGINFO to the foreground process on the BSDs and macOS
+func init() {
+	statusSignals = append(statusSignals, syscall.SIGINFO)
+}
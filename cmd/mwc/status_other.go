@@ -0,0 +1,9 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// statusSignals is empty, since there are no signals asking for the counts
+// so far on this platform
+var statusSignals []os.Signal
@@ -0,0 +1,100 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// TestStatusSignal checks that SIGUSR1 prints the counts so far while the
+// run goes on
+func TestStatusSignal(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Error creating pipe: %v", err)
+	}
+	oldStdin := os.Stdin
+	defer func() { os.Stdin = oldStdin }()
+	os.Stdin = r
+
+	go func() {
+		_, _ = w.WriteString("one two\n")
+		// Leave time for the handler to be installed and the data counted
+		time.Sleep(100 * time.Millisecond)
+		_ = syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+		time.Sleep(100 * time.Millisecond)
+		_, _ = w.WriteString("three\n")
+		_ = w.Close()
+	}()
+	stdout, stderr := captureOutput(t, []string{"-lw"})
+	if stdout != "       2       3\n" {
+		t.Errorf("Expected the final counts, got %q", stdout)
+	}
+	if !strings.Contains(stderr, "mwc: so far: 1 lines, 2 words in 0 inputs counted and 1 being counted") {
+		t.Errorf("Expected the counts so far on stderr, got %q", stderr)
+	}
+}
+
+// TestInterrupt checks that Ctrl-C stops the run with the counts so far,
+// marked as partial, rather than losing them
+func TestInterrupt(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"a.txt": "one two three\n",
+		"c.txt": "never counted\n",
+	})
+	chdir(t, dir)
+	if err := syscall.Mkfifo("b.fifo", 0600); err != nil {
+		t.Skipf("Can't create a named pipe: %v", err)
+	}
+
+	go func() {
+		w, err := os.OpenFile("b.fifo", os.O_WRONLY, 0)
+		if err != nil {
+			t.Errorf("Error opening the pipe: %v", err)
+			return
+		}
+		defer w.Close()
+		_, _ = w.WriteString("four five\n")
+		time.Sleep(100 * time.Millisecond)
+		_ = syscall.Kill(os.Getpid(), syscall.SIGINT)
+		time.Sleep(100 * time.Millisecond)
+		_, _ = w.WriteString("six\n")
+	}()
+	options, filenames, err := parseArgs([]string{"-l", "a.txt", "b.fifo", "c.txt"})
+	if err != nil {
+		t.Fatalf("Error parsing arguments: %v", err)
+	}
+	var status int
+	stdout, stderr := captureFunc(t, func() { status = run(options, filenames) })
+	if status != exitInterrupted {
+		t.Errorf("Expected exit status %d, got %d", exitInterrupted, status)
+	}
+	if !strings.HasPrefix(stdout, "       1 a.txt\n") || !strings.Contains(stdout, " b.fifo (interrupted)\n") ||
+		!strings.Contains(stdout, " total (partial)\n") || strings.Contains(stdout, "c.txt") {
+		t.Errorf("Expected the counts until Ctrl-C with a partial total, got %q", stdout)
+	}
+	if !strings.Contains(stderr, "interrupted; the counts are partial") {
+		t.Errorf("Expected the interruption to be reported, got %q", stderr)
+	}
+}
+
+// TestStatusPrintMetrics checks that reporting the counts so far doesn't add
+// the inputs still being counted to those already counted
+func TestStatusPrintMetrics(t *testing.T) {
+	status := newRunStatus()
+	status.finish("a.txt", wordcount.Counts{Metrics: map[string]int64{"sentences": 5}}, nil)
+	status.track("b.txt").OnProgress("b.txt", wordcount.Counts{Metrics: map[string]int64{"sentences": 2}})
+	for i := 0; i < 3; i++ {
+		captureFunc(t, func() { status.print(cliOptions{}) })
+	}
+	if got := status.done.Metrics["sentences"]; got != 5 {
+		t.Errorf("Expected 5 sentences counted, got %d", got)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// statusSignals are the signals asking for the counts so far
+var statusSignals = []os.Signal{syscall.SIGUSR1}
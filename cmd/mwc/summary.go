@@ -0,0 +1,257 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+const (
+	// defaultPageWords is the words on a standard manuscript page
+	defaultPageWords = 250
+	// defaultReadingSpeed is the average silent reading speed of adults
+	// reading non-fiction, in words a minute
+	defaultReadingSpeed = 238
+)
+
+// textSummary holds the counts behind the report of mwc summary
+type textSummary struct {
+	Words      int64
+	Chars      int64
+	NonSpace   int64 // characters other than white space
+	Sentences  int64
+	Paragraphs int64
+	Syllables  int64 // syllables of the words, as estimated by syllables
+}
+
+// add counts a text into the summary, leaving out its front matter, which
+// isn't prose
+func (s *textSummary) add(text string) error {
+	text = stripFrontMatter(text)
+	counts, err := wordcount.Count(strings.NewReader(text), wordcount.CountOptions{
+		WordCount: true, CharacterCount: true, Metrics: []string{"sentences"},
+	})
+	if err != nil {
+		return err
+	}
+	s.Words += counts.Words
+	s.Chars += counts.Chars
+	sentences, _ := counts.Get("sentences")
+	s.Sentences += sentences
+
+	// Paragraphs are runs of lines that aren't blank
+	blank := true
+	for _, line := range strings.Split(text, "\n") {
+		empty := strings.TrimSpace(line) == ""
+		if blank && !empty {
+			s.Paragraphs++
+		}
+		blank = empty
+	}
+	for _, r := range text {
+		if !unicode.IsSpace(r) {
+			s.NonSpace++
+		}
+	}
+	for _, word := range strings.Fields(text) {
+		s.Syllables += syllables(word)
+	}
+	return nil
+}
+
+// syllables estimates the syllables of an English word as its groups of
+// vowels, less a silent final e. Words without letters, such as numbers and
+// dashes, have none.
+func syllables(word string) int64 {
+	word = strings.ToLower(strings.TrimFunc(word, func(r rune) bool { return !unicode.IsLetter(r) }))
+	if word == "" {
+		return 0
+	}
+	var count int64
+	vowel := false
+	for _, r := range word {
+		isVowel := strings.ContainsRune("aeiouy", r)
+		if isVowel && !vowel {
+			count++
+		}
+		vowel = isVowel
+	}
+	if count > 1 && strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "le") {
+		count--
+	}
+	return max(count, 1)
+}
+
+// readingEase returns the Flesch reading ease of the text, from 100 for the
+// easiest to read down to 0 for the hardest, and its Flesch-Kincaid grade
+// level, from 0 up. The formulas go beyond those ranges for very short or very
+// long sentences and words, so the scores are clamped to them. Both need at
+// least one sentence.
+func (s *textSummary) readingEase() (ease, grade float64, ok bool) {
+	if s.Words == 0 || s.Sentences == 0 {
+		return 0, 0, false
+	}
+	wordsPerSentence := float64(s.Words) / float64(s.Sentences)
+	syllablesPerWord := float64(s.Syllables) / float64(s.Words)
+	ease = 206.835 - 1.015*wordsPerSentence - 84.6*syllablesPerWord
+	grade = 0.39*wordsPerSentence + 11.8*syllablesPerWord - 15.59
+	return min(max(ease, 0), 100), max(grade, 0), true
+}
+
+// summaryFrontMatterLines bounds how far stripFrontMatter looks for the end of
+// front matter, as wordcount.ReadFrontMatter does
+const summaryFrontMatterLines = 200
+
+// stripFrontMatter returns a text without the YAML front matter at its start,
+// the block between "---" lines that wordcount.ReadFrontMatter reads, closed
+// by "---" or "...". A text without front matter is returned as it is.
+func stripFrontMatter(text string) string {
+	first, rest, found := strings.Cut(strings.TrimPrefix(text, "\ufeff"), "\n")
+	if !found || strings.TrimRight(first, " \t\r") != "---" {
+		return text
+	}
+	for i := 0; i < summaryFrontMatterLines && rest != ""; i++ {
+		var line string
+		line, rest, _ = strings.Cut(rest, "\n")
+		if line = strings.TrimRight(line, " \t\r"); line == "---" || line == "..." {
+			return rest
+		}
+	}
+	return text
+}
+
+// easeDescription describes a Flesch reading ease score in the usual bands
+func easeDescription(ease float64) string {
+	switch {
+	case ease >= 90:
+		return "very easy"
+	case ease >= 80:
+		return "easy"
+	case ease >= 70:
+		return "fairly easy"
+	case ease >= 60:
+		return "standard"
+	case ease >= 50:
+		return "fairly difficult"
+	case ease >= 30:
+		return "difficult"
+	}
+	return "very difficult"
+}
+
+// formatReadingTime formats the time it takes to read words at a speed in
+// words a minute, rounded up to the minute
+func formatReadingTime(words int64, speed int) string {
+	minutes := int64(math.Ceil(float64(words) / float64(speed)))
+	if minutes < 60 {
+		return fmt.Sprintf("%d min", minutes)
+	}
+	return fmt.Sprintf("%d h %d min", minutes/60, minutes%60)
+}
+
+// printSummary prints the report of mwc summary, one count to a line
+func printSummary(s textSummary, pageWords, readingSpeed int) {
+	row := func(label, value string) { fmt.Printf("%-24s%s\n", label+":", value) }
+	row("Words", strconv.FormatInt(s.Words, 10))
+	row("Characters", strconv.FormatInt(s.Chars, 10))
+	row("Characters (no spaces)", strconv.FormatInt(s.NonSpace, 10))
+	row("Sentences", strconv.FormatInt(s.Sentences, 10))
+	row("Paragraphs", strconv.FormatInt(s.Paragraphs, 10))
+	row("Pages", fmt.Sprintf("%.1f (%d words a page)", float64(s.Words)/float64(pageWords), pageWords))
+	row("Reading time", fmt.Sprintf("%s (%d words a minute)", formatReadingTime(s.Words, readingSpeed), readingSpeed))
+	if ease, grade, ok := s.readingEase(); ok {
+		row("Reading ease", fmt.Sprintf("%.1f (%s)", ease, easeDescription(ease)))
+		row("Grade level", fmt.Sprintf("%.1f", grade))
+	}
+}
+
+// summary implements mwc summary, which prints the counts a word processor
+// shows for a document, of stdin or of all the files under the paths
+// together. It returns the exit status.
+func summary(args []string) int {
+	values, args, err := cutValueOptions(args, "page-words", "wpm")
+	pageWords, readingSpeed := defaultPageWords, defaultReadingSpeed
+	for _, setting := range []struct {
+		name  string
+		value *int
+	}{{"page-words", &pageWords}, {"wpm", &readingSpeed}} {
+		if err != nil || values[setting.name] == "" {
+			continue
+		}
+		n, parseErr := strconv.Atoi(values[setting.name])
+		if parseErr != nil || n <= 0 {
+			err = optionError("--"+setting.name, "invalid number for --%s: '%s'", setting.name, values[setting.name])
+			continue
+		}
+		*setting.value = n
+	}
+	var options cliOptions
+	var filenames []string
+	if err == nil {
+		options, filenames, err = parseArgs(append([]string{"-r"}, args...))
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[0], err)
+		_, _ = fmt.Fprintf(os.Stderr, "usage: %s summary [--page-words N] [--wpm N] [path ...]\n", os.Args[0])
+		return exitUsage
+	}
+	if options.HelpRequested {
+		printSummaryUsage()
+		return 0
+	}
+	setupLogging(options)
+
+	var s textSummary
+	status := 0
+	fail := func(err error) {
+		printFileError(err)
+		status = exitFileErrors
+	}
+	if len(filenames) == 0 && options.FilesFrom == "" {
+		data, err := io.ReadAll(os.Stdin)
+		if err == nil {
+			err = s.add(string(data))
+		}
+		if err != nil {
+			logError("reading stdin failed", err)
+			return exitFileErrors
+		}
+	} else {
+		var fsys fs.FS = osFS{}
+		if options.FSRoot != "" {
+			fsys = os.DirFS(options.FSRoot)
+		}
+		walker := newWalker(fsys, options)
+		for _, filename := range filenames {
+			walker.expand(filename, func(name string) {
+				data, err := readNamedFile(fsys, name)
+				if err == nil {
+					err = s.add(string(data))
+				}
+				if err != nil {
+					fail(err)
+				}
+			}, fail)
+		}
+	}
+	printSummary(s, pageWords, readingSpeed)
+	return status
+}
+
+func printSummaryUsage() {
+	fmt.Println("Usage: mwc summary [--page-words N] [--wpm N] [options] [path ...]")
+	fmt.Println("Print the words, characters with and without spaces, sentences, paragraphs,")
+	fmt.Println("pages, reading time and readability of stdin or of all the files under the")
+	fmt.Println("paths together, as a word processor shows them for a document.")
+	fmt.Println("\nOptions:")
+	fmt.Println("  --page-words N	Words on a page (default 250, a manuscript page)")
+	fmt.Println("  --wpm N	Reading speed in words a minute (default 238)")
+	fmt.Println("\nThe files are chosen with the same options as mwc -r; see mwc --help.")
+}
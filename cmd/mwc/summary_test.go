@@ -0,0 +1,102 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestSyllables tests the estimate of the syllables of English words
+func TestSyllables(t *testing.T) {
+	tests := []struct {
+		word     string
+		expected int64
+	}{
+		{"cat", 1},
+		{"Table", 2},
+		{"make", 1},
+		{"the", 1},
+		{"reading,", 2},
+		{"beautiful", 3},
+		{"rhythm", 1},
+		{"42", 0},
+		{"—", 0},
+	}
+	for _, tt := range tests {
+		if got := syllables(tt.word); got != tt.expected {
+			t.Errorf("syllables(%q) = %d, expected %d", tt.word, got, tt.expected)
+		}
+	}
+}
+
+// TestFormatReadingTime tests rounding reading times up to the minute
+func TestFormatReadingTime(t *testing.T) {
+	tests := []struct {
+		words    int64
+		expected string
+	}{
+		{0, "0 min"},
+		{1, "1 min"},
+		{476, "2 min"},
+		{477, "3 min"},
+		{238 * 75, "1 h 15 min"},
+	}
+	for _, tt := range tests {
+		if got := formatReadingTime(tt.words, defaultReadingSpeed); got != tt.expected {
+			t.Errorf("formatReadingTime(%d) = %q, expected %q", tt.words, got, tt.expected)
+		}
+	}
+}
+
+// TestSummary checks the report of mwc summary for a directory
+func TestSummary(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"01.md": "The cat sat on the mat. It was happy!\n\nThen it slept.\n",
+		"02.md": "---\ntitle: \"The End: A Story in Several Unusually Long Syllables\"\nauthor: Ada\n---\nThe end.\n",
+	})
+
+	var status int
+	stdout, stderr := captureFunc(t, func() { status = summary([]string{"--page-words", "5", dir}) })
+	expected := strings.Join([]string{
+		"Words:                  14",
+		"Characters:             63",
+		"Characters (no spaces): 48",
+		"Sentences:              4",
+		"Paragraphs:             3",
+		"Pages:                  2.8 (5 words a page)",
+		"Reading time:           1 min (238 words a minute)",
+		"Reading ease:           100.0 (very easy)",
+		"Grade level:            0.0",
+	}, "\n") + "\n"
+	if status != 0 || stdout != expected || stderr != "" {
+		t.Errorf("Expected status 0 and:\n%s\ngot %d and:\n%s%s", expected, status, stdout, stderr)
+	}
+
+	_, stderr = captureFunc(t, func() { status = summary([]string{dir, filepath.Join(dir, "missing.md")}) })
+	if status != exitFileErrors || !strings.Contains(stderr, "missing.md") {
+		t.Errorf("Expected status %d and the missing file, got %d and %q", exitFileErrors, status, stderr)
+	}
+	_, stderr = captureFunc(t, func() { status = summary([]string{"--wpm", "0"}) })
+	if status != 1 || !strings.Contains(stderr, "invalid number for --wpm: '0'") {
+		t.Errorf("Expected status 1 and an invalid --wpm, got %d and %q", status, stderr)
+	}
+}
+
+// TestStripFrontMatter tests leaving the front matter out of a text
+func TestStripFrontMatter(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"---\ntitle: A\n---\nBody.\n", "Body.\n"},
+		{"\ufeff---\r\ntitle: A\r\n...\r\nBody.\r\n", "Body.\r\n"},
+		{"---\nA horizontal rule, never closed\n", "---\nA horizontal rule, never closed\n"},
+		{"Body.\n---\nmore\n---\n", "Body.\n---\nmore\n---\n"},
+	}
+	for _, tt := range tests {
+		if got := stripFrontMatter(tt.text); got != tt.expected {
+			t.Errorf("stripFrontMatter(%q) = %q, expected %q", tt.text, got, tt.expected)
+		}
+	}
+}
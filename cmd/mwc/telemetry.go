@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// metricPoint is a value of a metric sent with --statsd or --otlp
+type metricPoint struct {
+	name     string
+	value    float64
+	integer  bool // counts are integers; derived counts and durations aren't
+	duration bool // a duration in seconds, sent to StatsD as a timing in milliseconds
+	tags     [][2]string
+}
+
+// runMetrics returns the metrics of a run: the printed counts and the time
+// taken for each file, tagged with its name, for the total, and the duration
+// of the whole run. Every metric also has the --metrics-tag tags.
+func runMetrics(options cliOptions, report runReport, elapsed time.Duration) []metricPoint {
+	var points []metricPoint
+	add := func(prefix string, tags [][2]string, file int) {
+		counts := report.Total
+		if file >= 0 {
+			counts = report.Files[file].Counts
+		}
+		for _, name := range options.Order {
+			if value, ok := counts.Get(name); ok {
+				points = append(points, metricPoint{name: prefix + name, value: float64(value), integer: true, tags: tags})
+			}
+		}
+		for _, expr := range options.Exprs {
+			points = append(points, metricPoint{name: prefix + expr.Name, value: expr.Eval(counts), tags: tags})
+		}
+	}
+	for i, name := range report.names {
+		tags := append([][2]string{{"file", name}}, options.MetricTags...)
+		add("mwc.", tags, i)
+		points = append(points, metricPoint{name: "mwc.file.duration", value: report.elapsed[i].Seconds(), duration: true, tags: tags})
+	}
+	add("mwc.total.", options.MetricTags, -1)
+	points = append(points,
+		metricPoint{name: "mwc.run.duration", value: elapsed.Seconds(), duration: true, tags: options.MetricTags},
+		metricPoint{name: "mwc.run.errors", value: float64(len(report.Errors)), integer: true, tags: options.MetricTags})
+	return points
+}
+
+// emitMetrics sends the metrics of a run to the StatsD and OTLP destinations
+// of the options, if any
+func emitMetrics(options cliOptions, report runReport, elapsed time.Duration) error {
+	if options.StatsD == "" && options.OTLPURL == "" {
+		return nil
+	}
+	points := runMetrics(options, report, elapsed)
+	if options.StatsD != "" {
+		if err := sendStatsD(options.StatsD, points); err != nil {
+			return fmt.Errorf("sending metrics to StatsD: %v", err)
+		}
+	}
+	if options.OTLPURL != "" {
+		if err := sendOTLP(options.OTLPURL, points, time.Now()); err != nil {
+			return fmt.Errorf("sending metrics over OTLP: %v", err)
+		}
+	}
+	return nil
+}
+
+// statsDPacketSize keeps packets within the payload of a typical Ethernet frame
+const statsDPacketSize = 1432
+
+// sendStatsD sends the metrics over UDP as StatsD gauges and timings, with
+// tags in the DogStatsD format that Datadog, Telegraf and others accept
+func sendStatsD(address string, points []metricPoint) error {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	var packet bytes.Buffer
+	for _, point := range points {
+		line := statsDLine(point)
+		if packet.Len() > 0 && packet.Len()+1+len(line) > statsDPacketSize {
+			if _, err := conn.Write(packet.Bytes()); err != nil {
+				return err
+			}
+			packet.Reset()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	if packet.Len() > 0 {
+		_, err = conn.Write(packet.Bytes())
+	}
+	return err
+}
+
+// statsDLine formats a metric as a StatsD line, such as
+// mwc.words:42|g|#file:a.txt
+func statsDLine(point metricPoint) string {
+	line := point.name + ":" + strconv.FormatFloat(point.value, 'f', -1, 64) + "|g"
+	if point.duration {
+		line = point.name + ":" + strconv.FormatFloat(point.value*1000, 'f', -1, 64) + "|ms"
+	}
+	if len(point.tags) > 0 {
+		// Commas and pipes would end the tag or the line
+		escape := strings.NewReplacer(",", "_", "|", "_", "\n", "_")
+		tags := make([]string, len(point.tags))
+		for i, tag := range point.tags {
+			tags[i] = escape.Replace(tag[0]) + ":" + escape.Replace(tag[1])
+		}
+		line += "|#" + strings.Join(tags, ",")
+	}
+	return line
+}
+
+// OTLP/JSON messages, as far as gauges need them
+type (
+	otlpAttribute struct {
+		Key   string `json:"key"`
+		Value struct {
+			StringValue string `json:"stringValue"`
+		} `json:"value"`
+	}
+	otlpDataPoint struct {
+		Attributes   []otlpAttribute `json:"attributes,omitempty"`
+		TimeUnixNano string          `json:"timeUnixNano"`
+		AsInt        string          `json:"asInt,omitempty"`
+		AsDouble     *float64        `json:"asDouble,omitempty"`
+	}
+	otlpMetric struct {
+		Name  string `json:"name"`
+		Unit  string `json:"unit,omitempty"`
+		Gauge struct {
+			DataPoints []otlpDataPoint `json:"dataPoints"`
+		} `json:"gauge"`
+	}
+)
+
+// sendOTLP posts the metrics as gauges to an OpenTelemetry collector with
+// OTLP over HTTP, encoded as JSON. An endpoint URL without a path gets the
+// standard /v1/metrics.
+func sendOTLP(endpoint string, points []metricPoint, now time.Time) error {
+	if u, err := url.Parse(endpoint); err == nil && (u.Path == "" || u.Path == "/") {
+		endpoint = strings.TrimSuffix(endpoint, "/") + "/v1/metrics"
+	}
+	var metrics []*otlpMetric
+	byName := map[string]*otlpMetric{}
+	for _, point := range points {
+		metric := byName[point.name]
+		if metric == nil {
+			metric = &otlpMetric{Name: point.name}
+			if point.duration {
+				metric.Unit = "s"
+			}
+			byName[point.name] = metric
+			metrics = append(metrics, metric)
+		}
+		dataPoint := otlpDataPoint{TimeUnixNano: strconv.FormatInt(now.UnixNano(), 10)}
+		for _, tag := range point.tags {
+			attribute := otlpAttribute{Key: tag[0]}
+			attribute.Value.StringValue = tag[1]
+			dataPoint.Attributes = append(dataPoint.Attributes, attribute)
+		}
+		if point.integer {
+			dataPoint.AsInt = strconv.FormatInt(int64(point.value), 10)
+		} else {
+			value := point.value
+			dataPoint.AsDouble = &value
+		}
+		metric.Gauge.DataPoints = append(metric.Gauge.DataPoints, dataPoint)
+	}
+
+	var service otlpAttribute
+	service.Key = "service.name"
+	service.Value.StringValue = "mwc"
+	body, err := json.Marshal(map[string]any{
+		"resourceMetrics": []any{map[string]any{
+			"resource": map[string]any{"attributes": []otlpAttribute{service}},
+			"scopeMetrics": []any{map[string]any{
+				"scope":   map[string]string{"name": "mwc"},
+				"metrics": metrics,
+			}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: notifyTimeout}
+	resp, err := client.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s responded %s", endpoint, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+// TestStatsDLine tests formatting metrics as StatsD lines
+func TestStatsDLine(t *testing.T) {
+	tests := []struct {
+		point    metricPoint
+		expected string
+	}{
+		{metricPoint{name: "mwc.words", value: 42, integer: true}, "mwc.words:42|g"},
+		{metricPoint{name: "mwc.density", value: 1.5}, "mwc.density:1.5|g"},
+		{metricPoint{name: "mwc.run.duration", value: 1.25, duration: true}, "mwc.run.duration:1250|ms"},
+		{metricPoint{name: "mwc.words", value: 1, integer: true, tags: [][2]string{{"file", "a,b|c.txt"}, {"profile", "nightly"}}},
+			"mwc.words:1|g|#file:a_b_c.txt,profile:nightly"},
+	}
+	for _, tt := range tests {
+		if got := statsDLine(tt.point); got != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, got)
+		}
+	}
+}
+
+// TestEmitMetrics checks the metrics sent to StatsD and an OTLP collector after a run
+func TestEmitMetrics(t *testing.T) {
+	statsd, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening: %v", err)
+	}
+	defer statsd.Close()
+	var otlpPath string
+	var otlpBody struct {
+		ResourceMetrics []struct {
+			ScopeMetrics []struct {
+				Metrics []otlpMetric `json:"metrics"`
+			} `json:"scopeMetrics"`
+		} `json:"resourceMetrics"`
+	}
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otlpPath = r.URL.Path
+		if err := json.NewDecoder(r.Body).Decode(&otlpBody); err != nil {
+			t.Errorf("Error decoding OTLP body: %v", err)
+		}
+	}))
+	defer collector.Close()
+
+	options, _, err := parseArgs([]string{"-w", "--statsd", statsd.LocalAddr().String(), "--otlp", collector.URL,
+		"--metrics-tag", "profile=nightly"})
+	if err != nil {
+		t.Fatalf("Error parsing arguments: %v", err)
+	}
+	var report runReport
+	report.add("a.txt", "", wordcount.FrontMatter{}, wordcount.Counts{Words: 3}, 2*time.Millisecond)
+	report.Total = wordcount.Counts{Words: 3}
+	if err := emitMetrics(options, report, time.Second); err != nil {
+		t.Fatalf("Error emitting metrics: %v", err)
+	}
+
+	buf := make([]byte, statsDPacketSize)
+	_ = statsd.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := statsd.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("Error reading StatsD packet: %v", err)
+	}
+	expected := "mwc.words:3|g|#file:a.txt,profile:nightly\n" +
+		"mwc.file.duration:2|ms|#file:a.txt,profile:nightly\n" +
+		"mwc.total.words:3|g|#profile:nightly\n" +
+		"mwc.run.duration:1000|ms|#profile:nightly\n" +
+		"mwc.run.errors:0|g|#profile:nightly"
+	if string(buf[:n]) != expected {
+		t.Errorf("Expected StatsD packet %q, got %q", expected, buf[:n])
+	}
+
+	if otlpPath != "/v1/metrics" {
+		t.Errorf("Expected metrics to be posted to /v1/metrics, got %q", otlpPath)
+	}
+	var names []string
+	for _, metric := range otlpBody.ResourceMetrics[0].ScopeMetrics[0].Metrics {
+		names = append(names, metric.Name)
+	}
+	if got := strings.Join(names, " "); got != "mwc.words mwc.file.duration mwc.total.words mwc.run.duration mwc.run.errors" {
+		t.Errorf("Unexpected OTLP metrics %q", got)
+	}
+	point := otlpBody.ResourceMetrics[0].ScopeMetrics[0].Metrics[0].Gauge.DataPoints[0]
+	if point.AsInt != "3" || len(point.Attributes) != 2 || point.Attributes[0].Value.StringValue != "a.txt" {
+		t.Errorf("Unexpected data point %+v", point)
+	}
+
+	for _, args := range [][]string{{"--statsd", "localhost"}, {"--otlp", "localhost:4318"}, {"--metrics-tag", "nightly"}} {
+		if _, _, err := parseArgs(args); err == nil {
+			t.Errorf("Expected an error for %q", args)
+		}
+	}
+}
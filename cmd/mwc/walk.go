@@ -0,0 +1,251 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+var (
+	// errIsDirectory is the error of a directory named without -r
+	errIsDirectory = errors.New("is a directory (use -r to count the files in it)")
+	// errSymlinkLoop is the error of a symbolic link to a directory that
+	// contains it, met with --follow-symlinks
+	errSymlinkLoop = errors.New("symbolic link loop")
+)
+
+// fileKey identifies a file on its device, whatever its name
+type fileKey struct {
+	device, inode uint64
+}
+
+// walker expands the local inputs named on the command line into the files
+// to count, in fsys
+type walker struct {
+	fsys    fs.FS
+	options cliOptions
+	counted map[fileKey]string // the name each file was first visited as, with --dedup-hardlinks
+}
+
+func newWalker(fsys fs.FS, options cliOptions) *walker {
+	return &walker{fsys: fsys, options: options, counted: map[fileKey]string{}}
+}
+
+// walk calls visit with the name of every regular file under the
+// directory root, in lexical order, that the --include and --exclude
+// patterns select and that no .wcignore file or, inside a git repository,
+// .gitignore file ignores. Hidden files are skipped unless options.Hidden is
+// set, as are devices and other special files, and symbolic links unless
+// options.FollowSymlinks is set; excluded, ignored and hidden directories
+// aren't read, nor are directories below options.MaxDepth. Files larger than
+// options.MaxFileSize are skipped with a warning, and with --type text, so
+// are files that aren't text. With --git-tracked, so are files git doesn't
+// track. Directories that can't be read and symbolic links that lead back to
+// a directory being walked are reported to fail as a *wordcount.FileError
+// and skipped, and the walk goes on.
+func (w *walker) walk(root string, visit func(name string), fail func(err error)) {
+	fsys, options := w.fsys, w.options
+	// Ignore files above root are found by their absolute paths
+	full := filepath.ToSlash(root)
+	if _, ok := fsys.(osFS); ok {
+		if abs, err := filepath.Abs(root); err == nil {
+			full = filepath.ToSlash(abs)
+		}
+	}
+	gitIgnore := options.GitIgnore
+	// With --git-tracked, only the files git lists are counted, along with
+	// the directories they are in
+	var tracked map[string]bool
+	if options.GitTracked {
+		var err error
+		if tracked, err = gitTrackedPaths(root); err != nil {
+			fail(&wordcount.FileError{Op: "open", Path: root, Err: err})
+			return
+		}
+		// What .gitignore files ignore is already left out of git's index,
+		// unless it was added anyway
+		if gitIgnore == "" {
+			gitIgnore = "off"
+		}
+	}
+	ignores := newIgnoreRules(fsys, full, gitIgnore)
+
+	// The directories being walked, by device and inode, to detect symbolic
+	// links that loop
+	var walking []fileKey
+	// Files directly in root are at depth 1
+	var walkDir func(name string, depth int)
+	walkDir = func(name string, depth int) {
+		if options.FollowSymlinks {
+			if key, ok := fileID(fsys, name); ok {
+				if slices.Contains(walking, key) {
+					fail(&wordcount.FileError{Op: "open", Path: name, Err: errSymlinkLoop})
+					return
+				}
+				walking = append(walking, key)
+				defer func() { walking = walking[:len(walking)-1] }()
+			}
+		}
+		ignores.load(path.Join(full, relativeName(root, name)))
+		entries, err := fs.ReadDir(fsys, name)
+		if err != nil {
+			fail(&wordcount.FileError{Op: "open", Path: name, Err: err})
+		}
+		for _, entry := range entries {
+			child := path.Join(name, entry.Name())
+			mode := entry.Type()
+			var info fs.FileInfo // of the file a followed link leads to
+			if mode&fs.ModeSymlink != 0 && options.FollowSymlinks {
+				info, err = fs.Stat(fsys, child)
+				if err != nil {
+					fail(&wordcount.FileError{Op: "open", Path: child, Err: err})
+					continue
+				}
+				mode = info.Mode().Type()
+			}
+			relative := relativeName(root, child)
+			if isHidden(entry.Name()) && !options.Hidden || matchFilters(options.Exclude, relative) ||
+				tracked != nil && !tracked[path.Join(full, relative)] || ignores.ignored(path.Join(full, relative), mode.IsDir()) {
+				continue
+			}
+			switch {
+			case mode.IsDir():
+				if (!ignores.git || entry.Name() != ".git") && (options.MaxDepth == 0 || depth < options.MaxDepth) {
+					walkDir(child, depth+1)
+				}
+			case mode.IsRegular():
+				if len(options.Include) > 0 && !matchFilters(options.Include, relative) {
+					continue
+				}
+				if options.MaxFileSize > 0 {
+					if info == nil {
+						info, err = entry.Info()
+					}
+					if err == nil && info.Size() > options.MaxFileSize {
+						logEvent(slog.LevelWarn, fmt.Sprintf("%s: skipping %s: %s is over --max-filesize", os.Args[0], child, formatSize(float64(info.Size()))),
+							"skipping large file", "file", child, "size", info.Size(), "max_filesize", options.MaxFileSize)
+						continue
+					}
+				}
+				if options.Type == "text" && !isText(fsys, child) {
+					logEvent(slog.LevelDebug, fmt.Sprintf("%s: skipping %s, which isn't text", os.Args[0], child),
+						"skipping file that isn't text", "file", child)
+					continue
+				}
+				visit(child)
+			}
+		}
+	}
+	walkDir(root, 1)
+}
+
+// isText reports whether a file looks like text, from the content type its
+// first 512 bytes suggest. Files that can't be read are taken for text, so
+// that counting them reports the error.
+func isText(fsys fs.FS, name string) bool {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return true
+	}
+	defer file.Close()
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return true
+	}
+	return strings.HasPrefix(http.DetectContentType(head[:n]), "text/")
+}
+
+// isHidden reports whether a file or directory name is hidden, as dotfiles are
+func isHidden(name string) bool {
+	return strings.HasPrefix(name, ".") && name != "." && name != ".."
+}
+
+// relativeName returns the name of a file found by walking root, relative to root
+func relativeName(root, name string) string {
+	if name == root {
+		return "."
+	}
+	if root == "." {
+		return name
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(name, root), "/")
+}
+
+// matchFilters reports whether a name relative to the directory walked
+// matches any of the --include or --exclude patterns. A pattern without a
+// slash, such as *.go, matches the last segment of the name at any depth; one
+// with a slash, such as vendor/** or /docs/*.md, matches the whole name.
+func matchFilters(patterns []string, relative string) bool {
+	for _, pattern := range patterns {
+		name := relative
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(relative)
+		}
+		if matchGlob(strings.TrimPrefix(pattern, "/"), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// expand calls visit with the name of every local file an input names: the
+// input itself, the files under it if it is a directory and the options are
+// recursive, or the files it matches if it is a glob pattern that isn't the
+// name of a file. With --dedup-hardlinks, a file already visited under another
+// name isn't visited again. Inputs that can't be expanded are reported to fail.
+func (w *walker) expand(name string, visit func(name string), fail func(err error)) {
+	fsys, options := w.fsys, w.options
+	if options.DedupHardlinks {
+		visit = w.once(visit)
+	}
+	info, err := fs.Stat(fsys, name)
+	switch {
+	case err == nil && info.IsDir() && options.Recursive:
+		w.walk(name, visit, fail)
+	case err != nil && isGlob(name):
+		matched := false
+		expandGlob(fsys, name, options.Recursive, options.Hidden, func(match string, dir bool) {
+			matched = true
+			if dir {
+				w.walk(match, visit, fail)
+			} else {
+				visit(match)
+			}
+		}, func(err error) {
+			matched = true
+			fail(err)
+		})
+		if !matched {
+			fail(&wordcount.FileError{Op: "open", Path: name, Err: errNoMatch})
+		}
+	default:
+		visit(name)
+	}
+}
+
+// once returns a visit function that calls visit for each file only once,
+// whatever the name it is found under
+func (w *walker) once(visit func(name string)) func(name string) {
+	return func(name string) {
+		if key, ok := fileID(w.fsys, name); ok {
+			if first, counted := w.counted[key]; counted {
+				logEvent(slog.LevelDebug, fmt.Sprintf("%s: skipping %s, the same file as %s", os.Args[0], name, first),
+					"skipping hard link", "file", name, "same_as", first)
+				return
+			}
+			w.counted[key] = name
+		}
+		visit(name)
+	}
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTree creates the files of a directory tree under dir, keyed by their
+// slash-separated paths
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+	}
+}
+
+// chdir changes the working directory to dir until the test ends
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get the working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+}
+
+// TestRecursive checks that -r counts every regular file under a directory, in
+// lexical order, with a total
+func TestRecursive(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"docs/b.md":              "two words\n",
+		"docs/a.md":              "one\n",
+		"docs/guide/intro.md":    "three more words\n",
+		"docs/guide/empty/.keep": "",
+		"docs/.drafts/c.md":      "hidden draft\n",
+	})
+	if err := os.Symlink("a.md", filepath.Join(dir, "docs", "link.md")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	chdir(t, dir)
+
+	stdout, stderr := captureOutput(t, []string{"-r", "-w", "docs"})
+	expected := "       1 docs/a.md\n       2 docs/b.md\n       3 docs/guide/intro.md\n       6 total\n"
+	if stdout != expected || stderr != "" {
+		t.Errorf("Expected:\n%s\ngot:\n%s%s", expected, stdout, stderr)
+	}
+
+	// -R is the same, and files named alongside directories are counted as usual
+	stdout, _ = captureOutput(t, []string{"-wR", "docs/guide", "docs/a.md"})
+	expected = "       3 docs/guide/intro.md\n       1 docs/a.md\n       4 total\n"
+	if stdout != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout)
+	}
+
+	// Hidden files and directories are skipped unless asked for
+	stdout, _ = captureOutput(t, []string{"-rw", "--hidden=include", "docs"})
+	expected = "       2 docs/.drafts/c.md\n       1 docs/a.md\n       2 docs/b.md\n       0 docs/guide/empty/.keep\n       3 docs/guide/intro.md\n       8 total\n"
+	if stdout != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout)
+	}
+	stdout, _ = captureOutput(t, []string{"-rw", "--hidden", "--hidden=skip", "docs/guide"})
+	if expected = "       3 docs/guide/intro.md\n"; stdout != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout)
+	}
+
+	// Without -r, a directory is reported and skipped
+	stdout, stderr = captureOutput(t, []string{"-w", "docs", "docs/b.md"})
+	if stdout != "       2 docs/b.md\n" {
+		t.Errorf("Expected only the file to be counted, got %q", stdout)
+	}
+	if !strings.Contains(stderr, "Error opening docs: is a directory (use -r") {
+		t.Errorf("Expected the directory to be reported, got %q", stderr)
+	}
+}
+
+// TestMatchFilters tests matching names against --include and --exclude patterns
+func TestMatchFilters(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		matched bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/mwc/main.go", true},
+		{"*.go", "main.go.orig", false},
+		{"vendor", "pkg/vendor", true},
+		{"vendor/**", "vendor", true},
+		{"vendor/**", "vendor/a/b.go", true},
+		{"vendor/**", "pkg/vendor/b.go", false},
+		{"**/vendor/**", "pkg/vendor/b.go", true},
+		{"/docs/*.md", "docs/a.md", true},
+		{"/docs/*.md", "site/docs/a.md", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			if matched := matchFilters([]string{tt.pattern}, tt.name); matched != tt.matched {
+				t.Errorf("Expected %v, got %v", tt.matched, matched)
+			}
+		})
+	}
+}
+
+// TestIncludeExclude checks that --include and --exclude select the files
+// counted under directories
+func TestIncludeExclude(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"main.go":               "package main\n",
+		"notes.txt":             "not counted\n",
+		"cmd/tool/tool.go":      "package tool\n",
+		"cmd/tool/tool_test.go": "package tool // tests\n",
+		"vendor/lib/lib.go":     "package lib\n",
+	})
+	chdir(t, dir)
+
+	stdout, stderr := captureOutput(t, []string{"-rl", "--include", "*.go", "--exclude", "vendor/**", "--exclude=*_test.go", "."})
+	expected := "       1 cmd/tool/tool.go\n       1 main.go\n       2 total\n"
+	if stdout != expected || stderr != "" {
+		t.Errorf("Expected:\n%s\ngot:\n%s%s", expected, stdout, stderr)
+	}
+
+	// Files named explicitly are always counted
+	stdout, _ = captureOutput(t, []string{"-rl", "--exclude", "*.txt", "notes.txt"})
+	if stdout != "       1 notes.txt\n" {
+		t.Errorf("Expected the named file to be counted, got %q", stdout)
+	}
+
+	for _, args := range [][]string{{"--include", "*.go"}, {"-r", "--exclude", "[a-"}} {
+		if _, _, err := parseArgs(args); err == nil {
+			t.Errorf("Expected %q to be rejected", args)
+		}
+	}
+}
+
+// TestFollowSymlinks checks that --follow-symlinks counts the files behind
+// symbolic links and skips links that loop
+func TestFollowSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"shared/notes.md": "shared notes here\n",
+		"docs/a.md":       "one\n",
+	})
+	for link, target := range map[string]string{
+		"docs/notes.md": "../shared/notes.md",
+		"docs/shared":   "../shared",
+		"docs/loop":     ".",
+		"docs/broken":   "missing.md",
+	} {
+		if err := os.Symlink(target, filepath.Join(dir, link)); err != nil {
+			t.Fatalf("Failed to create symlink: %v", err)
+		}
+	}
+	chdir(t, dir)
+
+	stdout, stderr := captureOutput(t, []string{"-rw", "docs"})
+	if expected := "       1 docs/a.md\n"; stdout != expected || stderr != "" {
+		t.Errorf("Expected:\n%s\ngot:\n%s%s", expected, stdout, stderr)
+	}
+
+	stdout, stderr = captureOutput(t, []string{"-rw", "--follow-symlinks", "docs"})
+	expected := "       1 docs/a.md\n       3 docs/notes.md\n       3 docs/shared/notes.md\n       7 total\n"
+	if stdout != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout)
+	}
+	for _, message := range []string{"Error opening docs/broken: ", "Error opening docs/loop: symbolic link loop"} {
+		if !strings.Contains(stderr, message) {
+			t.Errorf("Expected %q in %q", message, stderr)
+		}
+	}
+}
+
+// TestDedupHardlinks checks that --dedup-hardlinks counts a file with several
+// names once
+func TestDedupHardlinks(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"backup.1/a.md": "one two\n",
+		"backup.1/b.md": "three\n",
+	})
+	if err := os.MkdirAll(filepath.Join(dir, "backup.2"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	for _, name := range []string{"a.md", "b.md"} {
+		if err := os.Link(filepath.Join(dir, "backup.1", name), filepath.Join(dir, "backup.2", name)); err != nil {
+			t.Fatalf("Failed to create hard link: %v", err)
+		}
+	}
+	writeTree(t, dir, map[string]string{"backup.2/c.md": "new file\n"})
+	chdir(t, dir)
+
+	stdout, _ := captureOutput(t, []string{"-rw", "backup.1", "backup.2"})
+	if !strings.HasSuffix(stdout, "       8 total\n") {
+		t.Errorf("Expected every name to be counted, got:\n%s", stdout)
+	}
+
+	stdout, stderr := captureOutput(t, []string{"-rw", "--dedup-hardlinks", "--log-level=debug", "backup.1", "backup.2", "backup.1/a.md"})
+	expected := "       2 backup.1/a.md\n       1 backup.1/b.md\n       2 backup.2/c.md\n       5 total\n"
+	if stdout != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout)
+	}
+	if !strings.Contains(stderr, "skipping backup.2/a.md, the same file as backup.1/a.md") {
+		t.Errorf("Expected the hard link to be reported at debug level, got %q", stderr)
+	}
+}
+
+// TestMaxDepth checks that --max-depth limits how deep directories are counted
+func TestMaxDepth(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"top.md":       "one\n",
+		"a/second.md":  "one two\n",
+		"a/b/third.md": "one two three\n",
+	})
+	chdir(t, dir)
+
+	tests := []struct {
+		depth    string
+		expected string
+	}{
+		{"1", "       1 top.md\n"},
+		{"2", "       2 a/second.md\n       1 top.md\n       3 total\n"},
+		{"3", "       3 a/b/third.md\n       2 a/second.md\n       1 top.md\n       6 total\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.depth, func(t *testing.T) {
+			if stdout, _ := captureOutput(t, []string{"-rw", "--max-depth", tt.depth, "."}); stdout != tt.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, stdout)
+			}
+		})
+	}
+	if _, _, err := parseArgs([]string{"-r", "--max-depth=0"}); err == nil {
+		t.Errorf("Expected a depth of 0 to be rejected")
+	}
+}
+
+// TestMaxFileSize checks that --max-filesize skips large files with a warning
+func TestMaxFileSize(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"docs/small.md": "one two\n",
+		"docs/core":     strings.Repeat("x", 2048),
+	})
+	chdir(t, dir)
+
+	stdout, stderr := captureOutput(t, []string{"-rw", "--max-filesize", "1K", "docs"})
+	if stdout != "       2 docs/small.md\n" {
+		t.Errorf("Expected only the small file to be counted, got %q", stdout)
+	}
+	if !strings.Contains(stderr, "skipping docs/core: 2.0KB is over --max-filesize") {
+		t.Errorf("Expected the large file to be reported, got %q", stderr)
+	}
+	if stdout, _ := captureOutput(t, []string{"-rw", "--max-filesize=2K", "docs"}); !strings.HasSuffix(stdout, "       3 total\n") {
+		t.Errorf("Expected files up to the limit to be counted, got %q", stdout)
+	}
+}
+
+// TestTypeText checks that --type text skips files that don't look like text
+func TestTypeText(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"assets/notes.md":   "one two\n",
+		"assets/data.json":  `{"words": "three"}` + "\n",
+		"assets/empty.txt":  "",
+		"assets/logo.png":   "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR",
+		"assets/binary.bin": "ELF\x00\x01\x02 words in a binary",
+	})
+	chdir(t, dir)
+	defer setupLogging(cliOptions{})
+
+	stdout, stderr := captureOutput(t, []string{"-rw", "--type", "text", "--log-level", "debug", "assets"})
+	expected := "       2 assets/data.json\n       0 assets/empty.txt\n       2 assets/notes.md\n       4 total\n"
+	if stdout != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout)
+	}
+	if !strings.Contains(stderr, "skipping assets/logo.png, which isn't text") {
+		t.Errorf("Expected the image to be reported at debug level, got %q", stderr)
+	}
+	if _, _, err := parseArgs([]string{"-r", "--type", "image"}); err == nil {
+		t.Errorf("Expected an unknown type to be rejected")
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+)
+
+// watch runs mwc watch with the given arguments and returns the exit status.
+// It counts the named files and directories, or the current directory, like
+// mwc -r, then counts them again and reprints the report whenever a file
+// under them is saved, added or removed, until it is interrupted. On a
+// terminal the screen is cleared before each report, so the counts update in
+// place.
+func watch(args []string) int {
+	values, args, err := cutValueOptions(args, "poll")
+	poll := time.Second
+	var options cliOptions
+	var filenames []string
+	if err == nil && values["poll"] != "" {
+		if d, parseErr := time.ParseDuration(values["poll"]); parseErr != nil || d <= 0 {
+			err = optionError("--poll", "invalid duration for --poll: '%s'", values["poll"])
+		} else {
+			poll = d
+		}
+	}
+	if err == nil {
+		options, filenames, err = parseArgs(args)
+	}
+	if err == nil {
+		for _, filename := range filenames {
+			if _, remote := parseRemotePath(filename); remote || isObjectURL(filename) {
+				err = optionError(filename, "mwc watch can only watch local files, not '%s'", filename)
+				break
+			}
+		}
+	}
+	if err == nil && options.Remote != "" {
+		err = optionError("--remote", "mwc watch can't be combined with --remote")
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[0], err)
+		_, _ = fmt.Fprintf(os.Stderr, "usage: %s watch [--poll DURATION] [-clmw] [path ...]\n", os.Args[0])
+		return exitUsage
+	}
+	if options.HelpRequested {
+		printWatchUsage()
+		return 0
+	}
+	options.Recursive = true
+	if len(filenames) == 0 {
+		filenames = []string{"."}
+	}
+	setupLogging(options)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	clearScreen := isTerminal(os.Stdout)
+	first := true
+	watchInputs(ctx, options, filenames, poll, func(changed []string) {
+		switch {
+		case clearScreen:
+			fmt.Print("\x1b[H\x1b[2J")
+		case !first:
+			fmt.Println()
+		}
+		if !first {
+			_, _ = fmt.Fprintf(os.Stderr, "%s: %s changed at %s\n", os.Args[0], describeChanges(changed), time.Now().Format("15:04:05"))
+		}
+		first = false
+		run(options, filenames)
+	})
+	return 0
+}
+
+// watchedFile is what is compared to tell whether a watched file has changed
+type watchedFile struct {
+	size    int64
+	modTime time.Time
+}
+
+// watchInputs calls report once, then polls the files under the inputs every
+// poll interval and calls report again with the names of those that were
+// changed, added or removed since, in lexical order, until ctx is done.
+// Polling rather than subscribing to change notifications works the same on
+// every platform and file system, and an editor saving through a new file and
+// a rename is seen as a change like any other.
+func watchInputs(ctx context.Context, options cliOptions, filenames []string, poll time.Duration, report func(changed []string)) {
+	var fsys fs.FS = osFS{}
+	if options.FSRoot != "" {
+		fsys = os.DirFS(options.FSRoot)
+	}
+	snapshot := func() map[string]watchedFile {
+		files := map[string]watchedFile{}
+		walker := newWalker(fsys, options)
+		for _, filename := range filenames {
+			walker.expand(filename, func(name string) {
+				if info, err := fs.Stat(fsys, name); err == nil {
+					files[name] = watchedFile{size: info.Size(), modTime: info.ModTime()}
+				}
+			}, func(error) {})
+		}
+		return files
+	}
+
+	files := snapshot()
+	report(nil)
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		current := snapshot()
+		var changed []string
+		for name, file := range current {
+			if previous, ok := files[name]; !ok || previous != file {
+				changed = append(changed, name)
+			}
+		}
+		for name := range files {
+			if _, ok := current[name]; !ok {
+				changed = append(changed, name)
+			}
+		}
+		files = current
+		if len(changed) > 0 {
+			sort.Strings(changed)
+			report(changed)
+		}
+	}
+}
+
+// describeChanges names the files that changed, or how many when there are
+// more than a few
+func describeChanges(changed []string) string {
+	if len(changed) > 3 {
+		return fmt.Sprintf("%d files", len(changed))
+	}
+	return strings.Join(changed, ", ")
+}
+
+func printWatchUsage() {
+	fmt.Println("Usage: mwc watch [--poll DURATION] [-lwcm] [options] [path ...]")
+	fmt.Println("Count the files under the paths, or the current directory, and count them")
+	fmt.Println("again whenever one is saved, added or removed, until interrupted.")
+	fmt.Println("\nChanges are found by polling, not filesystem notifications: every --poll")
+	fmt.Println("interval, 1s by default, the files are listed again and their sizes and")
+	fmt.Println("modification times compared.")
+	fmt.Println("\nOptions:")
+	fmt.Println("  --poll DURATION	How often to check the files for changes (default 1s)")
+	fmt.Println("\nThe counts are selected with the same options as mwc; see mwc --help.")
+}
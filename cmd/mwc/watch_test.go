@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestWatchInputs checks that saving, adding and removing files under the
+// watched inputs is reported, and that hidden files aren't watched
+func TestWatchInputs(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"book/01.md":  "It was a dark night.\n",
+		"book/02.md":  "The end.\n",
+		"book/.notes": "hidden\n",
+	})
+	chdir(t, dir)
+
+	options, filenames, err := parseArgs([]string{"-r", "-w", "book"})
+	if err != nil {
+		t.Fatalf("Error parsing arguments: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	reports := make(chan []string)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		watchInputs(ctx, options, filenames, 10*time.Millisecond, func(changed []string) {
+			reports <- changed
+		})
+	}()
+	defer func() {
+		cancel()
+		for {
+			select {
+			case <-reports:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	next := func() []string {
+		select {
+		case changed := <-reports:
+			return changed
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for a report")
+			return nil
+		}
+	}
+	if changed := next(); changed != nil {
+		t.Errorf("Expected the first report to have no changes, got %q", changed)
+	}
+
+	writeTree(t, dir, map[string]string{"book/.notes": "still hidden\n"})
+	writeTree(t, dir, map[string]string{"book/01.md": "It was a dark and stormy night.\n"})
+	if changed := next(); !slices.Equal(changed, []string{"book/01.md"}) {
+		t.Errorf("Expected book/01.md to have changed, got %q", changed)
+	}
+
+	// Renaming is seen as removing one file and adding another
+	if err := os.Rename(filepath.Join(dir, "book", "02.md"), filepath.Join(dir, "book", "03.md")); err != nil {
+		t.Fatalf("Failed to rename file: %v", err)
+	}
+	if changed := next(); !slices.Equal(changed, []string{"book/02.md", "book/03.md"}) {
+		t.Errorf("Expected book/02.md and book/03.md to have changed, got %q", changed)
+	}
+}
+
+// TestWatchArgs checks that mwc watch rejects what it can't watch
+func TestWatchArgs(t *testing.T) {
+	tests := [][]string{
+		{"--poll", "soon"},
+		{"--poll=-1s"},
+		{"s3://bucket/book"},
+		{"--remote", "http://localhost:8080"},
+	}
+	for _, args := range tests {
+		var status int
+		_, stderr := captureFunc(t, func() { status = watch(args) })
+		if status != 1 || stderr == "" {
+			t.Errorf("Expected watch(%q) to fail, got status %d", args, status)
+		}
+	}
+}
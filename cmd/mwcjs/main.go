@@ -0,0 +1,130 @@
+//go:build js && wasm
+
+// Command mwcjs exposes the wordcount package to JavaScript when built with
+// GOOS=js GOARCH=wasm. It defines a global mwc object whose count function
+// counts a Uint8Array or string with the same rules as the mwc command:
+//
+//	const counts = mwc.count(bytes, {lines: true, words: true, metrics: ["sentences"]})
+//	// counts.lines, counts.words, counts.metrics.sentences
+//
+// Without any counts selected, lines, words and bytes are counted. Invalid
+// options are reported as {error: "..."}.
+package main
+
+import (
+	"bytes"
+	"slices"
+	"syscall/js"
+
+	"github.com/mvk059/word-count/wordcount"
+)
+
+func main() {
+	js.Global().Set("mwc", js.ValueOf(map[string]any{
+		"count":   js.FuncOf(count),
+		"metrics": js.FuncOf(metrics),
+	}))
+	// Keep the functions callable for the lifetime of the page
+	select {}
+}
+
+// count implements mwc.count(input, options)
+func count(_ js.Value, args []js.Value) any {
+	if len(args) == 0 {
+		return errorResult("count needs an input")
+	}
+	options, err := countOptions(optionalArg(args, 1))
+	if err != nil {
+		return errorResult(err.Error())
+	}
+	var data []byte
+	switch input := args[0]; input.Type() {
+	case js.TypeString:
+		data = []byte(input.String())
+	case js.TypeObject:
+		data = make([]byte, input.Get("length").Int())
+		js.CopyBytesToGo(data, input)
+	default:
+		return errorResult("count needs a Uint8Array or string")
+	}
+	counts, err := wordcount.Count(bytes.NewReader(data), options)
+	if err != nil {
+		return errorResult(err.Error())
+	}
+	return countsResult(counts, options)
+}
+
+// metrics implements mwc.metrics(), listing the registered metrics
+func metrics(js.Value, []js.Value) any {
+	var names []any
+	for _, name := range wordcount.Metrics() {
+		names = append(names, name)
+	}
+	return js.ValueOf(names)
+}
+
+// optionalArg returns the i-th argument, or undefined if it wasn't passed
+func optionalArg(args []js.Value, i int) js.Value {
+	if i < len(args) {
+		return args[i]
+	}
+	return js.Undefined()
+}
+
+// countOptions converts an options object, such as {words: true, unique: true},
+// into normalized and validated CountOptions
+func countOptions(object js.Value) (wordcount.CountOptions, error) {
+	var options wordcount.CountOptions
+	if object.Type() != js.TypeObject {
+		return options.Normalize(), nil
+	}
+	flags := []struct {
+		name string
+		flag *bool
+	}{
+		{"lines", &options.LineCount},
+		{"words", &options.WordCount},
+		{"characters", &options.CharacterCount},
+		{"bytes", &options.ByteCount},
+		{"unique", &options.UniqueCount},
+	}
+	for _, f := range flags {
+		*f.flag = object.Get(f.name).Truthy()
+	}
+	if list := object.Get("metrics"); list.Type() == js.TypeObject {
+		for i := 0; i < list.Length(); i++ {
+			options.Metrics = append(options.Metrics, list.Index(i).String())
+		}
+	}
+	if object.Get("unicodeWords").Truthy() {
+		options.WordSplitter = wordcount.UnicodeWords
+	}
+	options = options.Normalize()
+	return options, options.Validate()
+}
+
+// countsResult converts counts into a plain object with the requested counts,
+// keyed like the library's JSON encoding
+func countsResult(counts wordcount.Counts, options wordcount.CountOptions) js.Value {
+	result := map[string]any{}
+	for _, name := range options.Order {
+		if slices.Contains(options.Metrics, name) {
+			continue
+		}
+		value, _ := counts.Get(name)
+		result[name] = float64(value)
+	}
+	if len(counts.Metrics) > 0 {
+		metrics := map[string]any{}
+		for name, value := range counts.Metrics {
+			metrics[name] = float64(value)
+		}
+		result["metrics"] = metrics
+	}
+	return js.ValueOf(result)
+}
+
+// errorResult reports a failure as {error: message}
+func errorResult(message string) js.Value {
+	return js.ValueOf(map[string]any{"error": message})
+}
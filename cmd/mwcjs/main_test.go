@@ -0,0 +1,53 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"syscall/js"
+	"testing"
+)
+
+// TestCount tests counting strings and byte arrays from JavaScript values
+func TestCount(t *testing.T) {
+	bytes := js.Global().Get("Uint8Array").New(4)
+	js.CopyBytesToJS(bytes, []byte("a b\n"))
+	tests := []struct {
+		name     string
+		input    js.Value
+		options  map[string]any
+		expected map[string]int
+	}{
+		{"Default Options", js.ValueOf("Hello, World!\n"), nil, map[string]int{"lines": 1, "words": 2, "bytes": 14}},
+		{"Byte Array", bytes, map[string]any{"words": true}, map[string]int{"words": 2}},
+		{"Unicode Words", js.ValueOf("can't stop, 世界"), map[string]any{"words": true, "unicodeWords": true}, map[string]int{"words": 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := count(js.Undefined(), []js.Value{tt.input, js.ValueOf(tt.options)}).(js.Value)
+			if err := result.Get("error"); !err.IsUndefined() {
+				t.Fatalf("count() error = %s", err.String())
+			}
+			for name, expected := range tt.expected {
+				if got := result.Get(name).Int(); got != expected {
+					t.Errorf("count() %s = %d, want %d", name, got, expected)
+				}
+			}
+		})
+	}
+}
+
+// TestCountMetrics tests requesting metrics and reporting unknown ones
+func TestCountMetrics(t *testing.T) {
+	options := js.ValueOf(map[string]any{"metrics": []any{"sentences"}})
+	result := count(js.Undefined(), []js.Value{js.ValueOf("One. Two."), options}).(js.Value)
+	if got := result.Get("metrics").Get("sentences").Int(); got != 2 {
+		t.Errorf("count() sentences = %d, want 2", got)
+	}
+
+	options = js.ValueOf(map[string]any{"metrics": []any{"syllables"}})
+	result = count(js.Undefined(), []js.Value{js.ValueOf("One."), options}).(js.Value)
+	if got := result.Get("error").String(); got != `unknown metric "syllables"` {
+		t.Errorf("count() error = %q", got)
+	}
+}
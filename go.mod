@@ -1,3 +1,8 @@
-module word-count
+module github.com/mvk059/word-count
 
 go 1.22
+
+require (
+	github.com/rivo/uniseg v0.4.7
+	golang.org/x/text v0.21.0
+)
@@ -0,0 +1,41 @@
+package wordcount
+
+import (
+	"maps"
+	"sync"
+)
+
+// Accumulator sums the counts of several inputs. It is safe for concurrent
+// use, so inputs counted in parallel can add their results as they finish.
+// The zero value is an empty accumulator ready to use.
+type Accumulator struct {
+	mu     sync.Mutex
+	total  Counts
+	inputs int
+}
+
+// Add adds the counts of one input to the total. As with Counts.Add, unique
+// word counts aren't summed; use CountOptions.UniqueTotal for those.
+func (a *Accumulator) Add(counts Counts) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.total.Add(counts)
+	a.inputs++
+}
+
+// Total returns the sum of the counts added so far. Its metrics are a copy,
+// which later additions don't change.
+func (a *Accumulator) Total() Counts {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	total := a.total
+	total.Metrics = maps.Clone(a.total.Metrics)
+	return total
+}
+
+// Inputs returns the number of counts added so far
+func (a *Accumulator) Inputs() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.inputs
+}
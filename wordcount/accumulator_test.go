@@ -0,0 +1,52 @@
+package wordcount
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestAccumulator checks that counts added from many goroutines are all included in the total
+func TestAccumulator(t *testing.T) {
+	const goroutines, adds = 8, 1000
+	var accumulator Accumulator
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < adds; j++ {
+				accumulator.Add(Counts{Bytes: 3, Lines: 1, Words: 2, Chars: 3, Unique: 2})
+			}
+		}()
+	}
+	wg.Wait()
+
+	const n = goroutines * adds
+	if expected := (Counts{Bytes: 3 * n, Lines: n, Words: 2 * n, Chars: 3 * n}); !accumulator.Total().Equal(expected) {
+		t.Errorf("Expected %+v, got %+v", expected, accumulator.Total())
+	}
+	if accumulator.Inputs() != n {
+		t.Errorf("Expected %d inputs, got %d", n, accumulator.Inputs())
+	}
+}
+
+// TestAccumulatorMetrics checks that adding metrics changes neither copies of
+// the counts added to nor totals already returned
+func TestAccumulatorMetrics(t *testing.T) {
+	counts := Counts{Words: 1, Metrics: map[string]int64{"sentences": 2}}
+	sum := counts
+	sum.Add(Counts{Words: 1, Metrics: map[string]int64{"sentences": 3}})
+	if counts.Metrics["sentences"] != 2 || sum.Metrics["sentences"] != 5 {
+		t.Errorf("Expected the copy to keep 2 sentences and the sum to have 5, got %d and %d",
+			counts.Metrics["sentences"], sum.Metrics["sentences"])
+	}
+
+	var accumulator Accumulator
+	accumulator.Add(counts)
+	total := accumulator.Total()
+	total.Metrics["sentences"] = 100
+	accumulator.Add(counts)
+	if got := accumulator.Total().Metrics["sentences"]; got != 4 || total.Metrics["sentences"] != 100 {
+		t.Errorf("Expected a total of 4 sentences apart from the one returned, got %d", got)
+	}
+}
@@ -0,0 +1,147 @@
+package wordcount
+
+import (
+	"context"
+	"io"
+	"slices"
+)
+
+// Counter counts inputs with a fixed configuration. It is built by New from
+// functional options and can be reused for any number of inputs. Inputs can be
+// counted concurrently unless WithUniqueTotal is used.
+type Counter struct {
+	options CountOptions
+}
+
+// Option configures a Counter
+type Option func(*CountOptions)
+
+// New returns a Counter configured by the options. Counts are reported in the
+// order their options are given; without any of WithBytes, WithLines,
+// WithWords, WithChars, WithUnique or WithMetric, lines, words and bytes are
+// counted, as the mwc command does by default.
+func New(opts ...Option) *Counter {
+	return &Counter{options: buildOptions(opts)}
+}
+
+// buildOptions applies the options to empty CountOptions and normalizes
+// them, counting lines, words and bytes when none of the counts is requested
+func buildOptions(opts []Option) CountOptions {
+	var options CountOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options.Normalize()
+}
+
+// Count counts everything read from input until EOF. The context is checked
+// between reads; once it is done, Count returns an error matching
+// ErrInterrupted along with the counts of everything read until then.
+func (c *Counter) Count(ctx context.Context, input io.Reader) (Counts, error) {
+	return CountContext(ctx, input, c.options)
+}
+
+// Options returns the CountOptions the counter was built with, for use with
+// the functions that take them directly, such as Estimate and Resume
+func (c *Counter) Options() CountOptions {
+	options := c.options
+	options.Order = append([]string(nil), c.options.Order...)
+	return options
+}
+
+// withCount returns an option that enables a count and adds it to the order
+func withCount(name string, enable func(*CountOptions) *bool) Option {
+	return func(o *CountOptions) {
+		if flag := enable(o); !*flag {
+			*flag = true
+			o.Order = append(o.Order, name)
+		}
+	}
+}
+
+// WithBytes counts bytes
+func WithBytes() Option {
+	return withCount("bytes", func(o *CountOptions) *bool { return &o.ByteCount })
+}
+
+// WithLines counts newline characters
+func WithLines() Option {
+	return withCount("lines", func(o *CountOptions) *bool { return &o.LineCount })
+}
+
+// WithWords counts words
+func WithWords() Option {
+	return withCount("words", func(o *CountOptions) *bool { return &o.WordCount })
+}
+
+// WithChars counts UTF-8 encoded characters
+func WithChars() Option {
+	return withCount("characters", func(o *CountOptions) *bool { return &o.CharacterCount })
+}
+
+// WithUnique counts distinct words. Once the words take more than maxMemory
+// bytes the count becomes an approximation; 0 means unlimited.
+func WithUnique(maxMemory int64) Option {
+	count := withCount("unique", func(o *CountOptions) *bool { return &o.UniqueCount })
+	return func(o *CountOptions) {
+		count(o)
+		o.MaxMemory = maxMemory
+	}
+}
+
+// WithFoldCase counts distinct words that differ only in case as one, with
+// full Unicode case folding, so "Straße" and "STRASSE" are the same word
+func WithFoldCase() Option {
+	return func(o *CountOptions) { o.FoldCase = true }
+}
+
+// WithBytewise counts as GNU wc does in the C locale, where every byte is a
+// character and only ASCII white space separates words
+func WithBytewise() Option {
+	return func(o *CountOptions) { o.Bytewise = true }
+}
+
+// WithMetric counts the registered metric with the given name. Counting
+// fails with an error if no such metric is registered.
+func WithMetric(name string) Option {
+	return func(o *CountOptions) {
+		if !slices.Contains(o.Metrics, name) {
+			o.Metrics = append(o.Metrics, name)
+			o.Order = append(o.Order, name)
+		}
+	}
+}
+
+// WithWordSplitter finds words with splitter instead of splitting at white
+// space, such as UnicodeWords or a domain-specific tokenizer
+func WithWordSplitter(splitter WordSplitter) Option {
+	return func(o *CountOptions) { o.WordSplitter = splitter }
+}
+
+// WithUniqueTotal collects the distinct words of every input the counter
+// counts into total, for a unique word count across inputs
+func WithUniqueTotal(total *UniqueWords) Option {
+	return func(o *CountOptions) { o.UniqueTotal = total }
+}
+
+// WithBufferSize reads inputs in chunks of size bytes, from MinBufferSize to
+// MaxBufferSize, instead of sizing buffers from each input
+func WithBufferSize(size int) Option {
+	return func(o *CountOptions) { o.BufferSize = min(max(size, MinBufferSize), MaxBufferSize) }
+}
+
+// WithRateLimiter limits the rate at which inputs are read. A limiter shared
+// between counters limits their combined rate.
+func WithRateLimiter(limiter *RateLimiter) Option {
+	return func(o *CountOptions) { o.RateLimiter = limiter }
+}
+
+// WithHooks calls hooks as inputs are counted, for progress displays and logging
+func WithHooks(hooks *Hooks) Option {
+	return func(o *CountOptions) { o.Hooks = hooks }
+}
+
+// WithEstimateBlocks sets the number of blocks Estimate samples
+func WithEstimateBlocks(blocks int) Option {
+	return func(o *CountOptions) { o.EstimateBlocks = blocks }
+}
@@ -0,0 +1,61 @@
+package wordcount
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestNew checks that functional options configure the counts and their order
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []Option
+		order    []string
+		expected Counts
+	}{
+		{
+			name:     "Default Counts",
+			order:    []string{"lines", "words", "bytes"},
+			expected: Counts{Lines: 2, Words: 4, Bytes: 31},
+		},
+		{
+			name:     "Words Only",
+			options:  []Option{WithWords()},
+			order:    []string{"words"},
+			expected: Counts{Words: 4},
+		},
+		{
+			name:     "Custom Order",
+			options:  []Option{WithChars(), WithLines(), WithChars()},
+			order:    []string{"characters", "lines"},
+			expected: Counts{Chars: 27, Lines: 2},
+		},
+		{
+			name:     "Unique Words",
+			options:  []Option{WithUnique(0), WithBytes(), WithBufferSize(1)},
+			order:    []string{"unique", "bytes"},
+			expected: Counts{Unique: 4, Bytes: 31},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := New(tt.options...)
+			if order := counter.Options().Order; !slices.Equal(order, tt.order) {
+				t.Errorf("Expected order %v, got %v", tt.order, order)
+			}
+			// The counter is reusable
+			for i := 0; i < 2; i++ {
+				counts, err := counter.Count(context.Background(), strings.NewReader("Hello, 世界!\nGoodbye, World!\n"))
+				if err != nil {
+					t.Fatalf("Error processing input: %v", err)
+				}
+				if !counts.Equal(tt.expected) {
+					t.Errorf("Expected %+v, got %+v", tt.expected, counts)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package wordcount
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+func init() {
+	RegisterMetric("dialogue", func() Metric { return &dialogueMetric{name: "dialogue"} })
+	RegisterMetric("narration", func() Metric { return &dialogueMetric{name: "narration"} })
+}
+
+// dialogueMetric counts the words of fiction spoken in dialogue, inside
+// quotation marks, as the "dialogue" metric, or the others as "narration".
+// Words are split as the built-in word count splits them, so dialogue and
+// narration add up to words. A word belongs to dialogue if it starts inside
+// quotation marks, or with an opening one; see quoteState for the marks.
+type dialogueMetric struct {
+	name      string
+	dialogue  int64
+	narration int64
+	quotes    quoteState
+	inWord    bool
+}
+
+func (m *dialogueMetric) Name() string { return m.name }
+
+func (m *dialogueMetric) ProcessChunk(chunk []byte) {
+	for i := 0; i < len(chunk); {
+		r, size := rune(chunk[i]), 1
+		if r >= utf8.RuneSelf {
+			r, size = utf8.DecodeRune(chunk[i:])
+		}
+		i += size
+		quoted := m.quotes.next(r)
+		if r < utf8.RuneSelf && byteClass[r] == classSpace || r >= utf8.RuneSelf && unicode.IsSpace(r) {
+			m.inWord = false
+			continue
+		}
+		if !m.inWord {
+			m.inWord = true
+			if quoted {
+				m.dialogue++
+			} else {
+				m.narration++
+			}
+		}
+	}
+}
+
+func (m *dialogueMetric) Result() int64 {
+	if m.name == "dialogue" {
+		return m.dialogue
+	}
+	return m.narration
+}
@@ -0,0 +1,48 @@
+package wordcount
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// TestDialogue tests the dialogue and narration metrics, including text split
+// across reads
+func TestDialogue(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		dialogue  int64
+		narration int64
+	}{
+		{"Empty Input", "", 0, 0},
+		{"Narration Only", "It was a dark and stormy night.", 0, 7},
+		{"Straight Quotes", `"Go away," she said. "Now."`, 3, 2},
+		{"Curly Quotes", "“Go away,” she said. “Now.”", 3, 2},
+		{"Guillemets", "« Allez ! » dit-elle.", 4, 1},
+		{"Lone Closing Mark", "“Wait ” he said", 2, 2},
+		{"Apostrophes", "“Don’t,” said O’Brien's dog.", 1, 3},
+		{"Unclosed Paragraph", "“First paragraph\n\n“Second one.”\n\nThe end.", 4, 2},
+		{"Quote Inside Word", `He said:"Go" twice`, 0, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, input := range []io.Reader{strings.NewReader(tt.input), iotest.OneByteReader(strings.NewReader(tt.input))} {
+				counts, err := Count(input, CountOptions{WordCount: true, Metrics: []string{"dialogue", "narration"}})
+				if err != nil {
+					t.Fatalf("Error processing input: %v", err)
+				}
+				dialogue, _ := counts.Get("dialogue")
+				narration, _ := counts.Get("narration")
+				if dialogue != tt.dialogue || narration != tt.narration {
+					t.Errorf("Expected %d dialogue and %d narration words, got %d and %d", tt.dialogue, tt.narration, dialogue, narration)
+				}
+				if dialogue+narration != counts.Words {
+					t.Errorf("Expected dialogue and narration to add up to %d words, got %d", counts.Words, dialogue+narration)
+				}
+			}
+		})
+	}
+}
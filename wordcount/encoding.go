@@ -0,0 +1,95 @@
+package wordcount
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// countsJSON has the fields of Counts without its methods, so encoding/json
+// uses the struct tags instead of calling MarshalJSON again
+type countsJSON Counts
+
+// MarshalJSON encodes the counts as an object keyed by the names used in
+// CountOptions.Order, such as {"bytes":14,"lines":1,"words":2,"characters":14},
+// with "unique", "max_line_length" and "metrics" present only when counted
+func (c Counts) MarshalJSON() ([]byte, error) {
+	return json.Marshal(countsJSON(c))
+}
+
+// MarshalText encodes the counts as String does
+func (c Counts) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// String formats the counts as name=count pairs, such as
+// "lines=1 words=2 characters=14 bytes=14", followed by the unique words and
+// the longest line when counted and the metrics in order of name
+func (c Counts) String() string {
+	var b strings.Builder
+	write := func(name string, count int64) {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(strconv.FormatInt(count, 10))
+	}
+	write("lines", c.Lines)
+	write("words", c.Words)
+	write("characters", c.Chars)
+	write("bytes", c.Bytes)
+	if c.Unique != 0 {
+		write("unique", c.Unique)
+	}
+	if c.MaxLineLength != 0 {
+		write("max_line_length", c.MaxLineLength)
+	}
+	names := make([]string, 0, len(c.Metrics))
+	for name := range c.Metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		write(name, c.Metrics[name])
+	}
+	return b.String()
+}
+
+// fileCountJSON is the JSON encoding of a FileCount: the file name, title and
+// author alongside the fields of its counts
+type fileCountJSON struct {
+	Filename string `json:"filename"`
+	Title    string `json:"title,omitempty"`
+	Author   string `json:"author,omitempty"`
+	countsJSON
+}
+
+// MarshalJSON encodes the file count as one flat object, such as
+// {"filename":"a.txt","bytes":14,"lines":1,"words":2,"characters":14}, with
+// "title" and "author" present only when known
+func (f FileCount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fileCountJSON{Filename: f.Filename, Title: f.Title, Author: f.Author, countsJSON: countsJSON(f.Counts)})
+}
+
+// UnmarshalJSON decodes a file count encoded by MarshalJSON
+func (f *FileCount) UnmarshalJSON(data []byte) error {
+	var decoded fileCountJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	f.Filename, f.Title, f.Author, f.Counts = decoded.Filename, decoded.Title, decoded.Author, Counts(decoded.countsJSON)
+	return nil
+}
+
+// MarshalText encodes the file count as String does
+func (f FileCount) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// String formats the file count as the file name followed by its counts, such
+// as "a.txt: lines=1 words=2 characters=14 bytes=14"
+func (f FileCount) String() string {
+	return f.Filename + ": " + f.Counts.String()
+}
@@ -0,0 +1,74 @@
+package wordcount
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestCountsEncoding tests the JSON and text encodings of counts
+func TestCountsEncoding(t *testing.T) {
+	tests := []struct {
+		name         string
+		counts       Counts
+		expectedJSON string
+		expectedText string
+	}{
+		{
+			name:         "Built-in Counts",
+			counts:       Counts{Bytes: 14, Lines: 1, Words: 2, Chars: 14},
+			expectedJSON: `{"bytes":14,"lines":1,"words":2,"characters":14}`,
+			expectedText: "lines=1 words=2 characters=14 bytes=14",
+		},
+		{
+			name:         "Unique Words And Metrics",
+			counts:       Counts{Words: 3, Unique: 2, Metrics: map[string]int64{"vowels": 5, "sentences": 1}},
+			expectedJSON: `{"bytes":0,"lines":0,"words":3,"characters":0,"unique":2,"metrics":{"sentences":1,"vowels":5}}`,
+			expectedText: "lines=0 words=3 characters=0 bytes=0 unique=2 sentences=1 vowels=5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.counts)
+			if err != nil || string(data) != tt.expectedJSON {
+				t.Errorf("json.Marshal() = %s, %v, want %s", data, err, tt.expectedJSON)
+			}
+			var decoded Counts
+			if err := json.Unmarshal(data, &decoded); err != nil || !decoded.Equal(tt.counts) {
+				t.Errorf("json.Unmarshal() = %+v, %v, want %+v", decoded, err, tt.counts)
+			}
+			if text, _ := tt.counts.MarshalText(); string(text) != tt.expectedText {
+				t.Errorf("MarshalText() = %s, want %s", text, tt.expectedText)
+			}
+		})
+	}
+}
+
+// TestFileCountEncoding tests that file counts encode as flat objects and round trip
+func TestFileCountEncoding(t *testing.T) {
+	fc := FileCount{Filename: "a.txt", Counts: Counts{Bytes: 14, Lines: 1, Words: 2, Chars: 14}}
+	data, err := json.Marshal([]FileCount{fc})
+	expected := `[{"filename":"a.txt","bytes":14,"lines":1,"words":2,"characters":14}]`
+	if err != nil || string(data) != expected {
+		t.Errorf("json.Marshal() = %s, %v, want %s", data, err, expected)
+	}
+	var decoded []FileCount
+	if err := json.Unmarshal(data, &decoded); err != nil || len(decoded) != 1 ||
+		decoded[0].Filename != fc.Filename || !decoded[0].Counts.Equal(fc.Counts) {
+		t.Errorf("json.Unmarshal() = %+v, %v, want [%+v]", decoded, err, fc)
+	}
+	if s := fc.String(); s != "a.txt: lines=1 words=2 characters=14 bytes=14" {
+		t.Errorf("String() = %q", s)
+	}
+
+	fc = FileCount{Filename: "01.md", Title: "The Storm", Author: "Jane Doe", Counts: Counts{Words: 2}}
+	data, err = json.Marshal(fc)
+	expected = `{"filename":"01.md","title":"The Storm","author":"Jane Doe","bytes":0,"lines":0,"words":2,"characters":0}`
+	if err != nil || string(data) != expected {
+		t.Errorf("json.Marshal() = %s, %v, want %s", data, err, expected)
+	}
+	var titled FileCount
+	if err := json.Unmarshal(data, &titled); err != nil || titled.Title != fc.Title || titled.Author != fc.Author {
+		t.Errorf("json.Unmarshal() = %+v, %v, want %+v", titled, err, fc)
+	}
+}
@@ -0,0 +1,85 @@
+package wordcount
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// ErrIllegalOption is matched by errors.Is for every error caused by an
+// invalid option, whether in CountOptions or on the mwc command line
+var ErrIllegalOption = errors.New("illegal option")
+
+// OptionError reports an invalid option. It matches ErrIllegalOption.
+type OptionError struct {
+	Option string // the option, such as "Metrics" or "--buffer-size"
+	Msg    string // what is wrong with it
+}
+
+func (e *OptionError) Error() string {
+	return e.Msg
+}
+
+// Is reports whether target is ErrIllegalOption
+func (e *OptionError) Is(target error) bool {
+	return target == ErrIllegalOption
+}
+
+// ErrInterrupted is matched by errors.Is for the error returned when
+// counting stops early because its context is done. The counts returned with
+// it are those of the data read until then, rather than nothing.
+var ErrInterrupted = errors.New("counting interrupted")
+
+// InterruptedError reports that counting was stopped by its context before
+// the end of the input. It matches ErrInterrupted and unwraps to the
+// context's error, such as context.Canceled.
+type InterruptedError struct {
+	Err error
+}
+
+func (e *InterruptedError) Error() string {
+	return "counting interrupted: " + e.Err.Error()
+}
+
+// Is reports whether target is ErrInterrupted
+func (e *InterruptedError) Is(target error) bool {
+	return target == ErrInterrupted
+}
+
+func (e *InterruptedError) Unwrap() error {
+	return e.Err
+}
+
+// ReadError reports a failure reading an input, as opposed to opening it
+type ReadError struct {
+	Err error
+}
+
+func (e *ReadError) Error() string {
+	return "error reading file: " + e.Err.Error()
+}
+
+func (e *ReadError) Unwrap() error {
+	return e.Err
+}
+
+// FileError reports a failure opening or counting a named file. Whether the
+// file was missing or failed to read can be told apart with
+// errors.Is(err, fs.ErrNotExist) and errors.As with a *ReadError.
+type FileError struct {
+	Op   string // "open" or "count"
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	// Errors from opening the file already name it
+	var pathErr *fs.PathError
+	if errors.As(e.Err, &pathErr) && pathErr.Path == e.Path {
+		return e.Err.Error()
+	}
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
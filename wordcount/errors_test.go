@@ -0,0 +1,53 @@
+package wordcount
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"testing/iotest"
+)
+
+// TestErrors checks that missing files, failed reads and invalid options can be told apart
+func TestErrors(t *testing.T) {
+	fsys := fstest.MapFS{"a.txt": {Data: []byte("Hello\n")}}
+
+	_, err := CountFile(context.Background(), fsys, "missing.txt", CountOptions{})
+	var fileErr *FileError
+	if !errors.As(err, &fileErr) || fileErr.Path != "missing.txt" || fileErr.Op != "open" {
+		t.Errorf("Expected a FileError for missing.txt, got %v", err)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected fs.ErrNotExist, got %v", err)
+	}
+	if err.Error() != "open missing.txt: file does not exist" {
+		t.Errorf("Expected the path to be named once, got %q", err.Error())
+	}
+
+	_, err = Count(iotest.ErrReader(io.ErrUnexpectedEOF), CountOptions{})
+	var readErr *ReadError
+	if !errors.As(err, &readErr) || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Expected a ReadError wrapping the read error, got %v", err)
+	}
+	if errors.Is(err, ErrIllegalOption) {
+		t.Errorf("Expected a read error not to match ErrIllegalOption")
+	}
+
+	_, err = Count(iotest.ErrReader(io.ErrUnexpectedEOF), CountOptions{Metrics: []string{"syllables"}})
+	var optionErr *OptionError
+	if !errors.Is(err, ErrIllegalOption) || !errors.As(err, &optionErr) || optionErr.Option != "Metrics" {
+		t.Errorf("Expected an OptionError for Metrics, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = CountFile(ctx, fsys, "a.txt", CountOptions{})
+	if !errors.Is(err, ErrInterrupted) || errors.As(err, &fileErr) {
+		t.Errorf("Expected an InterruptedError rather than a FileError, got %v", err)
+	}
+	if err.Error() != "counting interrupted: context canceled" {
+		t.Errorf("Expected the context's error in the message, got %q", err.Error())
+	}
+}
@@ -0,0 +1,124 @@
+package wordcount
+
+import (
+	"io"
+	"math"
+	"unicode/utf8"
+)
+
+// Sampling parameters used by Estimate
+const (
+	DefaultEstimateBlocks = 64
+	EstimateBlockSize     = 64 * 1024
+	estimateZScore        = 1.96 // two-sided 95% confidence
+)
+
+// Estimate reads options.EstimateBlocks evenly spaced blocks of a file of
+// the given size and extrapolates the line, word and character counts from the
+// per-byte rates observed in the blocks. The byte count is exact. The returned
+// margin is the largest relative half-width of the 95% confidence intervals of
+// the extrapolated counts. Without options.EstimateBlocks, DefaultEstimateBlocks
+// are read; a file no larger than the blocks is counted exactly, with no margin.
+func Estimate(input io.ReaderAt, size int64, options CountOptions) (Counts, float64, error) {
+	if err := options.Validate(); err != nil {
+		return Counts{}, 0, err
+	}
+	blocks := options.EstimateBlocks
+	if blocks <= 1 {
+		blocks = DefaultEstimateBlocks
+	}
+	if size < int64(blocks)*EstimateBlockSize {
+		counts, err := Count(io.NewSectionReader(input, 0, size), options)
+		return counts, 0, err
+	}
+	buf := getBuffer(EstimateBlockSize)
+	defer putBuffer(buf)
+
+	// Per-byte rates of lines, words and characters for every sampled block
+	rates := [3][]float64{}
+	counter := counterPool.Get().(*fileCounter)
+	defer counterPool.Put(counter)
+
+	for i := 0; i < blocks; i++ {
+		// Every block but the first starts one byte early so the word
+		// state at the block start is known
+		offset := int64(i) * (size - EstimateBlockSize) / int64(blocks-1)
+		if offset > 0 {
+			offset--
+		}
+		n, err := input.ReadAt(*buf, offset)
+		if err != nil && err != io.EOF {
+			return Counts{}, 0, &ReadError{Err: err}
+		}
+		if options.RateLimiter != nil {
+			options.RateLimiter.Wait(n)
+		}
+		block := (*buf)[:n]
+
+		counter.reset(true)
+		counter.bytewise = options.Bytewise
+		if offset > 0 && len(block) > 0 {
+			// Don't count the tail of a word or rune cut off by the block start
+			counter.inWord = byteClass[block[0]] != classSpace
+			block = block[1:]
+			for len(block) > 0 && !utf8.RuneStart(block[0]) {
+				block = block[1:]
+			}
+		}
+		if len(block) == 0 {
+			continue
+		}
+		counter.write(block)
+		rates[0] = append(rates[0], float64(counter.lines)/float64(len(block)))
+		rates[1] = append(rates[1], float64(counter.words)/float64(len(block)))
+		rates[2] = append(rates[2], float64(counter.characters)/float64(len(block)))
+	}
+
+	// Finite population correction for sampling without replacement
+	sampled := float64(blocks) * EstimateBlockSize
+	correction := math.Sqrt(math.Max(0, 1-sampled/float64(size)))
+
+	var estimates [3]int64
+	margin := 0.0
+	for metric, samples := range rates {
+		mean, stddev := meanAndStddev(samples)
+		estimates[metric] = int64(math.Round(mean * float64(size)))
+		if mean > 0 {
+			relative := estimateZScore * stddev / math.Sqrt(float64(len(samples))) * correction / mean
+			margin = math.Max(margin, relative)
+		}
+	}
+
+	var counts Counts
+	if options.ByteCount {
+		counts.Bytes = size
+	}
+	if options.LineCount {
+		counts.Lines = estimates[0]
+	}
+	if options.WordCount {
+		counts.Words = estimates[1]
+	}
+	if options.CharacterCount {
+		counts.Chars = estimates[2]
+	}
+	return counts, margin, nil
+}
+
+// meanAndStddev returns the mean and sample standard deviation of values
+func meanAndStddev(values []float64) (mean, stddev float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	if len(values) < 2 {
+		return mean, 0
+	}
+	for _, v := range values {
+		stddev += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(stddev / float64(len(values)-1))
+}
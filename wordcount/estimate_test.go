@@ -0,0 +1,68 @@
+package wordcount
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestEstimate checks that sampled counts land close to the exact counts
+func TestEstimate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "large.txt")
+	if err := os.WriteFile(path, benchmarkText(16*1024*1024), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	options := CountOptions{LineCount: true, WordCount: true, ByteCount: true, CharacterCount: true, EstimateBlocks: DefaultEstimateBlocks}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open test file: %v", err)
+	}
+	defer file.Close()
+	exact, err := Count(file, options)
+	if err != nil {
+		t.Fatalf("Error processing input: %v", err)
+	}
+	estimated, margin, err := Estimate(file, exact.Bytes, options)
+	if err != nil {
+		t.Fatalf("Error estimating input: %v", err)
+	}
+
+	if margin <= 0 || margin > 0.05 {
+		t.Errorf("Expected a small positive margin of error, got %v", margin)
+	}
+	if estimated.Bytes != exact.Bytes {
+		t.Errorf("Expected exact byte count %d, got %d", exact.Bytes, estimated.Bytes)
+	}
+	for _, k := range []string{"lines", "words", "characters"} {
+		want, _ := exact.Get(k)
+		got, _ := estimated.Get(k)
+		if diff := math.Abs(float64(got-want)) / float64(want); diff > 0.01 {
+			t.Errorf("Expected %s estimate within 1%% of %d, got %d", k, want, got)
+		}
+	}
+}
+
+// TestEstimateSmallFile checks that a file smaller than the blocks sampled is
+// counted exactly, and that a single block is rejected rather than sampled
+func TestEstimateSmallFile(t *testing.T) {
+	text := "one two three\nfour five\n"
+	input := strings.NewReader(text)
+	options := CountOptions{LineCount: true, WordCount: true, ByteCount: true, EstimateBlocks: 4}
+	counts, margin, err := Estimate(input, int64(len(text)), options)
+	if err != nil {
+		t.Fatalf("Error estimating input: %v", err)
+	}
+	if counts.Lines != 2 || counts.Words != 5 || counts.Bytes != 24 || margin != 0 {
+		t.Errorf("Expected 2 lines, 5 words and 24 bytes with no margin, got %+v ±%v", counts, margin)
+	}
+
+	options.EstimateBlocks = 1
+	var optionErr *OptionError
+	if _, _, err := Estimate(input, int64(len(text)), options); !errors.As(err, &optionErr) {
+		t.Errorf("Expected an *OptionError for a single block, got %v", err)
+	}
+}
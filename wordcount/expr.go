@@ -0,0 +1,188 @@
+package wordcount
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expr is a count derived from other counts by an arithmetic expression, such
+// as words per line. It is evaluated from the final counts of each input, and
+// from the totals, rather than summed, so ratios stay ratios.
+type Expr struct {
+	Name   string // the name the derived count is reported by
+	Source string // the expression, as written
+	root   exprNode
+	names  []string
+}
+
+// ParseExpr parses a definition of the form "name=expression". Expressions
+// combine numbers and count names, such as words or sentences, with +, -, *,
+// / and parentheses, with the usual precedence.
+func ParseExpr(definition string) (*Expr, error) {
+	name, source, ok := strings.Cut(definition, "=")
+	name = strings.TrimSpace(name)
+	if !ok || !isExprName(name) {
+		return nil, fmt.Errorf("expected name=expression, got %q", definition)
+	}
+	p := exprParser{input: source}
+	p.next()
+	root, err := p.parseSum()
+	if err == nil && p.token != "" {
+		err = fmt.Errorf("unexpected %q", p.token)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", source, err)
+	}
+	return &Expr{Name: name, Source: strings.TrimSpace(source), root: root, names: p.names}, nil
+}
+
+// Names returns the names of the counts the expression uses, in order of
+// first use
+func (e *Expr) Names() []string {
+	return append([]string(nil), e.names...)
+}
+
+// Eval evaluates the expression with the given counts. Counts that weren't
+// counted are zero, and division by zero gives zero, so an empty input has a
+// density of zero rather than infinity.
+func (e *Expr) Eval(counts Counts) float64 {
+	return e.root.eval(counts)
+}
+
+// exprNode is a node of a parsed expression
+type exprNode interface {
+	eval(counts Counts) float64
+}
+
+type (
+	exprNumber float64
+	exprCount  string
+	exprNegate struct{ operand exprNode }
+	exprBinary struct {
+		op          byte
+		left, right exprNode
+	}
+)
+
+func (n exprNumber) eval(Counts) float64 { return float64(n) }
+
+func (n exprCount) eval(counts Counts) float64 {
+	count, _ := counts.Get(string(n))
+	return float64(count)
+}
+
+func (n exprNegate) eval(counts Counts) float64 { return -n.operand.eval(counts) }
+
+func (n exprBinary) eval(counts Counts) float64 {
+	left, right := n.left.eval(counts), n.right.eval(counts)
+	switch n.op {
+	case '+':
+		return left + right
+	case '-':
+		return left - right
+	case '*':
+		return left * right
+	}
+	if right == 0 {
+		return 0
+	}
+	return left / right
+}
+
+// exprParser is a recursive descent parser over the tokens of an expression
+type exprParser struct {
+	input string
+	token string // the current token; empty at the end of the input
+	names []string
+}
+
+// next moves to the next token: a number, a name or a single operator
+func (p *exprParser) next() {
+	p.input = strings.TrimLeftFunc(p.input, unicode.IsSpace)
+	end := strings.IndexFunc(p.input, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '.'
+	})
+	if end < 0 {
+		end = len(p.input)
+	}
+	if end == 0 && p.input != "" {
+		end = 1
+	}
+	p.token, p.input = p.input[:end], p.input[end:]
+}
+
+// parseSum parses terms joined by + and -
+func (p *exprParser) parseSum() (exprNode, error) {
+	left, err := p.parseProduct()
+	for err == nil && (p.token == "+" || p.token == "-") {
+		op := p.token[0]
+		p.next()
+		var right exprNode
+		right, err = p.parseProduct()
+		left = exprBinary{op: op, left: left, right: right}
+	}
+	return left, err
+}
+
+// parseProduct parses factors joined by * and /
+func (p *exprParser) parseProduct() (exprNode, error) {
+	left, err := p.parseFactor()
+	for err == nil && (p.token == "*" || p.token == "/") {
+		op := p.token[0]
+		p.next()
+		var right exprNode
+		right, err = p.parseFactor()
+		left = exprBinary{op: op, left: left, right: right}
+	}
+	return left, err
+}
+
+// parseFactor parses a number, a count name, a negation or a parenthesized sum
+func (p *exprParser) parseFactor() (exprNode, error) {
+	token := p.token
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case token == "-":
+		p.next()
+		operand, err := p.parseFactor()
+		return exprNegate{operand: operand}, err
+	case token == "(":
+		p.next()
+		node, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if p.token != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.next()
+		return node, nil
+	case isExprName(token):
+		p.next()
+		if !slices.Contains(p.names, token) {
+			p.names = append(p.names, token)
+		}
+		return exprCount(token), nil
+	}
+	number, err := strconv.ParseFloat(token, 64)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected %q", token)
+	}
+	p.next()
+	return exprNumber(number), nil
+}
+
+// isExprName reports whether s can name a count in an expression: a letter
+// followed by letters, digits and underscores
+func isExprName(s string) bool {
+	for i, r := range s {
+		if !unicode.IsLetter(r) && (i == 0 || (!unicode.IsDigit(r) && r != '_')) {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,64 @@
+package wordcount
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestExpr tests parsing and evaluating derived counts
+func TestExpr(t *testing.T) {
+	counts := Counts{Bytes: 120, Lines: 4, Words: 30, Metrics: map[string]int64{"sentences": 3}}
+	tests := []struct {
+		name          string
+		definition    string
+		expectedName  string
+		expectedNames []string
+		expected      float64
+	}{
+		{"Ratio", "density=words/lines", "density", []string{"words", "lines"}, 7.5},
+		{"Precedence", "x = bytes - words * 2 + 1", "x", []string{"bytes", "words"}, 61},
+		{"Parentheses And Negation", "x=-(bytes - words) / (lines + 2)", "x", []string{"bytes", "words", "lines"}, -15},
+		{"Metric", "per_sentence=words/sentences", "per_sentence", []string{"words", "sentences"}, 10},
+		{"Decimal Number", "kb=bytes/1.5", "kb", []string{"bytes"}, 80},
+		{"Division By Zero", "x=words/characters", "x", []string{"words", "characters"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := ParseExpr(tt.definition)
+			if err != nil {
+				t.Fatalf("ParseExpr() error = %v", err)
+			}
+			if expr.Name != tt.expectedName || !slices.Equal(expr.Names(), tt.expectedNames) {
+				t.Errorf("ParseExpr() = %s using %v, want %s using %v", expr.Name, expr.Names(), tt.expectedName, tt.expectedNames)
+			}
+			if value := expr.Eval(counts); value != tt.expected {
+				t.Errorf("Eval() = %v, want %v", value, tt.expected)
+			}
+		})
+	}
+}
+
+// TestExprErrors tests that malformed definitions are rejected
+func TestExprErrors(t *testing.T) {
+	tests := []struct {
+		definition  string
+		expectedErr string
+	}{
+		{"words/lines", `expected name=expression, got "words/lines"`},
+		{"2x=words", `expected name=expression, got "2x=words"`},
+		{"x=", `invalid expression "": unexpected end of expression`},
+		{"x=(words/lines", `invalid expression "(words/lines": missing )`},
+		{"x=words lines", `invalid expression "words lines": unexpected "lines"`},
+		{"x=words % 2", `invalid expression "words % 2": unexpected "%"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.definition, func(t *testing.T) {
+			_, err := ParseExpr(tt.definition)
+			if err == nil || err.Error() != tt.expectedErr {
+				t.Errorf("ParseExpr() error = %v, want %s", err, tt.expectedErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package wordcount
+
+import (
+	"bytes"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+)
+
+// dottedI is what full case folding turns a dotted capital İ into: an i
+// followed by a combining dot above
+var dottedI = []byte("i\u0307")
+
+// caseFolder folds the case of words for CountOptions.FoldCase. It isn't safe
+// for concurrent use, so each input gets its own.
+type caseFolder struct {
+	caser cases.Caser
+	buf   []byte
+}
+
+func newCaseFolder() *caseFolder {
+	return &caseFolder{caser: cases.Fold()}
+}
+
+// fold returns word with its case folded, so "Straße" and "STRASSE" both
+// become "strasse". A dotted capital İ folds to a plain i rather than an i
+// with a combining dot above, so "İstanbul" and "istanbul" are one word too.
+// The result is only valid until the next call.
+func (f *caseFolder) fold(word []byte) []byte {
+	f.buf = f.buf[:0]
+	for i, b := range word {
+		if b >= utf8.RuneSelf {
+			f.buf = append(f.buf, f.caser.Bytes(word[i:])...)
+			if bytes.Contains(f.buf, dottedI) {
+				f.buf = bytes.ReplaceAll(f.buf, dottedI, dottedI[:1])
+			}
+			return f.buf
+		}
+		// ASCII folds to lower case
+		if 'A' <= b && b <= 'Z' {
+			b += 'a' - 'A'
+		}
+		f.buf = append(f.buf, b)
+	}
+	return f.buf
+}
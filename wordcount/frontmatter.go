@@ -0,0 +1,108 @@
+package wordcount
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// frontMatterLines bounds how far ReadFrontMatter looks for the end of front
+// matter, so a long document that merely starts with a horizontal rule isn't
+// read to the end
+const frontMatterLines = 200
+
+// FrontMatter is the metadata of a Markdown document read from its YAML front
+// matter, the block between "---" lines at its start
+type FrontMatter struct {
+	Title  string
+	Author string
+}
+
+// ReadFrontMatter reads the title and author from the YAML front matter at the
+// start of r. Several authors, given as a list under author or authors, are
+// joined with commas. Only plain top-level fields are understood, which is all
+// the front matter of static site generators and Pandoc usually needs. Input
+// without front matter returns an empty FrontMatter and no error.
+func ReadFrontMatter(r io.Reader) (FrontMatter, error) {
+	var meta FrontMatter
+	reader := bufio.NewReader(r)
+	line, err := readFrontMatterLine(reader)
+	if strings.TrimPrefix(line, "\ufeff") != "---" {
+		if err == io.EOF {
+			err = nil
+		}
+		return FrontMatter{}, err
+	}
+
+	var list *[]string
+	var authors []string
+	for i := 0; i < frontMatterLines && err == nil; i++ {
+		line, err = readFrontMatterLine(reader)
+		if err != nil && err != io.EOF {
+			return FrontMatter{}, err
+		}
+		if line == "---" || line == "..." {
+			meta.Author = strings.Join(authors, ", ")
+			return meta, nil
+		}
+		// Items of a block list belong to the field above them
+		if trimmed := strings.TrimSpace(line); list != nil && strings.HasPrefix(trimmed, "- ") {
+			*list = append(*list, yamlScalar(trimmed[2:]))
+			continue
+		}
+		list = nil
+		if line == "" || line[0] == ' ' || line[0] == '\t' || line[0] == '#' {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "title":
+			meta.Title = yamlScalar(value)
+		case "author", "authors":
+			authors = nil
+			if value == "" {
+				list = &authors
+			} else if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+				for _, item := range strings.Split(value[1:len(value)-1], ",") {
+					if item = yamlScalar(item); item != "" {
+						authors = append(authors, item)
+					}
+				}
+			} else {
+				authors = []string{yamlScalar(value)}
+			}
+		}
+	}
+	// Without a closing line, the document has no front matter after all
+	return FrontMatter{}, nil
+}
+
+// readFrontMatterLine reads a line without its line ending or trailing spaces
+func readFrontMatterLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	return strings.TrimRight(line, " \t\r\n"), err
+}
+
+// yamlScalar returns the string a YAML scalar value stands for: quoted values
+// are unquoted, and comments are dropped from plain ones
+func yamlScalar(value string) string {
+	value = strings.TrimSpace(value)
+	switch {
+	case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			return unquoted
+		}
+		return value[1 : len(value)-1]
+	case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value
+}
@@ -0,0 +1,40 @@
+package wordcount
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestReadFrontMatter tests reading the title and author from YAML front matter
+func TestReadFrontMatter(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected FrontMatter
+	}{
+		{"No Front Matter", "# Chapter One\n\nIt was night.\n", FrontMatter{}},
+		{"Empty Input", "", FrontMatter{}},
+		{"Plain", "---\ntitle: The Storm\nauthor: Jane Doe\n---\nText\n", FrontMatter{"The Storm", "Jane Doe"}},
+		{"Quoted", "---\ntitle: \"Chapter 1: \\\"Rain\\\"\"\nauthor: 'O''Brien'\n...\n", FrontMatter{`Chapter 1: "Rain"`, "O'Brien"}},
+		{"Comment", "---\ntitle: Dusk # working title\n---\n", FrontMatter{Title: "Dusk"}},
+		{"Flow List", "---\nauthors: [Ann Lee, \"Bo Kim\"]\n---\n", FrontMatter{Author: "Ann Lee, Bo Kim"}},
+		{"Block List", "---\ntitle: Dawn\nauthor:\n  - Ann Lee\n  - Bo Kim\ndate: 2024-01-01\n---\n", FrontMatter{"Dawn", "Ann Lee, Bo Kim"}},
+		{"Nested Fields", "---\nseries:\n  title: Seasons\ntitle: Spring\n---\n", FrontMatter{Title: "Spring"}},
+		{"CRLF", "---\r\ntitle: Windows\r\n---\r\n", FrontMatter{Title: "Windows"}},
+		{"Byte Order Mark", "\ufeff---\ntitle: Marked\n---\n", FrontMatter{Title: "Marked"}},
+		{"Unclosed", "---\ntitle: Rule\n\nNot front matter after all.\n", FrontMatter{}},
+		{"Horizontal Rule", "---\n\nText after a rule.\n\n---\n", FrontMatter{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, err := ReadFrontMatter(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("Error reading front matter: %v", err)
+			}
+			if meta != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, meta)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package wordcount
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+)
+
+// CountFile counts the named file in fsys, such as an os.DirFS, embed.FS or
+// fstest.MapFS. Errors opening or reading the file are returned as a
+// *FileError; a cancelled context stops counting with an *InterruptedError
+// and the partial counts, as with CountContext.
+func CountFile(ctx context.Context, fsys fs.FS, name string, options CountOptions) (Counts, error) {
+	options.Hooks.fileStart(name)
+	counts, err := countFile(ctx, fsys, name, options)
+	options.Hooks.fileDone(name, counts, err)
+	return counts, err
+}
+
+// countFile is CountFile without the start and done hooks
+func countFile(ctx context.Context, fsys fs.FS, name string, options CountOptions) (Counts, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return Counts{}, &FileError{Op: "open", Path: name, Err: err}
+	}
+	defer file.Close()
+	counts, err := countContext(ctx, name, file, options)
+	if err != nil && !errors.Is(err, ErrInterrupted) {
+		err = &FileError{Op: "count", Path: name, Err: err}
+	}
+	return counts, err
+}
+
+// CountFS counts every regular file in fsys under root, in lexical order.
+// Counting stops at the first error, which is returned along with the counts
+// of the files counted before it. When the context is done, the partial
+// counts of the file being counted are included, with an *InterruptedError.
+func CountFS(ctx context.Context, fsys fs.FS, root string, options CountOptions) ([]FileCount, error) {
+	var fileCounts []FileCount
+	err := fs.WalkDir(fsys, root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return err
+		}
+		if ctx.Err() != nil {
+			return &InterruptedError{Err: ctx.Err()}
+		}
+		counts, err := CountFile(ctx, fsys, path, options)
+		if errors.Is(err, ErrInterrupted) {
+			fileCounts = append(fileCounts, FileCount{Filename: path, Counts: counts})
+		}
+		if err != nil {
+			return err
+		}
+		fileCounts = append(fileCounts, FileCount{Filename: path, Counts: counts})
+		return nil
+	})
+	return fileCounts, err
+}
@@ -0,0 +1,85 @@
+package wordcount
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// TestCountFS checks counting files through a virtual filesystem
+func TestCountFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"docs/a.txt":        {Data: []byte("Hello, World!\n")},
+		"docs/nested/b.txt": {Data: []byte("Goodbye, 世界!\n")},
+		"docs/empty.txt":    {Data: nil},
+		"other.txt":         {Data: []byte("not counted\n")},
+	}
+	options := CountOptions{LineCount: true, WordCount: true, CharacterCount: true}
+
+	counts, err := CountFile(context.Background(), fsys, "docs/nested/b.txt", options)
+	if err != nil {
+		t.Fatalf("Error counting file: %v", err)
+	}
+	if expected := (Counts{Lines: 1, Words: 2, Chars: 13}); !counts.Equal(expected) {
+		t.Errorf("Expected %+v, got %+v", expected, counts)
+	}
+
+	fileCounts, err := CountFS(context.Background(), fsys, "docs", options)
+	if err != nil {
+		t.Fatalf("Error counting files: %v", err)
+	}
+	expected := []FileCount{
+		{Filename: "docs/a.txt", Counts: Counts{Lines: 1, Words: 2, Chars: 14}},
+		{Filename: "docs/empty.txt"},
+		{Filename: "docs/nested/b.txt", Counts: Counts{Lines: 1, Words: 2, Chars: 13}},
+	}
+	if len(fileCounts) != len(expected) {
+		t.Fatalf("Expected %d files, got %d: %+v", len(expected), len(fileCounts), fileCounts)
+	}
+	for i := range expected {
+		if fileCounts[i].Filename != expected[i].Filename || !fileCounts[i].Counts.Equal(expected[i].Counts) {
+			t.Errorf("Expected %+v, got %+v", expected[i], fileCounts[i])
+		}
+	}
+
+	if _, err := CountFile(context.Background(), fsys, "missing.txt", options); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected fs.ErrNotExist, got %v", err)
+	}
+}
+
+// TestCountFSInterrupted checks that cancelling keeps the counts of finished files and the partial file
+func TestCountFSInterrupted(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.txt": {Data: []byte("Hello, World!\n")},
+		"b.txt": {Data: []byte(strings.Repeat("x", 3*MinBufferSize))},
+		"c.txt": {Data: []byte("not counted\n")},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hooks := &Hooks{OnProgress: func(name string, counts Counts) {
+		if name == "b.txt" {
+			cancel()
+		}
+	}}
+	options := CountOptions{ByteCount: true, BufferSize: MinBufferSize, Hooks: hooks}
+
+	fileCounts, err := CountFS(ctx, fsys, ".", options)
+	if !errors.Is(err, ErrInterrupted) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected ErrInterrupted and context.Canceled, got %v", err)
+	}
+	expected := []FileCount{
+		{Filename: "a.txt", Counts: Counts{Bytes: 14}},
+		{Filename: "b.txt", Counts: Counts{Bytes: MinBufferSize}},
+	}
+	if len(fileCounts) != len(expected) {
+		t.Fatalf("Expected %d files, got %d: %+v", len(expected), len(fileCounts), fileCounts)
+	}
+	for i := range expected {
+		if fileCounts[i].Filename != expected[i].Filename || !fileCounts[i].Counts.Equal(expected[i].Counts) {
+			t.Errorf("Expected %+v, got %+v", expected[i], fileCounts[i])
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package wordcount
+
+// Hooks are functions called as inputs are counted, so a program can drive
+// its own progress display or logging instead of waiting for the results.
+// Any of them may be nil. The name is the file name for CountFile and
+// CountFS and empty for inputs counted by Count, CountContext and
+// Counter.Count. Hooks are called from the goroutine counting the input, so
+// they should return quickly, and hooks shared by inputs counted
+// concurrently must be safe for concurrent use.
+type Hooks struct {
+	// OnFileStart is called before an input is counted
+	OnFileStart func(name string)
+	// OnProgress is called after each chunk read from an input is counted,
+	// with the counts of the input so far
+	OnProgress func(name string, counts Counts)
+	// OnFileDone is called once an input has been counted, or has failed,
+	// with the results the counting function returns
+	OnFileDone func(name string, counts Counts, err error)
+}
+
+// fileStart calls OnFileStart, if set
+func (h *Hooks) fileStart(name string) {
+	if h != nil && h.OnFileStart != nil {
+		h.OnFileStart(name)
+	}
+}
+
+// progress returns the function reporting the progress of an input to
+// OnProgress, or nil if there's no OnProgress
+func (h *Hooks) progress(name string, counter *fileCounter, options CountOptions) func() {
+	if h == nil || h.OnProgress == nil {
+		return nil
+	}
+	return func() {
+		h.OnProgress(name, counter.counts(options))
+	}
+}
+
+// fileDone calls OnFileDone, if set
+func (h *Hooks) fileDone(name string, counts Counts, err error) {
+	if h != nil && h.OnFileDone != nil {
+		h.OnFileDone(name, counts, err)
+	}
+}
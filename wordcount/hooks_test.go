@@ -0,0 +1,87 @@
+package wordcount
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"testing/iotest"
+)
+
+// hookLog records the hooks called, in order
+type hookLog struct {
+	events   []string
+	progress []Counts
+	done     Counts
+	err      error
+}
+
+func (l *hookLog) hooks() *Hooks {
+	return &Hooks{
+		OnFileStart: func(name string) { l.events = append(l.events, "start "+name) },
+		OnProgress: func(name string, counts Counts) {
+			l.events = append(l.events, "progress "+name)
+			l.progress = append(l.progress, counts)
+		},
+		OnFileDone: func(name string, counts Counts, err error) {
+			l.events = append(l.events, "done "+name)
+			l.done, l.err = counts, err
+		},
+	}
+}
+
+// TestHooks tests that the hooks see every chunk and the final counts of an input
+func TestHooks(t *testing.T) {
+	var log hookLog
+	counter := New(WithWords(), WithHooks(log.hooks()))
+	counts, err := counter.Count(context.Background(), iotest.OneByteReader(strings.NewReader("a b")))
+	if err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+
+	// One chunk per byte
+	expectedEvents := []string{"start ", "progress ", "progress ", "progress ", "done "}
+	if strings.Join(log.events, ",") != strings.Join(expectedEvents, ",") {
+		t.Errorf("hooks called %q, want %q", log.events, expectedEvents)
+	}
+	for i, words := range []int64{1, 1, 2} {
+		if log.progress[i].Words != words {
+			t.Errorf("progress %d words = %d, want %d", i, log.progress[i].Words, words)
+		}
+	}
+	if !log.done.Equal(counts) || log.err != nil {
+		t.Errorf("OnFileDone got %+v, %v, want %+v, nil", log.done, log.err, counts)
+	}
+}
+
+// TestHooksFS tests that the hooks get file names and errors from CountFS and CountFile
+func TestHooksFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"docs/a.txt": {Data: []byte("one two\n")},
+		"docs/b.txt": {Data: []byte("three\n")},
+	}
+	var log hookLog
+	options := CountOptions{WordCount: true, Hooks: log.hooks()}
+	if _, err := CountFS(context.Background(), fsys, "docs", options); err != nil {
+		t.Fatalf("CountFS() error = %v", err)
+	}
+	expectedEvents := []string{"start docs/a.txt", "progress docs/a.txt", "done docs/a.txt",
+		"start docs/b.txt", "progress docs/b.txt", "done docs/b.txt"}
+	if strings.Join(log.events, ",") != strings.Join(expectedEvents, ",") {
+		t.Errorf("hooks called %q, want %q", log.events, expectedEvents)
+	}
+	if log.done.Words != 1 {
+		t.Errorf("OnFileDone words = %d, want 1", log.done.Words)
+	}
+
+	log = hookLog{}
+	_, err := CountFile(context.Background(), fsys, "missing.txt", options)
+	if strings.Join(log.events, ",") != "start missing.txt,done missing.txt" {
+		t.Errorf("hooks called %q for a missing file", log.events)
+	}
+	if log.err != err || !errors.Is(log.err, fs.ErrNotExist) {
+		t.Errorf("OnFileDone error = %v, want %v", log.err, err)
+	}
+}
@@ -0,0 +1,110 @@
+package wordcount
+
+import "unicode/utf8"
+
+func init() {
+	RegisterMetric("invalid_utf8", func() Metric { return &malformedMetric{name: "invalid_utf8"} })
+	RegisterMetric("overlong", func() Metric { return &malformedMetric{name: "overlong"} })
+	RegisterMetric("surrogates", func() Metric { return &malformedMetric{name: "surrogates"} })
+}
+
+// Kinds of ill-formed UTF-8 sequences
+const (
+	malformedInvalid   = iota // any other invalid byte or sequence
+	malformedOverlong         // a code point encoded in more bytes than it needs
+	malformedSurrogate        // a UTF-16 surrogate, which UTF-8 can't encode
+)
+
+// malformedMetric counts ill-formed UTF-8 sequences: all of them as
+// "invalid_utf8", and of those, overlong encodings as "overlong" and encoded
+// UTF-16 surrogates, as CESU-8 and WTF-8 produce, as "surrogates". A sequence
+// is a lead byte and the continuation bytes following it, counted once
+// however many bytes it has.
+type malformedMetric struct {
+	name    string
+	count   int64
+	pending []byte // a sequence cut off at the end of the last chunk
+}
+
+func (m *malformedMetric) Name() string { return m.name }
+
+func (m *malformedMetric) ProcessChunk(chunk []byte) {
+	if len(m.pending) > 0 {
+		// Chunks never split a valid rune, but they may split an ill-formed
+		// sequence, which the chunk completes
+		chunk = append(m.pending, chunk...)
+		m.pending = nil
+	}
+	for i := 0; i < len(chunk); {
+		if chunk[i] < utf8.RuneSelf {
+			i++
+			continue
+		}
+		if r, size := utf8.DecodeRune(chunk[i:]); r != utf8.RuneError || size > 1 {
+			i += size
+			continue
+		}
+		size, kind := malformedSequence(chunk[i:])
+		if size == 0 {
+			// The sequence may go on in the next chunk
+			m.pending = append([]byte(nil), chunk[i:]...)
+			return
+		}
+		m.add(kind)
+		i += size
+	}
+}
+
+// add counts a sequence of the kind if it is one this metric counts
+func (m *malformedMetric) add(kind int) {
+	switch {
+	case m.name == "invalid_utf8",
+		m.name == "overlong" && kind == malformedOverlong,
+		m.name == "surrogates" && kind == malformedSurrogate:
+		m.count++
+	}
+}
+
+func (m *malformedMetric) Result() int64 {
+	if len(m.pending) > 0 && m.name == "invalid_utf8" {
+		// A sequence cut off by the end of the input
+		return m.count + 1
+	}
+	return m.count
+}
+
+// malformedSequence returns the length and kind of the ill-formed sequence
+// at the start of b, or 0 if b ends before the sequence does
+func malformedSequence(b []byte) (int, int) {
+	lead := b[0]
+	var n int
+	var value rune
+	switch {
+	case lead&0xe0 == 0xc0:
+		n, value = 2, rune(lead&0x1f)
+	case lead&0xf0 == 0xe0:
+		n, value = 3, rune(lead&0x0f)
+	case lead&0xf8 == 0xf0:
+		n, value = 4, rune(lead&0x07)
+	default:
+		// A stray continuation byte, or a byte that never starts a sequence
+		return 1, malformedInvalid
+	}
+	for i := 1; i < n; i++ {
+		if i == len(b) {
+			return 0, malformedInvalid
+		}
+		if b[i]&0xc0 != 0x80 {
+			// Cut short by a byte that doesn't continue it
+			return i, malformedInvalid
+		}
+		value = value<<6 | rune(b[i]&0x3f)
+	}
+	switch {
+	case n == 2 && value < 0x80, n == 3 && value < 0x800, n == 4 && value < 0x10000:
+		return n, malformedOverlong
+	case 0xd800 <= value && value <= 0xdfff:
+		return n, malformedSurrogate
+	}
+	return n, malformedInvalid
+}
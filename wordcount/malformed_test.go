@@ -0,0 +1,48 @@
+package wordcount
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// TestMalformedUTF8 tests the invalid_utf8, overlong and surrogates metrics,
+// including sequences split across reads
+func TestMalformedUTF8(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		invalid    int64
+		overlong   int64
+		surrogates int64
+	}{
+		{"Empty Input", "", 0, 0, 0},
+		{"Valid Text", "café 世界 \U0001f44b", 0, 0, 0},
+		{"Stray Bytes", "a\x80b\xff", 2, 0, 0},
+		{"Overlong Slash", "a\xc0\xafb \xe0\x80\xaf", 2, 2, 0},
+		{"Lone Surrogate", "x\xed\xa0\x80y", 1, 0, 1},
+		{"CESU-8 Pair", "\xed\xa0\xbd\xed\xb8\x80", 2, 0, 2},
+		{"Truncated Sequence", "\xe4\xb8 ok", 1, 0, 0},
+		{"Truncated At End", "ok \xf0\x9f\x91", 1, 0, 0},
+		{"Beyond Unicode", "\xf4\x90\x80\x80", 1, 0, 0},
+	}
+
+	metrics := []string{"invalid_utf8", "overlong", "surrogates"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, input := range []io.Reader{strings.NewReader(tt.input), iotest.OneByteReader(strings.NewReader(tt.input))} {
+				counts, err := Count(input, CountOptions{Metrics: metrics, CharacterCount: true})
+				if err != nil {
+					t.Fatalf("Error processing input: %v", err)
+				}
+				invalid, _ := counts.Get("invalid_utf8")
+				overlong, _ := counts.Get("overlong")
+				surrogates, _ := counts.Get("surrogates")
+				if invalid != tt.invalid || overlong != tt.overlong || surrogates != tt.surrogates {
+					t.Errorf("Expected %d %d %d, got %d %d %d", tt.invalid, tt.overlong, tt.surrogates, invalid, overlong, surrogates)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package wordcount
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"unicode/utf8"
+)
+
+// Metric is a count computed from the data of one input, in addition to the
+// built-in bytes, lines, words, characters and unique words. A Metric sees
+// every byte of the input once, in order, in chunks that never split a UTF-8
+// encoded rune; it keeps whatever state it needs between chunks, such as
+// whether the previous chunk ended inside a word.
+type Metric interface {
+	// Name is the name the metric is requested and reported by
+	Name() string
+	// ProcessChunk counts the next chunk of the input
+	ProcessChunk(chunk []byte)
+	// Result returns the count of the data processed so far. It is called
+	// once the whole input has been processed, and may also be called
+	// earlier for progress reports.
+	Result() int64
+}
+
+// metrics holds the registered metrics by name
+var (
+	metricsMu sync.RWMutex
+	metrics   = map[string]func() Metric{}
+)
+
+// RegisterMetric makes a metric available by name to CountOptions.Metrics.
+// The factory is called for every input counted, so each input gets a Metric
+// with fresh state. RegisterMetric panics if the name is already taken or is
+// one of the built-in counts, as registrations normally happen in init
+// functions where a clash is a programming error.
+func RegisterMetric(name string, factory func() Metric) {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	if _, ok := (Counts{}).builtin(name); ok {
+		panic(fmt.Sprintf("wordcount: metric %q is built in", name))
+	}
+	if _, ok := metrics[name]; ok {
+		panic(fmt.Sprintf("wordcount: metric %q registered twice", name))
+	}
+	metrics[name] = factory
+}
+
+// Metrics returns the names of the registered metrics, sorted
+func Metrics() []string {
+	metricsMu.RLock()
+	defer metricsMu.RUnlock()
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newMetrics creates a fresh Metric for each of the named metrics
+func newMetrics(names []string) ([]Metric, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	metricsMu.RLock()
+	defer metricsMu.RUnlock()
+	created := make([]Metric, 0, len(names))
+	for _, name := range names {
+		factory, ok := metrics[name]
+		if !ok {
+			return nil, &OptionError{Option: "Metrics", Msg: fmt.Sprintf("unknown metric %q", name)}
+		}
+		created = append(created, factory())
+	}
+	return created, nil
+}
+
+func init() {
+	RegisterMetric("sentences", func() Metric { return new(sentenceMetric) })
+}
+
+// sentenceMetric counts sentences: runs of text containing at least one
+// letter or digit and ending in '.', '!' or '?' followed by ASCII white space
+// or the end of the input. Text after the last terminator counts as a sentence
+// too, so "Hello there" is one sentence.
+type sentenceMetric struct {
+	sentences  int64
+	hasText    bool // whether the current sentence has a letter or digit
+	terminated bool // whether the last byte was a terminator
+}
+
+func (m *sentenceMetric) Name() string { return "sentences" }
+
+func (m *sentenceMetric) ProcessChunk(chunk []byte) {
+	for _, b := range chunk {
+		switch {
+		case b == '.' || b == '!' || b == '?':
+			m.terminated = true
+			continue
+		case byteClass[b] == classSpace:
+			if m.terminated && m.hasText {
+				m.sentences++
+				m.hasText = false
+			}
+		case b >= utf8.RuneSelf || ('0' <= b && b <= '9') || ('a' <= b|0x20 && b|0x20 <= 'z'):
+			// Bytes of multibyte runes are taken to be text
+			m.hasText = true
+		}
+		m.terminated = false
+	}
+}
+
+func (m *sentenceMetric) Result() int64 {
+	if m.hasText {
+		return m.sentences + 1
+	}
+	return m.sentences
+}
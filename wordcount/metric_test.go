@@ -0,0 +1,103 @@
+package wordcount
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// vowelMetric is a custom metric counting ASCII vowels
+type vowelMetric struct{ vowels int64 }
+
+func (m *vowelMetric) Name() string { return "vowels" }
+
+func (m *vowelMetric) ProcessChunk(chunk []byte) {
+	for _, b := range chunk {
+		if strings.IndexByte("aeiouAEIOU", b) >= 0 {
+			m.vowels++
+		}
+	}
+}
+
+func (m *vowelMetric) Result() int64 { return m.vowels }
+
+func init() {
+	RegisterMetric("vowels", func() Metric { return new(vowelMetric) })
+}
+
+// TestSentences tests the built-in sentence metric, including sentences split across reads
+func TestSentences(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected int64
+	}{
+		{"Empty Input", "", 0},
+		{"No Terminator", "Hello there", 1},
+		{"Several Sentences", "One. Two! Three? Four.", 4},
+		{"Ellipsis And Spacing", "Wait... what?!\n\nYes.  ", 3},
+		{"Decimal Number", "Pi is 3.14 roughly.", 1},
+		{"Punctuation Only", "... !!! ???", 0},
+		{"Unicode Text", "Привет мир. 世界です.", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, input := range []io.Reader{strings.NewReader(tt.input), iotest.OneByteReader(strings.NewReader(tt.input))} {
+				counts, err := Count(input, CountOptions{Metrics: []string{"sentences"}})
+				if err != nil {
+					t.Fatalf("Error processing input: %v", err)
+				}
+				if sentences, _ := counts.Get("sentences"); sentences != tt.expected {
+					t.Errorf("Expected %d sentences, got %d", tt.expected, sentences)
+				}
+			}
+		})
+	}
+}
+
+// TestCustomMetric checks that metrics registered outside the package are counted and summed
+func TestCustomMetric(t *testing.T) {
+	if !slices.Contains(Metrics(), "vowels") || !slices.Contains(Metrics(), "sentences") {
+		t.Fatalf("Expected the registered metrics to be listed, got %v", Metrics())
+	}
+
+	counter := New(WithWords(), WithMetric("vowels"), WithMetric("sentences"))
+	if order := counter.Options().Order; !slices.Equal(order, []string{"words", "vowels", "sentences"}) {
+		t.Errorf("Expected metrics in the order they were requested, got %v", order)
+	}
+	var total Accumulator
+	for _, input := range []string{"Hello, World!", "An apple a day."} {
+		counts, err := counter.Count(context.Background(), strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("Error processing input: %v", err)
+		}
+		total.Add(counts)
+	}
+	expected := Counts{Words: 6, Metrics: map[string]int64{"vowels": 8, "sentences": 2}}
+	if !total.Total().Equal(expected) {
+		t.Errorf("Expected %+v, got %+v", expected, total.Total())
+	}
+
+	if _, err := Count(bytes.NewReader(nil), CountOptions{Metrics: []string{"syllables"}}); err == nil {
+		t.Errorf("Expected an error for an unknown metric")
+	}
+}
+
+// TestRegisterMetricConflicts checks that registering a taken name panics
+func TestRegisterMetricConflicts(t *testing.T) {
+	for _, name := range []string{"vowels", "words"} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected registering %q to panic", name)
+				}
+			}()
+			RegisterMetric(name, func() Metric { return new(vowelMetric) })
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package wordcount
+
+import (
+	"fmt"
+	"slices"
+)
+
+// builtinCounts lists the built-in counts in the order Normalize adds them
+// to Order, as wc prints them
+var builtinCounts = []string{"lines", "words", "characters", "bytes", "max_line_length", "unique"}
+
+// enabled returns the flag enabling the built-in count with the given name,
+// or nil if there's no such count
+func (o *CountOptions) enabled(name string) *bool {
+	switch name {
+	case "bytes":
+		return &o.ByteCount
+	case "lines":
+		return &o.LineCount
+	case "words":
+		return &o.WordCount
+	case "characters":
+		return &o.CharacterCount
+	case "unique":
+		return &o.UniqueCount
+	case "max_line_length":
+		return &o.MaxLineLength
+	}
+	return nil
+}
+
+// Validate reports the first problem found in the options as an
+// *OptionError, or nil if they're consistent. It catches mistakes that would
+// otherwise go unnoticed until the output looks wrong: names in Order that
+// aren't counted or are listed twice, unknown or repeated metrics, sizes out
+// of range, and counts Estimate can't produce.
+func (o CountOptions) Validate() error {
+	for i, name := range o.Order {
+		if slices.Contains(o.Order[:i], name) {
+			return &OptionError{Option: "Order", Msg: fmt.Sprintf("count %q is listed twice in Order", name)}
+		}
+		if flag := o.enabled(name); flag != nil {
+			if !*flag {
+				return &OptionError{Option: "Order", Msg: fmt.Sprintf("count %q is in Order but isn't enabled", name)}
+			}
+		} else if !slices.Contains(o.Metrics, name) {
+			return &OptionError{Option: "Order", Msg: fmt.Sprintf("unknown count %q in Order", name)}
+		}
+	}
+	registered := Metrics()
+	for i, name := range o.Metrics {
+		if !slices.Contains(registered, name) {
+			return &OptionError{Option: "Metrics", Msg: fmt.Sprintf("unknown metric %q", name)}
+		}
+		if slices.Contains(o.Metrics[:i], name) {
+			return &OptionError{Option: "Metrics", Msg: fmt.Sprintf("metric %q is listed twice", name)}
+		}
+	}
+	if o.EstimateBlocks < 0 || o.EstimateBlocks == 1 {
+		return &OptionError{Option: "EstimateBlocks", Msg: fmt.Sprintf("EstimateBlocks is %d, but must be 0 or at least 2", o.EstimateBlocks)}
+	}
+	if o.EstimateBlocks > 0 && (o.UniqueCount || len(o.Metrics) > 0) {
+		return &OptionError{Option: "EstimateBlocks", Msg: "unique words and metrics can't be estimated"}
+	}
+	if o.EstimateBlocks > 0 && o.MaxLineLength {
+		return &OptionError{Option: "EstimateBlocks", Msg: "the longest line can't be estimated"}
+	}
+	if o.TabStop < 0 {
+		return &OptionError{Option: "TabStop", Msg: fmt.Sprintf("TabStop is %d, but can't be negative", o.TabStop)}
+	}
+	if o.MaxMemory < 0 {
+		return &OptionError{Option: "MaxMemory", Msg: fmt.Sprintf("MaxMemory is %d, but can't be negative", o.MaxMemory)}
+	}
+	if o.BufferSize != 0 && (o.BufferSize < MinBufferSize || o.BufferSize > MaxBufferSize) {
+		return &OptionError{Option: "BufferSize", Msg: fmt.Sprintf("BufferSize is %d, but must be 0 or between %d and %d", o.BufferSize, MinBufferSize, MaxBufferSize)}
+	}
+	return nil
+}
+
+// Normalize returns a copy of the options with conflicts resolved the way
+// the mwc command resolves them: repeated names are dropped from Order and
+// Metrics, every count named in Order is enabled, and enabled counts missing
+// from Order are added to it, built-in counts first. Without any counts,
+// lines, words and bytes are counted. A nonzero BufferSize is clamped to
+// MinBufferSize and MaxBufferSize. Problems Normalize can't resolve, such as
+// unknown metrics, are left for Validate to report.
+func (o CountOptions) Normalize() CountOptions {
+	order := make([]string, 0, len(o.Order)+len(builtinCounts))
+	metrics := make([]string, 0, len(o.Metrics))
+	for _, name := range o.Order {
+		if slices.Contains(order, name) {
+			continue
+		}
+		order = append(order, name)
+		if flag := o.enabled(name); flag != nil {
+			*flag = true
+		} else if !slices.Contains(metrics, name) {
+			metrics = append(metrics, name)
+		}
+	}
+	for _, name := range o.Metrics {
+		if !slices.Contains(metrics, name) {
+			metrics = append(metrics, name)
+		}
+	}
+	if len(order) == 0 && len(metrics) == 0 && !o.ByteCount && !o.LineCount && !o.WordCount && !o.CharacterCount && !o.UniqueCount && !o.MaxLineLength {
+		o.LineCount, o.WordCount, o.ByteCount = true, true, true
+	}
+	for _, name := range builtinCounts {
+		if *o.enabled(name) && !slices.Contains(order, name) {
+			order = append(order, name)
+		}
+	}
+	for _, name := range metrics {
+		if !slices.Contains(order, name) {
+			order = append(order, name)
+		}
+	}
+	o.Order = order
+	o.Metrics = nil
+	if len(metrics) > 0 {
+		o.Metrics = metrics
+	}
+	if o.BufferSize != 0 {
+		o.BufferSize = min(max(o.BufferSize, MinBufferSize), MaxBufferSize)
+	}
+	return o
+}
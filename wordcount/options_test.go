@@ -0,0 +1,82 @@
+package wordcount
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// TestValidate tests that inconsistent options are reported as option errors
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		options     CountOptions
+		expectedErr string
+	}{
+		{"Valid Options", CountOptions{LineCount: true, Metrics: []string{"sentences"}, Order: []string{"lines", "sentences"}}, ""},
+		{"Enabled Count Missing From Order", CountOptions{WordCount: true}, ""},
+		{"Repeated Count", CountOptions{LineCount: true, Order: []string{"lines", "lines"}}, `count "lines" is listed twice in Order`},
+		{"Count Not Enabled", CountOptions{Order: []string{"words"}}, `count "words" is in Order but isn't enabled`},
+		{"Unknown Count", CountOptions{Order: []string{"syllables"}}, `unknown count "syllables" in Order`},
+		{"Unknown Metric", CountOptions{Metrics: []string{"syllables"}}, `unknown metric "syllables"`},
+		{"Repeated Metric", CountOptions{Metrics: []string{"sentences", "sentences"}}, `metric "sentences" is listed twice`},
+		{"Single Estimate Block", CountOptions{EstimateBlocks: 1}, "EstimateBlocks is 1, but must be 0 or at least 2"},
+		{"Estimated Unique Words", CountOptions{UniqueCount: true, EstimateBlocks: 8}, "unique words and metrics can't be estimated"},
+		{"Negative Memory Budget", CountOptions{MaxMemory: -1}, "MaxMemory is -1, but can't be negative"},
+		{"Buffer Too Small", CountOptions{BufferSize: 16}, "BufferSize is 16, but must be 0 or between 512 and 268435456"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.options.Validate()
+			if tt.expectedErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.expectedErr {
+				t.Errorf("Validate() error = %v, want %s", err, tt.expectedErr)
+			}
+			if !errors.Is(err, ErrIllegalOption) {
+				t.Errorf("Validate() error = %v, want an ErrIllegalOption", err)
+			}
+		})
+	}
+}
+
+// TestNormalize tests that Normalize resolves conflicts into options that validate
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name            string
+		options         CountOptions
+		expectedOrder   []string
+		expectedMetrics []string
+	}{
+		{"No Counts", CountOptions{}, []string{"lines", "words", "bytes"}, nil},
+		{"Repeated Counts", CountOptions{Order: []string{"words", "lines", "words"}}, []string{"words", "lines"}, nil},
+		{"Flags Without Order", CountOptions{ByteCount: true, CharacterCount: true, LineCount: true}, []string{"lines", "characters", "bytes"}, nil},
+		{"Flag Added After Order", CountOptions{UniqueCount: true, WordCount: true, Order: []string{"unique"}}, []string{"unique", "words"}, nil},
+		{"Metric In Order", CountOptions{Order: []string{"sentences", "lines"}}, []string{"sentences", "lines"}, []string{"sentences"}},
+		{"Repeated Metric", CountOptions{Metrics: []string{"sentences", "sentences"}}, []string{"sentences"}, []string{"sentences"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := tt.options.Normalize()
+			if !slices.Equal(options.Order, tt.expectedOrder) {
+				t.Errorf("Normalize() Order = %v, want %v", options.Order, tt.expectedOrder)
+			}
+			if !slices.Equal(options.Metrics, tt.expectedMetrics) {
+				t.Errorf("Normalize() Metrics = %v, want %v", options.Metrics, tt.expectedMetrics)
+			}
+			if err := options.Validate(); err != nil {
+				t.Errorf("Validate() after Normalize() error = %v", err)
+			}
+		})
+	}
+
+	if options := (CountOptions{BufferSize: 16}).Normalize(); options.BufferSize != MinBufferSize {
+		t.Errorf("Normalize() BufferSize = %d, want %d", options.BufferSize, MinBufferSize)
+	}
+}
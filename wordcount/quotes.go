@@ -0,0 +1,116 @@
+package wordcount
+
+import (
+	"io"
+	"unicode"
+	"unicode/utf8"
+)
+
+// quoteState follows whether text is inside quotation marks: straight double
+// quotes open and close in turn, “ and « open, and ” and » close. Single
+// quotes are left alone, as they double as apostrophes. An unclosed
+// quotation ends with its paragraph, at a blank line, as speech running over
+// several paragraphs opens each of them again.
+type quoteState struct {
+	quoted   bool
+	newlines int // newlines since the last text, to find blank lines
+}
+
+// next updates the state with the next rune of the text and reports whether
+// the rune is quoted, or one of the marks around a quotation
+func (q *quoteState) next(r rune) bool {
+	if r < utf8.RuneSelf && byteClass[r] == classSpace || r >= utf8.RuneSelf && unicode.IsSpace(r) {
+		if r == '\n' {
+			q.newlines++
+			if q.newlines > 1 {
+				q.quoted = false
+			}
+		}
+		return q.quoted
+	}
+	q.newlines = 0
+	switch {
+	case r == '“' || r == '«' || r == '"' && !q.quoted:
+		q.quoted = true
+		return true
+	case r == '”' || r == '»' || r == '"':
+		quoted := q.quoted
+		q.quoted = false
+		return quoted
+	}
+	return q.quoted
+}
+
+// quoteFilter is the reader returned by NewQuoteFilter
+type quoteFilter struct {
+	reader     io.Reader
+	state      quoteState
+	lineStart  bool
+	indent     int    // columns of white space starting the current line
+	blockQuote bool   // whether the current line is a block quote
+	buf        []byte // data read from reader
+	pending    []byte // an incomplete UTF-8 sequence at the end of the last read
+	out        []byte // filtered data not yet returned
+	err        error
+}
+
+// NewQuoteFilter returns a reader that reads r without the text inside
+// quotation marks, the marks included, and without block quotes: lines
+// starting with '>', after up to three spaces, as in Markdown and email.
+// Quotation marks follow the same rules as the dialogue metric. Newlines are
+// kept, so lines are still the lines of r, and counting the reader's data
+// counts the text that isn't quoted, as academic word limits do.
+func NewQuoteFilter(r io.Reader) io.Reader {
+	return &quoteFilter{reader: r, lineStart: true, buf: make([]byte, 32*1024)}
+}
+
+func (f *quoteFilter) Read(p []byte) (int, error) {
+	for len(f.out) == 0 {
+		if f.err != nil {
+			return 0, f.err
+		}
+		n, err := f.reader.Read(f.buf)
+		data := append(f.pending, f.buf[:n]...)
+		// A rune split between reads is filtered once it is whole
+		keep := 0
+		if err == nil {
+			keep = incompleteSuffix(data)
+		}
+		f.out = f.filter(f.out[:0], data[:len(data)-keep])
+		f.pending = append([]byte(nil), data[len(data)-keep:]...)
+		f.err = err
+	}
+	n := copy(p, f.out)
+	f.out = f.out[n:]
+	return n, nil
+}
+
+// filter appends the data that isn't quoted to dst
+func (f *quoteFilter) filter(dst, data []byte) []byte {
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRune(data[i:])
+		text := data[i : i+size]
+		i += size
+		switch {
+		case r == '\n':
+			f.state.next(r)
+			f.lineStart, f.blockQuote, f.indent = true, false, 0
+			dst = append(dst, '\n')
+			continue
+		case f.lineStart && (r == ' ' || r == '\t'):
+			f.indent++
+			if r == '\t' {
+				f.indent += 3
+			}
+			dst = append(dst, text...)
+			continue
+		case f.lineStart:
+			// Indented further, it is a code block
+			f.lineStart, f.blockQuote = false, r == '>' && f.indent < 4
+		}
+		if !f.blockQuote && !f.state.next(r) {
+			dst = append(dst, text...)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,43 @@
+package wordcount
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// TestQuoteFilter tests leaving out quotations and block quotes, including
+// runes split across reads
+func TestQuoteFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"Empty Input", "", ""},
+		{"No Quotes", "It was a dark night.\n", "It was a dark night.\n"},
+		{"Straight Quotes", `As Smith wrote, "the end is near," and it was.`, "As Smith wrote,  and it was."},
+		{"Curly Quotes", "He called it “a triumph” twice.", "He called it  twice."},
+		{"Apostrophes", "Don’t drop O'Brien's words.", "Don’t drop O'Brien's words."},
+		{"Multiline Quotation", "“one\ntwo” three\n", "\n three\n"},
+		{"Unclosed Paragraph", "“Speech\n\nNarration.\n", "\n\nNarration.\n"},
+		{"Block Quote", "Intro:\n> Quoted line\n   > also quoted\n>no space\nOutro.\n", "Intro:\n\n   \n\nOutro.\n"},
+		{"Indented Code Is Kept", "    > kept\n", "    > kept\n"},
+		{"Greater Than Inside Line", "a > b\n", "a > b\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, input := range []io.Reader{strings.NewReader(tt.input), iotest.OneByteReader(strings.NewReader(tt.input))} {
+				data, err := io.ReadAll(NewQuoteFilter(input))
+				if err != nil {
+					t.Fatalf("Error reading: %v", err)
+				}
+				if string(data) != tt.expected {
+					t.Errorf("Expected %q, got %q", tt.expected, data)
+				}
+			}
+		})
+	}
+}
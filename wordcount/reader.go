@@ -0,0 +1,62 @@
+package wordcount
+
+import "io"
+
+// Reader is an io.Reader that counts the data read through it, so counting
+// can be added to an existing pipeline, such as an HTTP body or a
+// decompressor, without reading the data twice. A Reader isn't safe for
+// concurrent use.
+type Reader struct {
+	reader   io.Reader
+	options  CountOptions
+	counter  fileCounter
+	err      error // error setting up the counter, returned by Read
+	finished bool  // whether the underlying reader has reached EOF
+}
+
+// NewReader returns a Reader counting everything read from r. The options
+// select the counts as for New; without any, lines, words and bytes are counted.
+func NewReader(r io.Reader, opts ...Option) *Reader {
+	reader := &Reader{reader: r, options: buildOptions(opts)}
+	reader.err = reader.counter.setup(reader.options)
+	return reader
+}
+
+// Read reads from the underlying reader and counts the data read
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	n, err := r.reader.Read(p)
+	r.counter.write(p[:n])
+	if err == io.EOF && !r.finished {
+		r.finished = true
+		r.counter.finish()
+		if r.options.UniqueTotal != nil && r.counter.unique != nil {
+			r.options.UniqueTotal.Merge(r.counter.unique)
+		}
+	}
+	return n, err
+}
+
+// Counts returns the counts of the data read so far. Until the underlying
+// reader reaches EOF, a rune cut off at the end counts as invalid bytes, and
+// neither the unique words nor the metrics include it or a word cut off at
+// the end.
+func (r *Reader) Counts() Counts {
+	if r.finished {
+		return r.counter.counts(r.options)
+	}
+	// Finish a copy, leaving out the unique words and metrics it shares, and
+	// with its own line widths
+	counter := r.counter
+	counter.unique, counter.metrics = nil, nil
+	counter.unsplit = append([]byte(nil), r.counter.unsplit...)
+	if r.counter.widths != nil {
+		widths := *r.counter.widths
+		counter.widths = &widths
+	}
+	counter.finish()
+	counter.unique, counter.metrics = r.counter.unique, r.counter.metrics
+	return counter.counts(r.options)
+}
@@ -0,0 +1,96 @@
+package wordcount
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// TestReader checks that data read through a Reader is passed on unchanged and counted like Count would
+func TestReader(t *testing.T) {
+	input := strings.Repeat("Hello, 世界! Привет мир\nGoodbye, World!\n", 50)
+	options := []Option{WithLines(), WithWords(), WithBytes(), WithChars(), WithUnique(0)}
+	expected, err := New(options...).Count(context.Background(), strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Error processing input: %v", err)
+	}
+
+	for name, wrap := range map[string]func(io.Reader) io.Reader{
+		"One Byte":  iotest.OneByteReader,
+		"Half":      iotest.HalfReader,
+		"Unchanged": func(r io.Reader) io.Reader { return r },
+	} {
+		t.Run(name, func(t *testing.T) {
+			reader := NewReader(wrap(strings.NewReader(input)), options...)
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("Error reading: %v", err)
+			}
+			if string(data) != input {
+				t.Errorf("Expected the data to pass through unchanged")
+			}
+			if counts := reader.Counts(); !counts.Equal(expected) {
+				t.Errorf("Expected %+v, got %+v", expected, counts)
+			}
+		})
+	}
+}
+
+// TestReaderCountsMidStream checks that taking the counts part of the way
+// through a stream, even with a rune cut off, doesn't change its later counts
+func TestReaderCountsMidStream(t *testing.T) {
+	maxLineLength := withCount("max_line_length", func(o *CountOptions) *bool { return &o.MaxLineLength })
+	reader := NewReader(iotest.OneByteReader(strings.NewReader("ab界\nx\n")), WithChars(), maxLineLength)
+	buf := make([]byte, 1)
+	for {
+		_, err := reader.Read(buf)
+		widths := *reader.counter.widths
+		reader.Counts()
+		if *reader.counter.widths != widths {
+			t.Fatalf("Expected the counts so far to leave the line widths alone, got %+v, not %+v", *reader.counter.widths, widths)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Error reading: %v", err)
+		}
+	}
+	if counts := reader.Counts(); counts.MaxLineLength != 4 || counts.Chars != 6 {
+		t.Errorf("Expected 6 characters and a longest line of 4 columns, got %+v", counts)
+	}
+}
+
+// TestReaderPipeline checks counting the decompressed side of a gzip stream in a single pass
+func TestReaderPipeline(t *testing.T) {
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	_, _ = zw.Write([]byte("one two three\nfour\n"))
+	_ = zw.Close()
+
+	zr, err := gzip.NewReader(&compressed)
+	if err != nil {
+		t.Fatalf("Error opening gzip stream: %v", err)
+	}
+	reader := NewReader(zr)
+
+	// Part of the way through, the counts cover what has been read so far
+	part := make([]byte, 6)
+	if _, err := io.ReadFull(reader, part); err != nil {
+		t.Fatalf("Error reading: %v", err)
+	}
+	if counts := reader.Counts(); !counts.Equal(Counts{Lines: 0, Words: 2, Bytes: 6}) {
+		t.Errorf("Expected 2 words and 6 bytes so far, got %+v", counts)
+	}
+
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		t.Fatalf("Error reading: %v", err)
+	}
+	if counts := reader.Counts(); !counts.Equal(Counts{Lines: 2, Words: 4, Bytes: 19}) {
+		t.Errorf("Expected 2 lines, 4 words and 19 bytes, got %+v", counts)
+	}
+}
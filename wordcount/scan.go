@@ -0,0 +1,95 @@
+package wordcount
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Byte classes used by the word scanner
+const (
+	classWord      uint8 = iota // ASCII byte that belongs to a word
+	classSpace                  // ASCII white space
+	classMultibyte              // first byte of a multibyte (or invalid) UTF-8 sequence
+)
+
+// byteClass maps every byte value to its class so the hot loop needs one table lookup per byte
+var byteClass = func() [256]uint8 {
+	var table [256]uint8
+	for b := 0; b < 256; b++ {
+		switch {
+		case b >= utf8.RuneSelf:
+			table[b] = classMultibyte
+		case unicode.IsSpace(rune(b)):
+			table[b] = classSpace
+		default:
+			table[b] = classWord
+		}
+	}
+	return table
+}()
+
+// scanWords counts the words starting and the characters contained in chunk.
+// inWord carries word state across chunks; the updated state is returned.
+// ASCII bytes are classified with byteClass, and only multibyte runes fall
+// back to utf8.DecodeRune and unicode.IsSpace.
+func scanWords(chunk []byte, inWord bool) (words, characters int64, stillInWord bool) {
+	characters = int64(len(chunk))
+	for i := 0; i < len(chunk); {
+		switch byteClass[chunk[i]] {
+		case classSpace:
+			inWord = false
+			i++
+		case classWord:
+			if !inWord {
+				words++
+				inWord = true
+			}
+			i++
+		default:
+			r, size := utf8.DecodeRune(chunk[i:])
+			if unicode.IsSpace(r) {
+				inWord = false
+			} else if !inWord {
+				words++
+				inWord = true
+			}
+			// Every byte was counted as a character up front; a multibyte
+			// rune only counts once.
+			characters -= int64(size - 1)
+			i += size
+		}
+	}
+	return words, characters, inWord
+}
+
+// scanBytes counts the words starting in chunk as GNU wc does in the C
+// locale, where only ASCII white space separates words and a word needs a
+// printable ASCII character. Other bytes, control characters and bytes
+// outside ASCII, neither start nor end a word. inWord carries word state
+// across chunks.
+func scanBytes(chunk []byte, inWord bool) (words int64, stillInWord bool) {
+	for _, b := range chunk {
+		switch {
+		case byteClass[b] == classSpace:
+			inWord = false
+		case ' ' < b && b < 0x7f && !inWord:
+			words++
+			inWord = true
+		}
+	}
+	return words, inWord
+}
+
+// incompleteSuffix returns the length of the incomplete UTF-8 sequence at the
+// end of b, or 0 if b ends with a complete (or invalid) sequence
+func incompleteSuffix(b []byte) int {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-(utf8.UTFMax-1); i-- {
+		if utf8.RuneStart(b[i]) {
+			if utf8.FullRune(b[i:]) {
+				return 0
+			}
+			return len(b) - i
+		}
+	}
+	return 0
+}
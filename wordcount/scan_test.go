@@ -0,0 +1,84 @@
+package wordcount
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+// runeScan counts words and characters with a plain per-rune loop over the whole input
+func runeScan(input string) (words, characters int64) {
+	inWord := false
+	for _, r := range input {
+		characters++
+		if unicode.IsSpace(r) {
+			inWord = false
+		} else if !inWord {
+			words++
+			inWord = true
+		}
+	}
+	return words, characters
+}
+
+// TestScanWordsMatchesRuneScan compares the table-driven scanner against a plain per-rune scan
+func TestScanWordsMatchesRuneScan(t *testing.T) {
+	inputs := []string{
+		"",
+		"Hello, World!\n",
+		"  leading and trailing  ",
+		"tabs\tand\vform\ffeeds\r\n",
+		"Hello, 世界! Привет мир",
+		"no break em　ideographic",
+		"invalid \xff\xfe bytes",
+		"emoji 👋🏽 and combining é",
+	}
+
+	for _, input := range inputs {
+		t.Run(strconv.Quote(input), func(t *testing.T) {
+			expectedWords, expectedCharacters := runeScan(input)
+			words, characters, _ := scanWords([]byte(input), false)
+			if words != expectedWords || characters != expectedCharacters {
+				t.Errorf("Expected %d words and %d characters, got %d words and %d characters",
+					expectedWords, expectedCharacters, words, characters)
+			}
+		})
+	}
+}
+
+// TestBytewise checks that counting bytewise makes every byte a character and
+// splits words only at ASCII white space, as GNU wc does in the C locale
+func TestBytewise(t *testing.T) {
+	tests := []struct {
+		name                  string
+		input                 string
+		expectedWords         int64
+		expectedChars         int64
+		expectedMaxLineLength int64
+	}{
+		{"ASCII", "Hello, World!\n", 2, 14, 13},
+		{"Multibyte Characters", "caf\u00e9 \u4e16\u754c\n", 1, 13, 4},
+		{"Unicode Spaces Join Words", "no\u00a0break em\u3000space\n", 2, 21, 15},
+		{"ASCII White Space", "tabs\tand\vform\ffeeds\r\n", 4, 21, 15},
+		{"Invalid Bytes", "\xff\xfe \x80\n", 0, 5, 1},
+		{"Control Characters", "a\x01b \x01\n", 1, 6, 3},
+		{"Multibyte Word", "\u4e16\u754c x\n", 1, 9, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counts, err := Count(strings.NewReader(tt.input), CountOptions{
+				WordCount: true, CharacterCount: true, ByteCount: true, MaxLineLength: true, Bytewise: true,
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if counts.Words != tt.expectedWords || counts.Chars != tt.expectedChars || counts.Chars != counts.Bytes ||
+				counts.MaxLineLength != tt.expectedMaxLineLength {
+				t.Errorf("Expected %d words, %d characters and bytes and a longest line of %d, got %d words, %d characters, %d bytes and %d",
+					tt.expectedWords, tt.expectedChars, tt.expectedMaxLineLength, counts.Words, counts.Chars, counts.Bytes, counts.MaxLineLength)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package wordcount
+
+import (
+	"bytes"
+	"unicode"
+
+	"github.com/rivo/uniseg"
+)
+
+// WordSplitter finds the words of an input, replacing the default rule that a
+// word is a run of non-white-space runes. Split is called with the input's
+// data that hasn't been consumed yet and returns the number of bytes to
+// advance past, along with the next word if those bytes contain one. A
+// splitter that needs more data to decide returns 0, nil; the call is then
+// repeated once more data has been read, or with atEOF set at the end of the
+// input. The data never ends in the middle of a UTF-8 encoded rune, except
+// for invalid input at the end.
+//
+// A WordSplitter may be used for several inputs at once, so it shouldn't keep
+// state between calls.
+type WordSplitter interface {
+	Split(data []byte, atEOF bool) (advance int, word []byte)
+}
+
+// SplitterFunc adapts a function to a WordSplitter
+type SplitterFunc func(data []byte, atEOF bool) (advance int, word []byte)
+
+// Split calls f(data, atEOF)
+func (f SplitterFunc) Split(data []byte, atEOF bool) (int, []byte) {
+	return f(data, atEOF)
+}
+
+// UnicodeWords splits words at the word boundaries of Unicode Standard Annex
+// #29. Only segments containing a letter or a number count as words, so
+// punctuation and white space between words doesn't, while "can't" and
+// "3.14" are single words. Scripts written without spaces are split as the
+// annex specifies; Han ideographs, for instance, are one word each.
+var UnicodeWords WordSplitter = SplitterFunc(splitUnicodeWords)
+
+// unicodeLookahead is how many bytes beyond a word boundary splitUnicodeWords
+// wants to see before trusting it, since some boundary rules look ahead
+const unicodeLookahead = 32
+
+func splitUnicodeWords(data []byte, atEOF bool) (int, []byte) {
+	advance := 0
+	for advance < len(data) {
+		segment, rest, _ := uniseg.FirstWord(data[advance:], -1)
+		if !atEOF && len(rest) < unicodeLookahead {
+			break
+		}
+		advance += len(segment)
+		if bytes.IndexFunc(segment, isWordRune) >= 0 {
+			return advance, segment
+		}
+	}
+	return advance, nil
+}
+
+// isWordRune reports whether r makes a segment a word
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r)
+}
@@ -0,0 +1,65 @@
+package wordcount
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// splitCommas is a custom splitter treating comma separated fields as words
+func splitCommas(data []byte, atEOF bool) (int, []byte) {
+	if i := bytes.IndexByte(data, ','); i >= 0 {
+		return i + 1, data[:i]
+	}
+	if atEOF {
+		return len(data), data
+	}
+	return 0, nil
+}
+
+// TestWordSplitter tests counting words with UnicodeWords and a custom splitter, including words split across reads
+func TestWordSplitter(t *testing.T) {
+	tests := []struct {
+		name     string
+		splitter WordSplitter
+		input    string
+		expected Counts
+	}{
+		{"Empty Input", UnicodeWords, "", Counts{}},
+		{"Punctuation", UnicodeWords, "Hello, world! -- ok?", Counts{Words: 3, Chars: 20, Unique: 3}},
+		{"Apostrophe And Decimal", UnicodeWords, "can't 3.14 can't", Counts{Words: 3, Chars: 16, Unique: 2}},
+		{"Han Ideographs", UnicodeWords, "世界世界", Counts{Words: 4, Chars: 4, Unique: 2}},
+		{"Mixed Scripts", UnicodeWords, "Привет мир, hello\n", Counts{Words: 3, Chars: 18, Unique: 3}},
+		{"Custom Splitter", SplitterFunc(splitCommas), "a b,c,,a b", Counts{Words: 4, Chars: 10, Unique: 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := New(WithWords(), WithChars(), WithUnique(0), WithWordSplitter(tt.splitter))
+			for _, input := range []io.Reader{strings.NewReader(tt.input), iotest.OneByteReader(strings.NewReader(tt.input))} {
+				counts, err := counter.Count(context.Background(), input)
+				if err != nil {
+					t.Fatalf("Count() error = %v", err)
+				}
+				if !counts.Equal(tt.expected) {
+					t.Errorf("Count() = %+v, want %+v", counts, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+// TestWordSplitterLongInput tests a splitter over an input longer than the read buffer
+func TestWordSplitterLongInput(t *testing.T) {
+	input := strings.Repeat("one, two. three\n", 10000)
+	counts, err := Count(strings.NewReader(input), CountOptions{WordCount: true, BufferSize: MinBufferSize, WordSplitter: UnicodeWords})
+	if err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+	if counts.Words != 30000 {
+		t.Errorf("Count() words = %d, want 30000", counts.Words)
+	}
+}
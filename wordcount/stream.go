@@ -0,0 +1,103 @@
+package wordcount
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// Stream counts an input in the background, reporting progress as it goes.
+// Deltas receives the counts added by each chunk read, so a live display can
+// add them up as they arrive. A consumer that falls behind doesn't slow
+// counting down: deltas it hasn't received yet are combined into the next
+// one, so the deltas received always add up to the counts of the data read.
+type Stream struct {
+	Deltas <-chan Counts
+
+	done   chan struct{}
+	counts Counts
+	err    error
+}
+
+// NewStream starts counting input with the options and returns the Stream
+// reporting its progress. Unique words aren't included in the deltas, since
+// they can't be summed; the final counts from Wait include them.
+func NewStream(ctx context.Context, input io.Reader, options CountOptions) *Stream {
+	deltas := make(chan Counts, 1)
+	s := &Stream{Deltas: deltas, done: make(chan struct{})}
+
+	go func() {
+		defer close(s.done)
+		defer close(deltas)
+
+		counter := counterPool.Get().(*fileCounter)
+		defer counterPool.Put(counter)
+		if s.err = counter.setup(options); s.err != nil {
+			return
+		}
+		counter.ctx = ctx
+
+		deltaOptions := options
+		deltaOptions.UniqueCount = false
+		deltaOptions.MaxLineLength = false
+		var sent, pending Counts
+		counter.onChunk = func() {
+			current := counter.counts(deltaOptions)
+			pending.Add(current.sub(sent))
+			sent = current
+			select {
+			case deltas <- pending:
+				pending = Counts{}
+			default:
+			}
+		}
+
+		err := counter.countReader(throttle(input, options))
+		if err != nil && !errors.Is(err, ErrInterrupted) {
+			s.err = err
+			return
+		}
+		counter.finish()
+		s.counts, s.err = counter.counts(options), err
+		// The last chunk may have left a rune pending until finish
+		counter.onChunk()
+		for !pending.Equal(Counts{}) {
+			// Without blocking, either send what's left or take back a
+			// delta the consumer hasn't received and send them combined
+			select {
+			case deltas <- pending:
+				pending = Counts{}
+			case unreceived := <-deltas:
+				pending.Add(unreceived)
+			}
+		}
+		if err == nil && options.UniqueTotal != nil && counter.unique != nil {
+			options.UniqueTotal.Merge(counter.unique)
+		}
+	}()
+	return s
+}
+
+// Wait waits for counting to end and returns the counts, as CountContext
+// would. Deltas doesn't need to be drained first.
+func (s *Stream) Wait() (Counts, error) {
+	<-s.done
+	return s.counts, s.err
+}
+
+// sub returns the counts added since earlier, leaving out Unique
+func (c Counts) sub(earlier Counts) Counts {
+	delta := Counts{
+		Bytes: c.Bytes - earlier.Bytes, Lines: c.Lines - earlier.Lines,
+		Words: c.Words - earlier.Words, Chars: c.Chars - earlier.Chars,
+	}
+	for name, count := range c.Metrics {
+		if count != earlier.Metrics[name] {
+			if delta.Metrics == nil {
+				delta.Metrics = make(map[string]int64)
+			}
+			delta.Metrics[name] = count - earlier.Metrics[name]
+		}
+	}
+	return delta
+}
@@ -0,0 +1,63 @@
+package wordcount
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// TestStream checks that the deltas add up to the final counts, however quickly they are received
+func TestStream(t *testing.T) {
+	input := strings.Repeat("Hello, 世界! Привет мир\nGoodbye, World!\n", 200)
+	options := CountOptions{LineCount: true, WordCount: true, ByteCount: true, CharacterCount: true, BufferSize: MinBufferSize}
+	expected, err := Count(strings.NewReader(input), options)
+	if err != nil {
+		t.Fatalf("Error processing input: %v", err)
+	}
+
+	t.Run("Drained", func(t *testing.T) {
+		stream := NewStream(context.Background(), iotest.HalfReader(strings.NewReader(input)), options)
+		var sum Counts
+		deltas := 0
+		for delta := range stream.Deltas {
+			sum.Add(delta)
+			deltas++
+		}
+		counts, err := stream.Wait()
+		if err != nil {
+			t.Fatalf("Error processing input: %v", err)
+		}
+		if !counts.Equal(expected) || !sum.Equal(expected) {
+			t.Errorf("Expected %+v, got %+v with deltas adding up to %+v", expected, counts, sum)
+		}
+		if deltas < 2 {
+			t.Errorf("Expected several deltas, got %d", deltas)
+		}
+	})
+
+	t.Run("Not Drained", func(t *testing.T) {
+		stream := NewStream(context.Background(), strings.NewReader(input), options)
+		counts, err := stream.Wait()
+		if err != nil || !counts.Equal(expected) {
+			t.Fatalf("Expected %+v, got %+v (%v)", expected, counts, err)
+		}
+		var sum Counts
+		for delta := range stream.Deltas {
+			sum.Add(delta)
+		}
+		if !sum.Equal(expected) {
+			t.Errorf("Expected deltas adding up to %+v, got %+v", expected, sum)
+		}
+	})
+
+	t.Run("Read Error", func(t *testing.T) {
+		stream := NewStream(context.Background(), iotest.ErrReader(io.ErrUnexpectedEOF), options)
+		for range stream.Deltas {
+		}
+		if _, err := stream.Wait(); err == nil {
+			t.Errorf("Expected the read error")
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package wordcount
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+func init() {
+	RegisterMetric("zero_width", func() Metric { return &suspiciousMetric{name: "zero_width"} })
+	RegisterMetric("bidi_controls", func() Metric { return &suspiciousMetric{name: "bidi_controls"} })
+	RegisterMetric("confusables", func() Metric { return &suspiciousMetric{name: "confusables"} })
+}
+
+// isZeroWidth reports whether r is an invisible character that joins or
+// separates text without taking up space, such as a zero-width space
+func isZeroWidth(r rune) bool {
+	switch r {
+	case '\u200b', '\u200c', '\u200d', '\u2060', '\u180e', '\ufeff':
+		return true
+	}
+	return false
+}
+
+// isBidiControl reports whether r is a bidirectional formatting character,
+// which can make text display in a different order than it is read, as in
+// Trojan Source attacks
+func isBidiControl(r rune) bool {
+	switch {
+	case r == '\u061c' || r == '\u200e' || r == '\u200f':
+		return true
+	case '\u202a' <= r && r <= '\u202e', '\u2066' <= r && r <= '\u2069':
+		return true
+	}
+	return false
+}
+
+// latinLookalikes are Cyrillic and Greek letters that look like Latin ones,
+// written as escapes as they can't be told apart from them in source
+var latinLookalikes = map[rune]bool{
+	// Cyrillic а е о р с у х і ј ѕ ԁ ԛ ԝ һ ӏ
+	'\u0430': true, '\u0435': true, '\u043e': true, '\u0440': true, '\u0441': true, '\u0443': true,
+	'\u0445': true, '\u0456': true, '\u0458': true, '\u0455': true, '\u0501': true, '\u051b': true,
+	'\u051d': true, '\u04bb': true, '\u04cf': true,
+	// Cyrillic А В Е К М Н О Р С Т Х І Ј Ѕ
+	'\u0410': true, '\u0412': true, '\u0415': true, '\u041a': true, '\u041c': true, '\u041d': true,
+	'\u041e': true, '\u0420': true, '\u0421': true, '\u0422': true, '\u0425': true, '\u0406': true,
+	'\u0408': true, '\u0405': true,
+	// Greek ο ν α ι κ ρ
+	'\u03bf': true, '\u03bd': true, '\u03b1': true, '\u03b9': true, '\u03ba': true, '\u03c1': true,
+	// Greek Α Β Ε Ζ Η Ι Κ Μ Ν Ο Ρ Τ Υ Χ
+	'\u0391': true, '\u0392': true, '\u0395': true, '\u0396': true, '\u0397': true, '\u0399': true,
+	'\u039a': true, '\u039c': true, '\u039d': true, '\u039f': true, '\u03a1': true, '\u03a4': true,
+	'\u03a5': true, '\u03a7': true,
+}
+
+// suspiciousMetric counts characters that hide in text: invisible zero-width
+// characters as "zero_width", bidirectional controls as "bidi_controls", and
+// Cyrillic or Greek letters that look like Latin ones, in words that also
+// have Latin letters, as "confusables". A byte order mark at the very start
+// of the input isn't counted.
+type suspiciousMetric struct {
+	name      string
+	count     int64
+	started   bool  // whether a character has been seen
+	latin     bool  // whether the current word has a Latin letter
+	lookalike int64 // lookalike letters in the current word
+}
+
+func (m *suspiciousMetric) Name() string { return m.name }
+
+func (m *suspiciousMetric) ProcessChunk(chunk []byte) {
+	for i := 0; i < len(chunk); {
+		r, size := rune(chunk[i]), 1
+		if r >= utf8.RuneSelf {
+			r, size = utf8.DecodeRune(chunk[i:])
+		}
+		i += size
+		first := !m.started
+		m.started = true
+		switch m.name {
+		case "zero_width":
+			if isZeroWidth(r) && !(first && r == '\ufeff') {
+				m.count++
+			}
+		case "bidi_controls":
+			if isBidiControl(r) {
+				m.count++
+			}
+		case "confusables":
+			m.nextConfusable(r)
+		}
+	}
+}
+
+// nextConfusable tracks the letters of the current word, counting its
+// lookalikes once it ends if it has Latin letters too
+func (m *suspiciousMetric) nextConfusable(r rune) {
+	switch {
+	case r < utf8.RuneSelf && byteClass[r] == classSpace || r >= utf8.RuneSelf && unicode.IsSpace(r):
+		m.endWord()
+	case latinLookalikes[r]:
+		m.lookalike++
+	case r < utf8.RuneSelf && ('a' <= r|0x20 && r|0x20 <= 'z') || r >= utf8.RuneSelf && unicode.Is(unicode.Latin, r):
+		m.latin = true
+	}
+}
+
+// endWord counts the lookalikes of a word that mixes them with Latin letters
+func (m *suspiciousMetric) endWord() {
+	if m.latin {
+		m.count += m.lookalike
+	}
+	m.latin, m.lookalike = false, 0
+}
+
+func (m *suspiciousMetric) Result() int64 {
+	if m.latin {
+		// The last word of the input so far
+		return m.count + m.lookalike
+	}
+	return m.count
+}
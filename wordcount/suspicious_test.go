@@ -0,0 +1,49 @@
+package wordcount
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// TestSuspiciousChars tests the zero_width, bidi_controls and confusables
+// metrics, including text split across reads
+func TestSuspiciousChars(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		zeroWidth   int64
+		bidi        int64
+		confusables int64
+	}{
+		{"Empty Input", "", 0, 0, 0},
+		{"Plain Text", "Pay the invoice.", 0, 0, 0},
+		{"Zero-Width Space", "pass\u200bword and\u200d\u2060", 3, 0, 0},
+		{"Leading Byte Order Mark", "\ufeffText\ufeff", 1, 0, 0},
+		{"Trojan Source", "if access \u202e} \u2066// admin\u2069 {", 0, 3, 0},
+		{"Marks", "\u200eleft \u200fright", 0, 2, 0},
+		{"Cyrillic In Latin Word", "p\u0430ypal.com", 0, 0, 1},
+		{"Greek In Latin Word", "\u039fK \u0391pple", 0, 0, 2},
+		{"Whole Cyrillic Word", "\u0441\u043e\u0440 and cop", 0, 0, 0},
+		{"Last Word", "g\u043e\u043egle", 0, 0, 2},
+	}
+
+	metrics := []string{"zero_width", "bidi_controls", "confusables"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, input := range []io.Reader{strings.NewReader(tt.input), iotest.OneByteReader(strings.NewReader(tt.input))} {
+				counts, err := Count(input, CountOptions{Metrics: metrics})
+				if err != nil {
+					t.Fatalf("Error processing input: %v", err)
+				}
+				zeroWidth, _ := counts.Get("zero_width")
+				bidi, _ := counts.Get("bidi_controls")
+				confusables, _ := counts.Get("confusables")
+				if zeroWidth != tt.zeroWidth || bidi != tt.bidi || confusables != tt.confusables {
+					t.Errorf("Expected %d %d %d, got %d %d %d", tt.zeroWidth, tt.bidi, tt.confusables, zeroWidth, bidi, confusables)
+				}
+			}
+		})
+	}
+}
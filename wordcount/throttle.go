@@ -0,0 +1,65 @@
+package wordcount
+
+import (
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimiter limits the rate of reads shared by every input of a run. Reads
+// take tokens (bytes) from the bucket, which refills at rate bytes per second
+// up to burst bytes; a read that overdraws the bucket sleeps off the debt.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // bytes per second
+	burst  float64 // largest number of tokens the bucket holds
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a full bucket refilling at rate bytes per second.
+// The burst is a tenth of a second's worth of reads, within buffer size bounds.
+func NewRateLimiter(rate int64) *RateLimiter {
+	burst := min(max(rate/10, minAdaptiveBufferSize), maxAdaptiveBufferSize)
+	return &RateLimiter{rate: float64(rate), burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// Wait takes n tokens from the bucket, sleeping until the bucket has refilled enough to cover them
+func (b *RateLimiter) Wait(n int) {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens -= float64(n)
+	debt := b.tokens
+	b.mu.Unlock()
+
+	if debt < 0 {
+		time.Sleep(time.Duration(-debt / b.rate * float64(time.Second)))
+	}
+}
+
+// throttledReader is an io.Reader that takes every byte it reads from a RateLimiter
+type throttledReader struct {
+	reader io.Reader
+	bucket *RateLimiter
+}
+
+// Read reads at most a burst of bytes and waits for the bucket to pay for them
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if len(p) > int(t.bucket.burst) {
+		p = p[:int(t.bucket.burst)]
+	}
+	n, err := t.reader.Read(p)
+	t.bucket.Wait(n)
+	return n, err
+}
+
+// throttle wraps input in a throttledReader when the options set a rate limit
+func throttle(input io.Reader, options CountOptions) io.Reader {
+	if options.RateLimiter == nil {
+		return input
+	}
+	return &throttledReader{reader: input, bucket: options.RateLimiter}
+}
@@ -0,0 +1,30 @@
+package wordcount
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// TestThrottle checks that throttled reads don't exceed the configured rate
+func TestThrottle(t *testing.T) {
+	const rate = 200 * 1024
+	data := benchmarkText(100 * 1024)
+	options := CountOptions{ByteCount: true, RateLimiter: NewRateLimiter(rate)}
+
+	start := time.Now()
+	counts, err := Count(bytes.NewReader(data), options)
+	if err != nil {
+		t.Fatalf("Error processing input: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	if counts.Bytes != int64(len(data)) {
+		t.Errorf("Expected %d bytes, got %d", len(data), counts.Bytes)
+	}
+	// The initial burst is free; everything after it is paid for at the rate
+	minimum := time.Duration(float64(len(data)-rate/10) / rate * float64(time.Second))
+	if elapsed < minimum {
+		t.Errorf("Expected reading to take at least %v, took %v", minimum, elapsed)
+	}
+}
@@ -0,0 +1,147 @@
+package wordcount
+
+import (
+	"hash/maphash"
+	"math"
+	"math/bits"
+)
+
+// UniqueWords tracks the distinct words of one or more inputs. Words are kept
+// in an exact set until the set outgrows its memory budget, after which they
+// are counted approximately with a fixed-size HyperLogLog sketch.
+type UniqueWords struct {
+	exact  map[string]struct{}
+	memory int64 // approximate memory held by exact
+	budget int64 // 0 means unlimited
+	sketch *hyperLogLog
+}
+
+// uniqueWordOverhead approximates the memory a set entry uses beyond the word's bytes
+const uniqueWordOverhead = 48
+
+// NewUniqueWords returns an empty set with the given memory budget in bytes; 0 means unlimited
+func NewUniqueWords(budget int64) *UniqueWords {
+	return &UniqueWords{exact: make(map[string]struct{}), budget: budget}
+}
+
+// Add records a word
+func (u *UniqueWords) Add(word []byte) {
+	if u.sketch != nil {
+		u.sketch.add(word)
+		return
+	}
+	if _, ok := u.exact[string(word)]; ok {
+		return
+	}
+	u.exact[string(word)] = struct{}{}
+	u.memory += int64(len(word)) + uniqueWordOverhead
+	if u.budget > 0 && u.memory > u.budget {
+		u.switchToSketch()
+	}
+}
+
+// switchToSketch moves the exact set into a HyperLogLog sketch and frees it
+func (u *UniqueWords) switchToSketch() {
+	u.sketch = newHyperLogLog()
+	for word := range u.exact {
+		u.sketch.add([]byte(word))
+	}
+	u.exact, u.memory = nil, 0
+}
+
+// Merge adds every word recorded by other
+func (u *UniqueWords) Merge(other *UniqueWords) {
+	if other.sketch != nil && u.sketch == nil {
+		u.switchToSketch()
+	}
+	if u.sketch != nil {
+		if other.sketch != nil {
+			u.sketch.merge(other.sketch)
+			return
+		}
+		for word := range other.exact {
+			u.sketch.add([]byte(word))
+		}
+		return
+	}
+	for word := range other.exact {
+		u.Add([]byte(word))
+		if u.sketch != nil {
+			// The budget ran out part way; add the rest to the sketch
+			u.Merge(other)
+			return
+		}
+	}
+}
+
+// Count returns the number of distinct words
+func (u *UniqueWords) Count() int64 {
+	if u.sketch != nil {
+		return u.sketch.count()
+	}
+	return int64(len(u.exact))
+}
+
+// Approximate reports whether the count comes from the sketch
+func (u *UniqueWords) Approximate() bool {
+	return u.sketch != nil
+}
+
+// HyperLogLog parameters: 2^14 one-byte registers (16KB)
+const (
+	hyperLogLogPrecision = 14
+	hyperLogLogRegisters = 1 << hyperLogLogPrecision
+)
+
+// ApproximateUniqueError is the standard error of approximate unique word counts, about 0.8%
+const ApproximateUniqueError = 1.04 / 128 // 1.04 / sqrt(hyperLogLogRegisters)
+
+// hyperLogLogSeed is shared by every sketch so sketches can be merged
+var hyperLogLogSeed = maphash.MakeSeed()
+
+// hyperLogLog estimates the number of distinct values added to it in constant memory
+type hyperLogLog struct {
+	registers [hyperLogLogRegisters]uint8
+}
+
+// newHyperLogLog returns an empty sketch
+func newHyperLogLog() *hyperLogLog {
+	return new(hyperLogLog)
+}
+
+// add records a value
+func (h *hyperLogLog) add(value []byte) {
+	hash := maphash.Bytes(hyperLogLogSeed, value)
+	index := hash >> (64 - hyperLogLogPrecision)
+	rank := uint8(bits.LeadingZeros64(hash<<hyperLogLogPrecision|1<<(hyperLogLogPrecision-1))) + 1
+	if rank > h.registers[index] {
+		h.registers[index] = rank
+	}
+}
+
+// merge combines another sketch into this one
+func (h *hyperLogLog) merge(other *hyperLogLog) {
+	for i, rank := range other.registers {
+		if rank > h.registers[i] {
+			h.registers[i] = rank
+		}
+	}
+}
+
+// count returns the estimated number of distinct values
+func (h *hyperLogLog) count() int64 {
+	const m = float64(hyperLogLogRegisters)
+	sum, zeros := 0.0, 0
+	for _, rank := range h.registers {
+		sum += math.Ldexp(1, -int(rank))
+		if rank == 0 {
+			zeros++
+		}
+	}
+	estimate := 0.7213 / (1 + 1.079/m) * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		// Linear counting is more accurate for small cardinalities
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return int64(math.Round(estimate))
+}
@@ -0,0 +1,104 @@
+package wordcount
+
+import (
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// TestUniqueWords checks exact unique word counts, including words split across reads
+func TestUniqueWords(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected int64
+	}{
+		{"Empty Input", "", 0},
+		{"Repeated Words", "the cat and the hat and the bat\n", 5},
+		{"Case Sensitive", "Word word WORD", 3},
+		{"Unicode Words", "世界 мир 世界\u00a0мир", 2},
+		{"Punctuation Attached", "end. end end,", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := CountOptions{UniqueCount: true, Order: []string{"unique"}}
+			for _, input := range []io.Reader{strings.NewReader(tt.input), iotest.OneByteReader(strings.NewReader(tt.input))} {
+				counts, err := Count(input, options)
+				if err != nil {
+					t.Fatalf("Error processing input: %v", err)
+				}
+				if counts.Unique != tt.expected {
+					t.Errorf("Expected %d unique words, got %d", tt.expected, counts.Unique)
+				}
+			}
+		})
+	}
+}
+
+// TestUniqueWordsMemoryLimit checks that exceeding the budget switches to an approximate count
+func TestUniqueWordsMemoryLimit(t *testing.T) {
+	const distinct = 200000
+	var input strings.Builder
+	for i := 0; i < distinct; i++ {
+		input.WriteString("word")
+		input.WriteString(strconv.Itoa(i))
+		input.WriteByte(' ')
+	}
+
+	unique := NewUniqueWords(1024 * 1024)
+	counter := &fileCounter{unique: unique}
+	if err := counter.countReader(strings.NewReader(input.String())); err != nil {
+		t.Fatalf("Error processing input: %v", err)
+	}
+	if !unique.Approximate() {
+		t.Fatalf("Expected the budget to be exceeded")
+	}
+	if unique.exact != nil {
+		t.Errorf("Expected the exact set to be released")
+	}
+	if diff := math.Abs(float64(unique.Count()-distinct)) / distinct; diff > 4*ApproximateUniqueError {
+		t.Errorf("Expected about %d unique words, got %d", distinct, unique.Count())
+	}
+
+	// Merging an exact set into an approximate one keeps the total approximate
+	total := NewUniqueWords(0)
+	total.Add([]byte("extra"))
+	total.Merge(unique)
+	if !total.Approximate() {
+		t.Errorf("Expected the merged total to be approximate")
+	}
+}
+
+// TestFoldCase tests counting unique words that differ only in case as one
+func TestFoldCase(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected int64
+	}{
+		{"ASCII", "The the THE tHe", 1},
+		{"Sharp S", "Straße STRASSE strasse", 1},
+		{"Dotted I", "İstanbul istanbul ISTANBUL", 1},
+		{"Greek Sigma", "ΣΟΦΟΣ σοφος σοφοσ", 1},
+		{"Distinct Words", "Apple apple Pear", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counts, err := Count(strings.NewReader(tt.input), CountOptions{UniqueCount: true, FoldCase: true})
+			if err != nil {
+				t.Fatalf("Error processing input: %v", err)
+			}
+			if counts.Unique != tt.expected {
+				t.Errorf("Expected %d unique words, got %d", tt.expected, counts.Unique)
+			}
+			counts, _ = Count(strings.NewReader(tt.input), CountOptions{UniqueCount: true, FoldCase: true, WordSplitter: UnicodeWords})
+			if counts.Unique != tt.expected {
+				t.Errorf("Expected %d unique Unicode words, got %d", tt.expected, counts.Unique)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package wordcount
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/width"
+)
+
+// DefaultTabStop is how many columns apart tab stops are unless
+// CountOptions.TabStop says otherwise, as in terminals and wc -L
+const DefaultTabStop = 8
+
+// lineWidth tracks the display width of the lines of an input, for
+// CountOptions.MaxLineLength
+type lineWidth struct {
+	tabStop  int64
+	bytewise bool  // every byte is a character, as in the C locale
+	current  int64 // columns taken by the line so far
+	longest  int64 // widest line before the current one
+}
+
+// measure adds the characters of a chunk that doesn't split any rune. As with
+// wc -L, a tab moves to the next tab stop, a carriage return or form feed
+// ends a line as a newline does, wide East Asian characters take two
+// columns, and combining marks and other control characters take none. Bytes
+// outside ASCII take none either when measuring bytewise, as they aren't
+// printable in the C locale.
+func (w *lineWidth) measure(chunk []byte) {
+	for i := 0; i < len(chunk); {
+		b := chunk[i]
+		if b < utf8.RuneSelf {
+			i++
+			switch {
+			case b == '\n' || b == '\r' || b == '\f':
+				w.longest = max(w.longest, w.current)
+				w.current = 0
+			case b == '\t':
+				w.current += w.tabStop - w.current%w.tabStop
+			case b >= ' ' && b != 0x7f:
+				w.current++
+			}
+			continue
+		}
+		if w.bytewise {
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRune(chunk[i:])
+		i += size
+		w.current += int64(runeWidth(r))
+	}
+}
+
+// max returns the width of the widest line so far
+func (w *lineWidth) max() int64 {
+	return max(w.longest, w.current)
+}
+
+// runeWidth returns the columns a non-ASCII character takes on a terminal
+func runeWidth(r rune) int {
+	switch {
+	case r == utf8.RuneError || !unicode.IsPrint(r) && !unicode.IsSpace(r):
+		return 0
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,54 @@
+package wordcount
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// TestMaxLineLength tests the display width of the longest line, including
+// text split across reads
+func TestMaxLineLength(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		tabStop  int
+		expected int64
+	}{
+		{"Empty Input", "", 0, 0},
+		{"Last Line Longest", "ab\nabcde", 0, 5},
+		{"First Line Longest", "abcdef\nab\n", 0, 6},
+		{"Tab", "\tx\n", 0, 9},
+		{"Tab After Text", "abc\tx", 0, 9},
+		{"Tab Stop 4", "abc\tx\n\t\tx", 4, 9},
+		{"Tab Stop 2", "a\tb", 2, 3},
+		{"Carriage Return", "abcdef\rab", 0, 6},
+		{"Wide Characters", "你好 world\n", 0, 10},
+		{"Combining Marks", "cafe\u0301\n", 0, 4},
+		{"Zero-Width And Controls", "a\u200bb\x01c\n", 0, 3},
+		{"Accented", "déjà vu", 0, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := CountOptions{MaxLineLength: true, TabStop: tt.tabStop}
+			for _, input := range []io.Reader{strings.NewReader(tt.input), iotest.OneByteReader(strings.NewReader(tt.input))} {
+				counts, err := Count(input, options)
+				if err != nil {
+					t.Fatalf("Error processing input: %v", err)
+				}
+				if counts.MaxLineLength != tt.expected {
+					t.Errorf("Expected %d, got %d", tt.expected, counts.MaxLineLength)
+				}
+			}
+		})
+	}
+
+	var total Counts
+	total.Add(Counts{MaxLineLength: 12})
+	total.Add(Counts{MaxLineLength: 7})
+	if total.MaxLineLength != 12 {
+		t.Errorf("Expected the total to be the longest line, 12, got %d", total.MaxLineLength)
+	}
+}
@@ -0,0 +1,700 @@
+// Package wordcount counts the bytes, lines, words, and characters of text,
+// as the mwc command does. Inputs are read incrementally with pooled buffers,
+// so inputs of any size can be counted in constant memory.
+//
+// A word is a maximal run of runes that unicode.IsSpace doesn't report as
+// white space. Characters are UTF-8 encoded runes; every byte of an invalid
+// sequence counts as one character.
+package wordcount
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"maps"
+	"math/bits"
+	"os"
+	"runtime"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// CountOptions holds the flags for different counting options
+type CountOptions struct {
+	ByteCount      bool
+	LineCount      bool
+	WordCount      bool
+	CharacterCount bool
+	UniqueCount    bool     // Count distinct words
+	MaxLineLength  bool     // Find the display width of the longest line, as wc -L does
+	TabStop        int      // Columns between tab stops for MaxLineLength; 0 means DefaultTabStop
+	FoldCase       bool     // Count distinct words that differ only in case as one, with Unicode case folding
+	Bytewise       bool     // Count as GNU wc does in the C locale: every byte is a character and only ASCII white space separates words
+	Order          []string // Keeps track of the order in which options were specified
+	EstimateBlocks int      // Number of blocks Estimate samples
+	MaxMemory      int64    // Memory budget in bytes for exact unique word counting; 0 means unlimited
+	BufferSize     int      // Read size in bytes, from MinBufferSize to MaxBufferSize; 0 sizes buffers from each input
+	Metrics        []string // Names of registered metrics to count, reported in Counts.Metrics
+
+	// WordSplitter, if set, finds the words that are counted, instead of
+	// splitting at white space. Estimate and Resume always split at white space.
+	WordSplitter WordSplitter
+
+	// RateLimiter, if set, limits the rate at which inputs are read
+	RateLimiter *RateLimiter
+	// UniqueTotal, if set, collects the distinct words of every input
+	// counted with these options, for a total across inputs
+	UniqueTotal *UniqueWords
+	// Hooks, if set, are called as inputs are counted
+	Hooks *Hooks
+}
+
+// Counts holds the results of counting an input. Counts that weren't
+// requested by the options are zero.
+type Counts struct {
+	Bytes         int64            `json:"bytes"`
+	Lines         int64            `json:"lines"`
+	Words         int64            `json:"words"`
+	Chars         int64            `json:"characters"`
+	Unique        int64            `json:"unique,omitempty"`          // distinct words, with CountOptions.UniqueCount
+	MaxLineLength int64            `json:"max_line_length,omitempty"` // display width of the longest line, with CountOptions.MaxLineLength
+	Metrics       map[string]int64 `json:"metrics,omitempty"`         // results of CountOptions.Metrics by name
+}
+
+// Get returns the count with the given name, as used in CountOptions.Order,
+// and whether it was counted. Built-in counts are always known.
+func (c Counts) Get(name string) (int64, bool) {
+	if count, ok := c.builtin(name); ok {
+		return count, true
+	}
+	count, ok := c.Metrics[name]
+	return count, ok
+}
+
+// builtin returns the built-in count with the given name
+func (c Counts) builtin(name string) (int64, bool) {
+	switch name {
+	case "bytes":
+		return c.Bytes, true
+	case "lines":
+		return c.Lines, true
+	case "words":
+		return c.Words, true
+	case "characters":
+		return c.Chars, true
+	case "unique":
+		return c.Unique, true
+	case "max_line_length":
+		return c.MaxLineLength, true
+	}
+	return 0, false
+}
+
+// Add adds other to the counts. Distinct words can't be summed, since inputs
+// may share words, so Unique is left as it is; use CountOptions.UniqueTotal
+// for a total across inputs. The longest line is the longer of the two.
+func (c *Counts) Add(other Counts) {
+	c.Bytes += other.Bytes
+	c.Lines += other.Lines
+	c.Words += other.Words
+	c.Chars += other.Chars
+	c.MaxLineLength = max(c.MaxLineLength, other.MaxLineLength)
+	if len(other.Metrics) == 0 {
+		return
+	}
+	// Copies of c share its map of metrics, and mustn't see them change
+	metrics := make(map[string]int64, max(len(c.Metrics), len(other.Metrics)))
+	maps.Copy(metrics, c.Metrics)
+	for name, count := range other.Metrics {
+		metrics[name] += count
+	}
+	c.Metrics = metrics
+}
+
+// Equal reports whether both hold the same counts
+func (c Counts) Equal(other Counts) bool {
+	if c.Bytes != other.Bytes || c.Lines != other.Lines || c.Words != other.Words ||
+		c.Chars != other.Chars || c.Unique != other.Unique || c.MaxLineLength != other.MaxLineLength || len(c.Metrics) != len(other.Metrics) {
+		return false
+	}
+	for name, count := range c.Metrics {
+		if otherCount, ok := other.Metrics[name]; !ok || otherCount != count {
+			return false
+		}
+	}
+	return true
+}
+
+// FileCount holds the counts for a specific file, and the title and author
+// from its front matter when they were read
+type FileCount struct {
+	Filename string
+	Title    string
+	Author   string
+	Counts   Counts
+}
+
+// Count reads from the input and counts bytes, lines, words, and characters based on the options
+func Count(input io.Reader, options CountOptions) (Counts, error) {
+	return CountContext(context.Background(), input, options)
+}
+
+// CountContext is Count with a context. The context is checked between reads;
+// once it is done, CountContext returns an *InterruptedError, matching
+// ErrInterrupted, along with the counts of everything read until then. A
+// read already in progress isn't interrupted.
+func CountContext(ctx context.Context, input io.Reader, options CountOptions) (Counts, error) {
+	options.Hooks.fileStart("")
+	counts, err := countContext(ctx, "", input, options)
+	options.Hooks.fileDone("", counts, err)
+	return counts, err
+}
+
+// countContext is CountContext without the start and done hooks, for an
+// input with the given name
+func countContext(ctx context.Context, name string, input io.Reader, options CountOptions) (Counts, error) {
+	counter := counterPool.Get().(*fileCounter)
+	defer counterPool.Put(counter)
+	if err := counter.setup(options); err != nil {
+		return Counts{}, err
+	}
+	counter.ctx = ctx
+	counter.onChunk = options.Hooks.progress(name, counter, options)
+
+	if err := counter.countReader(throttle(input, options)); err != nil {
+		if errors.Is(err, ErrInterrupted) {
+			// Partial counts, without adding partial unique words to the total
+			counter.finish()
+			return counter.counts(options), err
+		}
+		return Counts{}, err
+	}
+	counter.finish()
+	if options.UniqueTotal != nil && counter.unique != nil {
+		options.UniqueTotal.Merge(counter.unique)
+	}
+	return counter.counts(options), nil
+}
+
+// State is the progress of counting an input. It can be saved and passed to
+// Resume later to count only the data appended to the input since.
+type State struct {
+	Bytes      int64  `json:"bytes"`
+	Lines      int64  `json:"lines"`
+	Words      int64  `json:"words"`
+	Characters int64  `json:"characters"`
+	InWord     bool   `json:"in_word"`           // whether the last byte counted was part of a word
+	Partial    []byte `json:"partial,omitempty"` // bytes of a rune cut off at the end of the data counted so far
+}
+
+// Resume counts everything read from input until EOF on top of state and
+// returns the new state. A word or rune cut off at the end of the previous
+// data is completed by the new data rather than counted twice. Unique words,
+// metrics and the longest line aren't tracked across resumes.
+func Resume(input io.Reader, state State, options CountOptions) (State, error) {
+	counter := counterPool.Get().(*fileCounter)
+	defer counterPool.Put(counter)
+	counter.reset(true)
+	counter.bufferSize = options.BufferSize
+	counter.bytewise = options.Bytewise
+	counter.bytes, counter.lines, counter.words, counter.characters = state.Bytes, state.Lines, state.Words, state.Characters
+	counter.inWord = state.InWord
+	counter.partialLen = copy(counter.partial[:], state.Partial)
+
+	if err := counter.countReader(throttle(input, options)); err != nil {
+		return State{}, err
+	}
+	return counter.state(), nil
+}
+
+// Counts returns the counts requested by the options as if the input ended
+// here. The bytes of a rune cut off at the end count as invalid characters.
+func (s State) Counts(options CountOptions) Counts {
+	counter := fileCounter{
+		bytes: s.Bytes, lines: s.Lines, words: s.Words, characters: s.Characters,
+		inWord: s.InWord, needRunes: true,
+	}
+	counter.partialLen = copy(counter.partial[:], s.Partial)
+	counter.finish()
+	return counter.counts(options)
+}
+
+// fileCounter holds the running counts for a single input
+type fileCounter struct {
+	bytes      int64
+	lines      int64
+	words      int64
+	characters int64
+	inWord     bool              // whether the last byte seen was part of a word
+	needRunes  bool              // whether words or characters are being counted
+	partial    [utf8.UTFMax]byte // bytes of a rune cut off at the end of the previous chunk
+	partialLen int
+	bufferSize int             // read size from CountOptions.BufferSize; 0 sizes the buffer from the input
+	unique     *UniqueWords    // distinct words, when unique words are being counted
+	folder     *caseFolder     // folds the case of unique words with CountOptions.FoldCase
+	widths     *lineWidth      // display widths of lines, with CountOptions.MaxLineLength
+	bytewise   bool            // treat every byte as a character, with CountOptions.Bytewise
+	metrics    []Metric        // registered metrics being counted
+	splitter   WordSplitter    // finds words instead of scanWords, when set
+	unsplit    []byte          // data the splitter hasn't consumed yet
+	word       []byte          // bytes of a word cut off at the end of the previous chunk
+	ctx        context.Context // stops counting between reads once done; nil never stops
+	onChunk    func()          // called after each nonempty chunk read is counted
+}
+
+// counterPool recycles fileCounter values between inputs
+var counterPool = sync.Pool{New: func() any { return new(fileCounter) }}
+
+// countReader adds the counts for everything read from input until EOF.
+// The caller calls finish once the input is complete.
+func (c *fileCounter) countReader(input io.Reader) error {
+	// A single buffer sized for the input; reads go straight into it, so
+	// every byte is copied exactly once.
+	size := c.bufferSize
+	if size == 0 {
+		size = readBufferSize(input)
+	}
+	// Overlapping reads with counting only pays off with a spare CPU
+	if isPipe(input) && runtime.GOMAXPROCS(0) > 1 {
+		return c.countPipelined(input, size)
+	}
+	buf := getBuffer(size)
+	defer putBuffer(buf)
+
+	for {
+		if err := c.err(); err != nil {
+			return err
+		}
+		n, err := input.Read(*buf)
+		if err != nil && err != io.EOF {
+			return &ReadError{Err: err}
+		}
+
+		c.write((*buf)[:n])
+		if c.onChunk != nil && n > 0 {
+			c.onChunk()
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// pipelineDepth is the number of buffers in flight between the reader and the counter
+const pipelineDepth = 4
+
+// readResult is one read handed from the reading goroutine to the counter
+type readResult struct {
+	buf *[]byte
+	n   int
+	err error
+}
+
+// err returns an *InterruptedError once the context is done
+func (c *fileCounter) err() error {
+	if c.ctx == nil || c.ctx.Err() == nil {
+		return nil
+	}
+	return &InterruptedError{Err: c.ctx.Err()}
+}
+
+// done returns the context's done channel, or nil when there is no context
+func (c *fileCounter) done() <-chan struct{} {
+	if c.ctx == nil {
+		return nil
+	}
+	return c.ctx.Done()
+}
+
+// countPipelined is countReader for pipes: a separate goroutine drains the pipe
+// into a ring of reusable buffers while the counter works through the filled
+// ones, so a fast producer isn't held up by counting.
+func (c *fileCounter) countPipelined(input io.Reader, size int) error {
+	free := make(chan *[]byte, pipelineDepth)
+	filled := make(chan readResult, pipelineDepth)
+	stop := make(chan struct{})
+	for i := 0; i < pipelineDepth; i++ {
+		free <- getBuffer(size)
+	}
+	cancelled := false
+	defer func() {
+		close(stop)
+		// The reader stops after handing over its final read, by which
+		// time every buffer is back in free. After a cancellation the
+		// reader may still be blocked in a read, so its buffers are left
+		// to the garbage collector instead.
+		for i := 0; i < pipelineDepth && !cancelled; i++ {
+			putBuffer(<-free)
+		}
+	}()
+
+	go func() {
+		for {
+			var buf *[]byte
+			select {
+			case buf = <-free:
+			case <-stop:
+				return
+			}
+			n, err := input.Read(*buf)
+			filled <- readResult{buf: buf, n: n, err: err}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		var result readResult
+		select {
+		case result = <-filled:
+		case <-c.done():
+			cancelled = true
+			return c.err()
+		}
+		c.write((*result.buf)[:result.n])
+		if c.onChunk != nil && result.n > 0 {
+			c.onChunk()
+		}
+		free <- result.buf
+
+		if result.err == io.EOF {
+			return nil
+		}
+		if result.err != nil {
+			return &ReadError{Err: result.err}
+		}
+	}
+}
+
+// isPipe reports whether input is a pipe, terminal or other non-regular file
+func isPipe(input io.Reader) bool {
+	if throttled, ok := input.(*throttledReader); ok {
+		input = throttled.reader
+	}
+	file, ok := input.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && !info.Mode().IsRegular()
+}
+
+// counts returns the counts requested by the options
+func (c *fileCounter) counts(options CountOptions) Counts {
+	var counts Counts
+
+	if options.ByteCount {
+		counts.Bytes = c.bytes
+	}
+
+	if options.LineCount {
+		counts.Lines = c.lines
+	}
+
+	if options.WordCount {
+		counts.Words = c.words
+	}
+
+	if options.CharacterCount {
+		counts.Chars = c.characters
+	}
+
+	if options.UniqueCount && c.unique != nil {
+		counts.Unique = c.unique.Count()
+	}
+
+	if options.MaxLineLength && c.widths != nil {
+		counts.MaxLineLength = c.widths.max()
+	}
+
+	if len(c.metrics) > 0 {
+		counts.Metrics = make(map[string]int64, len(c.metrics))
+		for _, metric := range c.metrics {
+			counts.Metrics[metric.Name()] = metric.Result()
+		}
+	}
+
+	return counts
+}
+
+// state returns the counter's progress as a State
+func (c *fileCounter) state() State {
+	return State{
+		Bytes: c.bytes, Lines: c.lines, Words: c.words, Characters: c.characters,
+		InWord: c.inWord, Partial: append([]byte(nil), c.partial[:c.partialLen]...),
+	}
+}
+
+// setup prepares the counter for counting a new input with the options
+func (c *fileCounter) setup(options CountOptions) error {
+	c.reset(options.WordCount || options.CharacterCount)
+	c.bufferSize = options.BufferSize
+	c.splitter = options.WordSplitter
+	c.bytewise = options.Bytewise
+	if options.UniqueCount {
+		c.unique = NewUniqueWords(options.MaxMemory)
+		if options.FoldCase {
+			c.folder = newCaseFolder()
+		}
+	}
+	if options.MaxLineLength {
+		c.widths = &lineWidth{tabStop: int64(options.TabStop), bytewise: options.Bytewise}
+		if c.widths.tabStop <= 0 {
+			c.widths.tabStop = DefaultTabStop
+		}
+	}
+	metrics, err := newMetrics(options.Metrics)
+	c.metrics = metrics
+	return err
+}
+
+// reset clears the counter so it can be reused for a new input
+func (c *fileCounter) reset(needRunes bool) {
+	*c = fileCounter{needRunes: needRunes, word: c.word[:0], unsplit: c.unsplit[:0]}
+}
+
+// finish counts the bytes of an incomplete rune at the end of the input as
+// invalid characters and adds the last word of the input to the unique words
+func (c *fileCounter) finish() {
+	if c.partialLen > 0 {
+		partial := c.partial[:c.partialLen]
+		c.partialLen = 0
+		c.scan(partial)
+	}
+	if c.splitter != nil {
+		c.splitWords(nil, true)
+	}
+	if c.unique != nil && len(c.word) > 0 {
+		c.addUnique(c.word)
+		c.word = c.word[:0]
+	}
+}
+
+// addUnique adds a word to the unique words, with its case folded when
+// counting with CountOptions.FoldCase
+func (c *fileCounter) addUnique(word []byte) {
+	if c.folder != nil {
+		word = c.folder.fold(word)
+	}
+	c.unique.Add(word)
+}
+
+// splitWords counts the words the splitter finds in chunk, keeping data the
+// splitter can't decide on yet until the next chunk or the end of the input
+func (c *fileCounter) splitWords(chunk []byte, atEOF bool) {
+	data := chunk
+	if len(c.unsplit) > 0 {
+		c.unsplit = append(c.unsplit, chunk...)
+		data = c.unsplit
+	}
+	for len(data) > 0 {
+		advance, word := c.splitter.Split(data, atEOF)
+		if advance <= 0 {
+			break
+		}
+		if word != nil {
+			c.words++
+			if c.unique != nil {
+				c.addUnique(word)
+			}
+		}
+		data = data[min(advance, len(data)):]
+	}
+	if atEOF {
+		data = nil
+	}
+	// data may alias unsplit; append copies overlapping bytes correctly
+	c.unsplit = append(c.unsplit[:0], data...)
+}
+
+// collectWords adds every complete word in chunk to the unique words. A word
+// running past the end of the chunk is kept until the next chunk completes it.
+func (c *fileCounter) collectWords(chunk []byte) {
+	start := 0
+	if len(c.word) == 0 {
+		start = -1
+	}
+	for i := 0; i < len(chunk); {
+		space := false
+		size := 1
+		switch byteClass[chunk[i]] {
+		case classSpace:
+			space = true
+		case classMultibyte:
+			if c.bytewise {
+				break
+			}
+			var r rune
+			r, size = utf8.DecodeRune(chunk[i:])
+			space = unicode.IsSpace(r)
+		}
+		if space && start >= 0 {
+			if len(c.word) > 0 {
+				c.word = append(c.word, chunk[start:i]...)
+				c.addUnique(c.word)
+				c.word = c.word[:0]
+			} else {
+				c.addUnique(chunk[start:i])
+			}
+			start = -1
+		} else if !space && start < 0 {
+			start = i
+		}
+		i += size
+	}
+	if start >= 0 {
+		c.word = append(c.word, chunk[start:]...)
+	}
+}
+
+// write adds the counts for the next chunk of the input
+func (c *fileCounter) write(chunk []byte) {
+	// For ASCII text (where each character is one byte), byte count and character count will be the same.
+	// For text with multibyte Unicode characters (like emoji or non-Latin scripts),
+	//  byte count will be larger than character count.
+	c.bytes += int64(len(chunk))
+	c.lines += int64(bytes.Count(chunk, []byte{'\n'}))
+
+	// Lines and bytes never need rune decoding, so skip the scan entirely
+	// unless words or characters were requested.
+	if !c.needRunes && c.unique == nil && len(c.metrics) == 0 && c.widths == nil {
+		return
+	}
+
+	// Bytes are characters of their own, so no rune is ever cut off
+	if c.bytewise {
+		c.scan(chunk)
+		return
+	}
+
+	// A rune cut off at the end of the previous chunk is completed with the
+	// first bytes of this one and scanned on its own
+	if c.partialLen > 0 {
+		var joined [2 * utf8.UTFMax]byte
+		n := copy(joined[:], c.partial[:c.partialLen])
+		n += copy(joined[n:], chunk[:min(len(chunk), utf8.UTFMax)])
+		if !utf8.FullRune(joined[:n]) {
+			// The chunk is too short to complete the rune
+			c.partialLen = copy(c.partial[:], joined[:n])
+			return
+		}
+		_, size := utf8.DecodeRune(joined[:n])
+		// If the next byte doesn't continue the sequence, the held-back
+		// bytes are invalid and each one counts as a character
+		size = max(size, c.partialLen)
+		c.scan(joined[:size])
+		chunk = chunk[size-c.partialLen:]
+		c.partialLen = 0
+	}
+
+	// Hold back an incomplete rune at the end of the chunk until the next one
+	end := len(chunk) - incompleteSuffix(chunk)
+	c.partialLen = copy(c.partial[:], chunk[end:])
+	c.scan(chunk[:end])
+}
+
+// scan adds the words and characters of a chunk that doesn't split any rune
+func (c *fileCounter) scan(chunk []byte) {
+	if c.splitter != nil {
+		c.characters += int64(utf8.RuneCount(chunk))
+		c.splitWords(chunk, false)
+	} else {
+		if c.needRunes && c.bytewise {
+			words, inWord := scanBytes(chunk, c.inWord)
+			c.words += words
+			c.characters += int64(len(chunk))
+			c.inWord = inWord
+		} else if c.needRunes {
+			words, characters, inWord := scanWords(chunk, c.inWord)
+			c.words += words
+			c.characters += characters
+			c.inWord = inWord
+		}
+		if c.unique != nil {
+			c.collectWords(chunk)
+		}
+	}
+	if c.widths != nil {
+		c.widths.measure(chunk)
+	}
+	for _, metric := range c.metrics {
+		metric.ProcessChunk(chunk)
+	}
+}
+
+// Read buffer sizes used by readBufferSize
+const (
+	minAdaptiveBufferSize = 4 * 1024    // smallest buffer, used for tiny regular files
+	maxAdaptiveBufferSize = 1024 * 1024 // largest buffer, used for big regular files
+	pipeBufferSize        = 64 * 1024   // matches the default pipe capacity on Linux
+
+	// Bounds for CountOptions.BufferSize
+	MinBufferSize = 512
+	MaxBufferSize = 256 * 1024 * 1024
+)
+
+// readBufferSize picks a buffer size for the input. Regular files get a buffer
+// just big enough to hold them (within bounds), so small files don't pay for a
+// large allocation and big files are read in few system calls. Pipes, terminals
+// and other readers deliver at most a pipe's worth of data per read.
+// Sizes are always powers of two so buffers can be pooled by size class.
+func readBufferSize(input io.Reader) int {
+	if throttled, ok := input.(*throttledReader); ok {
+		return readBufferSize(throttled.reader)
+	}
+	// Files opened through an fs.FS are sized like os.File
+	file, ok := input.(interface{ Stat() (fs.FileInfo, error) })
+	if !ok {
+		return pipeBufferSize
+	}
+	info, err := file.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return pipeBufferSize
+	}
+
+	size := info.Size()
+	if size <= minAdaptiveBufferSize {
+		return minAdaptiveBufferSize
+	}
+	if size >= maxAdaptiveBufferSize {
+		return maxAdaptiveBufferSize
+	}
+	return 1 << bits.Len64(uint64(size-1))
+}
+
+// bufferPools holds one pool per power-of-two buffer size from minAdaptiveBufferSize (4KB) to maxAdaptiveBufferSize (1MB)
+var bufferPools [9]sync.Pool
+
+// bufferClass returns the index into bufferPools for a power-of-two size
+func bufferClass(size int) int {
+	return bits.TrailingZeros(uint(size / minAdaptiveBufferSize))
+}
+
+// pooledSize reports whether buffers of the given size are pooled
+func pooledSize(size int) bool {
+	return size >= minAdaptiveBufferSize && size <= maxAdaptiveBufferSize && size&(size-1) == 0
+}
+
+// getBuffer returns a buffer of the given size, reusing a pooled one for
+// power-of-two sizes between minAdaptiveBufferSize and maxAdaptiveBufferSize
+func getBuffer(size int) *[]byte {
+	if pooledSize(size) {
+		if buf, ok := bufferPools[bufferClass(size)].Get().(*[]byte); ok {
+			return buf
+		}
+	}
+	buf := make([]byte, size)
+	return &buf
+}
+
+// putBuffer returns a buffer obtained from getBuffer to its pool
+func putBuffer(buf *[]byte) {
+	if pooledSize(len(*buf)) {
+		bufferPools[bufferClass(len(*buf))].Put(buf)
+	}
+}
@@ -0,0 +1,506 @@
+package wordcount
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// TestCount tests all counting options with multiple inputs
+func TestCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		options  CountOptions
+		expected Counts
+	}{
+		{
+			name:     "Byte Count - ASCII",
+			input:    "Hello, World!\n",
+			options:  CountOptions{ByteCount: true, Order: []string{"bytes"}},
+			expected: Counts{Bytes: 14},
+		},
+		{
+			name:     "Byte Count - Unicode",
+			input:    "Hello, 世界!\n",
+			options:  CountOptions{ByteCount: true, Order: []string{"bytes"}},
+			expected: Counts{Bytes: 15},
+		},
+		{
+			name:     "Line Count - Single Line",
+			input:    "Hello, World!",
+			options:  CountOptions{LineCount: true, Order: []string{"lines"}},
+			expected: Counts{Lines: 0},
+		},
+		{
+			name:     "Line Count - Multiple Lines",
+			input:    "Hello, World!\nGoodbye, World!\n",
+			options:  CountOptions{LineCount: true, Order: []string{"lines"}},
+			expected: Counts{Lines: 2},
+		},
+		{
+			name:     "Word Count - Single Word",
+			input:    "Hello",
+			options:  CountOptions{WordCount: true, Order: []string{"words"}},
+			expected: Counts{Words: 1},
+		},
+		{
+			name:     "Word Count - Multiple Words",
+			input:    "Hello, World!\nGoodbye, World!\n",
+			options:  CountOptions{WordCount: true, Order: []string{"words"}},
+			expected: Counts{Words: 4},
+		},
+		{
+			name:     "Word Count - ASCII Control Whitespace",
+			input:    "one\vtwo\fthree\rfour",
+			options:  CountOptions{WordCount: true, Order: []string{"words"}},
+			expected: Counts{Words: 4},
+		},
+		{
+			name:     "Word Count - Unicode Whitespace",
+			input:    "one\u00a0two\u2003three",
+			options:  CountOptions{WordCount: true, Order: []string{"words"}},
+			expected: Counts{Words: 3},
+		},
+		{
+			name:     "Character Count - ASCII",
+			input:    "Hello, World!\n",
+			options:  CountOptions{CharacterCount: true, Order: []string{"characters"}},
+			expected: Counts{Chars: 14},
+		},
+		{
+			name:     "Character Count - Unicode",
+			input:    "Hello, 世界!\n",
+			options:  CountOptions{CharacterCount: true, Order: []string{"characters"}},
+			expected: Counts{Chars: 11},
+		},
+		{
+			name:    "Default Option",
+			input:   "Hello, World!\nGoodbye, World!\n",
+			options: CountOptions{LineCount: true, WordCount: true, ByteCount: true, Order: []string{"lines", "words", "bytes"}},
+			expected: Counts{
+				Lines: 2,
+				Words: 4,
+				Bytes: 30,
+			},
+		},
+		{
+			name:    "All Options",
+			input:   "Hello, 世界!\nGoodbye, World!\n",
+			options: CountOptions{LineCount: true, WordCount: true, ByteCount: true, CharacterCount: true, Order: []string{"lines", "words", "bytes", "characters"}},
+			expected: Counts{
+				Lines: 2,
+				Words: 4,
+				Bytes: 31,
+				Chars: 27,
+			},
+		},
+		{
+			name:     "Empty Input",
+			input:    "",
+			options:  CountOptions{LineCount: true, WordCount: true, ByteCount: true, CharacterCount: true, Order: []string{"lines", "words", "bytes", "characters"}},
+			expected: Counts{Lines: 0, Words: 0, Bytes: 0, Chars: 0},
+		},
+		{
+			name:     "Only Whitespace",
+			input:    "   \n\t\n  ",
+			options:  CountOptions{LineCount: true, WordCount: true, ByteCount: true, CharacterCount: true, Order: []string{"lines", "words", "bytes", "characters"}},
+			expected: Counts{Lines: 2, Words: 0, Bytes: 8, Chars: 8},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := strings.NewReader(tt.input)
+			counts, err := Count(input, tt.options)
+			if err != nil {
+				t.Fatalf("Error processing input: %v", err)
+			}
+			if !counts.Equal(tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, counts)
+			}
+		})
+	}
+}
+
+// TestCountsGetAndAdd checks looking counts up by name and summing them
+func TestCountsGetAndAdd(t *testing.T) {
+	total := Counts{Bytes: 10, Lines: 1, Words: 2, Chars: 9, Unique: 2}
+	total.Add(Counts{Bytes: 5, Lines: 1, Words: 1, Chars: 5, Unique: 1})
+	if expected := (Counts{Bytes: 15, Lines: 2, Words: 3, Chars: 14, Unique: 2}); !total.Equal(expected) {
+		t.Errorf("Expected %+v, got %+v", expected, total)
+	}
+
+	for name, expected := range map[string]int64{"bytes": 15, "lines": 2, "words": 3, "characters": 14, "unique": 2} {
+		if count, ok := total.Get(name); !ok || count != expected {
+			t.Errorf("Get(%q): expected %d, got %d (ok=%v)", name, expected, count, ok)
+		}
+	}
+	if _, ok := total.Get("paragraphs"); ok {
+		t.Errorf("Expected unknown count names to be rejected")
+	}
+}
+
+// TestLinesAndBytesOnly checks that skipping the rune scan leaves word and character counts out
+func TestLinesAndBytesOnly(t *testing.T) {
+	options := CountOptions{LineCount: true, ByteCount: true, Order: []string{"lines", "bytes"}}
+	counts, err := Count(strings.NewReader("Hello, 世界!\nBye\n"), options)
+	if err != nil {
+		t.Fatalf("Error processing input: %v", err)
+	}
+	if counts.Lines != 2 || counts.Bytes != 19 {
+		t.Errorf("Expected 2 lines and 19 bytes, got %d lines and %d bytes", counts.Lines, counts.Bytes)
+	}
+	if counts.Words != 0 {
+		t.Errorf("Expected no word count, got %d", counts.Words)
+	}
+	if counts.Chars != 0 {
+		t.Errorf("Expected no character count, got %d", counts.Chars)
+	}
+}
+
+// benchmarkText returns roughly size bytes of mixed ASCII and Unicode text
+func benchmarkText(size int) []byte {
+	line := []byte("The quick brown fox jumps over the lazy dog. Größe 世界 👋\n")
+	return bytes.Repeat(line, size/len(line)+1)[:size]
+}
+
+// BenchmarkCountReader measures counting from a generic reader, such as a pipe
+func BenchmarkCountReader(b *testing.B) {
+	data := benchmarkText(32 * 1024 * 1024)
+	options := CountOptions{LineCount: true, WordCount: true, ByteCount: true, CharacterCount: true}
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Count(bytes.NewReader(data), options); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkCountFile measures counting from regular files of different sizes
+func BenchmarkCountFile(b *testing.B) {
+	options := CountOptions{LineCount: true, WordCount: true, ByteCount: true, CharacterCount: true}
+	for _, size := range []int{4 * 1024, 32 * 1024 * 1024} {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			path := filepath.Join(b.TempDir(), "bench.txt")
+			if err := os.WriteFile(path, benchmarkText(size), 0644); err != nil {
+				b.Fatal(err)
+			}
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				file, err := os.Open(path)
+				if err != nil {
+					b.Fatal(err)
+				}
+				_, err = Count(file, options)
+				_ = file.Close()
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkCountPipe measures counting from a pipe fed by another goroutine
+func BenchmarkCountPipe(b *testing.B) {
+	data := benchmarkText(32 * 1024 * 1024)
+	options := CountOptions{LineCount: true, WordCount: true, ByteCount: true, CharacterCount: true}
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r, w, err := os.Pipe()
+		if err != nil {
+			b.Fatal(err)
+		}
+		go func() {
+			_, _ = w.Write(data)
+			_ = w.Close()
+		}()
+		_, err = Count(r, options)
+		_ = r.Close()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestReadBufferSize checks that buffers are sized from the input type and are pool-friendly powers of two
+func TestReadBufferSize(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name     string
+		size     int
+		expected int
+	}{
+		{"Empty File", 0, minAdaptiveBufferSize},
+		{"Small File", 100, minAdaptiveBufferSize},
+		{"Medium File", 100 * 1024, 128 * 1024},
+		{"Exact Power of Two", 64 * 1024, 64 * 1024},
+		{"Large File", 3 * 1024 * 1024, maxAdaptiveBufferSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, strconv.Itoa(tt.size))
+			if err := os.WriteFile(path, make([]byte, tt.size), 0644); err != nil {
+				t.Fatalf("Failed to write test file: %v", err)
+			}
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatalf("Failed to open test file: %v", err)
+			}
+			defer file.Close()
+
+			if size := readBufferSize(file); size != tt.expected {
+				t.Errorf("Expected buffer size %d, got %d", tt.expected, size)
+			}
+		})
+	}
+
+	if size := readBufferSize(strings.NewReader("piped")); size != pipeBufferSize {
+		t.Errorf("Expected buffer size %d for a non-file reader, got %d", pipeBufferSize, size)
+	}
+}
+
+// TestBufferPool checks that pooled buffers come back with the requested size
+func TestBufferPool(t *testing.T) {
+	for size := minAdaptiveBufferSize; size <= maxAdaptiveBufferSize; size *= 2 {
+		buf := getBuffer(size)
+		if len(*buf) != size {
+			t.Errorf("Expected buffer of %d bytes, got %d", size, len(*buf))
+		}
+		putBuffer(buf)
+		if buf = getBuffer(size); len(*buf) != size {
+			t.Errorf("Expected reused buffer of %d bytes, got %d", size, len(*buf))
+		}
+	}
+}
+
+// TestBufferSize checks that counts don't depend on the read buffer size
+func TestBufferSize(t *testing.T) {
+	input := "Hello, 世界! Привет мир\nGoodbye, World!\n"
+	for _, size := range []int{MinBufferSize, 1000, minAdaptiveBufferSize, 3 * 1024 * 1024} {
+		t.Run(strconv.Itoa(size), func(t *testing.T) {
+			options := CountOptions{LineCount: true, WordCount: true, ByteCount: true, BufferSize: size}
+			counts, err := Count(strings.NewReader(strings.Repeat(input, 100)), options)
+			if err != nil {
+				t.Fatalf("Error processing input: %v", err)
+			}
+			if counts.Lines != 200 || counts.Words != 600 || counts.Bytes != int64(100*len(input)) {
+				t.Errorf("Expected 200 lines, 600 words and %d bytes, got %v", 100*len(input), counts)
+			}
+		})
+	}
+}
+
+// TestCountPipelined checks that the pipelined reader counts the same as the sequential one
+func TestCountPipelined(t *testing.T) {
+	data := benchmarkText(1024*1024 + 123)
+	sequential := &fileCounter{needRunes: true}
+	if err := sequential.countReader(bytes.NewReader(data)); err != nil {
+		t.Fatalf("Error processing input: %v", err)
+	}
+	sequential.finish()
+
+	readers := map[string]func() io.Reader{
+		"Pipe": func() io.Reader {
+			r, w, err := os.Pipe()
+			if err != nil {
+				t.Fatalf("Error creating pipe: %v", err)
+			}
+			t.Cleanup(func() { r.Close() })
+			go func() {
+				_, _ = w.Write(data)
+				_ = w.Close()
+			}()
+			return r
+		},
+		"Short Reads": func() io.Reader { return iotest.HalfReader(bytes.NewReader(data)) },
+	}
+	for name, reader := range readers {
+		t.Run(name, func(t *testing.T) {
+			pipelined := &fileCounter{needRunes: true}
+			if err := pipelined.countPipelined(reader(), pipeBufferSize); err != nil {
+				t.Fatalf("Error processing input: %v", err)
+			}
+			pipelined.finish()
+			options := CountOptions{LineCount: true, WordCount: true, ByteCount: true, CharacterCount: true}
+			if expected, actual := sequential.counts(options), pipelined.counts(options); !actual.Equal(expected) {
+				t.Errorf("Expected %+v, got %+v", expected, actual)
+			}
+		})
+	}
+
+	t.Run("Read Error", func(t *testing.T) {
+		counter := &fileCounter{}
+		err := counter.countPipelined(iotest.ErrReader(io.ErrUnexpectedEOF), pipeBufferSize)
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("Expected the read error, got %v", err)
+		}
+	})
+}
+
+// TestChunkBoundaries checks that runes split between reads are decoded as a whole
+func TestChunkBoundaries(t *testing.T) {
+	inputs := []string{
+		"Hello, 世界! Привет мир",
+		"no break　ideographic em space",
+		"emoji 👋🏽 and combining é",
+		"invalid \xff\xfe bytes and \xe4\xb8 cut rune",
+		"truncated at the end \xf0\x9f\x91",
+		"\xe4\xb8\x96",
+	}
+
+	readers := map[string]func(io.Reader) io.Reader{
+		"One Byte":  iotest.OneByteReader,
+		"Half":      iotest.HalfReader,
+		"Data Err":  iotest.DataErrReader,
+		"Unchanged": func(r io.Reader) io.Reader { return r },
+	}
+	options := CountOptions{WordCount: true, CharacterCount: true, UniqueCount: true, Order: []string{"words", "characters", "unique"}}
+
+	for _, input := range inputs {
+		expectedWords, expectedCharacters := runeScan(input)
+		for name, wrap := range readers {
+			t.Run(name+" "+strconv.Quote(input), func(t *testing.T) {
+				counts, err := Count(wrap(strings.NewReader(input)), options)
+				if err != nil {
+					t.Fatalf("Error processing input: %v", err)
+				}
+				if counts.Words != expectedWords || counts.Chars != expectedCharacters {
+					t.Errorf("Expected %d words and %d characters, got %d words and %d characters",
+						expectedWords, expectedCharacters, counts.Words, counts.Chars)
+				}
+				if counts.Unique > expectedWords {
+					t.Errorf("Expected at most %d unique words, got %d", expectedWords, counts.Unique)
+				}
+			})
+		}
+	}
+}
+
+// TestResume checks that counting in pieces matches counting the whole input
+func TestResume(t *testing.T) {
+	content := []byte("split wo\u4e16\u754c rd here\nand\u00a0more")
+	options := CountOptions{LineCount: true, WordCount: true, ByteCount: true, CharacterCount: true}
+	expected, err := Count(bytes.NewReader(content), options)
+	if err != nil {
+		t.Fatalf("Error processing input: %v", err)
+	}
+
+	for end := 0; end <= len(content); end++ {
+		state, err := Resume(bytes.NewReader(content[:end]), State{}, options)
+		if err != nil {
+			t.Fatalf("Error processing input: %v", err)
+		}
+		// Round-trip through JSON, as the incremental mode does between runs
+		data, _ := json.Marshal(state)
+		state = State{}
+		if err := json.Unmarshal(data, &state); err != nil {
+			t.Fatalf("Failed to decode state: %v", err)
+		}
+		if state, err = Resume(bytes.NewReader(content[end:]), state, options); err != nil {
+			t.Fatalf("Error processing input: %v", err)
+		}
+		if actual := state.Counts(options); !actual.Equal(expected) {
+			t.Errorf("Split at %d: expected %+v, got %+v", end, expected, actual)
+		}
+	}
+}
+
+// TestResumeBytewise checks that resuming counts bytewise as Count does
+func TestResumeBytewise(t *testing.T) {
+	content := []byte("caf\u00e9\u00a0bar \xff\n")
+	options := CountOptions{WordCount: true, CharacterCount: true, Bytewise: true}
+	expected, err := Count(bytes.NewReader(content), options)
+	if err != nil {
+		t.Fatalf("Error processing input: %v", err)
+	}
+
+	for end := 0; end <= len(content); end++ {
+		state, err := Resume(bytes.NewReader(content[:end]), State{}, options)
+		if err != nil {
+			t.Fatalf("Error processing input: %v", err)
+		}
+		if state, err = Resume(bytes.NewReader(content[end:]), state, options); err != nil {
+			t.Fatalf("Error processing input: %v", err)
+		}
+		if actual := state.Counts(options); !actual.Equal(expected) || actual.Chars != int64(len(content)) {
+			t.Errorf("Split at %d: expected %+v, got %+v", end, expected, actual)
+		}
+	}
+}
+
+// cancelReader cancels a context once a number of reads have been made
+type cancelReader struct {
+	reader io.Reader
+	reads  int
+	cancel context.CancelFunc
+}
+
+func (r *cancelReader) Read(p []byte) (int, error) {
+	if r.reads--; r.reads == 0 {
+		r.cancel()
+	}
+	return r.reader.Read(p)
+}
+
+// TestCountContext checks that cancellation stops counting between reads and keeps the partial counts
+func TestCountContext(t *testing.T) {
+	options := CountOptions{LineCount: true, ByteCount: true, BufferSize: MinBufferSize}
+	data := strings.Repeat("Hello, World!\n", 1000)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	counts, err := CountContext(ctx, &cancelReader{reader: strings.NewReader(data), reads: 3, cancel: cancel}, options)
+	if !errors.Is(err, ErrInterrupted) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected ErrInterrupted and context.Canceled, got %v", err)
+	}
+	if counts.Bytes != 3*MinBufferSize {
+		t.Errorf("Expected the %d bytes read before cancellation, got %d", 3*MinBufferSize, counts.Bytes)
+	}
+
+	t.Run("Done Before Start", func(t *testing.T) {
+		counts, err := CountContext(ctx, strings.NewReader(data), options)
+		if !errors.Is(err, context.Canceled) || !counts.Equal(Counts{}) {
+			t.Errorf("Expected no counts and context.Canceled, got %+v and %v", counts, err)
+		}
+	})
+
+	t.Run("Pipelined", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatalf("Error creating pipe: %v", err)
+		}
+		defer r.Close()
+		defer w.Close()
+		if _, err := w.WriteString(data); err != nil {
+			t.Fatalf("Error writing to pipe: %v", err)
+		}
+
+		// The writer stays open, so the reader blocks once the data is drained
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		counter := &fileCounter{ctx: ctx}
+		err = counter.countPipelined(r, pipeBufferSize)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+		}
+		if counter.bytes != int64(len(data)) {
+			t.Errorf("Expected %d bytes, got %d", len(data), counter.bytes)
+		}
+	})
+}